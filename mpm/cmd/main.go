@@ -6,20 +6,26 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 	"google.golang.org/grpc"
 
+	"mpm/config"
 	_ "mpm/docs"
+	"mpm/internal/geocode"
 	grpcserver "mpm/internal/grpc"
 	"mpm/internal/handlers"
 	"mpm/internal/repository"
 	"mpm/internal/service"
 	"mpm/internal/storage"
+	"mpm/internal/storage/google"
+	"mpm/internal/tus"
 	"mpm/middleware"
 	pb "mpm/proto/albums"
 )
@@ -45,26 +51,29 @@ import (
 // @description Введите токен в формате: Bearer {token}
 
 func main() {
+	// Подкоманды выполняются вместо запуска сервера
+	if len(os.Args) > 1 && os.Args[1] == "migrate-blobs" {
+		os.Exit(migrateBlobs(os.Args[2:]))
+	}
+
 	// Выводим информацию о доступных переменных окружения
 	log.Println("Доступные переменные окружения для настройки хранилища:")
 	log.Println("MPM_STORAGE_TYPE - тип хранилища (json или postgres, по умолчанию json)")
 	log.Println("MPM_DATA_PATH - путь к директории с данными для JSON-хранилища")
+	log.Println("MPM_FILES_PATH - путь к директории с файлами фотографий")
+	log.Println("MPM_MAX_UPLOAD_SIZE - максимальный размер загружаемого файла в байтах")
+	log.Println("MPM_FILES_DEDUP - хранить одинаковые файлы один раз (true или false, по умолчанию false)")
 
-	// Получаем настройки из переменных окружения
-	storageType := os.Getenv("MPM_STORAGE_TYPE")
-	dataDir := os.Getenv("MPM_DATA_PATH")
-	if dataDir == "" {
-		dataDir = "/opt/mpm/data" // Значение по умолчанию
-	}
-	saveInterval := 30 * time.Second
-	if intervalStr := os.Getenv("MPM_SAVE_INTERVAL"); intervalStr != "" {
-		if interval, err := time.ParseDuration(intervalStr); err == nil {
-			saveInterval = interval
-		}
+	// Пока сервер работает, перенос файлов из командной строки не должен изменять JSON-файлы
+	dataLock, err := lockDataDir()
+	if err != nil {
+		log.Printf("Не удалось захватить директорию данных: %v", err)
+		return
 	}
+	defer dataLock.Release()
 
 	// Создаем репозиторий
-	repo := repository.NewRepository(storageType, dataDir, saveInterval)
+	repo := newRepository()
 
 	log.Println("Репозиторий инициализирован")
 
@@ -88,20 +97,182 @@ func main() {
 
 	// Создание обработчика для альбомов
 	albumHandler := handlers.NewAlbumHandler(repo)
+
+	// Хранилища файлов фотографий и обработчик загрузки
+	cfg := config.LoadConfig()
+	providers, err := newFileProviders(ctx, cfg)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	fileStorage := providers.local
+
+	// Подписанные ссылки на файлы для доступа без JWT
+	signingKey, ephemeral, err := storage.DeriveSigningKey(cfg.Files.SigningKey, cfg.JWT.Secret)
+	if err != nil {
+		log.Printf("Ошибка создания ключа подписи ссылок: %v", err)
+		return
+	}
+	if ephemeral {
+		log.Println("Ключ подписи ссылок не задан, ссылки перестанут действовать после перезапуска")
+	}
+	urlSigner := storage.NewURLSigner(signingKey, cfg.Files.BaseURL)
+	fileStorage.SetURLSigner(urlSigner, cfg.Files.SignedURLTTL)
+
+	// Новые файлы сохраняются в выбранное хранилище, остальные остаются доступными для ранее загруженных
+	primaryStorage, err := providers.get(cfg.Files.Provider)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("Файлы сохраняются в хранилище %s", cfg.Files.Provider)
+
+	photoService := service.NewPhotoService(repo, primaryStorage, cfg.Files.Provider, cfg.Files.MaxUploadSize)
+	providers.register(photoService, cfg.Files.Provider)
+	photoService.SetRenditionSizes(cfg.Files.RenditionSizes)
+	if cfg.Files.Provider == google.StorageType {
+		// Уменьшенные копии попали бы в библиотеку Google Photos отдельными медиафайлами
+		photoService.SetRenditionSizes(nil)
+	}
+
+	// Место съемки определяется по справочнику без обращения к сети: по выгрузке GeoNames,
+	// если она указана, иначе по встроенной выборке крупных городов
+	if cfg.Geocoder.Enabled {
+		var geocoder *geocode.Geocoder
+		var err error
+		if cfg.Geocoder.CitiesFile != "" {
+			geocoder, err = geocode.NewFromFile(cfg.Geocoder.CitiesFile, cfg.Geocoder.MaxDistance)
+		} else {
+			geocoder, err = geocode.New(cfg.Geocoder.MaxDistance)
+		}
+		if err != nil {
+			log.Printf("Ошибка загрузки справочника населенных пунктов: %v", err)
+		} else {
+			photoService.SetGeocoder(geocoder)
+			log.Printf("Справочник населенных пунктов загружен: %d записей", geocoder.Len())
+		}
+	}
+
+	// Правила автоматической расстановки тегов применяются при загрузке и по запросу
+	ruleService := service.NewRuleService(repo)
+	photoService.SetRuleService(ruleService)
+	ruleHandler := handlers.NewRuleHandler(ruleService)
+
+	importLimits := service.DefaultImportLimits
+	importLimits.MaxArchiveSize = cfg.Files.MaxImportSize
+	photoService.SetImportLimits(importLimits)
+	photoService.SetImportDir(cfg.Files.ImportTempPath)
+	photoHandler := handlers.NewPhotoHandler(photoService, cfg.Files.MaxUploadFiles)
+	// Файлы отдаются через шифрующую обертку, если шифрование включено
+	fileHandler := handlers.NewFileHandler(providers.byType["local"], urlSigner, photoService)
+	photoHandler.SetSignedURLTTL(cfg.Files.SignedURLTTL, cfg.Files.SignedURLMaxTTL)
+
+	// Возобновляемые загрузки по протоколу tus
+	uploadStore, err := tus.NewStore(cfg.Uploads.Dir)
+	if err != nil {
+		log.Printf("Ошибка инициализации хранилища загрузок: %v", err)
+		return
+	}
+	uploadHandler := tus.NewHandler(uploadStore, "/api/uploads", cfg.Uploads.MaxSize, cfg.Uploads.Expiration,
+		handlers.TusCompleteFunc(photoService))
+	uploadHandler.SetValidateFunc(handlers.TusValidateFunc(photoService))
 	entityService := service.NewEntityService(repo)
 
+	// Подключение аккаунта и импорт альбомов Google Photos
+	var googleHandler *handlers.GoogleHandler
+	if providers.googleClient != nil {
+		googleImporter, err := service.NewGoogleImporter(providers.googleClient, repo, cfg.Google.ImportStatePath)
+		if err != nil {
+			log.Printf("Ошибка инициализации импорта из Google Photos: %v", err)
+			return
+		}
+		googleHandler = handlers.NewGoogleHandler(providers.googleClient, googleImporter)
+	}
+
+	// Перенос файлов между хранилищами
+	blobMigrator, err := service.NewBlobMigrator(photoService, cfg.Files.MigrationStatePath)
+	if err != nil {
+		log.Printf("Ошибка инициализации переноса файлов: %v", err)
+		return
+	}
+	migrationHandler := handlers.NewMigrationHandler(blobMigrator)
+
+	// Проверка хранилища: отсутствующие, поврежденные и ничьи файлы
+	storageScrubber := service.NewStorageScrubber(photoService, blobMigrator, cfg.Scrub.OrphanMinAge)
+	scrubHandler := handlers.NewScrubHandler(storageScrubber)
+
+	// Корзина удаленных альбомов и фотографий
+	trashService := service.NewTrashService(repo, photoService, cfg.Trash.Retention)
+	trashHandler := handlers.NewTrashHandler(trashService)
+
+	// Поиск фотографий на карте по координатам съемки
+	geoHandler := handlers.NewGeoHandler(service.NewGeoService(repo))
+
+	// Лента фотографий по дате съемки и воспоминания "в этот день"
+	timelineHandler := handlers.NewTimelineHandler(service.NewTimelineService(repo))
+
 	// Создание сервиса аутентификации
 	authService := service.NewAuthService(userStorage)
 	authHandler := handlers.NewAuthHandler(authService)
 
 	// Middlewares
 	authMiddleware := middleware.AuthMiddleware(authService)
+	adminMiddleware := middleware.AdminMiddleware(cfg.AdminUsers)
+	adminOnly := func(handler http.HandlerFunc) http.Handler {
+		return adminMiddleware(handler)
+	}
 
 	// Запускаем мониторинг с контекстом
 	entityService.StartMonitoring(ctx)
 
+	// Импортируем изображения из отслеживаемых каталогов в альбом "Default"
+	if len(cfg.Watch.Dirs) > 0 {
+		folderWatcher, err := service.NewFolderWatcher(photoService, repo, cfg.Watch.Dirs, cfg.Watch.StatePath,
+			cfg.Watch.Interval, cfg.Watch.StableFor)
+		if err != nil {
+			log.Printf("Ошибка инициализации отслеживания каталогов: %v", err)
+		} else {
+			folderWatcher.Start(ctx)
+		}
+	}
+
+	// Определяем место съемки и вычисляем перцептивные хеши для ранее загруженных фотографий по очереди
+	go func() {
+		if _, err := photoService.BackfillPlaces(ctx); err != nil {
+			log.Printf("Ошибка при определении места съемки: %v", err)
+		}
+		if _, err := photoService.BackfillPerceptualHashes(ctx); err != nil {
+			log.Printf("Ошибка при вычислении перцептивных хешей: %v", err)
+		}
+	}()
+
+	// Перешифровываем ключи файлов после смены мастер-ключа
+	if providers.rotated {
+		go func() {
+			if _, err := photoService.RewrapEncryptionKeys(ctx); err != nil {
+				log.Printf("Ошибка при смене ключей шифрования файлов: %v", err)
+			}
+		}()
+	}
+
+	// Удаляем брошенные загрузки
+	go uploadHandler.CleanupExpired(ctx, time.Hour)
+
+	// Проверяем хранилище по расписанию
+	if cfg.Scrub.Interval > 0 {
+		go storageScrubber.RunPeriodically(ctx, cfg.Scrub.Interval, service.ScrubOptions{
+			VerifyChecksums:   cfg.Scrub.VerifyChecksums,
+			QuarantineOrphans: cfg.Scrub.QuarantineOrphans,
+		})
+	}
+
+	// Удаляем из корзины альбомы и фотографии с истекшим сроком хранения
+	if cfg.Trash.Retention > 0 && cfg.Trash.PurgeInterval > 0 {
+		go trashService.RunPeriodically(ctx, cfg.Trash.PurgeInterval)
+	}
+
 	// Вызываем функцию генерации и сохранения сущностей сразу
-	err := entityService.GenerateAndSaveEntities(ctx)
+	err = entityService.GenerateAndSaveEntities(ctx)
 	if err != nil {
 		log.Printf("Ошибка при генерации и сохранении сущностей: %v", err)
 		stop() // Вместо log.Fatal отменяем контекст
@@ -141,6 +312,53 @@ func main() {
 	authMux.HandleFunc("GET /api/albums", albumHandler.GetAllAlbums)
 	authMux.HandleFunc("GET /api/albums/{id}", albumHandler.GetAlbumByID)
 	authMux.HandleFunc("DELETE /api/albums/{id}", albumHandler.DeleteAlbum)
+	authMux.HandleFunc("GET /api/albums/{id}/export.zip", photoHandler.ExportAlbum)
+	authMux.HandleFunc("GET /api/albums/{id}/map.geojson", geoHandler.GetAlbumMap)
+	authMux.HandleFunc("POST /api/albums/import", photoHandler.ImportAlbum)
+	authMux.HandleFunc("POST /api/albums/{id}/photos", photoHandler.UploadPhotos)
+	authMux.HandleFunc("GET /api/photos", photoHandler.ListPhotos)
+	authMux.HandleFunc("GET /api/photos/geo", geoHandler.SearchPhotos)
+	authMux.HandleFunc("GET /api/photos/{id}", photoHandler.GetPhotoByID)
+	authMux.HandleFunc("PUT /api/photos/{id}", photoHandler.UpdatePhoto)
+	authMux.HandleFunc("DELETE /api/photos/{id}", photoHandler.DeletePhoto)
+	authMux.HandleFunc("GET /api/photos/{id}/content", photoHandler.GetPhotoContent)
+	authMux.HandleFunc("GET /api/photos/{id}/thumb", photoHandler.GetPhotoThumbnail)
+	authMux.HandleFunc("GET /api/photos/{id}/motion", photoHandler.GetPhotoMotion)
+	authMux.HandleFunc("PUT /api/photos/{id}/motion", photoHandler.AttachPhotoMotion)
+	authMux.HandleFunc("GET /api/photos/{id}/similar", photoHandler.GetSimilarPhotos)
+	authMux.HandleFunc("GET /api/photos/{id}/suggested-tags", photoHandler.GetSuggestedTags)
+	authMux.HandleFunc("GET /api/photos/{id}/url", photoHandler.GetPhotoURL)
+	authMux.HandleFunc("GET /api/duplicates", photoHandler.GetDuplicates)
+	authMux.HandleFunc("GET /api/timeline", timelineHandler.GetTimeline)
+	authMux.Handle("GET /api/rules", adminOnly(ruleHandler.ListRules))
+	authMux.Handle("POST /api/rules", adminOnly(ruleHandler.CreateRule))
+	authMux.Handle("POST /api/rules/apply", adminOnly(ruleHandler.ApplyRules))
+	authMux.Handle("GET /api/rules/{id}", adminOnly(ruleHandler.GetRule))
+	authMux.Handle("PUT /api/rules/{id}", adminOnly(ruleHandler.UpdateRule))
+	authMux.Handle("DELETE /api/rules/{id}", adminOnly(ruleHandler.DeleteRule))
+	authMux.Handle("POST /api/rules/{id}/apply", adminOnly(ruleHandler.ApplyRule))
+	authMux.HandleFunc("GET /api/memories/today", timelineHandler.GetMemoriesToday)
+	authMux.HandleFunc("OPTIONS /api/uploads", uploadHandler.Options)
+	authMux.HandleFunc("POST /api/uploads", uploadHandler.Create)
+	authMux.HandleFunc("HEAD /api/uploads/{id}", uploadHandler.Head)
+	authMux.HandleFunc("PATCH /api/uploads/{id}", uploadHandler.Patch)
+	authMux.HandleFunc("DELETE /api/uploads/{id}", uploadHandler.Delete)
+	authMux.HandleFunc("POST /api/uploads/{id}", uploadHandler.MethodOverride)
+	authMux.Handle("GET /api/storage/migration", adminOnly(migrationHandler.GetStatus))
+	authMux.Handle("POST /api/storage/migration", adminOnly(migrationHandler.StartMigration))
+	authMux.Handle("GET /api/storage/scrub", adminOnly(scrubHandler.GetReport))
+	authMux.Handle("POST /api/storage/scrub", adminOnly(scrubHandler.StartScrub))
+	authMux.Handle("GET /api/trash", adminOnly(trashHandler.GetTrash))
+	authMux.Handle("DELETE /api/trash", adminOnly(trashHandler.EmptyTrash))
+	authMux.Handle("POST /api/trash/albums/{id}/restore", adminOnly(trashHandler.RestoreAlbum))
+	authMux.Handle("DELETE /api/trash/albums/{id}", adminOnly(trashHandler.PurgeAlbum))
+	authMux.Handle("POST /api/trash/photos/{id}/restore", adminOnly(trashHandler.RestorePhoto))
+	authMux.Handle("DELETE /api/trash/photos/{id}", adminOnly(trashHandler.PurgePhoto))
+	if googleHandler != nil {
+		authMux.HandleFunc("GET /api/google/status", googleHandler.GetStatus)
+		authMux.HandleFunc("GET /api/google/auth", googleHandler.GetAuthURL)
+		authMux.HandleFunc("POST /api/google/import", googleHandler.StartImport)
+	}
 	mux.HandleFunc("/swagger/", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 		httpSwagger.DeepLinking(true),
@@ -152,6 +370,18 @@ func main() {
 
 	mux.HandleFunc("/api/auth/login", authHandler.Login)
 
+	// Файлы по подписанным ссылкам доступны без авторизации
+	filesPrefix := "/files"
+	if u, err := url.Parse(cfg.Files.BaseURL); err == nil && u.Path != "" {
+		filesPrefix = strings.TrimSuffix(u.Path, "/")
+	}
+	mux.HandleFunc("GET "+filesPrefix+"/{path...}", fileHandler.ServeFile)
+
+	// Google перенаправляет браузер без JWT, запрос подтверждается параметром state
+	if googleHandler != nil {
+		mux.HandleFunc("GET /google/callback", googleHandler.Callback)
+	}
+
 	// Регистрируем наш AlbumServer
 	albumServer := grpcserver.NewAlbumServer(repo)
 	pb.RegisterAlbumServiceServer(grpcServer, albumServer)
@@ -213,3 +443,32 @@ func main() {
 	}()
 
 }
+
+// newRepository создает репозиторий по настройкам из переменных окружения
+func newRepository() *repository.Repository {
+	storageType := os.Getenv("MPM_STORAGE_TYPE")
+	saveInterval := 30 * time.Second
+	if intervalStr := os.Getenv("MPM_SAVE_INTERVAL"); intervalStr != "" {
+		if interval, err := time.ParseDuration(intervalStr); err == nil {
+			saveInterval = interval
+		}
+	}
+
+	return repository.NewRepository(storageType, dataDir(), saveInterval)
+}
+
+// lockDataDir захватывает директорию данных, если используется JSON-хранилище
+func lockDataDir() (*repository.DataDirLock, error) {
+	if storageType := os.Getenv("MPM_STORAGE_TYPE"); storageType != "" && !strings.EqualFold(storageType, repository.StorageTypeJSON) {
+		return nil, nil
+	}
+	return repository.LockDataDir(dataDir())
+}
+
+// dataDir возвращает директорию данных JSON-хранилища
+func dataDir() string {
+	if dir := os.Getenv("MPM_DATA_PATH"); dir != "" {
+		return dir
+	}
+	return "/opt/mpm/data" // Значение по умолчанию
+}
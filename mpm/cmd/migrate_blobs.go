@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"mpm/config"
+	"mpm/internal/repository"
+	"mpm/internal/service"
+)
+
+// migrateBlobs выполняет подкоманду migrate-blobs и возвращает код завершения процесса
+func migrateBlobs(args []string) int {
+	flags := flag.NewFlagSet("migrate-blobs", flag.ContinueOnError)
+	from := flags.String("from", "local", "хранилище, из которого переносятся файлы (local, s3, google, dropbox)")
+	to := flags.String("to", "", "хранилище, в которое переносятся файлы (local, s3, dropbox)")
+	deleteSource := flags.Bool("delete-source", false, "удалять файлы из исходного хранилища после переноса")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Использование: mpm migrate-blobs --from local --to s3 [--delete-source]")
+		fmt.Fprintln(flags.Output(), "Прерванный перенос продолжается при повторном запуске с теми же --from и --to.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *to == "" {
+		flags.Usage()
+		return 2
+	}
+
+	// Запущенный сервер перезаписал бы новые пути файлов своими данными
+	dataLock, err := lockDataDir()
+	if errors.Is(err, repository.ErrDataDirLocked) {
+		log.Println("Директорию данных использует запущенный сервер: остановите его на время переноса или используйте POST /api/storage/migration")
+		return 1
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	defer dataLock.Release()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg := config.LoadConfig()
+	providers, err := newFileProviders(ctx, cfg)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	for _, storageType := range []string{*from, *to} {
+		if _, err := providers.get(storageType); err != nil {
+			log.Println(err)
+			return 1
+		}
+	}
+
+	photoService := service.NewPhotoService(newRepository(), providers.byType["local"], "local", cfg.Files.MaxUploadSize)
+	providers.register(photoService, "local")
+
+	migrator, err := service.NewBlobMigrator(photoService, cfg.Files.MigrationStatePath)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	opts := service.MigrationOptions{From: *from, To: *to, DeleteSource: *deleteSource}
+	status, err := migrator.Run(ctx, opts, func(s service.MigrationStatus) {
+		done := s.Migrated + len(s.Errors)
+		log.Printf("Обработано %d из %d фотографий (%d%%), перенесено %d, скопировано %.1f МБ",
+			done, s.Total, done*100/max(s.Total, 1), s.Migrated, float64(s.Bytes)/(1<<20))
+	})
+	if err != nil {
+		log.Printf("Перенос файлов прерван: %v", err)
+		return 1
+	}
+
+	for _, e := range status.Errors {
+		log.Printf("Фотография ID=%d (%s) не перенесена: %s", e.PhotoID, e.Path, e.Error)
+	}
+	if len(status.Errors) > 0 {
+		log.Println("Запустите перенос повторно, чтобы обработать оставшиеся фотографии")
+		return 1
+	}
+	return 0
+}
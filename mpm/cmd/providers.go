@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"mpm/config"
+	"mpm/internal/service"
+	"mpm/internal/storage"
+	"mpm/internal/storage/dropbox"
+	"mpm/internal/storage/google"
+	"mpm/internal/storage/s3"
+)
+
+// providerSettings переменные окружения, без которых хранилище не подключается
+var providerSettings = map[string]string{
+	"s3":                "MPM_S3_ENDPOINT",
+	google.StorageType:  "MPM_GOOGLE_CLIENT_ID",
+	dropbox.StorageType: "MPM_DROPBOX_APP_KEY",
+}
+
+// fileProviders хранилища файлов, настроенные в конфигурации
+type fileProviders struct {
+	local        *storage.LocalStorage
+	googleClient *google.Client
+	byType       map[string]storage.Provider
+	rotated      bool // заданы предыдущие мастер-ключи, ключи файлов нужно перешифровать
+}
+
+// newFileProviders создает локальное хранилище и все настроенные облачные хранилища
+func newFileProviders(ctx context.Context, cfg *config.Config) (*fileProviders, error) {
+	local := storage.NewLocalStorage(cfg.Files.BasePath, cfg.Files.BaseURL)
+	if cfg.Files.Deduplicate {
+		casStorage, err := storage.NewContentAddressedStorage(cfg.Files.BasePath, cfg.Files.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка инициализации хранилища файлов: %w", err)
+		}
+		local = casStorage
+		log.Println("Хранилище файлов работает в режиме дедупликации")
+	}
+	p := &fileProviders{
+		local:  local,
+		byType: map[string]storage.Provider{"local": local},
+	}
+
+	// Google Photos подключается, если задан OAuth-клиент
+	if cfg.Google.ClientID != "" {
+		p.googleClient = google.NewClient(cfg.Google.APIURL, google.OAuthConfig{
+			ClientID:     cfg.Google.ClientID,
+			ClientSecret: cfg.Google.ClientSecret,
+			RedirectURL:  cfg.Google.RedirectURL,
+		}, google.NewFileTokenStore(cfg.Google.TokenPath), nil)
+		p.byType[google.StorageType] = google.NewStorage(p.googleClient, cfg.Google.AlbumID)
+	}
+
+	// Dropbox подключается, если задан ключ приложения
+	if cfg.Dropbox.AppKey != "" {
+		dropboxStorage, err := dropbox.New(dropbox.Config{
+			AppKey:       cfg.Dropbox.AppKey,
+			AppSecret:    cfg.Dropbox.AppSecret,
+			RefreshToken: cfg.Dropbox.RefreshToken,
+			Root:         cfg.Dropbox.Root,
+			ChunkSize:    cfg.Dropbox.ChunkSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка инициализации хранилища Dropbox: %w", err)
+		}
+		p.byType[dropbox.StorageType] = dropboxStorage
+	}
+
+	// S3 подключается, если задан адрес сервиса
+	if cfg.S3.Endpoint != "" {
+		s3Storage, err := s3.New(s3.Config{
+			Endpoint:     cfg.S3.Endpoint,
+			Region:       cfg.S3.Region,
+			Bucket:       cfg.S3.Bucket,
+			AccessKey:    cfg.S3.AccessKey,
+			SecretKey:    cfg.S3.SecretKey,
+			Prefix:       cfg.S3.Prefix,
+			UsePathStyle: cfg.S3.UsePathStyle,
+			PartSize:     cfg.S3.PartSize,
+			PresignTTL:   cfg.S3.PresignTTL,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка инициализации хранилища S3: %w", err)
+		}
+		if cfg.S3.CreateBucket {
+			if err := s3Storage.EnsureBucket(ctx); err != nil {
+				return nil, fmt.Errorf("ошибка инициализации хранилища S3: %w", err)
+			}
+		}
+		p.byType["s3"] = s3Storage
+	}
+
+	if cfg.Files.EncryptionKey != "" {
+		if err := p.encrypt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// encrypt оборачивает хранилища шифрованием, кроме Google Photos
+func (p *fileProviders) encrypt(cfg *config.Config) error {
+	if cfg.Files.Deduplicate {
+		return fmt.Errorf("шифрование файлов несовместимо с режимом дедупликации MPM_FILES_DEDUP")
+	}
+
+	current, err := storage.ParseMasterKey(cfg.Files.EncryptionKey)
+	if err != nil {
+		return fmt.Errorf("некорректный MPM_ENCRYPTION_KEY: %w", err)
+	}
+	var previous [][]byte
+	for _, value := range cfg.Files.EncryptionOldKeys {
+		key, err := storage.ParseMasterKey(value)
+		if err != nil {
+			return fmt.Errorf("некорректный ключ в MPM_ENCRYPTION_OLD_KEYS: %w", err)
+		}
+		previous = append(previous, key)
+	}
+	keys, err := storage.NewKeyRing(current, previous...)
+	if err != nil {
+		return err
+	}
+
+	for storageType, provider := range p.byType {
+		if storageType == google.StorageType {
+			continue
+		}
+		encrypted := storage.NewEncryptedStorage(provider, keys)
+		if storageType == "local" {
+			// Ссылки локального хранилища ведут на /files, где файлы расшифровываются
+			encrypted.DelegateURLs()
+		}
+		if cfg.Files.EncryptionAllowPlaintext {
+			encrypted.AllowPlaintext()
+		}
+		p.byType[storageType] = encrypted
+	}
+	p.rotated = len(previous) > 0
+	log.Printf("Файлы шифруются мастер-ключом %s", keys.CurrentID())
+	return nil
+}
+
+// get возвращает хранилище указанного типа или ошибку с подсказкой, как его настроить
+func (p *fileProviders) get(storageType string) (storage.Provider, error) {
+	if provider, ok := p.byType[storageType]; ok {
+		return provider, nil
+	}
+	if setting, ok := providerSettings[storageType]; ok {
+		return nil, fmt.Errorf("хранилище файлов %s не настроено, задайте %s", storageType, setting)
+	}
+	return nil, fmt.Errorf("неизвестный тип хранилища файлов: %s", storageType)
+}
+
+// register подключает к сервису все хранилища, кроме основного
+func (p *fileProviders) register(photoService *service.PhotoService, primary string) {
+	for storageType, provider := range p.byType {
+		if storageType != primary {
+			photoService.RegisterProvider(storageType, provider)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,11 +12,33 @@ type Config struct {
 	ServerPort string
 	GRPCPort   string
 	JWT        JWTConfig
+	AdminUsers []string // Пользователи, которым доступны административные операции
 
 	// Storage configuration
 	StorageType  string // "json" or "mongodb"
 	JSONDataPath string
 	MongoDB      MongoDBConfig
+
+	// File storage configuration
+	Files   FilesConfig
+	S3      S3Config
+	Google  GoogleConfig
+	Dropbox DropboxConfig
+
+	// Resumable uploads (tus)
+	Uploads UploadsConfig
+
+	// Watched folders
+	Watch WatchConfig
+
+	// Storage scrub
+	Scrub ScrubConfig
+
+	// Trash
+	Trash TrashConfig
+
+	// Offline reverse geocoding
+	Geocoder GeocoderConfig
 }
 
 type JWTConfig struct {
@@ -48,6 +71,102 @@ type MongoDBConfig struct {
 	EnableChangeStreams bool
 }
 
+type FilesConfig struct {
+	// Provider
+	Provider string // "local", "s3", "google" или "dropbox"
+
+	// Local storage settings
+	BasePath string
+	BaseURL  string
+
+	// Upload limits
+	MaxUploadSize  int64  // максимальный размер одного файла в байтах
+	MaxUploadFiles int    // максимальное количество файлов в одном запросе
+	MaxImportSize  int64  // максимальный размер импортируемого ZIP-архива в байтах
+	ImportTempPath string // каталог распаковки файлов импортируемого архива
+
+	// Content-addressed mode
+	Deduplicate bool // хранить файлы по SHA-256 со счетчиком ссылок
+
+	// Migration between providers
+	MigrationStatePath string // файл со списком уже перенесенных файлов
+
+	// Renditions
+	RenditionSizes []int // размеры уменьшенных копий по большей стороне
+
+	// Signed URLs
+	SigningKey      string        // ключ подписи ссылок, по умолчанию выводится из JWT_SECRET
+	SignedURLTTL    time.Duration // срок действия ссылки по умолчанию
+	SignedURLMaxTTL time.Duration // максимальный срок действия, который может запросить клиент
+
+	// Encryption at rest
+	EncryptionKey            string   // мастер-ключ AES-256 в base64 или hex; пустое значение отключает шифрование
+	EncryptionOldKeys        []string // предыдущие мастер-ключи, нужны до перешифрования ключей файлов
+	EncryptionAllowPlaintext bool     // читать без расшифровки файлы, сохраненные до включения шифрования
+}
+
+type S3Config struct {
+	Endpoint     string        // адрес сервиса, например http://minio:9000
+	Region       string        // регион, для MinIO обычно us-east-1
+	Bucket       string        // имя бакета
+	AccessKey    string        // ключ доступа
+	SecretKey    string        // секретный ключ
+	Prefix       string        // префикс ключей объектов внутри бакета
+	UsePathStyle bool          // адресация endpoint/bucket/key, нужна для MinIO
+	PartSize     int64         // размер части составной загрузки в байтах
+	PresignTTL   time.Duration // срок действия ссылок на объекты
+	CreateBucket bool          // создавать бакет при запуске, если он не существует
+}
+
+type GoogleConfig struct {
+	ClientID        string // OAuth-клиент Google; пустое значение отключает Google Photos
+	ClientSecret    string
+	RedirectURL     string // адрес /google/callback, зарегистрированный у OAuth-клиента
+	APIURL          string // адрес Google Photos Library API
+	TokenPath       string // файл с токеном подключенного аккаунта
+	ImportStatePath string // файл соответствия медиафайлов Google и фотографий
+	AlbumID         string // альбом, созданный приложением, в который попадают загрузки
+}
+
+type DropboxConfig struct {
+	AppKey       string // ключ приложения Dropbox; пустое значение отключает Dropbox
+	AppSecret    string // секрет приложения
+	RefreshToken string // токен обновления, полученный при авторизации приложения
+	Root         string // каталог внутри Dropbox, в котором хранятся файлы
+	ChunkSize    int64  // размер части загрузки сессией; файлы больше загружаются частями
+}
+
+type WatchConfig struct {
+	Dirs      []string      // каталоги, из которых импортируются новые изображения
+	Interval  time.Duration // период просмотра каталогов
+	StableFor time.Duration // сколько файл должен не изменяться перед импортом
+	StatePath string        // файл с состоянием уже импортированных файлов
+}
+
+type ScrubConfig struct {
+	Interval          time.Duration // период проверки хранилища; 0 отключает проверку по расписанию
+	VerifyChecksums   bool          // сверять SHA-256 оригиналов, требует чтения всех файлов
+	QuarantineOrphans bool          // переносить ничьи файлы в каталог quarantine/
+	OrphanMinAge      time.Duration // возраст, после которого файл без фотографии считается ничьим
+}
+
+type TrashConfig struct {
+	Retention     time.Duration // срок хранения в корзине; 0 отключает автоматическую очистку
+	PurgeInterval time.Duration // период удаления просроченного содержимого корзины
+}
+
+type GeocoderConfig struct {
+	Enabled     bool    // определять страну, регион и город по координатам съемки
+	MaxDistance float64 // наибольшее расстояние до населенного пункта в километрах
+	CitiesFile  string  // выгрузка GeoNames, например cities15000.zip; без нее только около 300 крупных городов
+}
+
+type UploadsConfig struct {
+	Dir        string        // каталог незавершенных загрузок
+	MaxSize    int64         // максимальный размер одной загрузки в байтах
+	Expiration time.Duration // сколько хранить незавершенную загрузку
+}
+
 type CollectionNames struct {
 	Users    string
 	Albums   string
@@ -67,6 +186,7 @@ func LoadConfig() *Config {
 		JWT: JWTConfig{
 			Secret: getEnvOrDefault("JWT_SECRET", ""),
 		},
+		AdminUsers: getEnvListOrDefault("MPM_ADMIN_USERS", nil),
 
 		// Storage configuration
 		StorageType:  getEnvOrDefault("MPM_STORAGE_TYPE", "json"),
@@ -102,6 +222,98 @@ func LoadConfig() *Config {
 		},
 	}
 
+	// File storage configuration
+	cfg.Files = FilesConfig{
+		Provider:           getEnvOrDefault("MPM_FILES_PROVIDER", "local"),
+		BasePath:           getEnvOrDefault("MPM_FILES_PATH", cfg.JSONDataPath+"/files"),
+		BaseURL:            getEnvOrDefault("MPM_FILES_BASE_URL", "/files"),
+		MaxUploadSize:      getEnvInt64OrDefault("MPM_MAX_UPLOAD_SIZE", 50<<20),
+		MaxUploadFiles:     int(getEnvInt64OrDefault("MPM_MAX_UPLOAD_FILES", 20)),
+		MaxImportSize:      getEnvInt64OrDefault("MPM_MAX_IMPORT_SIZE", 2<<30),
+		ImportTempPath:     getEnvOrDefault("MPM_IMPORT_TMP_PATH", cfg.JSONDataPath+"/tmp/import"),
+		Deduplicate:        getEnvBoolOrDefault("MPM_FILES_DEDUP", false),
+		MigrationStatePath: getEnvOrDefault("MPM_MIGRATION_STATE_PATH", cfg.JSONDataPath+"/blob_migration.json"),
+		RenditionSizes:     getEnvIntListOrDefault("MPM_RENDITION_SIZES", []int{256, 1024, 2048}),
+
+		SigningKey:      getEnvOrDefault("MPM_URL_SIGNING_KEY", ""),
+		SignedURLTTL:    getEnvDurationOrDefault("MPM_SIGNED_URL_TTL", time.Hour),
+		SignedURLMaxTTL: getEnvDurationOrDefault("MPM_SIGNED_URL_MAX_TTL", 7*24*time.Hour),
+
+		EncryptionKey:            getEnvOrDefault("MPM_ENCRYPTION_KEY", ""),
+		EncryptionOldKeys:        getEnvListOrDefault("MPM_ENCRYPTION_OLD_KEYS", nil),
+		EncryptionAllowPlaintext: getEnvBoolOrDefault("MPM_ENCRYPTION_ALLOW_PLAINTEXT", false),
+	}
+
+	// S3-compatible file storage configuration
+	cfg.S3 = S3Config{
+		Endpoint:     getEnvOrDefault("MPM_S3_ENDPOINT", ""),
+		Region:       getEnvOrDefault("MPM_S3_REGION", "us-east-1"),
+		Bucket:       getEnvOrDefault("MPM_S3_BUCKET", "mpm"),
+		AccessKey:    getEnvOrDefault("MPM_S3_ACCESS_KEY", ""),
+		SecretKey:    getEnvOrDefault("MPM_S3_SECRET_KEY", ""),
+		Prefix:       getEnvOrDefault("MPM_S3_PREFIX", ""),
+		UsePathStyle: getEnvBoolOrDefault("MPM_S3_PATH_STYLE", true),
+		PartSize:     getEnvInt64OrDefault("MPM_S3_PART_SIZE", 16<<20),
+		PresignTTL:   getEnvDurationOrDefault("MPM_S3_PRESIGN_TTL", time.Hour),
+		CreateBucket: getEnvBoolOrDefault("MPM_S3_CREATE_BUCKET", false),
+	}
+
+	// Google Photos configuration
+	cfg.Google = GoogleConfig{
+		ClientID:        getEnvOrDefault("MPM_GOOGLE_CLIENT_ID", ""),
+		ClientSecret:    getEnvOrDefault("MPM_GOOGLE_CLIENT_SECRET", ""),
+		RedirectURL:     getEnvOrDefault("MPM_GOOGLE_REDIRECT_URL", "http://localhost:8484/google/callback"),
+		APIURL:          getEnvOrDefault("MPM_GOOGLE_API_URL", "https://photoslibrary.googleapis.com"),
+		TokenPath:       getEnvOrDefault("MPM_GOOGLE_TOKEN_PATH", cfg.JSONDataPath+"/google_token.json"),
+		ImportStatePath: getEnvOrDefault("MPM_GOOGLE_IMPORT_STATE_PATH", cfg.JSONDataPath+"/google_import.json"),
+		AlbumID:         getEnvOrDefault("MPM_GOOGLE_ALBUM_ID", ""),
+	}
+
+	// Dropbox configuration
+	cfg.Dropbox = DropboxConfig{
+		AppKey:       getEnvOrDefault("MPM_DROPBOX_APP_KEY", ""),
+		AppSecret:    getEnvOrDefault("MPM_DROPBOX_APP_SECRET", ""),
+		RefreshToken: getEnvOrDefault("MPM_DROPBOX_REFRESH_TOKEN", ""),
+		Root:         getEnvOrDefault("MPM_DROPBOX_ROOT", "/mpm"),
+		ChunkSize:    getEnvInt64OrDefault("MPM_DROPBOX_CHUNK_SIZE", 8<<20),
+	}
+
+	// Resumable uploads configuration
+	cfg.Uploads = UploadsConfig{
+		Dir:        getEnvOrDefault("MPM_UPLOADS_PATH", cfg.JSONDataPath+"/uploads"),
+		MaxSize:    getEnvInt64OrDefault("MPM_TUS_MAX_SIZE", 2<<30),
+		Expiration: getEnvDurationOrDefault("MPM_TUS_EXPIRATION", 24*time.Hour),
+	}
+
+	// Watched folders configuration
+	cfg.Watch = WatchConfig{
+		Dirs:      getEnvListOrDefault("MPM_WATCH_DIRS", nil),
+		Interval:  getEnvDurationOrDefault("MPM_WATCH_INTERVAL", 10*time.Second),
+		StableFor: getEnvDurationOrDefault("MPM_WATCH_STABLE_FOR", 5*time.Second),
+		StatePath: getEnvOrDefault("MPM_WATCH_STATE_PATH", cfg.JSONDataPath+"/watch_state.json"),
+	}
+
+	// Storage scrub configuration
+	cfg.Scrub = ScrubConfig{
+		Interval:          getEnvDurationOrDefault("MPM_SCRUB_INTERVAL", 24*time.Hour),
+		VerifyChecksums:   getEnvBoolOrDefault("MPM_SCRUB_VERIFY_CHECKSUMS", false),
+		QuarantineOrphans: getEnvBoolOrDefault("MPM_SCRUB_QUARANTINE", false),
+		OrphanMinAge:      getEnvDurationOrDefault("MPM_SCRUB_ORPHAN_MIN_AGE", 24*time.Hour),
+	}
+
+	// Trash configuration
+	cfg.Trash = TrashConfig{
+		Retention:     getEnvDurationOrDefault("MPM_TRASH_RETENTION", 30*24*time.Hour),
+		PurgeInterval: getEnvDurationOrDefault("MPM_TRASH_PURGE_INTERVAL", time.Hour),
+	}
+
+	// Reverse geocoding configuration
+	cfg.Geocoder = GeocoderConfig{
+		Enabled:     getEnvBoolOrDefault("MPM_GEOCODER_ENABLED", true),
+		MaxDistance: getEnvFloatOrDefault("MPM_GEOCODER_MAX_DISTANCE", 50),
+		CitiesFile:  getEnvOrDefault("MPM_GEOCODER_CITIES_FILE", ""),
+	}
+
 	// If MongoDB URI is not provided, construct it from individual settings
 	if cfg.MongoDB.URI == "" && cfg.MongoDB.Username != "" && cfg.MongoDB.Password != "" {
 		cfg.MongoDB.URI = "mongodb://" + cfg.MongoDB.Username + ":" + cfg.MongoDB.Password + "@" +
@@ -128,6 +340,56 @@ func getEnvUint64OrDefault(key string, defaultValue uint64) uint64 {
 	return defaultValue
 }
 
+func getEnvInt64OrDefault(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvListOrDefault(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+func getEnvIntListOrDefault(key string, defaultValue []int) []int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var result []int
+	for _, part := range strings.Split(value, ",") {
+		parsed, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || parsed <= 0 {
+			return defaultValue
+		}
+		result = append(result, parsed)
+	}
+	return result
+}
+
 func getEnvBoolOrDefault(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if parsed, err := strconv.ParseBool(value); err == nil {
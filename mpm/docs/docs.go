@@ -31,7 +31,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Получить список всех альбомов",
+                "description": "Получить список всех альбомов. Вместо полных данных фотографий возвращаются их количество, обложка и несколько превью.\nУмные альбомы отмечены полем smart, их фотографии подбираются по сохраненному запросу.",
                 "consumes": [
                     "application/json"
                 ],
@@ -42,16 +42,31 @@ const docTemplate = `{
                     "albums"
                 ],
                 "summary": "Получить все альбомы",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 4,
+                        "description": "Количество превью в каждом альбоме",
+                        "name": "previews",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Album"
+                                "$ref": "#/definitions/models.AlbumSummary"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Некорректное количество превью",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -66,7 +81,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Создать новый альбом на основе предоставленных данных",
+                "description": "Создать новый альбом на основе предоставленных данных. Альбом с полем filter становится умным:\nего фотографии не хранятся, а подбираются по сохраненному запросу при каждом обращении.\nПоля запроса совпадают с фильтром GET /photos: tags, from, to, camera, q, media, album_id, user_id,\nа также bbox - область съемки {min_lon, min_lat, max_lon, max_lat}.",
                 "consumes": [
                     "application/json"
                 ],
@@ -110,6 +125,67 @@ const docTemplate = `{
                 }
             }
         },
+        "/albums/import": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Создает альбом из ZIP-архива (multipart/form-data, поле file) и загружает в него все изображения.\nЕсли в архиве есть manifest.json, из него восстанавливаются название, описание и теги альбома,\nа также теги и метаданные фотографий. Ошибки отдельных файлов перечисляются в поле errors.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Импортировать альбом из архива",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "ZIP-архив",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название альбома",
+                        "name": "name",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/service.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный архив или ни один файл не импортирован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большой архив",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/albums/{id}": {
             "get": {
                 "security": [
@@ -117,7 +193,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Получить данные конкретного альбома по его идентификатору",
+                "description": "Получить данные конкретного альбома по его идентификатору.\nФотографии умного альбома (smart) подбираются по сохраненному запросу filter, от новых к старым.",
                 "consumes": [
                     "application/json"
                 ],
@@ -170,7 +246,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Обновить данные существующего альбома по его идентификатору",
+                "description": "Обновить данные существующего альбома по его идентификатору. Передача filter превращает альбом\nв умный, отсутствие filter - в обычный.",
                 "consumes": [
                     "application/json"
                 ],
@@ -232,7 +308,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Удалить альбом по его идентификатору",
+                "description": "Перемещает альбом и его фотографии в корзину. Восстановить альбом можно\nчерез POST /trash/albums/{id}/restore до окончания срока хранения.",
                 "tags": [
                     "albums"
                 ],
@@ -248,7 +324,59 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "204": {
-                        "description": "Альбом успешно удален"
+                        "description": "Альбом перемещен в корзину"
+                    },
+                    "400": {
+                        "description": "Некорректный ID альбома",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/albums/{id}/export.zip": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Передает ZIP-архив с оригиналами всех фотографий альбома и файлом manifest.json,\nсодержащим поля альбома, имена, теги и метаданные фотографий. Архив формируется на лету.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Скачать альбом архивом",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ZIP-архив",
+                        "schema": {
+                            "type": "file"
+                        }
                     },
                     "400": {
                         "description": "Некорректный ID альбома",
@@ -271,6 +399,132 @@ const docTemplate = `{
                 }
             }
         },
+        "/albums/{id}/map.geojson": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает фотографии альбома с координатами GPS в виде коллекции точек GeoJSON (RFC 7946).\nТочки сгруппированы для указанного масштаба, в свойствах передаются count и photo_ids.\nТочка с одной фотографией дополнительно содержит photo_id, name и taken_at.\nБез параметра zoom группируются только совпадающие точки.",
+                "produces": [
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Карта альбома в формате GeoJSON",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Масштаб карты от 0 до 20",
+                        "name": "zoom",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/geo.FeatureCollection"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/albums/{id}/photos": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Загрузить одну или несколько фотографий или видео MP4/MOV в альбом (multipart/form-data, поле files).\nВидео с тем же именем, что и снимок (IMG_0001.HEIC и IMG_0001.MOV), сохраняется как видеоролик Live Photo.\nФайлы, уже имеющиеся в библиотеке, сохраняются и перечисляются в поле duplicates.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Загрузить фотографии в альбом",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Файлы изображений и видео",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.uploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос или ни один файл не принят",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.uploadResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большой запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/auth/login": {
             "post": {
                 "description": "Авторизация пользователя и получение JWT токена",
@@ -323,29 +577,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/duplicates": {
             "get": {
-                "description": "Получить список всех зарегистрированных пользователей",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Возвращает группы визуально похожих фотографий во всей библиотеке",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "photos"
+                ],
+                "summary": "Найти группы похожих фотографий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 8,
+                        "description": "Максимальное расстояние Хэмминга (0-20)",
+                        "name": "threshold",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Получить всех пользователей",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/service.DuplicateCluster"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Некорректный порог",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -354,84 +625,3508 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.loginRequest": {
-            "type": "object",
-            "properties": {
-                "password": {
-                    "type": "string"
-                },
-                "username": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.loginResponse": {
-            "type": "object",
-            "properties": {
-                "token": {
-                    "type": "string"
-                }
-            }
         },
-        "models.Album": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "description": "Дата создания альбома",
-                    "type": "string"
-                },
-                "description": {
-                    "description": "Название альбома",
-                    "type": "string"
+        "/google/auth": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает адрес страницы, на которой пользователь разрешает доступ к библиотеке Google Photos.\nПосле согласия Google перенаправляет браузер на /google/callback, и аккаунт подключается.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "google"
+                ],
+                "summary": "Подключить Google Photos",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GoogleAuthURL"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/google/import": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Запускает в фоне импорт всех альбомов подключенной библиотеки. Файлы не копируются:\nфотографии ссылаются на медиафайлы Google Photos. Повторный импорт добавляет только новые медиафайлы.\nХод импорта возвращает GET /google/status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "google"
+                ],
+                "summary": "Импортировать альбомы Google Photos",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.GoogleImportStatus"
+                        }
+                    },
+                    "409": {
+                        "description": "Аккаунт не подключен или импорт уже выполняется",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/google/status": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает, подключен ли аккаунт Google Photos, и состояние текущего или последнего импорта",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "google"
+                ],
+                "summary": "Состояние Google Photos",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GoogleStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/memories/today": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает фотографии, снятые в тот же календарный день в предыдущие годы,\nсгруппированные по годам от ближайшего к самому давнему. В невисокосный год\n28 февраля включает снимки, сделанные 29 февраля.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timeline"
+                ],
+                "summary": "Воспоминания \"в этот день\"",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "День в формате YYYY-MM-DD, по умолчанию сегодня",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "album_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "photo",
+                            "video",
+                            "live_photo"
+                        ],
+                        "type": "string",
+                        "description": "Тип медиафайла",
+                        "name": "media",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.MemoryYear"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Получить фотографии с фильтрацией по альбому, тегу, пользователю, камере, типу медиафайла и диапазону дат съемки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Получить список фотографий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "album_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Тег (можно указать несколько)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало диапазона (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец диапазона (RFC3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Производитель или модель камеры",
+                        "name": "camera",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока названия, тега, города, региона или страны съемки",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип медиафайла: photo, video или live_photo",
+                        "name": "media",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: taken_at, created_at, name; префикс - для обратного порядка",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Photo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры фильтра",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos/geo": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает фотографии с координатами GPS внутри области, сгруппированные на сервере\nдля указанного масштаба карты. Группа объединяет фотографии, попадающие в квадрат\nсо стороной 60 пикселей. Если масштаб не указан, он подбирается по размеру области.\nОбласть, пересекающая 180-й меридиан, задается с minLon больше maxLon.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Фотографии в области карты",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "37.3,55.5,37.9,55.9",
+                        "description": "Область minLon,minLat,maxLon,maxLat",
+                        "name": "bbox",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Масштаб карты от 0 до 20",
+                        "name": "zoom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "album_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Теги (фотография должна содержать все)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Производитель или модель камеры",
+                        "name": "camera",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока названия, тега, города, региона или страны съемки",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "photo",
+                            "video",
+                            "live_photo"
+                        ],
+                        "type": "string",
+                        "description": "Тип медиафайла",
+                        "name": "media",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало диапазона дат съемки (YYYY-MM-DD или RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец диапазона дат съемки (YYYY-MM-DD или RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.GeoSearchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Получить данные конкретной фотографии по ее идентификатору",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Получить фотографию по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Photo"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Изменить название, альбом, теги или метаданные фотографии",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Обновить фотографию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменяемые поля фотографии",
+                        "name": "photo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PhotoUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Photo"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии или данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография или альбом не найдены",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Перемещает фотографию в корзину. Файлы удаляются при очистке корзины.",
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Удалить фотографию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Фотография перемещена в корзину"
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos/{id}/content": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Потоковая передача файла фотографии с поддержкой Range, ETag и Last-Modified",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Получить содержимое фотографии",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Диапазон байт, например bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag ранее полученной версии",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Содержимое файла",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Часть содержимого файла",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Файл не изменился"
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "416": {
+                        "description": "Запрошенный диапазон недоступен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos/{id}/motion": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Потоковая передача видеоролика Live Photo с поддержкой Range, ETag и Last-Modified",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Получить видеоролик Live Photo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Диапазон байт, например bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Видеоролик",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Часть видеоролика",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Файл не изменился"
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография или видеоролик не найдены",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "416": {
+                        "description": "Запрошенный диапазон недоступен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Сохраняет видео MP4 или MOV (multipart/form-data, поле file) как видеоролик Live Photo снимка.\nРанее прикрепленный видеоролик заменяется.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Прикрепить видеоролик Live Photo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Видеоролик",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Photo"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID, файл не является видео или фотография сама является видео",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большой файл",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos/{id}/similar": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает фотографии, визуально похожие на указанную, включая пережатые и уменьшенные копии.\nСравнение выполняется по перцептивному хешу, результаты упорядочены по расстоянию.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Найти похожие фотографии",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 8,
+                        "description": "Максимальное расстояние Хэмминга (0-20)",
+                        "name": "threshold",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.SimilarPhoto"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии или порог",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos/{id}/suggested-tags": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает город, регион и страну, определенные по координатам GPS без обращения к сети,\nкоторыми фотография еще не отмечена. Без координат список пуст.\nТеги в нижнем регистре, пробелы заменены дефисами, например krasnodar-krai.\nВстроенный справочник содержит около 300 крупных городов; полный справочник GeoNames\n(например, cities15000.zip) подключается через MPM_GEOCODER_CITIES_FILE.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Предложить теги места съемки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.suggestedTagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos/{id}/thumb": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Отдает наименьшую уменьшенную копию, большая сторона которой не меньше size. Если копий нет, отдается оригинал.\nУ видео уменьшенных копий нет.",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Получить уменьшенную копию фотографии",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 256,
+                        "description": "Размер по большей стороне в пикселях",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Уменьшенная копия",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Файл не изменился"
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии или размер",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/photos/{id}/url": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Выдает ссылку на оригинал или уменьшенную копию, которая не требует авторизации\nи действует ограниченное время. Подходит для вставки в письма и сообщения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Получить подписанную ссылку на фотографию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "original",
+                        "description": "Область действия: original или thumbnail",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 256,
+                        "description": "Размер уменьшенной копии по большей стороне",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Срок действия, например 30m или 24h",
+                        "name": "ttl",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.SignedURL"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Подписанные ссылки не настроены",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rules": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает все правила автоматической расстановки тегов, включая отключенные",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Список правил расстановки тегов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TagRule"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Создает правило, которое добавляет теги фотографиям, удовлетворяющим всем условиям.\nПравило применяется к новым фотографиям при загрузке, к уже загруженным - через POST /rules/apply.\nПоля условий: name, album_id, user_id, media, hour, location, metadata (с ключом key).\nОператоры: equals, contains, prefix, glob, regex, exists (только metadata),\nbetween (hour, например \"9-18\" или \"22-4\"), within (location, \"minLon,minLat,maxLon,maxLat\").\nТеги проверяются так же, как в PUT /photos/{id}: без пробелов.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Создать правило расстановки тегов",
+                "parameters": [
+                    {
+                        "description": "Правило",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное правило",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rules/apply": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Применяет все включенные правила к уже загруженным фотографиям, кроме находящихся в корзине.\nС dry_run=true фотографии не изменяются, возвращается только статистика.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Применить правила к библиотеке",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Только подсчитать изменения",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.RuleApplyResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rules/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Получить правило расстановки тегов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID правила",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Заменяет условия, теги и состояние правила. Теги, уже добавленные фотографиям, не удаляются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Обновить правило расстановки тегов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Правило",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID или правило",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Удаляет правило. Теги, уже добавленные им фотографиям, сохраняются.",
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Удалить правило расстановки тегов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Правило удалено"
+                    },
+                    "400": {
+                        "description": "Некорректный ID правила",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rules/{id}/apply": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Применяет одно правило к уже загруженным фотографиям, даже если оно отключено.\nС dry_run=true фотографии не изменяются, возвращается только статистика.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Применить правило к библиотеке",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только подсчитать изменения",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.RuleApplyResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/storage/migration": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает ход текущего или итоги последнего переноса файлов между хранилищами",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "Состояние переноса файлов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.MigrationStatus"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Запускает в фоне копирование файлов фотографий из одного хранилища в другое, например из local в s3.\nКаждый файл проверяется по SHA-256, после чего фотография переключается на новое хранилище.\nСервис продолжает работать, а прерванный перенос продолжается без повторного копирования файлов.\nХод переноса возвращает GET /storage/migration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "Перенести файлы в другое хранилище",
+                "parameters": [
+                    {
+                        "description": "Исходное и новое хранилища",
+                        "name": "options",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.MigrationOptions"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.MigrationStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Хранилище не настроено или совпадает с исходным",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Перенос уже выполняется",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/storage/scrub": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает ход текущей или итоги последней проверки хранилища файлов:\nотсутствующие и поврежденные файлы, фотографии удаленных альбомов и ничьи файлы",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "Отчет проверки хранилища",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ScrubReport"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Запускает в фоне сверку фотографий с файлами во всех подключенных хранилищах.\nНичьи файлы ищутся в хранилищах, которые умеют перечислять файлы (local, s3),\nи с quarantine_orphans переносятся в каталог quarantine/ вместо удаления.\nТело запроса необязательно. Отчет возвращает GET /storage/scrub.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "Проверить хранилище файлов",
+                "parameters": [
+                    {
+                        "description": "Параметры проверки",
+                        "name": "options",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/service.ScrubOptions"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.ScrubReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Проверка уже выполняется",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/timeline": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает фотографии от новых к старым, сгруппированные по дням, месяцам или годам.\nДля фотографий без даты съемки используется дата загрузки. Count периода учитывает\nвсю выборку, а не только текущую страницу. Для следующей страницы передайте next_cursor\nв параметре cursor с теми же фильтрами; период на границе страниц повторяется с тем же ключом.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "timeline"
+                ],
+                "summary": "Лента фотографий по дате съемки",
+                "parameters": [
+                    {
+                        "enum": [
+                            "day",
+                            "month",
+                            "year"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Группировка",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Количество фотографий на странице, не больше 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "album_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Теги (фотография должна содержать все)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Производитель или модель камеры",
+                        "name": "camera",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока названия, тега, города, региона или страны съемки",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "photo",
+                            "video",
+                            "live_photo"
+                        ],
+                        "type": "string",
+                        "description": "Тип медиафайла",
+                        "name": "media",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало диапазона дат съемки (YYYY-MM-DD или RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец диапазона дат съемки (YYYY-MM-DD или RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.TimelinePage"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает удаленные альбомы с количеством удаленных вместе с ними фотографий\nи фотографии, удаленные отдельно. purge_at - время окончательного удаления.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Содержимое корзины",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.Trash"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Окончательно удаляет все альбомы и фотографии из корзины вместе с их файлами",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Очистить корзину",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.TrashPurgeResult"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/albums/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Окончательно удаляет альбом из корзины вместе со всеми его фотографиями и их файлами",
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Удалить альбом навсегда",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Альбом удален"
+                    },
+                    "400": {
+                        "description": "Некорректный ID альбома",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден в корзине",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/albums/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает альбом из корзины вместе с фотографиями, удаленными вместе с ним",
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Восстановить альбом",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID альбома",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Альбом восстановлен"
+                    },
+                    "400": {
+                        "description": "Некорректный ID альбома",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден в корзине",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/photos/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Окончательно удаляет фотографию из корзины вместе с ее файлами",
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Удалить фотографию навсегда",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Фотография удалена"
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена в корзине",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/photos/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает фотографию из корзины. Фотографию удаленного альбома\nможно восстановить только вместе с альбомом.",
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Восстановить фотографию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID фотографии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Фотография восстановлена"
+                    },
+                    "400": {
+                        "description": "Некорректный ID фотографии",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция доступна только администраторам",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Фотография не найдена в корзине",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Альбом фотографии находится в корзине",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Создает загрузку по протоколу tus 1.0. В Upload-Metadata ожидаются ключи filename и album_id.\nТело запроса с Content-Type application/offset+octet-stream сразу записывается как первый блок.",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Начать возобновляемую загрузку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "1.0.0",
+                        "description": "Версия протокола",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Полный размер файла",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Метаданные: пары ключ base64(значение) через запятую",
+                        "name": "Upload-Metadata",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Location содержит адрес загрузки"
+                    },
+                    "400": {
+                        "description": "Некорректные заголовки или метаданные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Альбом не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Неподдерживаемая версия протокола",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Превышен максимальный размер",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "options": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает версию протокола, поддерживаемые расширения и максимальный размер загрузки",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Возможности сервера tus",
+                "responses": {
+                    "204": {
+                        "description": "Tus-Version, Tus-Extension, Tus-Max-Size"
+                    }
+                }
+            }
+        },
+        "/uploads/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Удаляет незавершенную загрузку и полученные данные (расширение termination)",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Отменить загрузку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID загрузки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1.0.0",
+                        "description": "Версия протокола",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Загрузка удалена"
+                    },
+                    "404": {
+                        "description": "Загрузка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Возвращает количество полученных байт, чтобы клиент мог продолжить загрузку",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Состояние загрузки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID загрузки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1.0.0",
+                        "description": "Версия протокола",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload-Offset и Upload-Length"
+                    },
+                    "403": {
+                        "description": "Загрузка принадлежит другому пользователю",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Загрузка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Дописывает блок начиная с Upload-Offset. После получения последнего байта файл\nсохраняется как фотография, ссылка на нее возвращается в Content-Location. Если сохранить файл\nне удалось из-за временной ошибки, полученные данные остаются: запрос с Upload-Offset = Upload-Length\nи пустым телом повторяет сохранение.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Передать блок данных",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID загрузки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1.0.0",
+                        "description": "Версия протокола",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение блока",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Upload-Offset содержит новое смещение"
+                    },
+                    "404": {
+                        "description": "Загрузка не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Смещение не совпадает",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Блок выходит за пределы Upload-Length",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Неверный Content-Type",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Файл получен, но не может быть сохранен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Временная ошибка сохранения, запрос можно повторить",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Получить список всех зарегистрированных пользователей",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить всех пользователей",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "geo.BBox": {
+            "type": "object",
+            "properties": {
+                "max_lat": {
+                    "type": "number"
+                },
+                "max_lon": {
+                    "type": "number"
+                },
+                "min_lat": {
+                    "type": "number"
+                },
+                "min_lon": {
+                    "type": "number"
+                }
+            }
+        },
+        "geo.Cluster": {
+            "type": "object",
+            "properties": {
+                "bbox": {
+                    "description": "Границы точек группы",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/geo.BBox"
+                        }
+                    ]
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
+                },
+                "photo_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "geo.Feature": {
+            "type": "object",
+            "properties": {
+                "geometry": {
+                    "$ref": "#/definitions/geo.Geometry"
+                },
+                "properties": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Feature"
+                }
+            }
+        },
+        "geo.FeatureCollection": {
+            "type": "object",
+            "properties": {
+                "bbox": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/geo.Feature"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "FeatureCollection"
+                }
+            }
+        },
+        "geo.Geometry": {
+            "type": "object",
+            "properties": {
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "Point"
+                }
+            }
+        },
+        "handlers.GoogleAuthURL": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.GoogleStatus": {
+            "type": "object",
+            "properties": {
+                "connected": {
+                    "type": "boolean"
+                },
+                "import": {
+                    "$ref": "#/definitions/service.GoogleImportStatus"
+                }
+            }
+        },
+        "handlers.loginRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.loginResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.suggestedTagsResponse": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.uploadDuplicate": {
+            "type": "object",
+            "properties": {
+                "duplicate_of": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "photo_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.uploadError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.uploadResponse": {
+            "type": "object",
+            "properties": {
+                "duplicates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.uploadDuplicate"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.uploadError"
+                    }
+                },
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Photo"
+                    }
+                }
+            }
+        },
+        "models.Album": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата создания альбома",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Дата перемещения в корзину",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Название альбома",
+                    "type": "string"
+                },
+                "filter": {
+                    "description": "Filter сохраненный запрос, по которому подбираются фотографии умного альбома",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PhotoFilter"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Уникальный идентификатор альбома",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "photos": {
+                    "description": "Фотографии в альбоме",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Photo"
+                    }
+                },
+                "tags": {
+                    "description": "Теги альбома",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user": {
+                    "description": "Пользователь, который создал альбом",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.AlbumSummary": {
+            "type": "object",
+            "properties": {
+                "cover": {
+                    "$ref": "#/definitions/models.PhotoPreview"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "filter": {
+                    "$ref": "#/definitions/models.PhotoFilter"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "photo_count": {
+                    "type": "integer"
+                },
+                "previews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PhotoPreview"
+                    }
+                },
+                "smart": {
+                    "type": "boolean"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.Metadata": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "description": "Тип метаданных (например, \"camera\", \"location\", \"date_taken\")",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Значение метаданных",
+                    "type": "string"
+                }
+            }
+        },
+        "models.MotionClip": {
+            "type": "object",
+            "properties": {
+                "mime_type": {
+                    "description": "MIME-тип ролика",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Путь к файлу ролика в хранилище",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Размер файла в байтах",
+                    "type": "integer"
+                },
+                "video": {
+                    "$ref": "#/definitions/models.VideoInfo"
+                }
+            }
+        },
+        "models.Photo": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "description": "Альбом, к которому принадлежит фотография",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Album"
+                        }
+                    ]
+                },
+                "checksum": {
+                    "description": "SHA-256 содержимого файла",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Дата перемещения в корзину",
+                    "type": "string"
+                },
+                "height": {
+                    "description": "Высота с учетом ориентации",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор фотографии",
+                    "type": "integer"
+                },
+                "media_type": {
+                    "description": "photo, video или live_photo; пусто у загруженных ранее снимков",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Metadata"
+                    }
+                },
+                "mime_type": {
+                    "description": "MIME-тип файла, определенный по содержимому",
+                    "type": "string"
+                },
+                "motion": {
+                    "description": "Видеоролик Live Photo",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MotionClip"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "Название фотографии",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Путь к фотографии (локальный или url)",
+                    "type": "string"
+                },
+                "phash": {
+                    "description": "Перцептивный хеш (dHash) в шестнадцатеричном виде",
+                    "type": "string"
+                },
+                "renditions": {
+                    "description": "Уменьшенные копии",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Rendition"
+                    }
+                },
+                "size": {
+                    "description": "Размер файла в байтах",
+                    "type": "integer"
+                },
+                "storage_type": {
+                    "description": "Тип хранения фотографии (local, google, dropbox)",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Теги фотографии",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "taken_at": {
+                    "description": "Дата съемки из EXIF",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "Пользователь, который загрузил фотографию",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    ]
+                },
+                "video": {
+                    "description": "Параметры видео для MediaType video",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.VideoInfo"
+                        }
+                    ]
+                },
+                "width": {
+                    "description": "Ширина с учетом ориентации",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PhotoFilter": {
+            "type": "object",
+            "properties": {
+                "album_id": {
+                    "description": "Фотографии из указанного альбома",
+                    "type": "integer"
+                },
+                "bbox": {
+                    "description": "Область карты, в которой сделан снимок",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/geo.BBox"
+                        }
+                    ]
+                },
+                "camera": {
+                    "description": "Подстрока производителя или модели камеры",
+                    "type": "string"
+                },
+                "checksum": {
+                    "description": "Фотографии с указанной контрольной суммой",
+                    "type": "string"
+                },
+                "from": {
+                    "description": "Начало диапазона дат (включительно)",
+                    "type": "string"
+                },
+                "media": {
+                    "description": "Тип медиафайла: photo, video или live_photo",
+                    "type": "string"
+                },
+                "q": {
+                    "description": "Подстрока названия, тега или места съемки",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Фотография должна содержать все указанные теги",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "to": {
+                    "description": "Конец диапазона дат (включительно)",
+                    "type": "string"
+                },
+                "trashed": {
+                    "description": "Фотографии в корзине вместо обычных",
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "description": "Фотографии, загруженные пользователем",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PhotoPreview": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Rendition": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "description": "Фактическая высота копии",
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "Путь к файлу копии в хранилище",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Максимальная сторона, для которой создана копия",
+                    "type": "integer"
+                },
+                "width": {
+                    "description": "Фактическая ширина копии",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RuleCondition": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "Поле фотографии",
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Ключ метаданных для поля metadata",
+                    "type": "string"
+                },
+                "op": {
+                    "description": "Оператор сравнения",
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TagRule": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RuleCondition"
+                    }
+                },
+                "created_at": {
+                    "description": "Дата создания правила",
+                    "type": "string"
+                },
+                "disabled": {
+                    "description": "Отключенное правило не применяется",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Добавляемые теги",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "description": "Дата последнего изменения",
+                    "type": "string"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата регистрации пользователя",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email пользователя",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор пользователя",
+                    "type": "integer"
+                },
+                "password": {
+                    "description": "Хэш пароля (не возвращается в API)",
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Имя пользователя",
+                    "type": "string"
+                }
+            }
+        },
+        "models.VideoInfo": {
+            "type": "object",
+            "properties": {
+                "audio_codec": {
+                    "description": "FourCC звуковой дорожки, например mp4a",
+                    "type": "string"
+                },
+                "content_id": {
+                    "description": "Идентификатор Live Photo от камеры Apple",
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "Длительность в секундах",
+                    "type": "number"
+                },
+                "frame_rate": {
+                    "description": "Кадров в секунду",
+                    "type": "number"
+                },
+                "height": {
+                    "description": "Высота кадра с учетом поворота",
+                    "type": "integer"
+                },
+                "video_codec": {
+                    "description": "FourCC видеодорожки, например avc1 или hvc1",
+                    "type": "string"
+                },
+                "width": {
+                    "description": "Ширина кадра с учетом поворота",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.DuplicateCluster": {
+            "type": "object",
+            "properties": {
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Photo"
+                    }
+                }
+            }
+        },
+        "service.GeoSearchResult": {
+            "type": "object",
+            "properties": {
+                "bbox": {
+                    "$ref": "#/definitions/geo.BBox"
+                },
+                "clusters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/geo.Cluster"
+                    }
+                },
+                "total": {
+                    "description": "Количество фотографий во всех группах",
+                    "type": "integer"
+                },
+                "zoom": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.GoogleImportStatus": {
+            "type": "object",
+            "properties": {
+                "albums": {
+                    "description": "Обработано альбомов",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Ошибка, прервавшая импорт",
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ImportError"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "imported": {
+                    "description": "Добавлено фотографий",
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "skipped": {
+                    "description": "Пропущено уже импортированных",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "file": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ImportResult": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "$ref": "#/definitions/models.Album"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ImportError"
+                    }
+                },
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Photo"
+                    }
+                }
+            }
+        },
+        "service.MemoryYear": {
+            "type": "object",
+            "properties": {
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Photo"
+                    }
+                },
+                "year": {
+                    "type": "integer"
+                },
+                "years_ago": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.MigrationError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "photo_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.MigrationOptions": {
+            "type": "object",
+            "properties": {
+                "delete_source": {
+                    "description": "Удалять файлы из исходного хранилища после переноса",
+                    "type": "boolean"
+                },
+                "from": {
+                    "description": "Тип хранилища, из которого переносятся файлы",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "Тип хранилища, в которое переносятся файлы",
+                    "type": "string"
+                }
+            }
+        },
+        "service.MigrationStatus": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "description": "Скопировано байт",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Ошибка, прервавшая перенос",
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.MigrationError"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "migrated": {
+                    "description": "Перенесено фотографий",
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Фотографий в исходном хранилище на момент запуска",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.PhotoUpdate": {
+            "type": "object",
+            "properties": {
+                "album_id": {
+                    "type": "integer"
+                },
+                "metadata": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Metadata"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "service.RuleApplyResult": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "description": "Проверено фотографий",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "Изменения не сохранены",
+                    "type": "boolean"
+                },
+                "tags": {
+                    "description": "Количество фотографий, получивших каждый тег",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "updated": {
+                    "description": "Фотографий, получивших новые теги",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.ScrubIssue": {
+            "type": "object",
+            "properties": {
+                "album_id": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "photo_id": {
+                    "type": "integer"
+                },
+                "quarantined": {
+                    "description": "Путь ничьего файла в карантине",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "storage_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ScrubOptions": {
+            "type": "object",
+            "properties": {
+                "quarantine_orphans": {
+                    "description": "Переносить ничьи файлы в каталог quarantine/",
+                    "type": "boolean"
+                },
+                "verify_checksums": {
+                    "description": "Сверять SHA-256 оригиналов, требует чтения всех файлов",
+                    "type": "boolean"
+                }
+            }
+        },
+        "service.ScrubReport": {
+            "type": "object",
+            "properties": {
+                "album_missing": {
+                    "description": "Фотографий удаленных альбомов",
+                    "type": "integer"
+                },
+                "checksum_mismatches": {
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Ошибка, прервавшая проверку",
+                    "type": "string"
+                },
+                "files": {
+                    "description": "Проверено файлов фотографий",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ScrubIssue"
+                    }
+                },
+                "listed": {
+                    "description": "Файлов в хранилищах, которые умеют перечислять файлы",
+                    "type": "integer"
+                },
+                "missing": {
+                    "description": "Отсутствующих файлов",
+                    "type": "integer"
+                },
+                "options": {
+                    "$ref": "#/definitions/service.ScrubOptions"
+                },
+                "orphan_bytes": {
+                    "type": "integer"
+                },
+                "orphans": {
+                    "description": "Ничьих файлов",
+                    "type": "integer"
+                },
+                "photos": {
+                    "description": "Проверено фотографий",
+                    "type": "integer"
+                },
+                "quarantined": {
+                    "description": "Ничьих файлов, перенесенных в карантин",
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "unlisted": {
+                    "description": "Хранилища, в которых ничьи файлы не ищутся",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unreadable": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.SignedURL": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.SimilarPhoto": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "description": "Альбом, к которому принадлежит фотография",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Album"
+                        }
+                    ]
+                },
+                "checksum": {
+                    "description": "SHA-256 содержимого файла",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Дата перемещения в корзину",
+                    "type": "string"
+                },
+                "distance": {
+                    "description": "Расстояние Хэмминга между перцептивными хешами",
+                    "type": "integer"
+                },
+                "height": {
+                    "description": "Высота с учетом ориентации",
+                    "type": "integer"
                 },
                 "id": {
-                    "description": "Уникальный идентификатор альбома",
+                    "description": "Уникальный идентификатор фотографии",
                     "type": "integer"
                 },
+                "media_type": {
+                    "description": "photo, video или live_photo; пусто у загруженных ранее снимков",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Metadata"
+                    }
+                },
+                "mime_type": {
+                    "description": "MIME-тип файла, определенный по содержимому",
+                    "type": "string"
+                },
+                "motion": {
+                    "description": "Видеоролик Live Photo",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MotionClip"
+                        }
+                    ]
+                },
                 "name": {
+                    "description": "Название фотографии",
                     "type": "string"
                 },
-                "photos": {
-                    "description": "Фотографии в альбоме",
+                "path": {
+                    "description": "Путь к фотографии (локальный или url)",
+                    "type": "string"
+                },
+                "phash": {
+                    "description": "Перцептивный хеш (dHash) в шестнадцатеричном виде",
+                    "type": "string"
+                },
+                "renditions": {
+                    "description": "Уменьшенные копии",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Photo"
+                        "$ref": "#/definitions/models.Rendition"
                     }
                 },
+                "size": {
+                    "description": "Размер файла в байтах",
+                    "type": "integer"
+                },
+                "storage_type": {
+                    "description": "Тип хранения фотографии (local, google, dropbox)",
+                    "type": "string"
+                },
                 "tags": {
-                    "description": "Теги альбома",
+                    "description": "Теги фотографии",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "taken_at": {
+                    "description": "Дата съемки из EXIF",
+                    "type": "string"
+                },
                 "user": {
-                    "description": "Пользователь, который создал альбом",
+                    "description": "Пользователь, который загрузил фотографию",
                     "allOf": [
                         {
                             "$ref": "#/definitions/models.User"
                         }
                     ]
+                },
+                "video": {
+                    "description": "Параметры видео для MediaType video",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.VideoInfo"
+                        }
+                    ]
+                },
+                "width": {
+                    "description": "Ширина с учетом ориентации",
+                    "type": "integer"
                 }
             }
         },
-        "models.Metadata": {
+        "service.TimelineBucket": {
             "type": "object",
             "properties": {
+                "count": {
+                    "description": "Количество фотографий за период во всей выборке, а не только на странице",
+                    "type": "integer"
+                },
                 "key": {
-                    "description": "Тип метаданных (например, \"camera\", \"location\", \"date_taken\")",
+                    "description": "2024-06-01, 2024-06 или 2024",
                     "type": "string"
                 },
-                "value": {
-                    "description": "Значение метаданных",
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Photo"
+                    }
+                },
+                "start": {
+                    "description": "Начало периода",
                     "type": "string"
                 }
             }
         },
-        "models.Photo": {
+        "service.TimelinePage": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TimelineBucket"
+                    }
+                },
+                "group": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "description": "Пустой на последней странице",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Количество фотографий во всей выборке",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.Trash": {
+            "type": "object",
+            "properties": {
+                "albums": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TrashedAlbum"
+                    }
+                },
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TrashedPhoto"
+                    }
+                }
+            }
+        },
+        "service.TrashPurgeResult": {
+            "type": "object",
+            "properties": {
+                "albums": {
+                    "type": "integer"
+                },
+                "photos": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TrashedAlbum": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата создания альбома",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Дата перемещения в корзину",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Название альбома",
+                    "type": "string"
+                },
+                "filter": {
+                    "description": "Filter сохраненный запрос, по которому подбираются фотографии умного альбома",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PhotoFilter"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "Уникальный идентификатор альбома",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "photo_count": {
+                    "description": "Фотографии, удаленные вместе с альбомом",
+                    "type": "integer"
+                },
+                "photos": {
+                    "description": "Фотографии в альбоме",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Photo"
+                    }
+                },
+                "purge_at": {
+                    "description": "Время окончательного удаления",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Теги альбома",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user": {
+                    "description": "Пользователь, который создал альбом",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    ]
+                }
+            }
+        },
+        "service.TrashedPhoto": {
             "type": "object",
             "properties": {
                 "album": {
@@ -442,19 +4137,47 @@ const docTemplate = `{
                         }
                     ]
                 },
+                "checksum": {
+                    "description": "SHA-256 содержимого файла",
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "description": "Дата перемещения в корзину",
+                    "type": "string"
+                },
+                "height": {
+                    "description": "Высота с учетом ориентации",
+                    "type": "integer"
+                },
                 "id": {
                     "description": "Уникальный идентификатор фотографии",
                     "type": "integer"
                 },
+                "media_type": {
+                    "description": "photo, video или live_photo; пусто у загруженных ранее снимков",
+                    "type": "string"
+                },
                 "metadata": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.Metadata"
                     }
                 },
+                "mime_type": {
+                    "description": "MIME-тип файла, определенный по содержимому",
+                    "type": "string"
+                },
+                "motion": {
+                    "description": "Видеоролик Live Photo",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MotionClip"
+                        }
+                    ]
+                },
                 "name": {
                     "description": "Название фотографии",
                     "type": "string"
@@ -463,6 +4186,25 @@ const docTemplate = `{
                     "description": "Путь к фотографии (локальный или url)",
                     "type": "string"
                 },
+                "phash": {
+                    "description": "Перцептивный хеш (dHash) в шестнадцатеричном виде",
+                    "type": "string"
+                },
+                "purge_at": {
+                    "description": "Время окончательного удаления",
+                    "type": "string"
+                },
+                "renditions": {
+                    "description": "Уменьшенные копии",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Rendition"
+                    }
+                },
+                "size": {
+                    "description": "Размер файла в байтах",
+                    "type": "integer"
+                },
                 "storage_type": {
                     "description": "Тип хранения фотографии (local, google, dropbox)",
                     "type": "string"
@@ -474,6 +4216,10 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "taken_at": {
+                    "description": "Дата съемки из EXIF",
+                    "type": "string"
+                },
                 "user": {
                     "description": "Пользователь, который загрузил фотографию",
                     "allOf": [
@@ -481,27 +4227,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.User"
                         }
                     ]
-                }
-            }
-        },
-        "models.User": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "description": "Дата регистрации пользователя",
-                    "type": "string"
                 },
-                "email": {
-                    "description": "Email пользователя",
-                    "type": "string"
+                "video": {
+                    "description": "Параметры видео для MediaType video",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.VideoInfo"
+                        }
+                    ]
                 },
-                "id": {
-                    "description": "Уникальный идентификатор пользователя",
+                "width": {
+                    "description": "Ширина с учетом ориентации",
                     "type": "integer"
-                },
-                "username": {
-                    "description": "Имя пользователя",
-                    "type": "string"
                 }
             }
         }
@@ -0,0 +1,463 @@
+// Package exif извлекает EXIF-метаданные из JPEG и TIFF файлов без внешних утилит
+package exif
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrNoExif возвращается, если в файле нет EXIF-данных
+var ErrNoExif = errors.New("EXIF-данные не найдены")
+
+// Максимальное количество записей в одном IFD, защищает от поврежденных файлов
+const maxIFDEntries = 1024
+
+// Теги IFD0
+const (
+	tagMake        = 0x010F
+	tagModel       = 0x0110
+	tagOrientation = 0x0112
+	tagDateTime    = 0x0132
+	tagExifIFD     = 0x8769
+	tagGPSIFD      = 0x8825
+)
+
+// Теги Exif IFD
+const (
+	tagExposureTime       = 0x829A
+	tagFNumber            = 0x829D
+	tagISO                = 0x8827
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagFocalLength        = 0x920A
+	tagBodySerialNumber   = 0xA431
+	tagLensMake           = 0xA433
+	tagLensModel          = 0xA434
+	tagFocalLength35mm    = 0xA405
+)
+
+// Теги GPS IFD
+const (
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitudeRef  = 0x0005
+	tagGPSAltitude     = 0x0006
+)
+
+// Типы значений TIFF
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+	typeSLong     = 9
+	typeSRational = 10
+)
+
+// typeSizes размер одного значения для каждого типа TIFF
+var typeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// GPS координаты снимка
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  *float64 // Высота над уровнем моря в метрах
+}
+
+// Data содержит извлеченные EXIF-значения
+type Data struct {
+	Make            string
+	Model           string
+	LensMake        string
+	LensModel       string
+	SerialNumber    string
+	ExposureTime    string  // Выдержка в виде дроби, например "1/250"
+	FNumber         float64 // Диафрагма
+	ISO             int
+	FocalLength     float64 // Фокусное расстояние в мм
+	FocalLength35mm int     // Эквивалентное фокусное расстояние для 35 мм
+	DateTaken       time.Time
+	Orientation     int // Ориентация по EXIF (1-8), 0 если не указана
+	GPS             *GPS
+}
+
+// Decode извлекает EXIF из JPEG или TIFF файла
+func Decode(r io.ReaderAt, size int64) (*Data, error) {
+	header := make([]byte, 4)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, ErrNoExif
+	}
+
+	switch {
+	case header[0] == 0xFF && header[1] == 0xD8:
+		tiff, err := findJPEGExif(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return decodeTIFF(tiff)
+	case string(header[:2]) == "II" || string(header[:2]) == "MM":
+		return decodeTIFF(io.NewSectionReader(r, 0, size))
+	default:
+		return nil, ErrNoExif
+	}
+}
+
+// findJPEGExif находит сегмент APP1 с EXIF и возвращает его TIFF-содержимое
+func findJPEGExif(r io.ReaderAt, size int64) (*io.SectionReader, error) {
+	offset := int64(2) // пропускаем SOI
+	marker := make([]byte, 4)
+
+	for offset+4 <= size {
+		if _, err := r.ReadAt(marker, offset); err != nil {
+			return nil, ErrNoExif
+		}
+		if marker[0] != 0xFF {
+			return nil, ErrNoExif
+		}
+
+		code := marker[1]
+		// Маркеры без длины
+		if code == 0xD8 || code == 0x01 || (code >= 0xD0 && code <= 0xD7) {
+			offset += 2
+			continue
+		}
+		// Начало данных изображения или конец файла: дальше метаданных нет
+		if code == 0xDA || code == 0xD9 {
+			return nil, ErrNoExif
+		}
+
+		length := int64(binary.BigEndian.Uint16(marker[2:4]))
+		if length < 2 {
+			return nil, ErrNoExif
+		}
+
+		if code == 0xE1 && length >= 8 {
+			ident := make([]byte, 6)
+			if _, err := r.ReadAt(ident, offset+4); err == nil && string(ident) == "Exif\x00\x00" {
+				return io.NewSectionReader(r, offset+10, length-8), nil
+			}
+		}
+
+		offset += 2 + length
+	}
+
+	return nil, ErrNoExif
+}
+
+// tiffReader читает структуры TIFF с учетом порядка байт
+type tiffReader struct {
+	r     *io.SectionReader
+	order binary.ByteOrder
+}
+
+// ifdEntry запись каталога TIFF
+type ifdEntry struct {
+	tag    uint16
+	typ    uint16
+	count  uint32
+	offset uint32 // смещение значения или само значение, если оно помещается в 4 байта
+	raw    [4]byte
+}
+
+// decodeTIFF разбирает TIFF-заголовок и каталоги IFD0, Exif и GPS
+func decodeTIFF(r *io.SectionReader) (*Data, error) {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, ErrNoExif
+	}
+
+	t := &tiffReader{r: r}
+	switch string(header[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, ErrNoExif
+	}
+	if t.order.Uint16(header[2:4]) != 42 {
+		return nil, ErrNoExif
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(header[4:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	data := &Data{}
+	var dateTime, dateTimeOriginal, offsetTimeOriginal string
+
+	for _, e := range ifd0 {
+		switch e.tag {
+		case tagMake:
+			data.Make = t.ascii(e)
+		case tagModel:
+			data.Model = t.ascii(e)
+		case tagOrientation:
+			data.Orientation = int(t.uint(e))
+		case tagDateTime:
+			dateTime = t.ascii(e)
+		case tagExifIFD:
+			exifIFD, err := t.readIFD(t.uint(e))
+			if err != nil {
+				continue
+			}
+			for _, x := range exifIFD {
+				switch x.tag {
+				case tagExposureTime:
+					if num, den, ok := t.rational(x, 0); ok {
+						data.ExposureTime = formatExposure(num, den)
+					}
+				case tagFNumber:
+					data.FNumber = t.float(x, 0)
+				case tagISO:
+					data.ISO = int(t.uint(x))
+				case tagDateTimeOriginal:
+					dateTimeOriginal = t.ascii(x)
+				case tagOffsetTimeOriginal:
+					offsetTimeOriginal = t.ascii(x)
+				case tagFocalLength:
+					data.FocalLength = t.float(x, 0)
+				case tagFocalLength35mm:
+					data.FocalLength35mm = int(t.uint(x))
+				case tagBodySerialNumber:
+					data.SerialNumber = t.ascii(x)
+				case tagLensMake:
+					data.LensMake = t.ascii(x)
+				case tagLensModel:
+					data.LensModel = t.ascii(x)
+				}
+			}
+		case tagGPSIFD:
+			gpsIFD, err := t.readIFD(t.uint(e))
+			if err != nil {
+				continue
+			}
+			data.GPS = t.parseGPS(gpsIFD)
+		}
+	}
+
+	if dateTimeOriginal != "" {
+		data.DateTaken = parseDateTime(dateTimeOriginal, offsetTimeOriginal)
+	}
+	if data.DateTaken.IsZero() && dateTime != "" {
+		data.DateTaken = parseDateTime(dateTime, "")
+	}
+
+	return data, nil
+}
+
+// readIFD читает записи каталога по смещению
+func (t *tiffReader) readIFD(offset uint32) ([]ifdEntry, error) {
+	countBuf := make([]byte, 2)
+	if _, err := t.r.ReadAt(countBuf, int64(offset)); err != nil {
+		return nil, fmt.Errorf("ошибка чтения IFD: %w", err)
+	}
+
+	count := int(t.order.Uint16(countBuf))
+	if count == 0 || count > maxIFDEntries {
+		return nil, fmt.Errorf("некорректное количество записей IFD: %d", count)
+	}
+
+	buf := make([]byte, count*12)
+	if _, err := t.r.ReadAt(buf, int64(offset)+2); err != nil {
+		return nil, fmt.Errorf("ошибка чтения IFD: %w", err)
+	}
+
+	entries := make([]ifdEntry, count)
+	for i := range entries {
+		b := buf[i*12 : (i+1)*12]
+		entries[i] = ifdEntry{
+			tag:    t.order.Uint16(b[0:2]),
+			typ:    t.order.Uint16(b[2:4]),
+			count:  t.order.Uint32(b[4:8]),
+			offset: t.order.Uint32(b[8:12]),
+		}
+		copy(entries[i].raw[:], b[8:12])
+	}
+	return entries, nil
+}
+
+// value возвращает байты значения записи
+func (t *tiffReader) value(e ifdEntry) []byte {
+	size, ok := typeSizes[e.typ]
+	if !ok || e.count == 0 || e.count > 1<<16 {
+		return nil
+	}
+
+	total := size * e.count
+	if total <= 4 {
+		return e.raw[:total]
+	}
+
+	buf := make([]byte, total)
+	if _, err := t.r.ReadAt(buf, int64(e.offset)); err != nil {
+		return nil
+	}
+	return buf
+}
+
+// ascii возвращает строковое значение без завершающих нулей и пробелов
+func (t *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != typeASCII && e.typ != typeUndefined {
+		return ""
+	}
+	s := string(t.value(e))
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// uint возвращает первое целочисленное значение записи
+func (t *tiffReader) uint(e ifdEntry) uint32 {
+	b := t.value(e)
+	switch e.typ {
+	case typeByte, typeUndefined:
+		if len(b) >= 1 {
+			return uint32(b[0])
+		}
+	case typeShort:
+		if len(b) >= 2 {
+			return uint32(t.order.Uint16(b))
+		}
+	case typeLong, typeSLong:
+		if len(b) >= 4 {
+			return t.order.Uint32(b)
+		}
+	}
+	return 0
+}
+
+// rational возвращает числитель и знаменатель i-го рационального значения
+func (t *tiffReader) rational(e ifdEntry, i int) (int64, int64, bool) {
+	if e.typ != typeRational && e.typ != typeSRational {
+		return 0, 0, false
+	}
+	b := t.value(e)
+	if len(b) < (i+1)*8 {
+		return 0, 0, false
+	}
+	b = b[i*8:]
+
+	if e.typ == typeSRational {
+		num := int64(int32(t.order.Uint32(b[0:4])))
+		den := int64(int32(t.order.Uint32(b[4:8])))
+		return num, den, den != 0
+	}
+	num := int64(t.order.Uint32(b[0:4]))
+	den := int64(t.order.Uint32(b[4:8]))
+	return num, den, den != 0
+}
+
+// float возвращает i-е рациональное значение в виде числа
+func (t *tiffReader) float(e ifdEntry, i int) float64 {
+	num, den, ok := t.rational(e, i)
+	if !ok {
+		return 0
+	}
+	return float64(num) / float64(den)
+}
+
+// parseGPS собирает координаты из каталога GPS
+func (t *tiffReader) parseGPS(entries []ifdEntry) *GPS {
+	var latRef, lonRef string
+	var lat, lon ifdEntry
+	var alt *float64
+	var altBelowSea bool
+	var hasLat, hasLon bool
+
+	for _, e := range entries {
+		switch e.tag {
+		case tagGPSLatitudeRef:
+			latRef = t.ascii(e)
+		case tagGPSLatitude:
+			lat, hasLat = e, true
+		case tagGPSLongitudeRef:
+			lonRef = t.ascii(e)
+		case tagGPSLongitude:
+			lon, hasLon = e, true
+		case tagGPSAltitudeRef:
+			altBelowSea = t.uint(e) == 1
+		case tagGPSAltitude:
+			if _, _, ok := t.rational(e, 0); ok {
+				v := t.float(e, 0)
+				alt = &v
+			}
+		}
+	}
+
+	if !hasLat || !hasLon {
+		return nil
+	}
+
+	gps := &GPS{
+		Latitude:  t.degrees(lat),
+		Longitude: t.degrees(lon),
+		Altitude:  alt,
+	}
+	if strings.EqualFold(latRef, "S") {
+		gps.Latitude = -gps.Latitude
+	}
+	if strings.EqualFold(lonRef, "W") {
+		gps.Longitude = -gps.Longitude
+	}
+	if alt != nil && altBelowSea {
+		v := -*alt
+		gps.Altitude = &v
+	}
+
+	if math.IsNaN(gps.Latitude) || math.IsNaN(gps.Longitude) ||
+		math.Abs(gps.Latitude) > 90 || math.Abs(gps.Longitude) > 180 {
+		return nil
+	}
+	return gps
+}
+
+// degrees переводит градусы, минуты и секунды в десятичные градусы
+func (t *tiffReader) degrees(e ifdEntry) float64 {
+	return t.float(e, 0) + t.float(e, 1)/60 + t.float(e, 2)/3600
+}
+
+// parseDateTime разбирает дату EXIF вида "2006:01:02 15:04:05" с необязательным смещением "+03:00"
+func parseDateTime(value, offset string) time.Time {
+	loc := time.UTC
+	if offset != "" {
+		if t, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := t.Zone()
+			loc = time.FixedZone(offset, secs)
+		}
+	}
+
+	parsed, err := time.ParseInLocation("2006:01:02 15:04:05", value, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+// formatExposure форматирует выдержку в привычном виде: "1/250" или "2"
+func formatExposure(num, den int64) string {
+	if num == 0 {
+		return "0"
+	}
+	if num >= den {
+		v := float64(num) / float64(den)
+		if v == math.Trunc(v) {
+			return fmt.Sprintf("%d", int64(v))
+		}
+		return fmt.Sprintf("%.1f", v)
+	}
+	return fmt.Sprintf("1/%d", int64(math.Round(float64(den)/float64(num))))
+}
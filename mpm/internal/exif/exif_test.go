@@ -0,0 +1,206 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"mpm/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEntry запись IFD для построения тестового TIFF
+type testEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiEntry(tag uint16, s string) testEntry {
+	return testEntry{tag, typeASCII, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func shortEntry(order binary.ByteOrder, tag uint16, v uint16) testEntry {
+	b := make([]byte, 2)
+	order.PutUint16(b, v)
+	return testEntry{tag, typeShort, 1, b}
+}
+
+func longEntry(order binary.ByteOrder, tag uint16, v uint32) testEntry {
+	b := make([]byte, 4)
+	order.PutUint32(b, v)
+	return testEntry{tag, typeLong, 1, b}
+}
+
+func rationalEntry(order binary.ByteOrder, tag uint16, values ...uint32) testEntry {
+	b := make([]byte, len(values)*4)
+	for i, v := range values {
+		order.PutUint32(b[i*4:], v)
+	}
+	return testEntry{tag, typeRational, uint32(len(values) / 2), b}
+}
+
+// buildIFD собирает каталог со смещением off, значения длиннее 4 байт размещаются сразу после него
+func buildIFD(order binary.ByteOrder, off uint32, entries []testEntry) []byte {
+	head := make([]byte, 2+12*len(entries)+4)
+	order.PutUint16(head, uint16(len(entries)))
+	var data []byte
+	dataOff := off + uint32(len(head))
+
+	for i, e := range entries {
+		b := head[2+12*i:]
+		order.PutUint16(b[0:], e.tag)
+		order.PutUint16(b[2:], e.typ)
+		order.PutUint32(b[4:], e.count)
+		if len(e.data) <= 4 {
+			copy(b[8:12], e.data)
+			continue
+		}
+		order.PutUint32(b[8:], dataOff+uint32(len(data)))
+		data = append(data, e.data...)
+	}
+	return append(head, data...)
+}
+
+// buildTIFF собирает TIFF с IFD0, Exif и GPS каталогами
+func buildTIFF(order binary.ByteOrder) []byte {
+	exifEntries := []testEntry{
+		rationalEntry(order, tagExposureTime, 1, 250),
+		rationalEntry(order, tagFNumber, 28, 10),
+		shortEntry(order, tagISO, 400),
+		asciiEntry(tagDateTimeOriginal, "2023:08:14 18:30:05"),
+		asciiEntry(tagOffsetTimeOriginal, "+03:00"),
+		rationalEntry(order, tagFocalLength, 50, 1),
+		asciiEntry(tagLensModel, "EF 50mm f/1.8 STM"),
+	}
+	gpsEntries := []testEntry{
+		asciiEntry(tagGPSLatitudeRef, "N"),
+		rationalEntry(order, tagGPSLatitude, 43, 1, 35, 1, 8580, 1000),
+		asciiEntry(tagGPSLongitudeRef, "E"),
+		rationalEntry(order, tagGPSLongitude, 39, 1, 43, 1, 2400, 100),
+		{tagGPSAltitudeRef, typeByte, 1, []byte{0}},
+		rationalEntry(order, tagGPSAltitude, 125, 2),
+	}
+	ifd0 := func(exifOff, gpsOff uint32) []testEntry {
+		return []testEntry{
+			asciiEntry(tagMake, "Canon"),
+			asciiEntry(tagModel, "Canon EOS R6"),
+			shortEntry(order, tagOrientation, 6),
+			asciiEntry(tagDateTime, "2024:01:01 00:00:00"),
+			longEntry(order, tagExifIFD, exifOff),
+			longEntry(order, tagGPSIFD, gpsOff),
+		}
+	}
+
+	size0 := uint32(len(buildIFD(order, 8, ifd0(0, 0))))
+	exifOff := 8 + size0
+	exifIFD := buildIFD(order, exifOff, exifEntries)
+	gpsOff := exifOff + uint32(len(exifIFD))
+	gpsIFD := buildIFD(order, gpsOff, gpsEntries)
+
+	header := []byte("MM\x00\x2A\x00\x00\x00\x08")
+	if order == binary.LittleEndian {
+		header = []byte("II\x2A\x00\x08\x00\x00\x00")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.Write(buildIFD(order, 8, ifd0(exifOff, gpsOff)))
+	buf.Write(exifIFD)
+	buf.Write(gpsIFD)
+	return buf.Bytes()
+}
+
+// buildJPEG оборачивает TIFF в сегмент APP1 JPEG-файла
+func buildJPEG(tiff []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFF, 0xD8})
+	// JFIF сегмент перед EXIF, чтобы проверить пропуск сегментов
+	buf.Write([]byte{0xFF, 0xE0, 0x00, 0x07, 'J', 'F', 'I', 'F', 0x00})
+	buf.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(tiff)+8))
+	buf.WriteString("Exif\x00\x00")
+	buf.Write(tiff)
+	buf.Write([]byte{0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9})
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"jpeg big endian", buildJPEG(buildTIFF(binary.BigEndian))},
+		{"jpeg little endian", buildJPEG(buildTIFF(binary.LittleEndian))},
+		{"tiff", buildTIFF(binary.LittleEndian)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Decode(bytes.NewReader(tt.data), int64(len(tt.data)))
+			require.NoError(t, err)
+
+			assert.Equal(t, "Canon", data.Make)
+			assert.Equal(t, "Canon EOS R6", data.Model)
+			assert.Equal(t, "EF 50mm f/1.8 STM", data.LensModel)
+			assert.Equal(t, "1/250", data.ExposureTime)
+			assert.InDelta(t, 2.8, data.FNumber, 0.001)
+			assert.Equal(t, 400, data.ISO)
+			assert.InDelta(t, 50.0, data.FocalLength, 0.001)
+			assert.Equal(t, 6, data.Orientation)
+			assert.True(t, data.DateTaken.Equal(time.Date(2023, 8, 14, 15, 30, 5, 0, time.UTC)), "дата съемки: %v", data.DateTaken)
+
+			require.NotNil(t, data.GPS)
+			assert.InDelta(t, 43.585717, data.GPS.Latitude, 0.00001)
+			assert.InDelta(t, 39.723333, data.GPS.Longitude, 0.00001)
+			require.NotNil(t, data.GPS.Altitude)
+			assert.InDelta(t, 62.5, *data.GPS.Altitude, 0.001)
+		})
+	}
+}
+
+func TestDecode_NoExif(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"jpeg without app1", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 0x00, 0x00, 0xFF, 0xDA, 0x00, 0x02}},
+		{"png", []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}},
+		{"truncated", []byte{0xFF}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(bytes.NewReader(tt.data), int64(len(tt.data)))
+			assert.ErrorIs(t, err, ErrNoExif)
+		})
+	}
+
+	t.Run("corrupted ifd offset", func(t *testing.T) {
+		tiff := []byte("II\x2A\x00\xFF\xFF\x00\x00")
+		_, err := Decode(bytes.NewReader(tiff), int64(len(tiff)))
+		assert.Error(t, err)
+	})
+}
+
+func TestData_Metadata(t *testing.T) {
+	data, err := Decode(bytes.NewReader(buildTIFF(binary.BigEndian)), int64(len(buildTIFF(binary.BigEndian))))
+	require.NoError(t, err)
+
+	values := map[string]string{}
+	for _, m := range data.Metadata() {
+		values[m.Key] = m.Value
+	}
+
+	assert.Equal(t, "Canon", values[models.MetadataCameraMake])
+	assert.Equal(t, "2.8", values[models.MetadataFNumber])
+	assert.Equal(t, "50", values[models.MetadataFocalLength])
+	assert.Equal(t, "400", values[models.MetadataISO])
+	assert.Equal(t, "2023-08-14T18:30:05+03:00", values[models.MetadataDateTaken])
+	assert.Equal(t, "43.585717", values[models.MetadataGPSLatitude])
+	assert.NotContains(t, values, models.MetadataCameraSerial, "пустые значения не сохраняются")
+}
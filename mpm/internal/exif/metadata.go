@@ -0,0 +1,62 @@
+package exif
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"mpm/internal/models"
+)
+
+// Metadata преобразует EXIF-данные в список метаданных фотографии. Пустые значения пропускаются.
+func (d *Data) Metadata() []models.Metadata {
+	result := []models.Metadata{}
+	add := func(key, value string) {
+		if value != "" {
+			result = append(result, models.Metadata{Key: key, Value: value})
+		}
+	}
+
+	add(models.MetadataCameraMake, d.Make)
+	add(models.MetadataCameraModel, d.Model)
+	add(models.MetadataCameraSerial, d.SerialNumber)
+	add(models.MetadataLensMake, d.LensMake)
+	add(models.MetadataLensModel, d.LensModel)
+	add(models.MetadataExposureTime, d.ExposureTime)
+	if d.FNumber > 0 {
+		add(models.MetadataFNumber, formatFloat(d.FNumber, 1))
+	}
+	if d.ISO > 0 {
+		add(models.MetadataISO, strconv.Itoa(d.ISO))
+	}
+	if d.FocalLength > 0 {
+		add(models.MetadataFocalLength, formatFloat(d.FocalLength, 1))
+	}
+	if d.FocalLength35mm > 0 {
+		add(models.MetadataFocalLength35mm, strconv.Itoa(d.FocalLength35mm))
+	}
+	if !d.DateTaken.IsZero() {
+		add(models.MetadataDateTaken, d.DateTaken.Format(time.RFC3339))
+	}
+	if d.Orientation > 0 {
+		add(models.MetadataOrientation, strconv.Itoa(d.Orientation))
+	}
+	if d.GPS != nil {
+		add(models.MetadataGPSLatitude, formatFloat(d.GPS.Latitude, 6))
+		add(models.MetadataGPSLongitude, formatFloat(d.GPS.Longitude, 6))
+		if d.GPS.Altitude != nil {
+			add(models.MetadataGPSAltitude, formatFloat(*d.GPS.Altitude, 1))
+		}
+	}
+
+	return result
+}
+
+// formatFloat форматирует число без лишних нулей в дробной части
+func formatFloat(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
@@ -0,0 +1,160 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// MinZoom и MaxZoom допустимые уровни масштаба карты
+	MinZoom = 0
+	MaxZoom = 20
+
+	// ClusterRadius размер ячейки кластеризации в пикселях карты
+	ClusterRadius = 60
+
+	tileSize = 256
+	// maxMercatorLat широта, за которой проекция Меркатора уходит в бесконечность
+	maxMercatorLat = 85.05112878
+)
+
+// Cluster группа близко расположенных фотографий. Координаты - центр группы.
+type Cluster struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Count    int     `json:"count"`
+	PhotoIDs []int   `json:"photo_ids"`
+	BBox     BBox    `json:"bbox"` // Границы точек группы
+}
+
+// ZoomFor подбирает масштаб, при котором область занимает экран шириной около тысячи пикселей
+func ZoomFor(bbox BBox) int {
+	width := bbox.Width()
+	if width <= 0 {
+		return MaxZoom
+	}
+	return ClampZoom(int(math.Floor(math.Log2(360/width))) + 2)
+}
+
+// ClampZoom приводит масштаб к допустимому диапазону
+func ClampZoom(zoom int) int {
+	return min(max(zoom, MinZoom), MaxZoom)
+}
+
+// ClusterPoints группирует точки по сетке в проекции веб-Меркатора на указанном масштабе
+func ClusterPoints(points []Point, zoom int) []Cluster {
+	type accumulator struct {
+		latSum, lonSum float64
+		ids            []int
+		bbox           BBox
+	}
+
+	worldSize := float64(tileSize) * math.Exp2(float64(ClampZoom(zoom)))
+	groups := make(map[cell]*accumulator)
+	var order []cell
+
+	for _, p := range points {
+		x, y := project(p.Lat, p.Lon, worldSize)
+		c := cell{x: int(x / ClusterRadius), y: int(y / ClusterRadius)}
+
+		acc, ok := groups[c]
+		if !ok {
+			acc = &accumulator{bbox: BBox{MinLon: p.Lon, MinLat: p.Lat, MaxLon: p.Lon, MaxLat: p.Lat}}
+			groups[c] = acc
+			order = append(order, c)
+		}
+		acc.latSum += p.Lat
+		acc.lonSum += p.Lon
+		acc.ids = append(acc.ids, p.ID)
+		acc.bbox.MinLat = min(acc.bbox.MinLat, p.Lat)
+		acc.bbox.MaxLat = max(acc.bbox.MaxLat, p.Lat)
+		acc.bbox.MinLon = min(acc.bbox.MinLon, p.Lon)
+		acc.bbox.MaxLon = max(acc.bbox.MaxLon, p.Lon)
+	}
+
+	clusters := make([]Cluster, 0, len(groups))
+	for _, c := range order {
+		acc := groups[c]
+		sort.Ints(acc.ids)
+		n := float64(len(acc.ids))
+		clusters = append(clusters, Cluster{
+			Lat:      round(acc.latSum / n),
+			Lon:      round(acc.lonSum / n),
+			Count:    len(acc.ids),
+			PhotoIDs: acc.ids,
+			BBox:     acc.bbox,
+		})
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].PhotoIDs[0] < clusters[j].PhotoIDs[0]
+	})
+	return clusters
+}
+
+// project переводит координаты в пиксели карты размером worldSize в проекции веб-Меркатора
+func project(lat, lon, worldSize float64) (float64, float64) {
+	lat = min(max(lat, -maxMercatorLat), maxMercatorLat)
+	sin := math.Sin(lat * math.Pi / 180)
+
+	x := (lon + 180) / 360 * worldSize
+	y := (0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)) * worldSize
+
+	// Точки на правой и нижней границе карты относятся к последней ячейке
+	return min(max(x, 0), worldSize-1), min(max(y, 0), worldSize-1)
+}
+
+// round округляет координату до шести знаков, что соответствует точности около 10 см
+func round(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// FeatureCollection коллекция объектов GeoJSON (RFC 7946)
+type FeatureCollection struct {
+	Type     string    `json:"type" example:"FeatureCollection"`
+	BBox     []float64 `json:"bbox,omitempty"`
+	Features []Feature `json:"features"`
+}
+
+// Feature объект GeoJSON с геометрией-точкой
+type Feature struct {
+	Type       string                 `json:"type" example:"Feature"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry геометрия GeoJSON. Координаты указываются в порядке долгота, широта.
+type Geometry struct {
+	Type        string    `json:"type" example:"Point"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// NewFeatureCollection представляет группы фотографий в виде точек GeoJSON
+func NewFeatureCollection(clusters []Cluster) FeatureCollection {
+	collection := FeatureCollection{Type: "FeatureCollection", Features: make([]Feature, 0, len(clusters))}
+	if len(clusters) == 0 {
+		return collection
+	}
+
+	bbox := clusters[0].BBox
+	for _, c := range clusters {
+		bbox.MinLon = min(bbox.MinLon, c.BBox.MinLon)
+		bbox.MinLat = min(bbox.MinLat, c.BBox.MinLat)
+		bbox.MaxLon = max(bbox.MaxLon, c.BBox.MaxLon)
+		bbox.MaxLat = max(bbox.MaxLat, c.BBox.MaxLat)
+
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			Geometry: Geometry{Type: "Point", Coordinates: []float64{c.Lon, c.Lat}},
+			Properties: map[string]interface{}{
+				"count":     c.Count,
+				"photo_ids": c.PhotoIDs,
+			},
+		})
+	}
+	collection.BBox = []float64{bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat}
+	return collection
+}
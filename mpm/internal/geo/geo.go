@@ -0,0 +1,218 @@
+// Package geo ищет фотографии по координатам съемки и группирует их для отображения на карте
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Point координаты фотографии
+type Point struct {
+	ID  int
+	Lat float64
+	Lon float64
+}
+
+// BBox прямоугольная область на карте, может пересекать 180-й меридиан
+type BBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// World область, покрывающая всю карту
+var World = BBox{MinLon: -180, MinLat: -90, MaxLon: 180, MaxLat: 90}
+
+// ParseBBox разбирает область в формате GeoJSON: "minLon,minLat,maxLon,maxLat"
+func ParseBBox(s string) (BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("область должна содержать 4 числа: minLon,minLat,maxLon,maxLat")
+	}
+
+	var values [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return BBox{}, fmt.Errorf("некорректное число в области: %q", part)
+		}
+		values[i] = v
+	}
+
+	bbox := BBox{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+	if err := bbox.Validate(); err != nil {
+		return BBox{}, err
+	}
+	return bbox, nil
+}
+
+// Validate проверяет границы области
+func (b BBox) Validate() error {
+	if !ValidLatitude(b.MinLat) || !ValidLatitude(b.MaxLat) {
+		return fmt.Errorf("широта должна быть в диапазоне от -90 до 90")
+	}
+	if !ValidLongitude(b.MinLon) || !ValidLongitude(b.MaxLon) {
+		return fmt.Errorf("долгота должна быть в диапазоне от -180 до 180")
+	}
+	if b.MinLat > b.MaxLat {
+		return fmt.Errorf("минимальная широта больше максимальной")
+	}
+	return nil
+}
+
+// Contains проверяет, попадает ли точка в область
+func (b BBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.crossesAntimeridian() {
+		return lon >= b.MinLon || lon <= b.MaxLon
+	}
+	return lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// Split делит область, пересекающую 180-й меридиан, на две обычные
+func (b BBox) Split() []BBox {
+	if !b.crossesAntimeridian() {
+		return []BBox{b}
+	}
+	return []BBox{
+		{MinLon: b.MinLon, MinLat: b.MinLat, MaxLon: 180, MaxLat: b.MaxLat},
+		{MinLon: -180, MinLat: b.MinLat, MaxLon: b.MaxLon, MaxLat: b.MaxLat},
+	}
+}
+
+// Width возвращает ширину области в градусах долготы
+func (b BBox) Width() float64 {
+	if b.crossesAntimeridian() {
+		return 360 - b.MinLon + b.MaxLon
+	}
+	return b.MaxLon - b.MinLon
+}
+
+func (b BBox) crossesAntimeridian() bool {
+	return b.MinLon > b.MaxLon
+}
+
+// ValidLatitude проверяет диапазон широты
+func ValidLatitude(lat float64) bool {
+	return lat >= -90 && lat <= 90
+}
+
+// ValidLongitude проверяет диапазон долготы
+func ValidLongitude(lon float64) bool {
+	return lon >= -180 && lon <= 180
+}
+
+// earthRadius средний радиус Земли в километрах
+const earthRadius = 6371.0
+
+// Distance возвращает расстояние между точками по поверхности Земли в километрах
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(min(a, 1)))
+}
+
+// Around возвращает область, содержащую все точки не дальше radius километров от центра
+func Around(lat, lon, radius float64) BBox {
+	dLat := radius / earthRadius * 180 / math.Pi
+	bbox := BBox{MinLat: max(lat-dLat, -90), MaxLat: min(lat+dLat, 90)}
+
+	// Вблизи полюса область охватывает все долготы
+	cos := math.Cos(lat * math.Pi / 180)
+	if bbox.MinLat == -90 || bbox.MaxLat == 90 || cos*180 <= dLat {
+		bbox.MinLon, bbox.MaxLon = -180, 180
+		return bbox
+	}
+
+	dLon := dLat / cos
+	bbox.MinLon, bbox.MaxLon = lon-dLon, lon+dLon
+	if bbox.MinLon < -180 {
+		bbox.MinLon += 360
+	}
+	if bbox.MaxLon > 180 {
+		bbox.MaxLon -= 360
+	}
+	return bbox
+}
+
+// cellSize размер ячейки пространственного индекса в градусах
+const cellSize = 1.0
+
+type cell struct {
+	x, y int
+}
+
+// Index пространственный индекс точек на сетке из ячеек по одному градусу
+type Index struct {
+	cells map[cell][]Point
+	size  int
+}
+
+// NewIndex строит индекс по точкам. Точки с некорректными координатами пропускаются.
+func NewIndex(points []Point) *Index {
+	idx := &Index{cells: make(map[cell][]Point)}
+	for _, p := range points {
+		if !ValidLatitude(p.Lat) || !ValidLongitude(p.Lon) {
+			continue
+		}
+		c := cellOf(p.Lat, p.Lon)
+		idx.cells[c] = append(idx.cells[c], p)
+		idx.size++
+	}
+	return idx
+}
+
+// Len возвращает количество точек в индексе
+func (idx *Index) Len() int {
+	return idx.size
+}
+
+// Within возвращает точки внутри области в порядке возрастания ID
+func (idx *Index) Within(bbox BBox) []Point {
+	points := []Point{}
+	for _, part := range bbox.Split() {
+		minCell, maxCell := cellOf(part.MinLat, part.MinLon), cellOf(part.MaxLat, part.MaxLon)
+
+		// Для большой области дешевле перебрать непустые ячейки, чем все ячейки области
+		area := (maxCell.x - minCell.x + 1) * (maxCell.y - minCell.y + 1)
+		if area > len(idx.cells) {
+			for c, cellPoints := range idx.cells {
+				if c.x >= minCell.x && c.x <= maxCell.x && c.y >= minCell.y && c.y <= maxCell.y {
+					points = appendWithin(points, cellPoints, part)
+				}
+			}
+			continue
+		}
+
+		for x := minCell.x; x <= maxCell.x; x++ {
+			for y := minCell.y; y <= maxCell.y; y++ {
+				points = appendWithin(points, idx.cells[cell{x, y}], part)
+			}
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].ID < points[j].ID })
+	return points
+}
+
+func appendWithin(dst, points []Point, bbox BBox) []Point {
+	for _, p := range points {
+		if bbox.Contains(p.Lat, p.Lon) {
+			dst = append(dst, p)
+		}
+	}
+	return dst
+}
+
+func cellOf(lat, lon float64) cell {
+	return cell{x: int(math.Floor(lon / cellSize)), y: int(math.Floor(lat / cellSize))}
+}
@@ -0,0 +1,130 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBBox(t *testing.T) {
+	bbox, err := ParseBBox("37.3, 55.5,37.9,55.9")
+	require.NoError(t, err)
+	assert.Equal(t, BBox{MinLon: 37.3, MinLat: 55.5, MaxLon: 37.9, MaxLat: 55.9}, bbox)
+
+	for _, s := range []string{"", "1,2,3", "a,1,2,3", "0,91,1,92", "-181,0,1,1", "0,10,1,5", "NaN,0,1,1"} {
+		_, err := ParseBBox(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestBBox(t *testing.T) {
+	pacific := BBox{MinLon: 170, MinLat: -20, MaxLon: -170, MaxLat: 0}
+
+	assert.True(t, pacific.Contains(-10, 175))
+	assert.True(t, pacific.Contains(-10, -175))
+	assert.False(t, pacific.Contains(-10, 0))
+	assert.False(t, pacific.Contains(10, 175))
+	assert.Equal(t, 20.0, pacific.Width())
+	assert.Len(t, pacific.Split(), 2)
+	assert.Equal(t, 360.0, World.Width())
+}
+
+func TestIndex_Within(t *testing.T) {
+	points := []Point{
+		{ID: 1, Lat: 55.7558, Lon: 37.6173},  // Москва
+		{ID: 2, Lat: 59.9343, Lon: 30.3351},  // Санкт-Петербург
+		{ID: 3, Lat: -17.7134, Lon: 178.065}, // Фиджи
+		{ID: 4, Lat: -13.759, Lon: -172.1},   // Самоа
+		{ID: 5, Lat: 95, Lon: 0},             // Некорректная точка
+	}
+	idx := NewIndex(points)
+	assert.Equal(t, 4, idx.Len())
+
+	ids := func(points []Point) []int {
+		result := []int{}
+		for _, p := range points {
+			result = append(result, p.ID)
+		}
+		return result
+	}
+
+	assert.Equal(t, []int{1}, ids(idx.Within(BBox{MinLon: 37.3, MinLat: 55.5, MaxLon: 37.9, MaxLat: 55.9})))
+	assert.ElementsMatch(t, []int{3, 4}, ids(idx.Within(BBox{MinLon: 170, MinLat: -20, MaxLon: -170, MaxLat: 0})))
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, ids(idx.Within(World)))
+	assert.Empty(t, idx.Within(BBox{MinLon: 0, MinLat: 0, MaxLon: 1, MaxLat: 1}))
+}
+
+func TestClusterPoints(t *testing.T) {
+	points := []Point{
+		{ID: 3, Lat: 55.7558, Lon: 37.6173},
+		{ID: 1, Lat: 55.7520, Lon: 37.6175},
+		{ID: 2, Lat: 59.9343, Lon: 30.3351},
+	}
+
+	t.Run("Мелкий масштаб объединяет близкие точки", func(t *testing.T) {
+		clusters := ClusterPoints(points, 5)
+		require.Len(t, clusters, 2)
+
+		assert.Equal(t, 2, clusters[0].Count)
+		assert.Equal(t, []int{1, 3}, clusters[0].PhotoIDs)
+		assert.InDelta(t, 55.7539, clusters[0].Lat, 1e-6)
+		assert.Equal(t, BBox{MinLon: 37.6173, MinLat: 55.7520, MaxLon: 37.6175, MaxLat: 55.7558}, clusters[0].BBox)
+		assert.Equal(t, []int{2}, clusters[1].PhotoIDs)
+	})
+
+	t.Run("Крупный масштаб разделяет точки", func(t *testing.T) {
+		assert.Len(t, ClusterPoints(points, MaxZoom), 3)
+	})
+
+	t.Run("Весь мир в одной группе", func(t *testing.T) {
+		clusters := ClusterPoints(append(points, Point{ID: 4, Lat: 90, Lon: 180}), MinZoom)
+		assert.Len(t, clusters, 2, "полюс и 180-й меридиан не выходят за пределы карты")
+	})
+
+	t.Run("Пустой список", func(t *testing.T) {
+		assert.Empty(t, ClusterPoints(nil, 10))
+	})
+}
+
+func TestZoomFor(t *testing.T) {
+	assert.Equal(t, 2, ZoomFor(World))
+	assert.Equal(t, 11, ZoomFor(BBox{MinLon: 37.3, MinLat: 55.5, MaxLon: 37.9, MaxLat: 55.9}))
+	assert.Equal(t, MaxZoom, ZoomFor(BBox{MinLon: 10, MinLat: 10, MaxLon: 10, MaxLat: 10}))
+}
+
+func TestNewFeatureCollection(t *testing.T) {
+	collection := NewFeatureCollection(ClusterPoints([]Point{
+		{ID: 1, Lat: 55.75, Lon: 37.61},
+		{ID: 2, Lat: 59.93, Lon: 30.33},
+	}, MaxZoom))
+
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Equal(t, []float64{30.33, 55.75, 37.61, 59.93}, collection.BBox)
+	require.Len(t, collection.Features, 2)
+	assert.Equal(t, []float64{37.61, 55.75}, collection.Features[0].Geometry.Coordinates, "долгота перед широтой")
+	assert.Equal(t, 1, collection.Features[0].Properties["count"])
+
+	empty := NewFeatureCollection(nil)
+	assert.NotNil(t, empty.Features)
+	assert.Nil(t, empty.BBox)
+}
+
+func TestDistance(t *testing.T) {
+	assert.InDelta(t, 634, Distance(55.7558, 37.6173, 59.9343, 30.3351), 5, "Москва - Санкт-Петербург")
+	assert.Zero(t, Distance(10, 20, 10, 20))
+	assert.InDelta(t, 111.2, Distance(0, 179.5, 0, -179.5), 0.5, "через 180-й меридиан")
+}
+
+func TestAround(t *testing.T) {
+	bbox := Around(55.75, 37.62, 10)
+	assert.True(t, bbox.Contains(55.75, 37.62))
+	assert.True(t, bbox.Contains(55.83, 37.62), "9 км к северу")
+	assert.False(t, bbox.Contains(55.75, 38), "24 км к востоку")
+
+	pacific := Around(0, 179.9, 50)
+	assert.True(t, pacific.Contains(0, -179.9), "область переходит через 180-й меридиан")
+
+	polar := Around(89.9, 0, 50)
+	assert.Equal(t, 360.0, polar.Width(), "у полюса область охватывает все долготы")
+}
@@ -0,0 +1,283 @@
+// Package geocode определяет страну, регион и город по координатам съемки без обращения к сети.
+// В исполняемый файл встроена выборка крупных городов и курортов; полный справочник
+// населенных пунктов загружается из выгрузки GeoNames (cities500, cities1000, cities5000 или cities15000).
+package geocode
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	_ "embed"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mpm/internal/geo"
+)
+
+// DefaultMaxDistance расстояние в километрах, дальше которого ближайший населенный пункт не считается местом съемки
+const DefaultMaxDistance = 50.0
+
+//go:embed data/cities.tsv
+var citiesData string
+
+//go:embed data/countries.tsv
+var countriesData string
+
+// Place место съемки
+type Place struct {
+	City        string  `json:"city"`
+	Region      string  `json:"region,omitempty"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Distance    float64 `json:"distance_km"` // Расстояние от точки съемки до населенного пункта
+}
+
+// Geocoder ищет ближайший к точке населенный пункт. Безопасен для одновременного использования.
+type Geocoder struct {
+	places      []Place
+	index       *geo.Index
+	maxDistance float64
+}
+
+// New загружает встроенный справочник крупных городов и курортов
+func New(maxDistance float64) (*Geocoder, error) {
+	return parse(strings.NewReader(countriesData), strings.NewReader(citiesData), maxDistance)
+}
+
+// NewFromFile загружает справочник из выгрузки GeoNames в формате .txt, .txt.gz или .zip
+func NewFromFile(path string, maxDistance float64) (*Geocoder, error) {
+	cities, err := openDump(path)
+	if err != nil {
+		return nil, err
+	}
+	defer cities.Close()
+
+	regions := make(map[string]string)
+	if admin1, err := os.Open(filepath.Join(filepath.Dir(path), "admin1CodesASCII.txt")); err == nil {
+		err = readTSV(admin1, 2, func(fields []string) error {
+			regions[fields[0]] = fields[1]
+			return nil
+		})
+		admin1.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения справочника регионов: %w", err)
+		}
+	}
+
+	return parseGeoNames(strings.NewReader(countriesData), cities, regions, maxDistance)
+}
+
+// openDump открывает выгрузку GeoNames, распаковывая ее при необходимости
+func openDump(path string) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range archive.File {
+			if strings.HasSuffix(file.Name, ".txt") && !strings.HasPrefix(filepath.Base(file.Name), "readme") {
+				r, err := file.Open()
+				if err != nil {
+					archive.Close()
+					return nil, err
+				}
+				return readCloser{r, archive}, nil
+			}
+		}
+		archive.Close()
+		return nil, fmt.Errorf("архив %s не содержит выгрузку GeoNames", path)
+
+	case strings.HasSuffix(path, ".gz"):
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		r, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return readCloser{r, file}, nil
+	}
+	return os.Open(path)
+}
+
+// readCloser читает распакованные данные и закрывает исходный файл
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// parse читает справочники стран и населенных пунктов в формате TSV
+func parse(countries, cities io.Reader, maxDistance float64) (*Geocoder, error) {
+	if maxDistance <= 0 {
+		maxDistance = DefaultMaxDistance
+	}
+
+	names, err := readCountries(countries)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Geocoder{maxDistance: maxDistance}
+	var points []geo.Point
+	err = readTSV(cities, 5, func(fields []string) error {
+		lat, errLat := strconv.ParseFloat(fields[1], 64)
+		lon, errLon := strconv.ParseFloat(fields[2], 64)
+		if errLat != nil || errLon != nil || !geo.ValidLatitude(lat) || !geo.ValidLongitude(lon) {
+			return fmt.Errorf("некорректные координаты населенного пункта %s", fields[0])
+		}
+		country, ok := names[fields[3]]
+		if !ok {
+			return fmt.Errorf("неизвестный код страны %s у населенного пункта %s", fields[3], fields[0])
+		}
+
+		points = append(points, geo.Point{ID: len(g.places), Lat: lat, Lon: lon})
+		g.places = append(g.places, Place{
+			City:        fields[0],
+			Region:      fields[4],
+			Country:     country,
+			CountryCode: fields[3],
+			Lat:         lat,
+			Lon:         lon,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения справочника населенных пунктов: %w", err)
+	}
+
+	g.index = geo.NewIndex(points)
+	return g, nil
+}
+
+// readCountries читает справочник названий стран по ISO-коду
+func readCountries(r io.Reader) (map[string]string, error) {
+	names := make(map[string]string)
+	err := readTSV(r, 2, func(fields []string) error {
+		names[fields[0]] = fields[1]
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения справочника стран: %w", err)
+	}
+	return names, nil
+}
+
+// Колонки выгрузки GeoNames, используемые справочником
+const (
+	geoNamesName    = 1
+	geoNamesLat     = 4
+	geoNamesLon     = 5
+	geoNamesCountry = 8
+	geoNamesAdmin1  = 10
+	geoNamesColumns = 19
+)
+
+// parseGeoNames читает выгрузку населенных пунктов GeoNames
+func parseGeoNames(countries, cities io.Reader, regions map[string]string, maxDistance float64) (*Geocoder, error) {
+	if maxDistance <= 0 {
+		maxDistance = DefaultMaxDistance
+	}
+
+	names, err := readCountries(countries)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Geocoder{maxDistance: maxDistance}
+	var points []geo.Point
+	err = readTSV(cities, geoNamesColumns, func(fields []string) error {
+		lat, errLat := strconv.ParseFloat(fields[geoNamesLat], 64)
+		lon, errLon := strconv.ParseFloat(fields[geoNamesLon], 64)
+		if errLat != nil || errLon != nil || !geo.ValidLatitude(lat) || !geo.ValidLongitude(lon) {
+			return fmt.Errorf("некорректные координаты населенного пункта %s", fields[geoNamesName])
+		}
+
+		code := fields[geoNamesCountry]
+		country, ok := names[code]
+		if !ok {
+			country = code
+		}
+		region, ok := regions[code+"."+fields[geoNamesAdmin1]]
+		if !ok {
+			region = fields[geoNamesAdmin1]
+		}
+
+		points = append(points, geo.Point{ID: len(g.places), Lat: lat, Lon: lon})
+		g.places = append(g.places, Place{
+			City:        fields[geoNamesName],
+			Region:      region,
+			Country:     country,
+			CountryCode: code,
+			Lat:         lat,
+			Lon:         lon,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения выгрузки GeoNames: %w", err)
+	}
+
+	g.index = geo.NewIndex(points)
+	return g, nil
+}
+
+// readTSV вызывает handle для каждой строки с не менее чем columns колонками
+func readTSV(r io.Reader, columns int, handle func(fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	// Альтернативные названия крупных городов в выгрузке GeoNames занимают десятки килобайт
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) < columns {
+			return fmt.Errorf("строка %d: ожидается %d колонок, получено %d", line, columns, len(fields))
+		}
+		if err := handle(fields); err != nil {
+			return fmt.Errorf("строка %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Len возвращает количество населенных пунктов в справочнике
+func (g *Geocoder) Len() int {
+	return len(g.places)
+}
+
+// Reverse возвращает ближайший к точке населенный пункт, если он не дальше максимального расстояния
+func (g *Geocoder) Reverse(lat, lon float64) (Place, bool) {
+	if !geo.ValidLatitude(lat) || !geo.ValidLongitude(lon) {
+		return Place{}, false
+	}
+
+	best, bestDistance := -1, 0.0
+	for _, p := range g.index.Within(geo.Around(lat, lon, g.maxDistance)) {
+		d := geo.Distance(lat, lon, p.Lat, p.Lon)
+		if d <= g.maxDistance && (best < 0 || d < bestDistance) {
+			best, bestDistance = p.ID, d
+		}
+	}
+	if best < 0 {
+		return Place{}, false
+	}
+
+	place := g.places[best]
+	place.Distance = math.Round(bestDistance*10) / 10
+	return place, true
+}
@@ -0,0 +1,173 @@
+package geocode
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeocoder_Reverse(t *testing.T) {
+	g, err := New(0)
+	require.NoError(t, err)
+	assert.Greater(t, g.Len(), 100, "встроенный справочник загружен")
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		city     string
+		country  string
+	}{
+		{"Центр Сочи", 43.5855, 39.7231, "Sochi", "Russia"},
+		{"Аэропорт Сочи ближе к Адлеру", 43.4499, 39.9566, "Adler", "Russia"},
+		{"Роза Хутор", 43.6707, 40.2963, "Krasnaya Polyana", "Russia"},
+		{"Эрмитаж", 59.9398, 30.3146, "Saint Petersburg", "Russia"},
+		{"Эйфелева башня", 48.8584, 2.2945, "Paris", "France"},
+		{"Южное полушарие", -33.8568, 151.2153, "Sydney", "Australia"},
+		{"Рядом со 180-м меридианом", 64.7, 177.6, "Anadyr", "Russia"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			place, ok := g.Reverse(tt.lat, tt.lon)
+			require.True(t, ok)
+			assert.Equal(t, tt.city, place.City)
+			assert.Equal(t, tt.country, place.Country)
+			assert.LessOrEqual(t, place.Distance, DefaultMaxDistance)
+		})
+	}
+
+	t.Run("Регион и код страны", func(t *testing.T) {
+		place, ok := g.Reverse(43.5855, 39.7231)
+		require.True(t, ok)
+		assert.Equal(t, "Krasnodar Krai", place.Region)
+		assert.Equal(t, "RU", place.CountryCode)
+		assert.InDelta(t, 1.7, place.Distance, 0.5)
+	})
+
+	t.Run("Далеко от населенных пунктов", func(t *testing.T) {
+		_, ok := g.Reverse(0, -30)
+		assert.False(t, ok, "середина Атлантики")
+		_, ok = g.Reverse(-90, 0)
+		assert.False(t, ok, "Южный полюс")
+		_, ok = g.Reverse(100, 0)
+		assert.False(t, ok, "некорректная широта")
+	})
+}
+
+func TestParse(t *testing.T) {
+	countries := "# comment\nRU\tRussia\n"
+
+	t.Run("Малый радиус", func(t *testing.T) {
+		g, err := parse(strings.NewReader(countries), strings.NewReader("Sochi\t43.59917\t39.72569\tRU\tKrasnodar Krai\n"), 1)
+		require.NoError(t, err)
+		_, ok := g.Reverse(43.4499, 39.9566)
+		assert.False(t, ok)
+	})
+
+	for name, cities := range map[string]string{
+		"Мало колонок":         "Sochi\t43.6\t39.7\tRU\n",
+		"Некорректная широта":  "Sochi\tabc\t39.7\tRU\tKrasnodar Krai\n",
+		"Неизвестная страна":   "Sochi\t43.6\t39.7\tXX\tKrasnodar Krai\n",
+		"Широта вне диапазона": "Sochi\t143.6\t39.7\tRU\tKrasnodar Krai\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parse(strings.NewReader(countries), strings.NewReader(cities), 0)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// geoNamesRow формирует строку выгрузки GeoNames из 19 колонок
+func geoNamesRow(name string, lat, lon, country, admin1 string) string {
+	fields := make([]string, 19)
+	fields[0], fields[1], fields[2] = "1", name, name
+	fields[4], fields[5], fields[6], fields[7] = lat, lon, "P", "PPL"
+	fields[8], fields[10], fields[14] = country, admin1, "3000"
+	return strings.Join(fields, "\t") + "\n"
+}
+
+func TestNewFromFile(t *testing.T) {
+	dump := geoNamesRow("Myshkin", "57.78778", "38.45444", "RU", "88") +
+		geoNamesRow("Plyos", "57.46058", "41.51219", "RU", "37") +
+		geoNamesRow("Tórshavn", "62.00973", "-6.77164", "FO", "00")
+
+	// Мышкин не входит во встроенную выборку: ближайшие города из нее дальше 50 км
+	embedded, err := New(0)
+	require.NoError(t, err)
+	_, ok := embedded.Reverse(57.7885, 38.4560)
+	require.False(t, ok)
+
+	dir := t.TempDir()
+	write := func(name string, write func(f *os.File)) string {
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		write(f)
+		require.NoError(t, f.Close())
+		return path
+	}
+	files := map[string]string{
+		"txt": write("cities15000.txt", func(f *os.File) {
+			_, err := f.WriteString(dump)
+			require.NoError(t, err)
+		}),
+		"gz": write("cities5000.txt.gz", func(f *os.File) {
+			gz := gzip.NewWriter(f)
+			_, err := gz.Write([]byte(dump))
+			require.NoError(t, err)
+			require.NoError(t, gz.Close())
+		}),
+		"zip": write("cities15000.zip", func(f *os.File) {
+			archive := zip.NewWriter(f)
+			w, err := archive.Create("cities15000.txt")
+			require.NoError(t, err)
+			_, err = w.Write([]byte(dump))
+			require.NoError(t, err)
+			require.NoError(t, archive.Close())
+		}),
+	}
+
+	for format, path := range files {
+		t.Run(format, func(t *testing.T) {
+			g, err := NewFromFile(path, 0)
+			require.NoError(t, err)
+			assert.Equal(t, 3, g.Len())
+
+			place, ok := g.Reverse(57.7885, 38.4560)
+			require.True(t, ok)
+			assert.Equal(t, "Myshkin", place.City)
+			assert.Equal(t, "Russia", place.Country)
+			assert.Equal(t, "88", place.Region, "без справочника регионов указывается код")
+
+			place, ok = g.Reverse(62.01, -6.77)
+			require.True(t, ok)
+			assert.Equal(t, "FO", place.Country, "страна вне справочника называется кодом")
+		})
+	}
+
+	t.Run("Названия регионов", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "admin1CodesASCII.txt"),
+			[]byte("RU.88\tYaroslavl Oblast\tYaroslavl Oblast\t468898\nRU.37\tIvanovo Oblast\tIvanovo Oblast\t555235\n"), 0644))
+		g, err := NewFromFile(files["txt"], 0)
+		require.NoError(t, err)
+		place, ok := g.Reverse(57.46, 41.51)
+		require.True(t, ok)
+		assert.Equal(t, "Plyos", place.City)
+		assert.Equal(t, "Ivanovo Oblast", place.Region)
+	})
+
+	t.Run("Ошибки", func(t *testing.T) {
+		_, err := NewFromFile(filepath.Join(dir, "missing.txt"), 0)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		short := filepath.Join(dir, "short.txt")
+		require.NoError(t, os.WriteFile(short, []byte("Sochi\t43.6\t39.7\tRU\tKrasnodar Krai\n"), 0644))
+		_, err = NewFromFile(short, 0)
+		assert.Error(t, err, "встроенный формат не является выгрузкой GeoNames")
+	})
+}
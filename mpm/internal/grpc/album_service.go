@@ -2,8 +2,10 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"mpm/internal/geo"
 	"mpm/internal/models"
 	"mpm/internal/repository"
 	pb "mpm/proto/albums"
@@ -32,12 +34,7 @@ func (s *AlbumServer) GetAlbums(ctx context.Context, req *pb.GetAlbumsRequest) (
 	}
 
 	for _, album := range albums {
-		result.Albums = append(result.Albums, &pb.Album{
-			Id:          int32(album.ID),
-			Name:        album.Name,
-			Description: album.Description,
-			CreatedAt:   album.CreatedAt.Format(time.RFC3339),
-		})
+		result.Albums = append(result.Albums, toProtoAlbum(album))
 	}
 	return result, nil
 }
@@ -50,6 +47,18 @@ func (s *AlbumServer) CreateAlbum(ctx context.Context, req *pb.CreateAlbumReques
 		CreatedAt:   time.Now(),
 	}
 
+	// Альбом с сохраненным запросом становится умным
+	if req.Filter != nil {
+		filter, err := fromProtoFilter(req.Filter)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "некорректный запрос умного альбома: %v", err)
+		}
+		album.Filter = &filter
+		if err := album.ValidateFilter(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "некорректный запрос умного альбома: %v", err)
+		}
+	}
+
 	// Добавляем альбом в репозиторий
 	id, err := s.repository.AddAlbum(ctx, album)
 	if err != nil {
@@ -63,12 +72,7 @@ func (s *AlbumServer) CreateAlbum(ctx context.Context, req *pb.CreateAlbumReques
 	}
 
 	// Преобразуем в формат proto и возвращаем
-	return &pb.Album{
-		Id:          int32(createdAlbum.ID),
-		Name:        createdAlbum.Name,
-		Description: createdAlbum.Description,
-		CreatedAt:   createdAlbum.CreatedAt.Format(time.RFC3339),
-	}, nil
+	return toProtoAlbum(createdAlbum), nil
 }
 
 func (s *AlbumServer) DeleteAlbum(ctx context.Context, req *pb.DeleteAlbumRequest) (*pb.DeleteAlbumResponse, error) {
@@ -80,7 +84,7 @@ func (s *AlbumServer) DeleteAlbum(ctx context.Context, req *pb.DeleteAlbumReques
 	// Преобразуем ID из int32 в int
 	albumID := int(req.Id)
 
-	// Удаляем альбом через репозиторий
+	// Перемещаем альбом в корзину через репозиторий
 	err := s.repository.DeleteAlbum(ctx, albumID)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "ошибка удаления альбома: %v", err)
@@ -90,3 +94,90 @@ func (s *AlbumServer) DeleteAlbum(ctx context.Context, req *pb.DeleteAlbumReques
 		Success: true,
 	}, nil
 }
+
+// toProtoAlbum преобразует альбом в формат proto
+func toProtoAlbum(album models.Album) *pb.Album {
+	result := &pb.Album{
+		Id:          int32(album.ID),
+		Name:        album.Name,
+		Description: album.Description,
+		CreatedAt:   album.CreatedAt.Format(time.RFC3339),
+	}
+	if album.Filter != nil {
+		result.Filter = toProtoFilter(*album.Filter)
+	}
+	return result
+}
+
+// toProtoFilter преобразует сохраненный запрос умного альбома в формат proto
+func toProtoFilter(filter models.PhotoFilter) *pb.PhotoFilter {
+	result := &pb.PhotoFilter{
+		Tags:   filter.Tags,
+		Camera: filter.Camera,
+		Query:  filter.Query,
+		Media:  filter.Media,
+	}
+	if filter.From != nil {
+		result.From = filter.From.Format(time.RFC3339)
+	}
+	if filter.To != nil {
+		result.To = filter.To.Format(time.RFC3339)
+	}
+	if filter.AlbumID != nil {
+		result.AlbumId = int32(*filter.AlbumID)
+	}
+	if filter.UserID != nil {
+		result.UserId = int32(*filter.UserID)
+	}
+	if filter.BBox != nil {
+		result.Bbox = &pb.BBox{
+			MinLon: filter.BBox.MinLon,
+			MinLat: filter.BBox.MinLat,
+			MaxLon: filter.BBox.MaxLon,
+			MaxLat: filter.BBox.MaxLat,
+		}
+	}
+	return result
+}
+
+// fromProtoFilter преобразует запрос умного альбома из формата proto
+func fromProtoFilter(filter *pb.PhotoFilter) (models.PhotoFilter, error) {
+	result := models.PhotoFilter{
+		Tags:   filter.Tags,
+		Camera: filter.Camera,
+		Query:  filter.Query,
+		Media:  filter.Media,
+	}
+
+	if filter.From != "" {
+		from, err := models.ParseFilterTime(filter.From, false)
+		if err != nil {
+			return result, fmt.Errorf("некорректная дата from")
+		}
+		result.From = &from
+	}
+	if filter.To != "" {
+		to, err := models.ParseFilterTime(filter.To, true)
+		if err != nil {
+			return result, fmt.Errorf("некорректная дата to")
+		}
+		result.To = &to
+	}
+	if filter.AlbumId != 0 {
+		id := int(filter.AlbumId)
+		result.AlbumID = &id
+	}
+	if filter.UserId != 0 {
+		id := int(filter.UserId)
+		result.UserID = &id
+	}
+	if filter.Bbox != nil {
+		result.BBox = &geo.BBox{
+			MinLon: filter.Bbox.MinLon,
+			MinLat: filter.Bbox.MinLat,
+			MaxLon: filter.Bbox.MaxLon,
+			MaxLat: filter.Bbox.MaxLat,
+		}
+	}
+	return result, nil
+}
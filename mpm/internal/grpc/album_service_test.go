@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"mpm/internal/models"
+	"mpm/internal/repository"
+	pb "mpm/proto/albums"
+)
+
+func TestAlbumServer_SmartAlbums(t *testing.T) {
+	repo := repository.NewRepository("json", t.TempDir(), time.Hour)
+	server := NewAlbumServer(repo)
+	ctx := context.Background()
+
+	created := time.Date(2024, 7, 15, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.SaveEntity(models.Album{ID: 1, Name: "Отпуск", CreatedAt: created}))
+	require.NoError(t, repo.SaveEntity(models.Photo{ID: 1, Name: "sea.jpg", Album: &models.Album{ID: 1}, Tags: []string{"море"}, CreatedAt: created}))
+
+	t.Run("Создание умного альбома", func(t *testing.T) {
+		album, err := server.CreateAlbum(ctx, &pb.CreateAlbumRequest{
+			Name: "Море 2024",
+			Filter: &pb.PhotoFilter{
+				Tags:    []string{"море"},
+				From:    "2024-01-01",
+				To:      "2024-12-31",
+				AlbumId: 1,
+				Bbox:    &pb.BBox{MinLon: 30, MinLat: 40, MaxLon: 50, MaxLat: 60},
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, album.Filter)
+		assert.Equal(t, []string{"море"}, album.Filter.Tags)
+		assert.Equal(t, "2024-01-01T00:00:00Z", album.Filter.From)
+		assert.Equal(t, "2024-12-31T23:59:59Z", album.Filter.To)
+		assert.Equal(t, int32(1), album.Filter.AlbumId)
+		assert.Zero(t, album.Filter.UserId)
+		assert.Equal(t, 60.0, album.Filter.Bbox.MaxLat)
+
+		stored, err := repo.FindAlbumByID(ctx, int(album.Id))
+		require.NoError(t, err)
+		assert.True(t, stored.IsSmart())
+		assert.Nil(t, stored.Filter.UserID, "нулевой ID означает отсутствие условия")
+	})
+
+	t.Run("Список отмечает умные альбомы", func(t *testing.T) {
+		resp, err := server.GetAlbums(ctx, &pb.GetAlbumsRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Albums, 2)
+		for _, album := range resp.Albums {
+			assert.Equal(t, album.Id != 1, album.Filter != nil, album.Name)
+		}
+	})
+
+	t.Run("Некорректный запрос", func(t *testing.T) {
+		for _, filter := range []*pb.PhotoFilter{
+			{From: "вчера"},
+			{Media: "audio"},
+			{From: "2024-12-01", To: "2024-01-01"},
+			{Bbox: &pb.BBox{MinLon: 0, MinLat: -100, MaxLon: 10, MaxLat: 10}},
+		} {
+			_, err := server.CreateAlbum(ctx, &pb.CreateAlbumRequest{Name: "Ошибка", Filter: filter})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), filter.String())
+		}
+	})
+}
@@ -10,6 +10,13 @@ import (
 	"strings"
 )
 
+const (
+	// defaultAlbumPreviews количество превью в списке альбомов по умолчанию
+	defaultAlbumPreviews = 4
+	// maxAlbumPreviews максимальное количество превью, которое можно запросить
+	maxAlbumPreviews = 50
+)
+
 type AlbumHandler struct {
 	repo *repository.Repository
 }
@@ -22,7 +29,10 @@ func NewAlbumHandler(repo *repository.Repository) *AlbumHandler {
 
 // CreateAlbum godoc
 // @Summary Создать новый альбом
-// @Description Создать новый альбом на основе предоставленных данных
+// @Description Создать новый альбом на основе предоставленных данных. Альбом с полем filter становится умным:
+// @Description его фотографии не хранятся, а подбираются по сохраненному запросу при каждом обращении.
+// @Description Поля запроса совпадают с фильтром GET /photos: tags, from, to, camera, q, media, album_id, user_id,
+// @Description а также bbox - область съемки {min_lon, min_lat, max_lon, max_lat}.
 // @Tags albums
 // @Accept json
 // @Produce json
@@ -46,6 +56,11 @@ func (h *AlbumHandler) CreateAlbum(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := album.ValidateFilter(); err != nil {
+		http.Error(w, "Некорректный запрос умного альбома: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Добавление альбома через репозиторий
 	id, err := h.repo.AddAlbum(ctx, album)
 	if err != nil {
@@ -78,7 +93,8 @@ func (h *AlbumHandler) CreateAlbum(w http.ResponseWriter, r *http.Request) {
 
 // UpdateAlbum godoc
 // @Summary Обновить альбом
-// @Description Обновить данные существующего альбома по его идентификатору
+// @Description Обновить данные существующего альбома по его идентификатору. Передача filter превращает альбом
+// @Description в умный, отсутствие filter - в обычный.
 // @Tags albums
 // @Accept json
 // @Produce json
@@ -126,6 +142,12 @@ func (h *AlbumHandler) UpdateAlbum(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	updatedAlbum.ID = id
+	if err := updatedAlbum.ValidateFilter(); err != nil {
+		http.Error(w, "Некорректный запрос умного альбома: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Обновляем альбом через репозиторий
 	if err := h.repo.UpdateAlbum(ctx, id, updatedAlbum); err != nil {
 		if strings.Contains(err.Error(), "не найден") {
@@ -144,12 +166,15 @@ func (h *AlbumHandler) UpdateAlbum(w http.ResponseWriter, r *http.Request) {
 
 // GetAllAlbums godoc
 // @Summary Получить все альбомы
-// @Description Получить список всех альбомов
+// @Description Получить список всех альбомов. Вместо полных данных фотографий возвращаются их количество, обложка и несколько превью.
+// @Description Умные альбомы отмечены полем smart, их фотографии подбираются по сохраненному запросу.
 // @Tags albums
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Success 200 {array} models.Album
+// @Param previews query int false "Количество превью в каждом альбоме" default(4)
+// @Success 200 {array} models.AlbumSummary
+// @Failure 400 {object} string "Некорректное количество превью"
 // @Failure 500 {object} string "Внутренняя ошибка сервера"
 // @Router /albums [get]
 func (h *AlbumHandler) GetAllAlbums(w http.ResponseWriter, r *http.Request) {
@@ -158,6 +183,16 @@ func (h *AlbumHandler) GetAllAlbums(w http.ResponseWriter, r *http.Request) {
 	// Получаем контекст из запроса
 	ctx := r.Context()
 
+	previews := defaultAlbumPreviews
+	if v := r.URL.Query().Get("previews"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxAlbumPreviews {
+			http.Error(w, "Некорректное количество превью", http.StatusBadRequest)
+			return
+		}
+		previews = n
+	}
+
 	// Получаем все альбомы из репозитория
 	albums, err := h.repo.GetAllAlbums(ctx)
 	if err != nil {
@@ -166,11 +201,34 @@ func (h *AlbumHandler) GetAllAlbums(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Получаем все фотографии одним запросом и группируем их по альбомам
+	photos, err := h.repo.FindPhotos(ctx, models.PhotoFilter{})
+	if err != nil {
+		log.Printf("Ошибка при получении фотографий: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+	photosByAlbum := make(map[int][]models.Photo)
+	for _, photo := range photos {
+		if photo.Album != nil {
+			photosByAlbum[photo.Album.ID] = append(photosByAlbum[photo.Album.ID], photo)
+		}
+	}
+
+	summaries := make([]models.AlbumSummary, 0, len(albums))
+	for _, album := range albums {
+		if album.IsSmart() {
+			summaries = append(summaries, album.Summary(smartAlbumPhotos(*album.Filter, photos), previews))
+			continue
+		}
+		summaries = append(summaries, album.Summary(albumPhotos(album, photosByAlbum[album.ID]), previews))
+	}
+
 	// Устанавливаем заголовок Content-Type
 	w.Header().Set("Content-Type", "application/json")
 
 	// Сериализуем альбомы в JSON и отправляем клиенту
-	if err := json.NewEncoder(w).Encode(albums); err != nil {
+	if err := json.NewEncoder(w).Encode(summaries); err != nil {
 		log.Printf("Ошибка при сериализации альбомов: %v", err)
 		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
 		return
@@ -182,7 +240,8 @@ func (h *AlbumHandler) GetAllAlbums(w http.ResponseWriter, r *http.Request) {
 
 // GetAlbumByID godoc
 // @Summary Получить альбом по ID
-// @Description Получить данные конкретного альбома по его идентификатору
+// @Description Получить данные конкретного альбома по его идентификатору.
+// @Description Фотографии умного альбома (smart) подбираются по сохраненному запросу filter, от новых к старым.
 // @Tags albums
 // @Accept json
 // @Produce json
@@ -259,11 +318,12 @@ func splitPath(path string) []string {
 
 // DeleteAlbum godoc
 // @Summary Удалить альбом
-// @Description Удалить альбом по его идентификатору
+// @Description Перемещает альбом и его фотографии в корзину. Восстановить альбом можно
+// @Description через POST /trash/albums/{id}/restore до окончания срока хранения.
 // @Tags albums
 // @Param id path int true "ID альбома"
 // @Security Bearer
-// @Success 204 "Альбом успешно удален"
+// @Success 204 "Альбом перемещен в корзину"
 // @Failure 400 {object} string "Некорректный ID альбома"
 // @Failure 404 {object} string "Альбом не найден"
 // @Failure 500 {object} string "Внутренняя ошибка сервера"
@@ -314,5 +374,37 @@ func (h *AlbumHandler) DeleteAlbum(w http.ResponseWriter, r *http.Request) {
 
 	// Возвращаем успешный статус без тела ответа
 	w.WriteHeader(http.StatusNoContent)
-	log.Printf("Успешно удален альбом с ID=%d", id)
+	log.Printf("Альбом с ID=%d перемещен в корзину", id)
+}
+
+// smartAlbumPhotos отбирает фотографии умного альбома по его запросу, от новых к старым
+func smartAlbumPhotos(filter models.PhotoFilter, photos []models.Photo) []models.Photo {
+	result := []models.Photo{}
+	for _, photo := range photos {
+		if filter.Match(photo) {
+			result = append(result, photo)
+		}
+	}
+	_ = models.SortPhotos(result, "-taken_at")
+	return result
+}
+
+// albumPhotos объединяет фотографии, сохраненные внутри альбома, с загруженными в него фотографиями
+func albumPhotos(album models.Album, uploaded []models.Photo) []models.Photo {
+	if len(album.Photos) == 0 {
+		return uploaded
+	}
+
+	seen := make(map[int]bool, len(album.Photos))
+	photos := make([]models.Photo, 0, len(album.Photos)+len(uploaded))
+	for _, photo := range album.Photos {
+		seen[photo.ID] = true
+		photos = append(photos, photo)
+	}
+	for _, photo := range uploaded {
+		if !seen[photo.ID] {
+			photos = append(photos, photo)
+		}
+	}
+	return photos
 }
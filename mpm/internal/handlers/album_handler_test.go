@@ -9,9 +9,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestAlbumHandler создаем тестовую структуру обработчика с интерфейсом
@@ -587,3 +589,107 @@ func TestUpdateAlbum(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
+
+func TestSmartAlbums(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	handler := NewAlbumHandler(env.repo)
+	env.mux.HandleFunc("POST /api/albums", handler.CreateAlbum)
+	env.mux.HandleFunc("GET /api/albums", handler.GetAllAlbums)
+	env.mux.HandleFunc("GET /api/albums/{id}", handler.GetAlbumByID)
+	env.mux.HandleFunc("PUT /api/albums/{id}", handler.UpdateAlbum)
+
+	do := func(method, url, body string, v interface{}) int {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+		if v != nil && w.Code < 300 {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(v))
+		}
+		return w.Code
+	}
+
+	first := env.uploadTestPhoto(t, "beach_01.png", testPNG(t))
+	env.uploadTestPhoto(t, "city.png", testPNG(t))
+
+	var created models.Album
+	t.Run("Создание умного альбома", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/albums",
+			`{"name":"Пляж","filter":{"q":"beach","media":"photo"},"photos":[{"id":2}]}`, &created))
+		assert.True(t, created.IsSmart())
+		require.NotNil(t, created.Filter)
+		assert.Equal(t, "beach", created.Filter.Query)
+		require.Len(t, created.Photos, 1, "переданные фотографии заменяются результатом запроса")
+		assert.Equal(t, first.ID, created.Photos[0].ID)
+	})
+
+	t.Run("Фотографии подбираются при каждом запросе", func(t *testing.T) {
+		second := env.uploadTestPhoto(t, "beach_02.png", testPNG(t))
+
+		var album models.Album
+		require.Equal(t, http.StatusOK, do(http.MethodGet, fmt.Sprintf("/api/albums/%d", created.ID), "", &album))
+		assert.True(t, album.IsSmart())
+		assert.Len(t, album.Photos, 2)
+		assert.Contains(t, []int{album.Photos[0].ID, album.Photos[1].ID}, second.ID)
+
+		var summaries []models.AlbumSummary
+		require.Equal(t, http.StatusOK, do(http.MethodGet, "/api/albums", "", &summaries))
+		require.Len(t, summaries, 2)
+		for _, summary := range summaries {
+			if summary.ID == created.ID {
+				assert.True(t, summary.Smart)
+				assert.Equal(t, 2, summary.PhotoCount)
+			} else {
+				assert.False(t, summary.Smart)
+				assert.Equal(t, 3, summary.PhotoCount)
+			}
+		}
+	})
+
+	t.Run("В умный альбом нельзя загрузить фотографии", func(t *testing.T) {
+		body, contentType := multipartBody(t, map[string][]byte{"beach_03.png": testPNG(t)})
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/albums/%d/photos", created.ID), body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Даты без времени", func(t *testing.T) {
+		photo, err := env.repo.FindPhotoByID(first.ID)
+		require.NoError(t, err)
+		taken := time.Date(2024, 12, 31, 18, 30, 0, 0, time.UTC)
+		photo.TakenAt = &taken
+		require.NoError(t, env.repo.UpdatePhoto(context.Background(), photo.ID, photo))
+
+		var album models.Album
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/albums",
+			`{"name":"2024","filter":{"from":"2024-01-01","to":"2024-12-31"}}`, &album))
+		require.Len(t, album.Photos, 1, "to включает фотографию, снятую 31 декабря")
+		assert.Equal(t, first.ID, album.Photos[0].ID)
+
+		require.Equal(t, http.StatusOK, do(http.MethodPut, fmt.Sprintf("/api/albums/%d", album.ID),
+			`{"name":"2024","filter":{"from":"2024-01-01","to":"2024-12-30"}}`, nil))
+		var updated models.Album
+		require.Equal(t, http.StatusOK, do(http.MethodGet, fmt.Sprintf("/api/albums/%d", album.ID), "", &updated))
+		assert.Empty(t, updated.Photos)
+	})
+
+	t.Run("Некорректный запрос", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/albums",
+			`{"name":"Корзина","filter":{"trashed":true}}`, nil))
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/albums",
+			`{"name":"Даты","filter":{"from":"01.01.2024"}}`, nil))
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/albums",
+			`{"name":"Даты","filter":{"from":"2024-12-01T00:00:00Z","to":"2024-01-01T00:00:00Z"}}`, nil))
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, fmt.Sprintf("/api/albums/%d", created.ID),
+			fmt.Sprintf(`{"name":"Сам в себе","filter":{"album_id":%d}}`, created.ID), nil))
+	})
+
+	t.Run("Умный альбом становится обычным", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do(http.MethodPut, fmt.Sprintf("/api/albums/%d", created.ID), `{"name":"Пляж"}`, nil))
+
+		var album models.Album
+		require.Equal(t, http.StatusOK, do(http.MethodGet, fmt.Sprintf("/api/albums/%d", created.ID), "", &album))
+		assert.False(t, album.IsSmart())
+		assert.Nil(t, album.Filter)
+	})
+}
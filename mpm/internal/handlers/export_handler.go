@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ExportAlbum godoc
+// @Summary Скачать альбом архивом
+// @Description Передает ZIP-архив с оригиналами всех фотографий альбома и файлом manifest.json,
+// @Description содержащим поля альбома, имена, теги и метаданные фотографий. Архив формируется на лету.
+// @Tags albums
+// @Produce application/zip
+// @Security Bearer
+// @Param id path int true "ID альбома"
+// @Success 200 {file} file "ZIP-архив"
+// @Failure 400 {object} string "Некорректный ID альбома"
+// @Failure 404 {object} string "Альбом не найден"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /albums/{id}/export.zip [get]
+func (h *PhotoHandler) ExportAlbum(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/albums/{id}/export.zip")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID альбома", http.StatusBadRequest)
+		return
+	}
+
+	export, err := h.photoService.PrepareAlbumExport(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, "Альбом не найден", http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при подготовке экспорта альбома: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.Filename()}))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	// Заголовки уже отправлены, поэтому ошибку можно только залогировать: клиент получит оборванный архив
+	if err := h.photoService.WriteAlbumZip(ctx, w, export); err != nil {
+		log.Printf("Ошибка при экспорте альбома с ID=%d: %v", id, err)
+		return
+	}
+	log.Printf("Экспортирован альбом с ID=%d: %d фотографий", id, len(export.Photos))
+}
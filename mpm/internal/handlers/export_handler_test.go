@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAlbum(t *testing.T) {
+	t.Run("Архив содержит оригиналы и manifest.json", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		png := testPNG(t)
+		jpg := testJPEG(t, 32, 16)
+		first := env.uploadTestPhoto(t, "beach.png", png)
+		env.uploadTestPhoto(t, "beach.png", jpg)
+
+		tags := []string{"море"}
+		_, err := env.handler.photoService.UpdatePhoto(context.Background(), first.ID, service.PhotoUpdate{Tags: tags})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/albums/1/export.zip", nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "filename=Album_1.zip")
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		require.NoError(t, err)
+
+		files := make(map[string][]byte)
+		for _, f := range archive.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+			files[f.Name] = data
+		}
+		require.Len(t, files, 3)
+		assert.Equal(t, png, files["beach.png"])
+		assert.Equal(t, jpg, files["beach (2).png"])
+
+		var manifest models.AlbumManifest
+		require.NoError(t, json.Unmarshal(files[models.ManifestFilename], &manifest))
+		assert.Equal(t, models.ManifestVersion, manifest.Version)
+		assert.Equal(t, 1, manifest.Album.ID)
+		assert.Equal(t, "Album 1", manifest.Album.Name)
+		require.Len(t, manifest.Photos, 2)
+		assert.Equal(t, "beach.png", manifest.Photos[0].File)
+		assert.Equal(t, tags, manifest.Photos[0].Tags)
+		assert.Equal(t, first.Checksum, manifest.Photos[0].Checksum)
+		assert.Equal(t, "beach (2).png", manifest.Photos[1].File)
+	})
+
+	t.Run("Отсутствующий файл пропускается", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		photo := env.uploadTestPhoto(t, "lost.png", testPNG(t))
+		require.NoError(t, os.Remove(filepath.Join(env.filesDir, photo.Path)))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/albums/1/export.zip", nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		require.NoError(t, err)
+		require.Len(t, archive.File, 1)
+		assert.Equal(t, models.ManifestFilename, archive.File[0].Name)
+	})
+
+	t.Run("Альбом не найден", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/albums/99/export.zip", nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
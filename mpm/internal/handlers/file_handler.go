@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mpm/internal/service"
+	"mpm/internal/storage"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FileHandler отдает файлы хранилища по подписанным ссылкам без авторизации
+type FileHandler struct {
+	provider     storage.Provider
+	signer       *storage.URLSigner
+	photoService *service.PhotoService
+}
+
+func NewFileHandler(provider storage.Provider, signer *storage.URLSigner, photoService *service.PhotoService) *FileHandler {
+	return &FileHandler{
+		provider:     provider,
+		signer:       signer,
+		photoService: photoService,
+	}
+}
+
+// ServeFile отдает файл по подписанной ссылке, выданной GET /api/photos/{id}/url
+func (h *FileHandler) ServeFile(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /files/{path}")
+
+	filePath := r.PathValue("path")
+	if filePath == "" || slices.Contains(strings.Split(filePath, "/"), "..") {
+		http.Error(w, "Файл не найден", http.StatusNotFound)
+		return
+	}
+
+	expires, err := h.signer.Verify(filePath, r.URL.Query())
+	if err != nil {
+		if errors.Is(err, storage.ErrURLExpired) {
+			http.Error(w, "Срок действия ссылки истек", http.StatusForbidden)
+		} else {
+			http.Error(w, "Неверная подпись ссылки", http.StatusForbidden)
+		}
+		return
+	}
+
+	// Ссылка на уменьшенную копию не должна открывать оригинал, и наоборот
+	scope, err := h.photoService.FileScope(r.Context(), filePath)
+	if err != nil || scope != r.URL.Query().Get("scope") {
+		http.Error(w, "Ссылка не относится к запрошенному файлу", http.StatusForbidden)
+		return
+	}
+
+	reader, err := h.provider.GetReader(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, "Файл не найден", http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при открытии файла %s: %v", filePath, err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer reader.Close()
+
+	// Файлы хранилища не изменяются после записи, поэтому ссылку можно кешировать до ее истечения
+	maxAge := int(time.Until(expires).Seconds())
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", max(maxAge, 0)))
+
+	sum := sha256.Sum256([]byte(filePath))
+	etag := fmt.Sprintf(`"f%x"`, sum[:8])
+	serveContent(w, r, path.Base(filePath), sniffContentType(reader), etag, time.Time{}, 0, reader)
+}
+
+// sniffContentType определяет тип изображения или видео по содержимому, если reader поддерживает Seek
+func sniffContentType(reader io.Reader) string {
+	rs, ok := reader.(io.ReadSeeker)
+	if !ok {
+		return ""
+	}
+	contentType, err := storage.SniffMedia(rs)
+	if err != nil {
+		return ""
+	}
+	return contentType
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"mpm/internal/service"
+	"mpm/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedURLs(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	signer := storage.NewURLSigner([]byte("test-key"), "/files")
+	env.storage.SetURLSigner(signer, time.Hour)
+	env.mux.HandleFunc("GET /files/{path...}", NewFileHandler(env.storage, signer, env.handler.photoService).ServeFile)
+
+	data := testPNG(t)
+	photo := env.uploadTestPhoto(t, "mail.png", data)
+
+	getURL := func(t *testing.T, query string) (*httptest.ResponseRecorder, service.SignedURL) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/photos/"+strconv.Itoa(photo.ID)+"/url"+query, nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+
+		var signed service.SignedURL
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&signed))
+		}
+		return w, signed
+	}
+	fetch := func(link string) *httptest.ResponseRecorder {
+		// Запрос без авторизации
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, link, nil))
+		return w
+	}
+
+	t.Run("Оригинал доступен по подписанной ссылке", func(t *testing.T) {
+		w, signed := getURL(t, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, storage.ScopeOriginal, signed.Scope)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), signed.ExpiresAt, 2*time.Second)
+
+		file := fetch(signed.URL)
+		require.Equal(t, http.StatusOK, file.Code)
+		assert.Equal(t, data, file.Body.Bytes())
+		assert.Equal(t, "image/png", file.Header().Get("Content-Type"))
+		assert.Contains(t, file.Header().Get("Cache-Control"), "public, max-age=")
+	})
+
+	t.Run("Уменьшенная копия", func(t *testing.T) {
+		w, signed := getURL(t, "?scope=thumbnail&size=256&ttl=10m")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, storage.ScopeThumbnail, signed.Scope)
+		assert.Contains(t, signed.URL, "scope=thumbnail")
+
+		file := fetch(signed.URL)
+		require.Equal(t, http.StatusOK, file.Code)
+		assert.Equal(t, "image/jpeg", file.Header().Get("Content-Type"))
+	})
+
+	t.Run("Измененная ссылка отклоняется", func(t *testing.T) {
+		_, signed := getURL(t, "?scope=thumbnail")
+		u, err := url.Parse(signed.URL)
+		require.NoError(t, err)
+
+		// Попытка получить оригинал по ссылке на уменьшенную копию
+		original := *u
+		original.Path = "/files/" + photo.Path
+		assert.Equal(t, http.StatusForbidden, fetch(original.String()).Code)
+
+		query := u.Query()
+		query.Set("expires", "9999999999")
+		extended := *u
+		extended.RawQuery = query.Encode()
+		assert.Equal(t, http.StatusForbidden, fetch(extended.String()).Code)
+
+		assert.Equal(t, http.StatusForbidden, fetch(u.Path).Code)
+	})
+
+	t.Run("Область действия ссылки проверяется по файлу", func(t *testing.T) {
+		stored, err := env.handler.photoService.GetPhoto(context.Background(), photo.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, stored.Renditions)
+
+		// Подпись верна, но область действия не соответствует файлу
+		original, _, err := env.storage.GetSignedURL(stored.Path, storage.ScopeThumbnail, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, fetch(original).Code)
+
+		thumbnail, _, err := env.storage.GetSignedURL(stored.Renditions[0].Path, storage.ScopeOriginal, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, fetch(thumbnail).Code)
+
+		unscoped, _, err := env.storage.GetSignedURL(stored.Path, "", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, fetch(unscoped).Code)
+	})
+
+	t.Run("Просроченная ссылка", func(t *testing.T) {
+		link, _, err := env.storage.GetSignedURL(photo.Path, storage.ScopeOriginal, -time.Minute)
+		require.NoError(t, err)
+
+		w := fetch(link)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.True(t, strings.Contains(w.Body.String(), "истек"))
+	})
+
+	t.Run("Некорректные параметры", func(t *testing.T) {
+		w, _ := getURL(t, "?scope=everything")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, _ = getURL(t, "?ttl=9999h")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetPhotoURL_SigningDisabled(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	photo := env.uploadTestPhoto(t, "mail.png", testPNG(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/photos/"+strconv.Itoa(photo.ID)+"/url", nil)
+	w := httptest.NewRecorder()
+	env.mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
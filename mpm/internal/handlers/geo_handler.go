@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mpm/internal/geo"
+	"mpm/internal/service"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GeoHandler ищет фотографии по координатам съемки и отдает их для отображения на карте
+type GeoHandler struct {
+	geo *service.GeoService
+}
+
+func NewGeoHandler(geo *service.GeoService) *GeoHandler {
+	return &GeoHandler{geo: geo}
+}
+
+// SearchPhotos godoc
+// @Summary Фотографии в области карты
+// @Description Возвращает фотографии с координатами GPS внутри области, сгруппированные на сервере
+// @Description для указанного масштаба карты. Группа объединяет фотографии, попадающие в квадрат
+// @Description со стороной 60 пикселей. Если масштаб не указан, он подбирается по размеру области.
+// @Description Область, пересекающая 180-й меридиан, задается с minLon больше maxLon.
+// @Tags photos
+// @Produce json
+// @Param bbox query string true "Область minLon,minLat,maxLon,maxLat" example(37.3,55.5,37.9,55.9)
+// @Param zoom query int false "Масштаб карты от 0 до 20"
+// @Param album_id query int false "ID альбома"
+// @Param user_id query int false "ID пользователя"
+// @Param tag query []string false "Теги (фотография должна содержать все)" collectionFormat(multi)
+// @Param camera query string false "Производитель или модель камеры"
+// @Param q query string false "Подстрока названия, тега, города, региона или страны съемки"
+// @Param media query string false "Тип медиафайла" Enums(photo, video, live_photo)
+// @Param from query string false "Начало диапазона дат съемки (YYYY-MM-DD или RFC3339)"
+// @Param to query string false "Конец диапазона дат съемки (YYYY-MM-DD или RFC3339)"
+// @Security Bearer
+// @Success 200 {object} service.GeoSearchResult
+// @Failure 400 {object} string "Некорректные параметры запроса"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/geo [get]
+func (h *GeoHandler) SearchPhotos(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos/geo")
+
+	query := r.URL.Query()
+	if query.Get("bbox") == "" {
+		http.Error(w, "Не указана область bbox", http.StatusBadRequest)
+		return
+	}
+	bbox, err := geo.ParseBBox(query.Get("bbox"))
+	if err != nil {
+		http.Error(w, "Некорректная область bbox: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	zoom, err := parseZoom(query, geo.ZoomFor(bbox))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parsePhotoFilter(query)
+	if err != nil {
+		http.Error(w, "Некорректный параметр фильтра: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.geo.Search(r.Context(), bbox, zoom, filter)
+	if err != nil {
+		log.Printf("Ошибка при поиске фотографий в области: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+	log.Printf("В области %s найдено %d фотографий, групп: %d", query.Get("bbox"), result.Total, len(result.Clusters))
+}
+
+// GetAlbumMap godoc
+// @Summary Карта альбома в формате GeoJSON
+// @Description Возвращает фотографии альбома с координатами GPS в виде коллекции точек GeoJSON (RFC 7946).
+// @Description Точки сгруппированы для указанного масштаба, в свойствах передаются count и photo_ids.
+// @Description Точка с одной фотографией дополнительно содержит photo_id, name и taken_at.
+// @Description Без параметра zoom группируются только совпадающие точки.
+// @Tags albums
+// @Produce application/geo+json
+// @Param id path int true "ID альбома"
+// @Param zoom query int false "Масштаб карты от 0 до 20"
+// @Security Bearer
+// @Success 200 {object} geo.FeatureCollection
+// @Failure 400 {object} string "Некорректные параметры запроса"
+// @Failure 404 {object} string "Альбом не найден"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /albums/{id}/map.geojson [get]
+func (h *GeoHandler) GetAlbumMap(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/albums/{id}/map.geojson")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID альбома", http.StatusBadRequest)
+		return
+	}
+
+	zoom, err := parseZoom(r.URL.Query(), geo.MaxZoom)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collection, err := h.geo.AlbumMap(r.Context(), id, zoom)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, "Альбом не найден", http.StatusNotFound)
+			return
+		}
+		log.Printf("Ошибка при построении карты альбома с ID=%d: %v", id, err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(collection); err != nil {
+		log.Printf("Ошибка при сериализации ответа: %v", err)
+	}
+}
+
+// parseZoom разбирает масштаб карты из параметра zoom
+func parseZoom(query url.Values, defaultZoom int) (int, error) {
+	v := query.Get("zoom")
+	if v == "" {
+		return defaultZoom, nil
+	}
+
+	zoom, err := strconv.Atoi(v)
+	if err != nil || zoom < geo.MinZoom || zoom > geo.MaxZoom {
+		return 0, fmt.Errorf("масштаб zoom должен быть целым числом от %d до %d", geo.MinZoom, geo.MaxZoom)
+	}
+	return zoom, nil
+}
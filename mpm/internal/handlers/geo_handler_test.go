@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mpm/internal/geo"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoHandler(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	handler := NewGeoHandler(service.NewGeoService(env.repo))
+	env.mux.HandleFunc("GET /api/photos/geo", handler.SearchPhotos)
+	env.mux.HandleFunc("GET /api/albums/{id}/map.geojson", handler.GetAlbumMap)
+
+	takenAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	located := func(id int, name, lat, lon string, tags ...string) models.Photo {
+		return models.Photo{
+			ID:      id,
+			Name:    name,
+			Album:   &models.Album{ID: 1},
+			Tags:    tags,
+			TakenAt: &takenAt,
+			Metadata: []models.Metadata{
+				{Key: models.MetadataGPSLatitude, Value: lat},
+				{Key: models.MetadataGPSLongitude, Value: lon},
+			},
+		}
+	}
+	require.NoError(t, env.repo.SaveEntity(located(1, "kremlin.jpg", "55.752000", "37.617500", "город")))
+	require.NoError(t, env.repo.SaveEntity(located(2, "square.jpg", "55.753900", "37.620800")))
+	require.NoError(t, env.repo.SaveEntity(located(3, "neva.jpg", "59.934300", "30.335100", "город")))
+	require.NoError(t, env.repo.SaveEntity(models.Photo{ID: 4, Name: "nogps.jpg", Album: &models.Album{ID: 1}}))
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	t.Run("Поиск в области с группировкой", func(t *testing.T) {
+		w := get("/api/photos/geo?bbox=20,50,40,60&zoom=5")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result service.GeoSearchResult
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		assert.Equal(t, 3, result.Total)
+		assert.Equal(t, 5, result.Zoom)
+		require.Len(t, result.Clusters, 2)
+		assert.Equal(t, []int{1, 2}, result.Clusters[0].PhotoIDs)
+		assert.Equal(t, []int{3}, result.Clusters[1].PhotoIDs)
+	})
+
+	t.Run("Масштаб по размеру области и фильтр по тегу", func(t *testing.T) {
+		w := get("/api/photos/geo?bbox=37.3,55.5,37.9,55.9&tag=город")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result service.GeoSearchResult
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		assert.Equal(t, 11, result.Zoom)
+		require.Len(t, result.Clusters, 1)
+		assert.Equal(t, []int{1}, result.Clusters[0].PhotoIDs)
+	})
+
+	t.Run("Некорректные параметры", func(t *testing.T) {
+		for _, url := range []string{
+			"/api/photos/geo",
+			"/api/photos/geo?bbox=1,2,3",
+			"/api/photos/geo?bbox=0,0,1,1&zoom=25",
+			"/api/photos/geo?bbox=0,0,1,1&media=audio",
+			"/api/albums/1/map.geojson?zoom=abc",
+			"/api/albums/abc/map.geojson",
+		} {
+			assert.Equal(t, http.StatusBadRequest, get(url).Code, url)
+		}
+	})
+
+	t.Run("Карта альбома в GeoJSON", func(t *testing.T) {
+		w := get("/api/albums/1/map.geojson")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/geo+json", w.Header().Get("Content-Type"))
+
+		var collection geo.FeatureCollection
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&collection))
+		assert.Equal(t, "FeatureCollection", collection.Type)
+		require.Len(t, collection.Features, 3, "без zoom близкие точки не объединяются")
+
+		feature := collection.Features[0]
+		assert.Equal(t, "Point", feature.Geometry.Type)
+		assert.Equal(t, []float64{37.6175, 55.752}, feature.Geometry.Coordinates)
+		assert.Equal(t, "kremlin.jpg", feature.Properties["name"])
+		assert.Equal(t, float64(1), feature.Properties["photo_id"])
+		assert.Equal(t, "2024-06-01T12:00:00Z", feature.Properties["taken_at"])
+
+		w = get("/api/albums/1/map.geojson?zoom=3")
+		require.Equal(t, http.StatusOK, w.Code)
+		collection = geo.FeatureCollection{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&collection))
+		require.Len(t, collection.Features, 2)
+		assert.Equal(t, float64(2), collection.Features[0].Properties["count"])
+		assert.NotContains(t, collection.Features[0].Properties, "photo_id")
+	})
+
+	t.Run("Альбом не найден", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/api/albums/99/map.geojson").Code)
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/internal/storage/google"
+	"mpm/middleware"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// oauthStateTTL сколько действует параметр state, выданный для подключения аккаунта
+const oauthStateTTL = 10 * time.Minute
+
+// GoogleHandler подключает аккаунт Google Photos и запускает импорт альбомов
+type GoogleHandler struct {
+	client   *google.Client
+	importer *service.GoogleImporter
+
+	mutex  sync.Mutex
+	states map[string]time.Time // Выданные значения state и срок их действия
+}
+
+func NewGoogleHandler(client *google.Client, importer *service.GoogleImporter) *GoogleHandler {
+	return &GoogleHandler{
+		client:   client,
+		importer: importer,
+		states:   make(map[string]time.Time),
+	}
+}
+
+// GoogleStatus состояние подключения Google Photos и последнего импорта
+type GoogleStatus struct {
+	Connected bool                       `json:"connected"`
+	Import    service.GoogleImportStatus `json:"import"`
+}
+
+// GoogleAuthURL адрес страницы согласия Google
+type GoogleAuthURL struct {
+	URL string `json:"url"`
+}
+
+// GetStatus godoc
+// @Summary Состояние Google Photos
+// @Description Возвращает, подключен ли аккаунт Google Photos, и состояние текущего или последнего импорта
+// @Tags google
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} GoogleStatus
+// @Router /google/status [get]
+func (h *GoogleHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/google/status")
+
+	writeJSON(w, http.StatusOK, GoogleStatus{
+		Connected: h.client.Connected(),
+		Import:    h.importer.Status(),
+	})
+}
+
+// GetAuthURL godoc
+// @Summary Подключить Google Photos
+// @Description Возвращает адрес страницы, на которой пользователь разрешает доступ к библиотеке Google Photos.
+// @Description После согласия Google перенаправляет браузер на /google/callback, и аккаунт подключается.
+// @Tags google
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} GoogleAuthURL
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /google/auth [get]
+func (h *GoogleHandler) GetAuthURL(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/google/auth")
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		log.Printf("Ошибка создания параметра state: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+	state := hex.EncodeToString(buf)
+
+	h.mutex.Lock()
+	now := time.Now()
+	for s, expires := range h.states {
+		if now.After(expires) {
+			delete(h.states, s)
+		}
+	}
+	h.states[state] = now.Add(oauthStateTTL)
+	h.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, GoogleAuthURL{URL: h.client.AuthCodeURL(state)})
+}
+
+// Callback принимает перенаправление от Google после согласия пользователя
+func (h *GoogleHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /google/callback")
+
+	query := r.URL.Query()
+	if !h.consumeState(query.Get("state")) {
+		http.Error(w, "Неверный или устаревший параметр state", http.StatusBadRequest)
+		return
+	}
+	if reason := query.Get("error"); reason != "" {
+		http.Error(w, "Доступ к Google Photos не предоставлен: "+reason, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.client.Connect(r.Context(), query.Get("code")); err != nil {
+		log.Printf("Ошибка подключения аккаунта Google: %v", err)
+		http.Error(w, "Не удалось подключить аккаунт Google", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("Аккаунт Google Photos подключен. Окно можно закрыть."))
+	log.Println("Аккаунт Google Photos подключен")
+}
+
+// consumeState проверяет state и удаляет его, чтобы ссылку нельзя было использовать повторно
+func (h *GoogleHandler) consumeState(state string) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	expires, ok := h.states[state]
+	delete(h.states, state)
+	return ok && time.Now().Before(expires)
+}
+
+// StartImport godoc
+// @Summary Импортировать альбомы Google Photos
+// @Description Запускает в фоне импорт всех альбомов подключенной библиотеки. Файлы не копируются:
+// @Description фотографии ссылаются на медиафайлы Google Photos. Повторный импорт добавляет только новые медиафайлы.
+// @Description Ход импорта возвращает GET /google/status.
+// @Tags google
+// @Produce json
+// @Security Bearer
+// @Success 202 {object} service.GoogleImportStatus
+// @Failure 409 {object} string "Аккаунт не подключен или импорт уже выполняется"
+// @Router /google/import [post]
+func (h *GoogleHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/google/import")
+
+	if !h.client.Connected() {
+		http.Error(w, google.ErrNotConnected.Error(), http.StatusConflict)
+		return
+	}
+
+	user, _ := r.Context().Value(middleware.UserContextKey).(*models.User)
+	if err := h.importer.Start(r.Context(), user); err != nil {
+		if errors.Is(err, service.ErrImportRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("Ошибка запуска импорта из Google Photos: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, h.importer.Status())
+}
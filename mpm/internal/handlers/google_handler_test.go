@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/internal/storage/google"
+	"mpm/internal/storage/google/googletest"
+	"mpm/middleware"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleHandler(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	server := googletest.NewServer(t)
+	dir := t.TempDir()
+
+	client := google.NewClient(server.URL, google.OAuthConfig{
+		ClientID:     googletest.ClientID,
+		ClientSecret: googletest.ClientSecret,
+		RedirectURL:  "http://localhost/google/callback",
+		TokenURL:     server.TokenURL(),
+	}, google.NewFileTokenStore(filepath.Join(dir, "token.json")), nil)
+	env.handler.photoService.RegisterProvider(google.StorageType, google.NewStorage(client, ""))
+
+	importer, err := service.NewGoogleImporter(client, env.repo, filepath.Join(dir, "import.json"))
+	require.NoError(t, err)
+	handler := NewGoogleHandler(client, importer)
+
+	env.mux.HandleFunc("GET /api/google/status", handler.GetStatus)
+	env.mux.HandleFunc("GET /api/google/auth", handler.GetAuthURL)
+	env.mux.HandleFunc("POST /api/google/import", handler.StartImport)
+	env.mux.HandleFunc("GET /google/callback", handler.Callback)
+
+	user := &models.User{ID: 1, Username: "admin"}
+	do := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, user))
+		rec := httptest.NewRecorder()
+		env.mux.ServeHTTP(rec, req)
+		return rec
+	}
+	status := func() GoogleStatus {
+		rec := do(http.MethodGet, "/api/google/status")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var s GoogleStatus
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&s))
+		return s
+	}
+
+	t.Run("Импорт без подключенного аккаунта", func(t *testing.T) {
+		assert.False(t, status().Connected)
+		assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/api/google/import").Code)
+	})
+
+	t.Run("Подключение аккаунта", func(t *testing.T) {
+		rec := do(http.MethodGet, "/api/google/auth")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var auth GoogleAuthURL
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&auth))
+		link, err := url.Parse(auth.URL)
+		require.NoError(t, err)
+		state := link.Query().Get("state")
+		require.NotEmpty(t, state)
+
+		rec = do(http.MethodGet, "/google/callback?state=wrong&code="+googletest.AuthCode)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = do(http.MethodGet, "/google/callback?state="+state+"&code="+googletest.AuthCode)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.True(t, status().Connected)
+
+		rec = do(http.MethodGet, "/google/callback?state="+state+"&code="+googletest.AuthCode)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "state одноразовый")
+	})
+
+	t.Run("Импорт альбомов", func(t *testing.T) {
+		data := testPNG(t)
+		server.AddAlbum("Отпуск", googletest.Item{
+			Filename:     "sea.png",
+			MimeType:     "image/png",
+			CreationTime: "2023-07-14T09:30:00Z",
+			Width:        2,
+			Height:       2,
+			Data:         data,
+		})
+
+		rec := do(http.MethodPost, "/api/google/import")
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+		require.Eventually(t, func() bool { return !status().Import.Running }, 5*time.Second, 10*time.Millisecond)
+		result := status().Import
+		assert.Empty(t, result.Error)
+		assert.Equal(t, 1, result.Albums)
+		assert.Equal(t, 1, result.Imported)
+
+		photos, err := env.repo.FindPhotos(context.Background(), models.PhotoFilter{})
+		require.NoError(t, err)
+		require.Len(t, photos, 1)
+		photo := photos[0]
+		assert.Equal(t, "sea.png", photo.Name)
+		assert.Equal(t, google.StorageType, photo.StorageType)
+		assert.Equal(t, "Отпуск", photo.Album.Name)
+
+		rec = do(http.MethodGet, fmt.Sprintf("/api/photos/%d/content", photo.ID))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body, _ := io.ReadAll(rec.Body)
+		assert.Equal(t, data, body, "содержимое читается из Google Photos")
+	})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/middleware"
+	"net/http"
+)
+
+// ImportAlbum godoc
+// @Summary Импортировать альбом из архива
+// @Description Создает альбом из ZIP-архива (multipart/form-data, поле file) и загружает в него все изображения.
+// @Description Если в архиве есть manifest.json, из него восстанавливаются название, описание и теги альбома,
+// @Description а также теги и метаданные фотографий. Ошибки отдельных файлов перечисляются в поле errors.
+// @Tags albums
+// @Accept mpfd
+// @Produce json
+// @Security Bearer
+// @Param file formData file true "ZIP-архив"
+// @Param name formData string false "Название альбома"
+// @Success 201 {object} service.ImportResult
+// @Failure 400 {object} string "Некорректный архив или ни один файл не импортирован"
+// @Failure 413 {object} string "Слишком большой архив"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /albums/import [post]
+func (h *PhotoHandler) ImportAlbum(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/albums/import")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	// Ограничиваем размер архива
+	if maxSize := h.photoService.ImportLimits().MaxArchiveSize; maxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartMemoryLimit)
+	}
+
+	if err := r.ParseMultipartForm(multipartMemoryLimit); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Слишком большой архив", http.StatusRequestEntityTooLarge)
+			return
+		}
+		log.Printf("Ошибка при разборе multipart-запроса: %v", err)
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Архив не передан", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	user, _ := ctx.Value(middleware.UserContextKey).(*models.User)
+
+	result, err := h.photoService.ImportAlbum(ctx, file, header.Size, service.ImportOptions{
+		Name:        r.FormValue("name"),
+		ArchiveName: header.Filename,
+		User:        user,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidArchive) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Ошибка при импорте архива: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, result)
+	log.Printf("Успешно импортирован альбом с ID=%d: %d фотографий, ошибок: %d", result.Album.ID, len(result.Photos), len(result.Errors))
+}
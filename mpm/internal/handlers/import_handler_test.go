@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zipEntry запись тестового архива
+type zipEntry struct {
+	name   string
+	data   []byte
+	method uint16
+}
+
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: e.method})
+		require.NoError(t, err)
+		_, err = w.Write(e.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func (env *photoTestEnv) importArchive(t *testing.T, filename string, archive []byte, name string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(archive)
+	require.NoError(t, err)
+	if name != "" {
+		require.NoError(t, writer.WriteField("name", name))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/albums/import", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	env.mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportAlbum(t *testing.T) {
+	t.Run("Экспортированный альбом восстанавливается с тегами и описанием", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		ctx := context.Background()
+		require.NoError(t, env.repo.UpdateAlbum(ctx, 1, models.Album{Name: "Отпуск", Description: "Лето у моря", Tags: []string{"лето"}}))
+
+		photo := env.uploadTestPhoto(t, "beach.png", testPNG(t))
+		metadata := []models.Metadata{{Key: "location", Value: "Сочи"}}
+		_, err := env.handler.photoService.UpdatePhoto(ctx, photo.ID, service.PhotoUpdate{Tags: []string{"море"}, Metadata: metadata})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/albums/1/export.zip", nil)
+		exported := httptest.NewRecorder()
+		env.mux.ServeHTTP(exported, req)
+		require.Equal(t, http.StatusOK, exported.Code)
+
+		w := env.importArchive(t, "backup.zip", exported.Body.Bytes(), "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var result service.ImportResult
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		assert.Empty(t, result.Errors)
+		assert.NotEqual(t, 1, result.Album.ID)
+		assert.Equal(t, "Отпуск", result.Album.Name)
+		assert.Equal(t, "Лето у моря", result.Album.Description)
+		assert.Equal(t, []string{"лето"}, result.Album.Tags)
+
+		require.Len(t, result.Photos, 1)
+		imported := result.Photos[0]
+		assert.Equal(t, "beach.png", imported.Name)
+		assert.Equal(t, result.Album.ID, imported.Album.ID)
+		assert.Equal(t, []string{"море"}, imported.Tags)
+		assert.Equal(t, metadata, imported.Metadata)
+		assert.Equal(t, photo.Checksum, imported.Checksum)
+	})
+
+	t.Run("Ошибки отдельных файлов не прерывают импорт", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		archive := buildZip(t,
+			zipEntry{name: "good.png", data: testPNG(t)},
+			zipEntry{name: "../../etc/evil.png", data: testPNG(t)},
+			zipEntry{name: "notes.txt", data: []byte("не изображение")},
+			zipEntry{name: "bomb.png", data: make([]byte, 4<<20), method: zip.Deflate},
+			zipEntry{name: "__MACOSX/._good.png", data: []byte{0}},
+			zipEntry{name: "nested/", data: nil},
+		)
+
+		w := env.importArchive(t, "mixed.zip", archive, "Смешанный")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var result service.ImportResult
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		assert.Equal(t, "Смешанный", result.Album.Name)
+		require.Len(t, result.Photos, 1)
+		assert.Equal(t, "good.png", result.Photos[0].Name)
+
+		errs := make(map[string]string)
+		for _, e := range result.Errors {
+			errs[e.File] = e.Error
+		}
+		assert.Len(t, errs, 3)
+		assert.Contains(t, errs["../../etc/evil.png"], "небезопасный путь")
+		assert.Equal(t, service.ErrNotAnImage.Error(), errs["notes.txt"])
+		assert.Contains(t, errs["bomb.png"], "степень сжатия")
+	})
+
+	t.Run("Альбом без импортированных фотографий удаляется", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		ctx := context.Background()
+		before, err := env.repo.GetAllAlbums(ctx)
+		require.NoError(t, err)
+
+		archive := buildZip(t, zipEntry{name: "notes.txt", data: []byte("не изображение")})
+		w := env.importArchive(t, "notes.zip", archive, "Пустой")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "notes.txt")
+		after, err := env.repo.GetAllAlbums(ctx)
+		require.NoError(t, err)
+		assert.Len(t, after, len(before))
+		trashed, err := env.repo.FindDeletedAlbums(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, trashed)
+	})
+
+	t.Run("Файлы распаковываются в каталог импорта", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		importDir := filepath.Join(t.TempDir(), "tmp", "import")
+		env.handler.photoService.SetImportDir(importDir)
+
+		w := env.importArchive(t, "one.zip", buildZip(t, zipEntry{name: "a.png", data: testPNG(t)}), "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		entries, err := os.ReadDir(importDir)
+		require.NoError(t, err, "каталог импорта должен быть создан")
+		assert.Empty(t, entries, "временные файлы удаляются после импорта")
+	})
+
+	t.Run("Название альбома по имени архива", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+
+		w := env.importArchive(t, "Поездка 2024.zip", buildZip(t, zipEntry{name: "a.png", data: testPNG(t)}), "")
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var result service.ImportResult
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		assert.Equal(t, "Поездка 2024", result.Album.Name)
+	})
+
+	t.Run("Некорректный архив", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+
+		w := env.importArchive(t, "broken.zip", []byte("not a zip"), "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
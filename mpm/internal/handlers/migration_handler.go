@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mpm/internal/service"
+	"net/http"
+)
+
+// MigrationHandler запускает перенос файлов фотографий между хранилищами
+type MigrationHandler struct {
+	migrator *service.BlobMigrator
+}
+
+func NewMigrationHandler(migrator *service.BlobMigrator) *MigrationHandler {
+	return &MigrationHandler{migrator: migrator}
+}
+
+// GetStatus godoc
+// @Summary Состояние переноса файлов
+// @Description Возвращает ход текущего или итоги последнего переноса файлов между хранилищами
+// @Tags storage
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} service.MigrationStatus
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /storage/migration [get]
+func (h *MigrationHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/storage/migration")
+
+	writeJSON(w, http.StatusOK, h.migrator.Status())
+}
+
+// StartMigration godoc
+// @Summary Перенести файлы в другое хранилище
+// @Description Запускает в фоне копирование файлов фотографий из одного хранилища в другое, например из local в s3.
+// @Description Каждый файл проверяется по SHA-256, после чего фотография переключается на новое хранилище.
+// @Description Сервис продолжает работать, а прерванный перенос продолжается без повторного копирования файлов.
+// @Description Ход переноса возвращает GET /storage/migration.
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param options body service.MigrationOptions true "Исходное и новое хранилища"
+// @Success 202 {object} service.MigrationStatus
+// @Failure 400 {object} string "Хранилище не настроено или совпадает с исходным"
+// @Failure 409 {object} string "Перенос уже выполняется"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /storage/migration [post]
+func (h *MigrationHandler) StartMigration(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/storage/migration")
+
+	var opts service.MigrationOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.migrator.Start(r.Context(), opts); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMigration):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrMigrationRunning):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Printf("Ошибка запуска переноса файлов: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, h.migrator.Status())
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/internal/storage"
+	"mpm/middleware"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationHandler(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	targetDir := t.TempDir()
+	env.handler.photoService.RegisterProvider("s3", storage.NewLocalStorage(targetDir, "/files"))
+
+	migrator, err := service.NewBlobMigrator(env.handler.photoService, filepath.Join(t.TempDir(), "migration.json"))
+	require.NoError(t, err)
+	handler := NewMigrationHandler(migrator)
+	env.mux.HandleFunc("GET /api/storage/migration", handler.GetStatus)
+	env.mux.HandleFunc("POST /api/storage/migration", handler.StartMigration)
+
+	user := &models.User{ID: 1, Username: "admin"}
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, user))
+		rec := httptest.NewRecorder()
+		env.mux.ServeHTTP(rec, req)
+		return rec
+	}
+	status := func() service.MigrationStatus {
+		rec := do(http.MethodGet, "/api/storage/migration", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var s service.MigrationStatus
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&s))
+		return s
+	}
+
+	data := testPNG(t)
+	photo, err := env.handler.photoService.Upload(context.Background(), 1, user, service.UploadFile{
+		File:     storage.NewBytesFile(data),
+		Filename: "sea.png",
+		Size:     int64(len(data)),
+	})
+	require.NoError(t, err)
+
+	t.Run("Некорректные параметры", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/storage/migration", "{").Code)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/storage/migration", `{"from":"local","to":"dropbox"}`).Code)
+	})
+
+	t.Run("Перенос в другое хранилище", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/storage/migration", `{"from":"local","to":"s3","delete_source":true}`)
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+		require.Eventually(t, func() bool { return !status().Running }, 5*time.Second, 10*time.Millisecond)
+		result := status()
+		assert.Empty(t, result.Error)
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, 1, result.Migrated)
+
+		migrated, err := env.repo.FindPhotoByID(photo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "s3", migrated.StorageType)
+		_, err = os.Stat(filepath.Join(env.filesDir, photo.Path))
+		assert.True(t, os.IsNotExist(err), "исходный файл удален")
+
+		rec = do(http.MethodGet, fmt.Sprintf("/api/photos/%d/content", photo.ID), "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		body, _ := io.ReadAll(rec.Body)
+		assert.Equal(t, data, body, "содержимое читается из нового хранилища")
+	})
+}
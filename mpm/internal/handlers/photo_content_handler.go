@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mpm/internal/models"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetPhotoContent godoc
+// @Summary Получить содержимое фотографии
+// @Description Потоковая передача файла фотографии с поддержкой Range, ETag и Last-Modified
+// @Tags photos
+// @Produce octet-stream
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Param Range header string false "Диапазон байт, например bytes=0-1023"
+// @Param If-None-Match header string false "ETag ранее полученной версии"
+// @Success 200 {file} file "Содержимое файла"
+// @Success 206 {file} file "Часть содержимого файла"
+// @Success 304 "Файл не изменился"
+// @Failure 400 {object} string "Некорректный ID фотографии"
+// @Failure 404 {object} string "Фотография не найдена"
+// @Failure 416 {object} string "Запрошенный диапазон недоступен"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id}/content [get]
+func (h *PhotoHandler) GetPhotoContent(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos/{id}/content")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	photo, reader, err := h.photoService.OpenContent(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, "Фотография не найдена", http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при открытии фотографии: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer reader.Close()
+
+	serveContent(w, r, photo.Name, photo.MimeType, photoETag(photo), photo.CreatedAt, photo.Size, reader)
+}
+
+// GetPhotoThumbnail godoc
+// @Summary Получить уменьшенную копию фотографии
+// @Description Отдает наименьшую уменьшенную копию, большая сторона которой не меньше size. Если копий нет, отдается оригинал.
+// @Description У видео уменьшенных копий нет.
+// @Tags photos
+// @Produce jpeg
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Param size query int false "Размер по большей стороне в пикселях" default(256)
+// @Success 200 {file} file "Уменьшенная копия"
+// @Success 304 "Файл не изменился"
+// @Failure 400 {object} string "Некорректный ID фотографии или размер"
+// @Failure 404 {object} string "Фотография не найдена"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id}/thumb [get]
+func (h *PhotoHandler) GetPhotoThumbnail(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos/{id}/thumb")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	size := models.DefaultPreviewSize
+	if v := r.URL.Query().Get("size"); v != "" {
+		size, err = strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			http.Error(w, "Некорректный размер", http.StatusBadRequest)
+			return
+		}
+	}
+
+	photo, rendition, reader, err := h.photoService.OpenThumbnail(ctx, id, size)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, "Фотография не найдена", http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при открытии уменьшенной копии: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer reader.Close()
+
+	if rendition == nil {
+		serveContent(w, r, photo.Name, photo.MimeType, photoETag(photo), photo.CreatedAt, photo.Size, reader)
+		return
+	}
+
+	etag := fmt.Sprintf(`"p%d-t%d-%x"`, photo.ID, rendition.Size, photo.CreatedAt.UnixNano())
+	serveContent(w, r, path.Base(rendition.Path), "image/jpeg", etag, photo.CreatedAt, 0, reader)
+}
+
+// serveContent отдает файл клиенту с поддержкой Range и условных запросов
+func serveContent(w http.ResponseWriter, r *http.Request, name, contentType, etag string, modTime time.Time, size int64, reader io.Reader) {
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": name}))
+
+	if rs, ok := reader.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, modTime, rs)
+		return
+	}
+
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, modTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Без Seek частичные ответы не поддерживаются
+	w.Header().Set("Accept-Ranges", "none")
+	if size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		log.Printf("Ошибка при передаче файла %s: %v", name, err)
+	}
+}
+
+// notModified проверяет условные заголовки запроса
+func notModified(r *http.Request, etag string, modTime time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modTime.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !modTime.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
+
+// photoETag формирует ETag для содержимого фотографии
+func photoETag(photo models.Photo) string {
+	return fmt.Sprintf(`"p%d-%x-%x"`, photo.ID, photo.Size, photo.CreatedAt.UnixNano())
+}
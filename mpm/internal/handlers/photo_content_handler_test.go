@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPhotoContent(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	data := testPNG(t)
+	photo := env.uploadTestPhoto(t, "content.png", data)
+	url := fmt.Sprintf("/api/photos/%d/content", photo.ID)
+
+	t.Run("Полный файл", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+		assert.Equal(t, data, w.Body.Bytes())
+	})
+
+	t.Run("Частичное содержимое", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Range", "bytes=0-7")
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, fmt.Sprintf("bytes 0-7/%d", len(data)), w.Header().Get("Content-Range"))
+		assert.Equal(t, data[:8], w.Body.Bytes())
+	})
+
+	t.Run("Недопустимый диапазон", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", len(data)+10))
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	})
+
+	t.Run("Условный запрос по ETag", func(t *testing.T) {
+		first := httptest.NewRecorder()
+		env.mux.ServeHTTP(first, httptest.NewRequest(http.MethodGet, url, nil))
+		etag := first.Header().Get("ETag")
+
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("If-None-Match", etag)
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("Фотография не найдена", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/photos/999/content", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetPhotoThumbnail(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	photo := env.uploadTestPhoto(t, "big.jpg", testJPEG(t, 1200, 800))
+
+	assert.Equal(t, 1200, photo.Width)
+	assert.Equal(t, 800, photo.Height)
+	require.Len(t, photo.Renditions, 3)
+	assert.Equal(t, 256, photo.Renditions[0].Size)
+	assert.Equal(t, 171, photo.Renditions[0].Height)
+	assert.Equal(t, 1024, photo.Renditions[1].Size)
+	assert.Equal(t, 1200, photo.Renditions[2].Width, "изображение не увеличивается")
+
+	t.Run("Выбор подходящей копии", func(t *testing.T) {
+		for size, wantWidth := range map[string]int{"": 256, "200": 256, "512": 1024, "4000": 1200} {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/photos/%d/thumb?size=%s", photo.ID, size), nil)
+			w := httptest.NewRecorder()
+			env.mux.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+			img, err := jpeg.Decode(w.Body)
+			require.NoError(t, err)
+			assert.Equal(t, wantWidth, img.Bounds().Dx(), "size=%s", size)
+		}
+	})
+
+	t.Run("Изображение без копий отдается в оригинале", func(t *testing.T) {
+		small := env.uploadTestPhoto(t, "small.png", testPNG(t))
+		require.Len(t, small.Renditions, 1)
+
+		require.NoError(t, env.repo.UpdatePhoto(context.Background(), small.ID, models.Photo{Name: small.Name, Path: small.Path, MimeType: small.MimeType}))
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/photos/%d/thumb", small.ID), nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Некорректный размер", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/photos/%d/thumb?size=-1", photo.ID), nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Очистка корзины удаляет копии", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/photos/%d", photo.ID), nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		for _, r := range photo.Renditions {
+			_, err := os.Stat(filepath.Join(env.filesDir, r.Path))
+			assert.NoError(t, err, "копия %s сохраняется в корзине", r.Path)
+		}
+
+		trash := service.NewTrashService(env.repo, env.handler.photoService, 0)
+		require.NoError(t, trash.PurgePhoto(context.Background(), photo.ID))
+		for _, r := range photo.Renditions {
+			_, err := os.Stat(filepath.Join(env.filesDir, r.Path))
+			assert.True(t, os.IsNotExist(err), "копия %s должна быть удалена", r.Path)
+		}
+	})
+}
+
+func TestServeContent_WithoutSeek(t *testing.T) {
+	data := []byte("streamed content")
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Полная передача без поддержки Range", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Range", "bytes=0-3")
+		w := httptest.NewRecorder()
+
+		serveContent(w, req, "file.bin", "", `"etag"`, modTime, int64(len(data)), io.NopCloser(bytes.NewReader(data)))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "none", w.Header().Get("Accept-Ranges"))
+		assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+		assert.Equal(t, data, w.Body.Bytes())
+	})
+
+	t.Run("If-Modified-Since", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+
+		serveContent(w, req, "file.bin", "", `"etag"`, modTime, int64(len(data)), io.NopCloser(bytes.NewReader(data)))
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+}
@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/middleware"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// multipartMemoryLimit объем multipart-данных, который держим в памяти, остальное уходит во временные файлы
+const multipartMemoryLimit = 32 << 20
+
+type PhotoHandler struct {
+	photoService   *service.PhotoService
+	maxUploadFiles int
+
+	// Срок действия подписанных ссылок: по умолчанию и максимальный
+	urlTTL    time.Duration
+	maxURLTTL time.Duration
+}
+
+// uploadError описывает ошибку загрузки отдельного файла
+type uploadError struct {
+	Filename string `json:"filename"`
+	Error    string `json:"error"`
+}
+
+// uploadDuplicate сообщает, что загруженный файл уже есть в библиотеке
+type uploadDuplicate struct {
+	Filename    string `json:"filename"`
+	PhotoID     int    `json:"photo_id"`
+	DuplicateOf []int  `json:"duplicate_of"`
+}
+
+// uploadResponse результат загрузки нескольких файлов
+type uploadResponse struct {
+	Photos     []models.Photo    `json:"photos"`
+	Errors     []uploadError     `json:"errors,omitempty"`
+	Duplicates []uploadDuplicate `json:"duplicates,omitempty"`
+}
+
+func NewPhotoHandler(photoService *service.PhotoService, maxUploadFiles int) *PhotoHandler {
+	return &PhotoHandler{
+		photoService:   photoService,
+		maxUploadFiles: maxUploadFiles,
+		urlTTL:         time.Hour,
+		maxURLTTL:      7 * 24 * time.Hour,
+	}
+}
+
+// UploadPhotos godoc
+// @Summary Загрузить фотографии в альбом
+// @Description Загрузить одну или несколько фотографий или видео MP4/MOV в альбом (multipart/form-data, поле files).
+// @Description Видео с тем же именем, что и снимок (IMG_0001.HEIC и IMG_0001.MOV), сохраняется как видеоролик Live Photo.
+// @Description Файлы, уже имеющиеся в библиотеке, сохраняются и перечисляются в поле duplicates.
+// @Tags photos
+// @Accept mpfd
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID альбома"
+// @Param files formData file true "Файлы изображений и видео"
+// @Success 201 {object} uploadResponse
+// @Failure 400 {object} uploadResponse "Некорректный запрос или ни один файл не принят"
+// @Failure 404 {object} string "Альбом не найден"
+// @Failure 413 {object} string "Слишком большой запрос"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /albums/{id}/photos [post]
+func (h *PhotoHandler) UploadPhotos(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/albums/{id}/photos")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	albumID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID альбома", http.StatusBadRequest)
+		return
+	}
+
+	// Ограничиваем общий размер запроса
+	if maxSize := h.photoService.MaxUploadSize(); maxSize > 0 && h.maxUploadFiles > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize*int64(h.maxUploadFiles)+multipartMemoryLimit)
+	}
+
+	if err := r.ParseMultipartForm(multipartMemoryLimit); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Слишком большой запрос", http.StatusRequestEntityTooLarge)
+			return
+		}
+		log.Printf("Ошибка при разборе multipart-запроса: %v", err)
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+
+	// Принимаем файлы как из поля files, так и из поля file
+	var headers []*multipart.FileHeader
+	headers = append(headers, r.MultipartForm.File["files"]...)
+	headers = append(headers, r.MultipartForm.File["file"]...)
+	if len(headers) == 0 {
+		http.Error(w, "Файлы не переданы", http.StatusBadRequest)
+		return
+	}
+	if h.maxUploadFiles > 0 && len(headers) > h.maxUploadFiles {
+		http.Error(w, "Слишком много файлов в запросе", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := ctx.Value(middleware.UserContextKey).(*models.User)
+
+	motions := pairMotionClips(headers)
+	clips := make(map[*multipart.FileHeader]bool, len(motions))
+	for _, clip := range motions {
+		clips[clip] = true
+	}
+
+	response := uploadResponse{Photos: []models.Photo{}}
+	for _, header := range headers {
+		// Видеоролик Live Photo загружается вместе со своим снимком
+		if clips[header] {
+			continue
+		}
+
+		photo, err := h.uploadFile(r, albumID, user, header)
+		if err != nil {
+			if strings.Contains(err.Error(), "не найден") {
+				http.Error(w, "Альбом не найден", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, service.ErrSmartAlbum) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Ошибка при загрузке файла %s: %v", header.Filename, err)
+			response.Errors = append(response.Errors, uploadError{Filename: header.Filename, Error: uploadErrorMessage(err)})
+			if clip, ok := motions[header]; ok {
+				response.Errors = append(response.Errors, uploadError{Filename: clip.Filename, Error: "Снимок Live Photo не загружен"})
+			}
+			continue
+		}
+
+		if clip, ok := motions[header]; ok {
+			livePhoto, err := h.attachMotion(r, photo.ID, clip)
+			if err != nil {
+				log.Printf("Ошибка при загрузке видеоролика %s: %v", clip.Filename, err)
+				response.Errors = append(response.Errors, uploadError{Filename: clip.Filename, Error: uploadErrorMessage(err)})
+			} else {
+				photo = livePhoto
+			}
+		}
+		response.Photos = append(response.Photos, photo)
+
+		// Дубликат все равно сохраняется, но пользователь получает о нем сообщение
+		duplicates, err := h.photoService.FindDuplicates(ctx, photo)
+		if err != nil {
+			log.Printf("Ошибка при поиске дубликатов файла %s: %v", header.Filename, err)
+			continue
+		}
+		if len(duplicates) > 0 {
+			duplicate := uploadDuplicate{Filename: header.Filename, PhotoID: photo.ID}
+			for _, d := range duplicates {
+				duplicate.DuplicateOf = append(duplicate.DuplicateOf, d.ID)
+			}
+			response.Duplicates = append(response.Duplicates, duplicate)
+		}
+	}
+
+	status := http.StatusCreated
+	if len(response.Photos) == 0 {
+		status = http.StatusBadRequest
+	}
+
+	writeJSON(w, status, response)
+	log.Printf("Загружено %d фотографий в альбом с ID=%d, ошибок: %d", len(response.Photos), albumID, len(response.Errors))
+}
+
+// ListPhotos godoc
+// @Summary Получить список фотографий
+// @Description Получить фотографии с фильтрацией по альбому, тегу, пользователю, камере, типу медиафайла и диапазону дат съемки
+// @Tags photos
+// @Produce json
+// @Security Bearer
+// @Param album_id query int false "ID альбома"
+// @Param tag query []string false "Тег (можно указать несколько)" collectionFormat(multi)
+// @Param user_id query int false "ID пользователя"
+// @Param from query string false "Начало диапазона (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец диапазона (RFC3339 или YYYY-MM-DD)"
+// @Param camera query string false "Производитель или модель камеры"
+// @Param q query string false "Подстрока названия, тега, города, региона или страны съемки"
+// @Param media query string false "Тип медиафайла: photo, video или live_photo"
+// @Param sort query string false "Сортировка: taken_at, created_at, name; префикс - для обратного порядка"
+// @Success 200 {array} models.Photo
+// @Failure 400 {object} string "Некорректные параметры фильтра"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos [get]
+func (h *PhotoHandler) ListPhotos(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	filter, err := parsePhotoFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	photos, err := h.photoService.ListPhotos(ctx, filter)
+	if err != nil {
+		log.Printf("Ошибка при получении фотографий: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	if err := models.SortPhotos(photos, r.URL.Query().Get("sort")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, photos)
+	log.Printf("Успешно отправлены данные о %d фотографиях", len(photos))
+}
+
+// GetPhotoByID godoc
+// @Summary Получить фотографию по ID
+// @Description Получить данные конкретной фотографии по ее идентификатору
+// @Tags photos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Success 200 {object} models.Photo
+// @Failure 400 {object} string "Некорректный ID фотографии"
+// @Failure 404 {object} string "Фотография не найдена"
+// @Router /photos/{id} [get]
+func (h *PhotoHandler) GetPhotoByID(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos/{id}")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	photo, err := h.photoService.GetPhoto(ctx, id)
+	if err != nil {
+		http.Error(w, "Фотография не найдена", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, photo)
+	log.Printf("Успешно отправлены данные о фотографии с ID=%d", id)
+}
+
+// UpdatePhoto godoc
+// @Summary Обновить фотографию
+// @Description Изменить название, альбом, теги или метаданные фотографии
+// @Tags photos
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Param photo body service.PhotoUpdate true "Изменяемые поля фотографии"
+// @Success 200 {object} models.Photo
+// @Failure 400 {object} string "Некорректный ID фотографии или данные"
+// @Failure 404 {object} string "Фотография или альбом не найдены"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id} [put]
+func (h *PhotoHandler) UpdatePhoto(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос PUT /api/photos/{id}")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	var update service.PhotoUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		log.Printf("Ошибка при декодировании JSON: %v", err)
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	photo, err := h.photoService.UpdatePhoto(ctx, id, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPhoto):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case strings.Contains(err.Error(), "не найден"):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			log.Printf("Ошибка при обновлении фотографии: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, photo)
+	log.Printf("Успешно обновлена фотография с ID=%d", id)
+}
+
+// DeletePhoto godoc
+// @Summary Удалить фотографию
+// @Description Перемещает фотографию в корзину. Файлы удаляются при очистке корзины.
+// @Tags photos
+// @Param id path int true "ID фотографии"
+// @Security Bearer
+// @Success 204 "Фотография перемещена в корзину"
+// @Failure 400 {object} string "Некорректный ID фотографии"
+// @Failure 404 {object} string "Фотография не найдена"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id} [delete]
+func (h *PhotoHandler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос DELETE /api/photos/{id}")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.photoService.DeletePhoto(ctx, id); err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, "Фотография не найдена", http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при удалении фотографии: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("Фотография с ID=%d перемещена в корзину", id)
+}
+
+// parsePhotoFilter разбирает параметры фильтра фотографий из строки запроса
+func parsePhotoFilter(query url.Values) (models.PhotoFilter, error) {
+	var filter models.PhotoFilter
+
+	if v := query.Get("album_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("некорректный album_id")
+		}
+		filter.AlbumID = &id
+	}
+
+	if v := query.Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("некорректный user_id")
+		}
+		filter.UserID = &id
+	}
+
+	filter.Tags = query["tag"]
+	filter.Camera = query.Get("camera")
+	filter.Query = strings.TrimSpace(query.Get("q"))
+
+	switch media := query.Get("media"); media {
+	case "", models.MediaTypePhoto, models.MediaTypeVideo, models.MediaTypeLivePhoto:
+		filter.Media = media
+	default:
+		return filter, fmt.Errorf("некорректный тип медиафайла media")
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := models.ParseFilterTime(v, false)
+		if err != nil {
+			return filter, fmt.Errorf("некорректная дата from")
+		}
+		filter.From = &from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := models.ParseFilterTime(v, true)
+		if err != nil {
+			return filter, fmt.Errorf("некорректная дата to")
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// writeJSON сериализует значение в JSON и отправляет клиенту
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Ошибка при сериализации ответа: %v", err)
+	}
+}
+
+// uploadFile открывает файл из multipart-запроса и передает его в сервис
+func (h *PhotoHandler) uploadFile(r *http.Request, albumID int, user *models.User, header *multipart.FileHeader) (models.Photo, error) {
+	file, err := header.Open()
+	if err != nil {
+		return models.Photo{}, err
+	}
+	defer file.Close()
+
+	return h.photoService.Upload(r.Context(), albumID, user, service.UploadFile{
+		File:     file,
+		Filename: header.Filename,
+		Size:     header.Size,
+	})
+}
+
+// uploadErrorMessage возвращает сообщение об ошибке, которое можно показать клиенту
+func uploadErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, service.ErrNotAnImage):
+		return service.ErrNotAnImage.Error()
+	case errors.Is(err, service.ErrNotAVideo):
+		return service.ErrNotAVideo.Error()
+	case errors.Is(err, service.ErrFileTooLarge):
+		return service.ErrFileTooLarge.Error()
+	default:
+		return "Внутренняя ошибка сервера"
+	}
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"mpm/internal/models"
+	"mpm/internal/repository"
+	"mpm/internal/service"
+	"mpm/internal/storage"
+	"mpm/middleware"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPNG создает небольшое PNG-изображение для тестов
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// photoTestEnv окружение для тестов обработчика фотографий
+type photoTestEnv struct {
+	repo     *repository.Repository
+	storage  *storage.LocalStorage
+	filesDir string
+	handler  *PhotoHandler
+	mux      *http.ServeMux
+}
+
+func newPhotoTestEnv(t *testing.T, maxUploadSize int64) *photoTestEnv {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	filesDir := filepath.Join(dataDir, "files")
+
+	repo := repository.NewRepository("json", dataDir, time.Hour)
+	require.NoError(t, repo.SaveEntity(models.Album{ID: 1, Name: "Album 1", CreatedAt: time.Now()}))
+
+	fileStorage := storage.NewLocalStorage(filesDir, "/files")
+	photoService := service.NewPhotoService(repo, fileStorage, "local", maxUploadSize)
+	handler := NewPhotoHandler(photoService, 5)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/albums/{id}/photos", handler.UploadPhotos)
+	mux.HandleFunc("GET /api/photos", handler.ListPhotos)
+	mux.HandleFunc("GET /api/photos/{id}", handler.GetPhotoByID)
+	mux.HandleFunc("PUT /api/photos/{id}", handler.UpdatePhoto)
+	mux.HandleFunc("DELETE /api/photos/{id}", handler.DeletePhoto)
+	mux.HandleFunc("GET /api/photos/{id}/content", handler.GetPhotoContent)
+	mux.HandleFunc("GET /api/photos/{id}/thumb", handler.GetPhotoThumbnail)
+	mux.HandleFunc("GET /api/photos/{id}/motion", handler.GetPhotoMotion)
+	mux.HandleFunc("PUT /api/photos/{id}/motion", handler.AttachPhotoMotion)
+	mux.HandleFunc("GET /api/photos/{id}/similar", handler.GetSimilarPhotos)
+	mux.HandleFunc("GET /api/photos/{id}/suggested-tags", handler.GetSuggestedTags)
+	mux.HandleFunc("GET /api/photos/{id}/url", handler.GetPhotoURL)
+	mux.HandleFunc("GET /api/duplicates", handler.GetDuplicates)
+	mux.HandleFunc("GET /api/albums/{id}/export.zip", handler.ExportAlbum)
+	mux.HandleFunc("POST /api/albums/import", handler.ImportAlbum)
+
+	return &photoTestEnv{repo: repo, storage: fileStorage, filesDir: filesDir, handler: handler, mux: mux}
+}
+
+// multipartBody формирует multipart-запрос с набором файлов
+func multipartBody(t *testing.T, files map[string][]byte) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, data := range files {
+		part, err := writer.CreateFormFile("files", name)
+		require.NoError(t, err)
+		_, err = part.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return &body, writer.FormDataContentType()
+}
+
+func TestUploadPhotos(t *testing.T) {
+	t.Run("Успешная загрузка нескольких файлов", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		body, contentType := multipartBody(t, map[string][]byte{
+			"first.png":  testPNG(t),
+			"second.png": testPNG(t),
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/albums/1/photos", body)
+		req.Header.Set("Content-Type", contentType)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, &models.User{ID: 7, Username: "tester", Password: "secret"}))
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var resp uploadResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Len(t, resp.Photos, 2)
+		assert.Empty(t, resp.Errors)
+
+		for _, photo := range resp.Photos {
+			assert.NotZero(t, photo.ID)
+			assert.Equal(t, "local", photo.StorageType)
+			assert.Equal(t, "image/png", photo.MimeType)
+			require.NotNil(t, photo.Album)
+			assert.Equal(t, 1, photo.Album.ID)
+			require.NotNil(t, photo.User)
+			assert.Equal(t, 7, photo.User.ID)
+			assert.Empty(t, photo.User.Password, "пароль не должен попадать в фотографию")
+
+			_, err := os.Stat(filepath.Join(env.filesDir, photo.Path))
+			assert.NoError(t, err, "файл должен быть сохранен в хранилище")
+		}
+
+		assert.Len(t, env.repo.GetAllPhotos(), 2)
+	})
+
+	t.Run("Файл, не являющийся изображением, отклоняется", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		body, contentType := multipartBody(t, map[string][]byte{
+			"fake.jpg": []byte("это просто текст, а не картинка"),
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/albums/1/photos", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp uploadResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Empty(t, resp.Photos)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "fake.jpg", resp.Errors[0].Filename)
+		assert.Empty(t, env.repo.GetAllPhotos())
+	})
+
+	t.Run("Частичная загрузка при превышении размера", func(t *testing.T) {
+		small := testPNG(t)
+		large := append(testPNG(t), make([]byte, 2048)...)
+
+		env := newPhotoTestEnv(t, int64(len(small)+100))
+		body, contentType := multipartBody(t, map[string][]byte{
+			"small.png": small,
+			"large.png": large,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/albums/1/photos", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var resp uploadResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Len(t, resp.Photos, 1)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "large.png", resp.Errors[0].Filename)
+		assert.Equal(t, service.ErrFileTooLarge.Error(), resp.Errors[0].Error)
+	})
+
+	t.Run("Альбом не найден", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		body, contentType := multipartBody(t, map[string][]byte{"photo.png": testPNG(t)})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/albums/999/photos", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Некорректный ID альбома", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		body, contentType := multipartBody(t, map[string][]byte{"photo.png": testPNG(t)})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/albums/abc/photos", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Запрос без файлов", func(t *testing.T) {
+		env := newPhotoTestEnv(t, 1<<20)
+		body, contentType := multipartBody(t, map[string][]byte{})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/albums/1/photos", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// uploadTestPhoto загружает одну фотографию и возвращает ее
+func (env *photoTestEnv) uploadTestPhoto(t *testing.T, name string, data []byte) models.Photo {
+	t.Helper()
+
+	body, contentType := multipartBody(t, map[string][]byte{name: data})
+	req := httptest.NewRequest(http.MethodPost, "/api/albums/1/photos", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	env.mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp uploadResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Photos, 1)
+	return resp.Photos[0]
+}
+
+func TestPhotoCRUD(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	require.NoError(t, env.repo.SaveEntity(models.Album{ID: 2, Name: "Album 2", CreatedAt: time.Now()}))
+
+	first := env.uploadTestPhoto(t, "first.png", testPNG(t))
+	second := env.uploadTestPhoto(t, "second.png", testPNG(t))
+
+	t.Run("Список фотографий", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/photos", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var photos []models.Photo
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&photos))
+		assert.Len(t, photos, 2)
+	})
+
+	t.Run("Некорректный фильтр", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/photos?from=вчера", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Обновление фотографии", func(t *testing.T) {
+		body := strings.NewReader(`{"name":"renamed.png","album_id":2,"tags":["море"]}`)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/photos/%d", first.ID), body))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var photo models.Photo
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&photo))
+		assert.Equal(t, "renamed.png", photo.Name)
+		assert.Equal(t, 2, photo.Album.ID)
+		assert.Equal(t, first.Path, photo.Path, "путь к файлу не должен меняться")
+	})
+
+	t.Run("Фильтр по альбому и тегу", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/photos?album_id=2&tag=море", nil))
+
+		var photos []models.Photo
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&photos))
+		require.Len(t, photos, 1)
+		assert.Equal(t, first.ID, photos[0].ID)
+	})
+
+	t.Run("Фильтр по дате", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/photos?to=2000-01-01", nil))
+
+		var photos []models.Photo
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&photos))
+		assert.Empty(t, photos)
+	})
+
+	t.Run("Обновление с перемещением в несуществующий альбом", func(t *testing.T) {
+		body := strings.NewReader(`{"album_id":999}`)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/photos/%d", first.ID), body))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Получение и удаление фотографии в корзину", func(t *testing.T) {
+		url := fmt.Sprintf("/api/photos/%d", second.ID)
+
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, url, nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		_, err := os.Stat(filepath.Join(env.filesDir, second.Path))
+		assert.NoError(t, err, "файл фотографии в корзине сохраняется")
+
+		w = httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, url, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestUploadPhotos_Duplicates(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	data := testPNG(t)
+	original := env.uploadTestPhoto(t, "original.png", data)
+	require.NotEmpty(t, original.Checksum)
+
+	body, contentType := multipartBody(t, map[string][]byte{"copy.png": data})
+	req := httptest.NewRequest(http.MethodPost, "/api/albums/1/photos", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	env.mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp uploadResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Photos, 1)
+	assert.Equal(t, original.Checksum, resp.Photos[0].Checksum)
+	require.Len(t, resp.Duplicates, 1)
+	assert.Equal(t, "copy.png", resp.Duplicates[0].Filename)
+	assert.Equal(t, resp.Photos[0].ID, resp.Duplicates[0].PhotoID)
+	assert.Equal(t, []int{original.ID}, resp.Duplicates[0].DuplicateOf)
+}
+
+// testJPEG создает JPEG-изображение указанного размера
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
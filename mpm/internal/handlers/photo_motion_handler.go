@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// GetPhotoMotion godoc
+// @Summary Получить видеоролик Live Photo
+// @Description Потоковая передача видеоролика Live Photo с поддержкой Range, ETag и Last-Modified
+// @Tags photos
+// @Produce octet-stream
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Param Range header string false "Диапазон байт, например bytes=0-1023"
+// @Success 200 {file} file "Видеоролик"
+// @Success 206 {file} file "Часть видеоролика"
+// @Success 304 "Файл не изменился"
+// @Failure 400 {object} string "Некорректный ID фотографии"
+// @Failure 404 {object} string "Фотография или видеоролик не найдены"
+// @Failure 416 {object} string "Запрошенный диапазон недоступен"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id}/motion [get]
+func (h *PhotoHandler) GetPhotoMotion(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos/{id}/motion")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	photo, reader, err := h.photoService.OpenMotion(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, "Видеоролик не найден", http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при открытии видеоролика: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer reader.Close()
+
+	motion := photo.Motion
+	etag := fmt.Sprintf(`"p%d-m%x-%x"`, photo.ID, motion.Size, photo.CreatedAt.UnixNano())
+	serveContent(w, r, path.Base(motion.Path), motion.MimeType, etag, photo.CreatedAt, motion.Size, reader)
+}
+
+// AttachPhotoMotion godoc
+// @Summary Прикрепить видеоролик Live Photo
+// @Description Сохраняет видео MP4 или MOV (multipart/form-data, поле file) как видеоролик Live Photo снимка.
+// @Description Ранее прикрепленный видеоролик заменяется.
+// @Tags photos
+// @Accept mpfd
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Param file formData file true "Видеоролик"
+// @Success 200 {object} models.Photo
+// @Failure 400 {object} string "Некорректный ID, файл не является видео или фотография сама является видео"
+// @Failure 404 {object} string "Фотография не найдена"
+// @Failure 413 {object} string "Слишком большой файл"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id}/motion [put]
+func (h *PhotoHandler) AttachPhotoMotion(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос PUT /api/photos/{id}/motion")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	if maxSize := h.photoService.MaxUploadSize(); maxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartMemoryLimit)
+	}
+	if err := r.ParseMultipartForm(multipartMemoryLimit); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Слишком большой файл", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) != 1 {
+		http.Error(w, "Ожидается один файл в поле file", http.StatusBadRequest)
+		return
+	}
+
+	photo, err := h.attachMotion(r, id, headers[0])
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotAVideo), errors.Is(err, service.ErrInvalidPhoto):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrFileTooLarge):
+			http.Error(w, "Слишком большой файл", http.StatusRequestEntityTooLarge)
+		case strings.Contains(err.Error(), "не найден"):
+			http.Error(w, "Фотография не найдена", http.StatusNotFound)
+		default:
+			log.Printf("Ошибка при сохранении видеоролика: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, photo)
+	log.Printf("К фотографии с ID=%d прикреплен видеоролик", id)
+}
+
+// attachMotion открывает видеоролик Live Photo из multipart-запроса и прикрепляет его к снимку
+func (h *PhotoHandler) attachMotion(r *http.Request, photoID int, header *multipart.FileHeader) (models.Photo, error) {
+	file, err := header.Open()
+	if err != nil {
+		return models.Photo{}, err
+	}
+	defer file.Close()
+
+	return h.photoService.AttachMotion(r.Context(), photoID, service.UploadFile{
+		File:     file,
+		Filename: header.Filename,
+		Size:     header.Size,
+	})
+}
+
+// pairMotionClips находит пары Live Photo: снимок и видео с тем же именем без расширения
+func pairMotionClips(headers []*multipart.FileHeader) map[*multipart.FileHeader]*multipart.FileHeader {
+	type pair struct {
+		stills, clips []*multipart.FileHeader
+	}
+	groups := make(map[string]*pair)
+	for _, header := range headers {
+		ext := path.Ext(header.Filename)
+		base := strings.ToLower(strings.TrimSuffix(header.Filename, ext))
+		if groups[base] == nil {
+			groups[base] = &pair{}
+		}
+		switch strings.ToLower(ext) {
+		case ".mov", ".mp4":
+			groups[base].clips = append(groups[base].clips, header)
+		default:
+			groups[base].stills = append(groups[base].stills, header)
+		}
+	}
+
+	motions := make(map[*multipart.FileHeader]*multipart.FileHeader)
+	for _, g := range groups {
+		if len(g.stills) == 1 && len(g.clips) == 1 {
+			motions[g.stills[0]] = g.clips[0]
+		}
+	}
+	return motions
+}
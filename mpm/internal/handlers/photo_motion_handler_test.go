@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMP4 формирует минимальный контейнер с одной видеодорожкой 1280x720 длительностью 2 секунды
+func testMP4(t *testing.T, brand string) []byte {
+	t.Helper()
+
+	box := func(typ string, parts ...[]byte) []byte {
+		content := bytes.Join(parts, nil)
+		b := make([]byte, 8, 8+len(content))
+		binary.BigEndian.PutUint32(b, uint32(8+len(content)))
+		copy(b[4:], typ)
+		return append(b, content...)
+	}
+	u32 := func(values ...uint32) []byte {
+		b := make([]byte, 4*len(values))
+		for i, v := range values {
+			binary.BigEndian.PutUint32(b[i*4:], v)
+		}
+		return b
+	}
+
+	tkhd := box("tkhd", u32(0, 0, 0, 1, 0, 0, 0, 0, 0, 0), u32(1<<16, 0, 0, 0, 1<<16, 0, 0, 0, 1<<30), u32(1280<<16, 720<<16))
+	mdia := box("mdia",
+		box("mdhd", u32(0, 0, 0, 1000, 2000, 0)),
+		box("hdlr", u32(0, 0), []byte("vide"), u32(0, 0, 0), []byte{0}),
+		box("minf", box("stbl", box("stsd", u32(0, 1), box("avc1", make([]byte, 78))))),
+	)
+	moov := box("moov", box("mvhd", u32(0, 0, 0, 1000, 2000)), box("trak", tkhd, mdia))
+	return bytes.Join([][]byte{box("ftyp", []byte(brand), u32(0)), moov, box("mdat", make([]byte, 256))}, nil)
+}
+
+func TestVideoAndLivePhoto(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	clip := testMP4(t, "qt  ")
+
+	upload := func(files map[string][]byte) uploadResponse {
+		body, contentType := multipartBody(t, files)
+		req := httptest.NewRequest(http.MethodPost, "/api/albums/1/photos", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+
+		var resp uploadResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+
+	var live, movie models.Photo
+
+	t.Run("Снимок и ролик с одинаковым именем образуют Live Photo", func(t *testing.T) {
+		resp := upload(map[string][]byte{"IMG_0001.PNG": testPNG(t), "IMG_0001.MOV": clip})
+		require.Len(t, resp.Photos, 1)
+		assert.Empty(t, resp.Errors)
+
+		live = resp.Photos[0]
+		assert.Equal(t, models.MediaTypeLivePhoto, live.MediaType)
+		assert.Equal(t, "image/png", live.MimeType)
+		require.NotNil(t, live.Motion)
+		assert.Equal(t, "video/quicktime", live.Motion.MimeType)
+		assert.Equal(t, 2.0, live.Motion.Video.Duration)
+		assert.Equal(t, "avc1", live.Motion.Video.VideoCodec)
+
+		_, err := os.Stat(filepath.Join(env.filesDir, live.Motion.Path))
+		assert.NoError(t, err, "ролик должен быть сохранен рядом со снимком")
+	})
+
+	t.Run("Ролик Live Photo отдается по диапазонам", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/photos/%d/motion", live.ID), nil)
+		req.Header.Set("Range", "bytes=4-11")
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "video/quicktime", w.Header().Get("Content-Type"))
+		assert.Equal(t, fmt.Sprintf("bytes 4-11/%d", len(clip)), w.Header().Get("Content-Range"))
+		assert.Equal(t, clip[4:12], w.Body.Bytes())
+	})
+
+	t.Run("Отдельное видео", func(t *testing.T) {
+		resp := upload(map[string][]byte{"holiday.mp4": testMP4(t, "isom")})
+		require.Len(t, resp.Photos, 1)
+
+		movie = resp.Photos[0]
+		assert.Equal(t, models.MediaTypeVideo, movie.MediaType)
+		assert.Equal(t, "video/mp4", movie.MimeType)
+		assert.Equal(t, 1280, movie.Width)
+		assert.Equal(t, 720, movie.Height)
+		require.NotNil(t, movie.Video)
+		assert.Equal(t, 2.0, movie.Video.Duration)
+		assert.Empty(t, movie.Renditions, "у видео нет уменьшенных копий")
+
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/photos/%d/thumb", movie.ID), nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/photos/%d/motion", movie.ID), nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Фильтр по типу медиафайла", func(t *testing.T) {
+		list := func(media string) []models.Photo {
+			w := httptest.NewRecorder()
+			env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/photos?media="+media, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			var photos []models.Photo
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&photos))
+			return photos
+		}
+
+		videos := list(models.MediaTypeVideo)
+		require.Len(t, videos, 1)
+		assert.Equal(t, movie.ID, videos[0].ID)
+		assert.Len(t, list(models.MediaTypeLivePhoto), 1)
+		assert.Empty(t, list(models.MediaTypePhoto))
+
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/photos?media=audio", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Прикрепление ролика к существующему снимку", func(t *testing.T) {
+		still := env.uploadTestPhoto(t, "still.png", testPNG(t))
+		attach := func(id int, name string, data []byte) *httptest.ResponseRecorder {
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			part, err := writer.CreateFormFile("file", name)
+			require.NoError(t, err)
+			_, err = part.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+
+			req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/photos/%d/motion", id), &body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+			env.mux.ServeHTTP(w, req)
+			return w
+		}
+
+		assert.Equal(t, http.StatusBadRequest, attach(still.ID, "still.mov", testPNG(t)).Code, "изображение вместо ролика")
+		assert.Equal(t, http.StatusBadRequest, attach(movie.ID, "clip.mov", clip).Code, "ролик к видео")
+		assert.Equal(t, http.StatusNotFound, attach(9999, "clip.mov", clip).Code)
+
+		w := attach(still.ID, "clip.mov", clip)
+		require.Equal(t, http.StatusOK, w.Code)
+		var photo models.Photo
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&photo))
+		assert.Equal(t, models.MediaTypeLivePhoto, photo.MediaType)
+		require.NotNil(t, photo.Motion)
+	})
+
+	t.Run("Удаление из корзины удаляет и ролик", func(t *testing.T) {
+		trash := service.NewTrashService(env.repo, env.handler.photoService, time.Hour)
+		require.NoError(t, env.handler.photoService.DeletePhoto(context.Background(), live.ID))
+		require.NoError(t, trash.PurgePhoto(context.Background(), live.ID))
+
+		_, err := os.Stat(filepath.Join(env.filesDir, live.Motion.Path))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mpm/internal/similarity"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GetSimilarPhotos godoc
+// @Summary Найти похожие фотографии
+// @Description Возвращает фотографии, визуально похожие на указанную, включая пережатые и уменьшенные копии.
+// @Description Сравнение выполняется по перцептивному хешу, результаты упорядочены по расстоянию.
+// @Tags photos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Param threshold query int false "Максимальное расстояние Хэмминга (0-20)" default(8)
+// @Success 200 {array} service.SimilarPhoto
+// @Failure 400 {object} string "Некорректный ID фотографии или порог"
+// @Failure 404 {object} string "Фотография не найдена"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id}/similar [get]
+func (h *PhotoHandler) GetSimilarPhotos(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos/{id}/similar")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	threshold, err := parseThreshold(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	photos, err := h.photoService.SimilarPhotos(ctx, id, threshold)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, "Фотография не найдена", http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при поиске похожих фотографий: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, photos)
+	log.Printf("Найдено %d похожих фотографий для фотографии с ID=%d", len(photos), id)
+}
+
+// GetDuplicates godoc
+// @Summary Найти группы похожих фотографий
+// @Description Возвращает группы визуально похожих фотографий во всей библиотеке
+// @Tags photos
+// @Produce json
+// @Security Bearer
+// @Param threshold query int false "Максимальное расстояние Хэмминга (0-20)" default(8)
+// @Success 200 {array} service.DuplicateCluster
+// @Failure 400 {object} string "Некорректный порог"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /duplicates [get]
+func (h *PhotoHandler) GetDuplicates(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/duplicates")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	threshold, err := parseThreshold(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clusters, err := h.photoService.DuplicateClusters(ctx, threshold)
+	if err != nil {
+		log.Printf("Ошибка при поиске дубликатов: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, clusters)
+	log.Printf("Найдено %d групп похожих фотографий", len(clusters))
+}
+
+// parseThreshold разбирает порог сходства из строки запроса
+func parseThreshold(query url.Values) (int, error) {
+	v := query.Get("threshold")
+	if v == "" {
+		return similarity.DefaultThreshold, nil
+	}
+
+	threshold, err := strconv.Atoi(v)
+	if err != nil || threshold < 0 || threshold > similarity.MaxThreshold {
+		return 0, fmt.Errorf("некорректный порог сходства")
+	}
+	return threshold, nil
+}
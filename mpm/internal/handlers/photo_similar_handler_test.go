@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gradientJPEG создает JPEG с диагональным градиентом; invert меняет направление градиента
+func gradientJPEG(t *testing.T, w, h int, invert bool) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8((x*200/w + y*55/h) % 256)
+			if (x*8/w)%2 == 0 {
+				v /= 2
+			}
+			if invert {
+				v = 255 - v
+			}
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}))
+	return buf.Bytes()
+}
+
+func TestSimilarPhotos(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	original := env.uploadTestPhoto(t, "original.jpg", gradientJPEG(t, 800, 600, false))
+	resized := env.uploadTestPhoto(t, "whatsapp.jpg", gradientJPEG(t, 400, 300, false))
+	other := env.uploadTestPhoto(t, "other.jpg", gradientJPEG(t, 800, 600, true))
+
+	require.NotEmpty(t, original.PHash)
+	assert.NotEqual(t, original.Checksum, resized.Checksum, "побайтово файлы различаются")
+
+	t.Run("Похожие на фотографию", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/photos/%d/similar", original.ID), nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var similar []service.SimilarPhoto
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&similar))
+		require.Len(t, similar, 1)
+		assert.Equal(t, resized.ID, similar[0].ID)
+		assert.LessOrEqual(t, similar[0].Distance, 8)
+	})
+
+	t.Run("Группы дубликатов", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/duplicates", nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var clusters []service.DuplicateCluster
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&clusters))
+		require.Len(t, clusters, 1)
+		var ids []int
+		for _, p := range clusters[0].Photos {
+			ids = append(ids, p.ID)
+		}
+		assert.Equal(t, []int{original.ID, resized.ID}, ids)
+		assert.NotContains(t, ids, other.ID)
+	})
+
+	t.Run("Некорректный порог", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/duplicates?threshold=65", nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Фотография не найдена", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/photos/999/similar", nil)
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// suggestedTagsResponse предложенные теги фотографии
+type suggestedTagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// GetSuggestedTags godoc
+// @Summary Предложить теги места съемки
+// @Description Возвращает город, регион и страну, определенные по координатам GPS без обращения к сети,
+// @Description которыми фотография еще не отмечена. Без координат список пуст.
+// @Description Теги в нижнем регистре, пробелы заменены дефисами, например krasnodar-krai.
+// @Description Встроенный справочник содержит около 300 крупных городов; полный справочник GeoNames
+// @Description (например, cities15000.zip) подключается через MPM_GEOCODER_CITIES_FILE.
+// @Tags photos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Success 200 {object} suggestedTagsResponse
+// @Failure 400 {object} string "Некорректный ID фотографии"
+// @Failure 404 {object} string "Фотография не найдена"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id}/suggested-tags [get]
+func (h *PhotoHandler) GetSuggestedTags(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos/{id}/suggested-tags")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.photoService.SuggestTags(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, "Фотография не найдена", http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при подборе тегов: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, suggestedTagsResponse{Tags: tags})
+}
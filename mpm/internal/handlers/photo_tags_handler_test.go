@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"mpm/internal/geocode"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhotoPlaces(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	geocoder, err := geocode.New(geocode.DefaultMaxDistance)
+	require.NoError(t, err)
+
+	// Фотография загружена до включения геокодирования и не отмечена тегами
+	require.NoError(t, env.repo.SaveEntity(models.Photo{
+		ID:    1,
+		Name:  "IMG_0001.jpg",
+		Album: &models.Album{ID: 1},
+		Tags:  []string{},
+		Metadata: []models.Metadata{
+			{Key: models.MetadataGPSLatitude, Value: "43.585500"},
+			{Key: models.MetadataGPSLongitude, Value: "39.723100"},
+		},
+	}))
+	require.NoError(t, env.repo.SaveEntity(models.Photo{ID: 2, Name: "IMG_0002.jpg", Album: &models.Album{ID: 1}, Tags: []string{}}))
+
+	get := func(url string, v interface{}) int {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(v))
+		}
+		return w.Code
+	}
+
+	var photos []models.Photo
+	require.Equal(t, http.StatusOK, get("/api/photos?q=sochi", &photos))
+	assert.Empty(t, photos, "место съемки еще не определено")
+
+	env.handler.photoService.SetGeocoder(geocoder)
+
+	var suggested suggestedTagsResponse
+	require.Equal(t, http.StatusOK, get("/api/photos/1/suggested-tags", &suggested))
+	assert.Equal(t, []string{"sochi", "krasnodar-krai", "russia"}, suggested.Tags)
+
+	// Предложенные теги проходят проверку тегов при изменении фотографии
+	_, err = env.handler.photoService.UpdatePhoto(context.Background(), 1, service.PhotoUpdate{Tags: suggested.Tags})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, get("/api/photos/1/suggested-tags", &suggested))
+	assert.Empty(t, suggested.Tags, "все предложенные теги уже добавлены")
+
+	updated, err := env.handler.photoService.BackfillPlaces(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	require.Equal(t, http.StatusOK, get("/api/photos?q=Sochi", &photos))
+	require.Len(t, photos, 1)
+	assert.Equal(t, 1, photos[0].ID)
+
+	require.Equal(t, http.StatusOK, get("/api/photos/2/suggested-tags", &suggested))
+	assert.Empty(t, suggested.Tags, "у фотографии нет координат")
+	assert.Equal(t, http.StatusNotFound, get("/api/photos/99/suggested-tags", &suggested))
+	assert.Equal(t, http.StatusBadRequest, get("/api/photos/abc/suggested-tags", &suggested))
+}
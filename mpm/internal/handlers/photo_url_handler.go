@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/internal/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetSignedURLTTL задает срок действия подписанных ссылок по умолчанию и максимальный
+func (h *PhotoHandler) SetSignedURLTTL(ttl, maxTTL time.Duration) {
+	h.urlTTL = ttl
+	h.maxURLTTL = maxTTL
+}
+
+// GetPhotoURL godoc
+// @Summary Получить подписанную ссылку на фотографию
+// @Description Выдает ссылку на оригинал или уменьшенную копию, которая не требует авторизации
+// @Description и действует ограниченное время. Подходит для вставки в письма и сообщения.
+// @Tags photos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID фотографии"
+// @Param scope query string false "Область действия: original или thumbnail" default(original)
+// @Param size query int false "Размер уменьшенной копии по большей стороне" default(256)
+// @Param ttl query string false "Срок действия, например 30m или 24h"
+// @Success 200 {object} service.SignedURL
+// @Failure 400 {object} string "Некорректные параметры"
+// @Failure 404 {object} string "Фотография не найдена"
+// @Failure 501 {object} string "Подписанные ссылки не настроены"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /photos/{id}/url [get]
+func (h *PhotoHandler) GetPhotoURL(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/photos/{id}/url")
+
+	// Получаем контекст из запроса
+	ctx := r.Context()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	scope := query.Get("scope")
+	if scope == "" {
+		scope = storage.ScopeOriginal
+	}
+
+	size := models.DefaultPreviewSize
+	if v := query.Get("size"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size <= 0 {
+			http.Error(w, "Некорректный размер", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ttl := h.urlTTL
+	if v := query.Get("ttl"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 || ttl > h.maxURLTTL {
+			http.Error(w, fmt.Sprintf("Некорректный срок действия, максимум %s", h.maxURLTTL), http.StatusBadRequest)
+			return
+		}
+	}
+
+	signed, err := h.photoService.SignedURL(ctx, id, scope, size, ttl)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrSignedURLsDisabled):
+			http.Error(w, "Подписанные ссылки не настроены", http.StatusNotImplemented)
+		case errors.Is(err, service.ErrInvalidPhoto):
+			http.Error(w, "Некорректная область действия ссылки", http.StatusBadRequest)
+		case strings.Contains(err.Error(), "не найден"):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			log.Printf("Ошибка при создании ссылки на фотографию: %v", err)
+			http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, signed)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RuleHandler управляет правилами автоматической расстановки тегов
+type RuleHandler struct {
+	rules *service.RuleService
+}
+
+func NewRuleHandler(rules *service.RuleService) *RuleHandler {
+	return &RuleHandler{rules: rules}
+}
+
+// ListRules godoc
+// @Summary Список правил расстановки тегов
+// @Description Возвращает все правила автоматической расстановки тегов, включая отключенные
+// @Tags rules
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.TagRule
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /rules [get]
+func (h *RuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/rules")
+
+	rules, err := h.rules.ListRules(r.Context())
+	if err != nil {
+		log.Printf("Ошибка при получении правил: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// GetRule godoc
+// @Summary Получить правило расстановки тегов
+// @Tags rules
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID правила"
+// @Success 200 {object} models.TagRule
+// @Failure 400 {object} string "Некорректный ID правила"
+// @Failure 404 {object} string "Правило не найдено"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /rules/{id} [get]
+func (h *RuleHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/rules/{id}")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID правила", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.rules.GetRule(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// CreateRule godoc
+// @Summary Создать правило расстановки тегов
+// @Description Создает правило, которое добавляет теги фотографиям, удовлетворяющим всем условиям.
+// @Description Правило применяется к новым фотографиям при загрузке, к уже загруженным - через POST /rules/apply.
+// @Description Поля условий: name, album_id, user_id, media, hour, location, metadata (с ключом key).
+// @Description Операторы: equals, contains, prefix, glob, regex, exists (только metadata),
+// @Description between (hour, например "9-18" или "22-4"), within (location, "minLon,minLat,maxLon,maxLat").
+// @Description Теги проверяются так же, как в PUT /photos/{id}: без пробелов.
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param rule body models.TagRule true "Правило"
+// @Success 201 {object} models.TagRule
+// @Failure 400 {object} string "Некорректное правило"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /rules [post]
+func (h *RuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/rules")
+
+	var rule models.TagRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		log.Printf("Ошибка при декодировании JSON: %v", err)
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.rules.CreateRule(r.Context(), rule)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+	log.Printf("Успешно создано правило с ID=%d", created.ID)
+}
+
+// UpdateRule godoc
+// @Summary Обновить правило расстановки тегов
+// @Description Заменяет условия, теги и состояние правила. Теги, уже добавленные фотографиям, не удаляются.
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID правила"
+// @Param rule body models.TagRule true "Правило"
+// @Success 200 {object} models.TagRule
+// @Failure 400 {object} string "Некорректный ID или правило"
+// @Failure 404 {object} string "Правило не найдено"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /rules/{id} [put]
+func (h *RuleHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос PUT /api/rules/{id}")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID правила", http.StatusBadRequest)
+		return
+	}
+
+	var rule models.TagRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		log.Printf("Ошибка при декодировании JSON: %v", err)
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.rules.UpdateRule(r.Context(), id, rule)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+	log.Printf("Правило с ID=%d обновлено", id)
+}
+
+// DeleteRule godoc
+// @Summary Удалить правило расстановки тегов
+// @Description Удаляет правило. Теги, уже добавленные им фотографиям, сохраняются.
+// @Tags rules
+// @Security Bearer
+// @Param id path int true "ID правила"
+// @Success 204 "Правило удалено"
+// @Failure 400 {object} string "Некорректный ID правила"
+// @Failure 404 {object} string "Правило не найдено"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /rules/{id} [delete]
+func (h *RuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос DELETE /api/rules/{id}")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID правила", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.rules.DeleteRule(r.Context(), id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("Правило с ID=%d удалено", id)
+}
+
+// ApplyRules godoc
+// @Summary Применить правила к библиотеке
+// @Description Применяет все включенные правила к уже загруженным фотографиям, кроме находящихся в корзине.
+// @Description С dry_run=true фотографии не изменяются, возвращается только статистика.
+// @Tags rules
+// @Produce json
+// @Security Bearer
+// @Param dry_run query bool false "Только подсчитать изменения"
+// @Success 200 {object} service.RuleApplyResult
+// @Failure 400 {object} string "Некорректные параметры запроса"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /rules/apply [post]
+func (h *RuleHandler) ApplyRules(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/rules/apply")
+	h.apply(w, r, 0)
+}
+
+// ApplyRule godoc
+// @Summary Применить правило к библиотеке
+// @Description Применяет одно правило к уже загруженным фотографиям, даже если оно отключено.
+// @Description С dry_run=true фотографии не изменяются, возвращается только статистика.
+// @Tags rules
+// @Produce json
+// @Security Bearer
+// @Param id path int true "ID правила"
+// @Param dry_run query bool false "Только подсчитать изменения"
+// @Success 200 {object} service.RuleApplyResult
+// @Failure 400 {object} string "Некорректные параметры запроса"
+// @Failure 404 {object} string "Правило не найдено"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /rules/{id}/apply [post]
+func (h *RuleHandler) ApplyRule(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/rules/{id}/apply")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Некорректный ID правила", http.StatusBadRequest)
+		return
+	}
+	h.apply(w, r, id)
+}
+
+func (h *RuleHandler) apply(w http.ResponseWriter, r *http.Request, id int) {
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Некорректный параметр dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.rules.Apply(r.Context(), id, dryRun)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+	log.Printf("Правила проверены на %d фотографиях, новые теги у %d", result.Checked, result.Updated)
+}
+
+// writeError отправляет ответ с кодом, соответствующим ошибке сервиса правил
+func (h *RuleHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRule):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "не найден"):
+		http.Error(w, "Правило не найдено", http.StatusNotFound)
+	default:
+		log.Printf("Ошибка при работе с правилами: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+	}
+}
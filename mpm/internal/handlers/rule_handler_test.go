@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleHandler(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	ruleService := service.NewRuleService(env.repo)
+	env.handler.photoService.SetRuleService(ruleService)
+
+	handler := NewRuleHandler(ruleService)
+	env.mux.HandleFunc("GET /api/rules", handler.ListRules)
+	env.mux.HandleFunc("POST /api/rules", handler.CreateRule)
+	env.mux.HandleFunc("POST /api/rules/apply", handler.ApplyRules)
+	env.mux.HandleFunc("GET /api/rules/{id}", handler.GetRule)
+	env.mux.HandleFunc("PUT /api/rules/{id}", handler.UpdateRule)
+	env.mux.HandleFunc("DELETE /api/rules/{id}", handler.DeleteRule)
+	env.mux.HandleFunc("POST /api/rules/{id}/apply", handler.ApplyRule)
+
+	// Фотографии, загруженные до создания правила
+	studio := models.Photo{
+		ID:    1,
+		Name:  "DSC_0001.jpg",
+		Album: &models.Album{ID: 1},
+		Tags:  []string{},
+		Metadata: []models.Metadata{
+			{Key: models.MetadataCameraSerial, Value: "6012345"},
+		},
+	}
+	require.NoError(t, env.repo.SaveEntity(studio))
+	require.NoError(t, env.repo.SaveEntity(models.Photo{ID: 2, Name: "IMG_0002.jpg", Album: &models.Album{ID: 1}, Tags: []string{}}))
+
+	do := func(method, url string, body interface{}, v interface{}) int {
+		var reader bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&reader).Encode(body))
+		}
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(method, url, &reader))
+		if v != nil && w.Code < 300 {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(v))
+		}
+		return w.Code
+	}
+
+	rule := models.TagRule{
+		Name: "Студия по серийному номеру",
+		Conditions: []models.RuleCondition{
+			{Field: models.RuleFieldMetadata, Key: models.MetadataCameraSerial, Operator: models.RuleOpEquals, Value: "6012345"},
+		},
+		Tags: []string{" студия "},
+	}
+
+	var created models.TagRule
+	t.Run("Создание правила", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/rules", rule, &created))
+		assert.Equal(t, 1, created.ID)
+		assert.Equal(t, []string{"студия"}, created.Tags)
+
+		var rules []models.TagRule
+		require.Equal(t, http.StatusOK, do(http.MethodGet, "/api/rules", nil, &rules))
+		assert.Len(t, rules, 1)
+		assert.Equal(t, "студия", env.repo.GetAllTags()[0].Name, "тег правила добавлен в справочник")
+	})
+
+	t.Run("Применение к библиотеке", func(t *testing.T) {
+		var result service.RuleApplyResult
+		require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/rules/apply?dry_run=true", nil, &result))
+		assert.Equal(t, service.RuleApplyResult{Checked: 2, Updated: 1, Tags: map[string]int{"студия": 1}, DryRun: true}, result)
+
+		photo, err := env.repo.FindPhotoByID(1)
+		require.NoError(t, err)
+		assert.Empty(t, photo.Tags, "пробный запуск не изменяет фотографии")
+
+		require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/rules/apply", nil, &result))
+		assert.Equal(t, 1, result.Updated)
+		photo, _ = env.repo.FindPhotoByID(1)
+		assert.Equal(t, []string{"студия"}, photo.Tags)
+
+		require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/rules/1/apply", nil, &result))
+		assert.Zero(t, result.Updated, "тег уже добавлен")
+	})
+
+	t.Run("Правило применяется при загрузке", func(t *testing.T) {
+		update := rule
+		update.Conditions = []models.RuleCondition{{Field: models.RuleFieldName, Operator: models.RuleOpGlob, Value: "studio_*.png"}}
+		var updated models.TagRule
+		require.Equal(t, http.StatusOK, do(http.MethodPut, "/api/rules/1", update, &updated))
+		require.NotNil(t, updated.UpdatedAt)
+
+		photo := env.uploadTestPhoto(t, "STUDIO_01.png", testPNG(t))
+		assert.Equal(t, []string{"студия"}, photo.Tags)
+
+		other := env.uploadTestPhoto(t, "beach.png", testPNG(t))
+		assert.Empty(t, other.Tags)
+	})
+
+	t.Run("Отключенное правило не применяется при загрузке", func(t *testing.T) {
+		var disabled models.TagRule
+		require.NoError(t, json.Unmarshal([]byte(`{"name":"Студия","disabled":true,
+			"conditions":[{"field":"name","op":"glob","value":"studio_*"}],"tags":["студия"]}`), &disabled))
+		require.Equal(t, http.StatusOK, do(http.MethodPut, "/api/rules/1", disabled, nil))
+
+		photo := env.uploadTestPhoto(t, "STUDIO_02.png", testPNG(t))
+		assert.Empty(t, photo.Tags)
+	})
+
+	t.Run("Ошибки", func(t *testing.T) {
+		invalid := rule
+		invalid.Conditions = []models.RuleCondition{{Field: "size", Operator: "equals", Value: "1"}}
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/rules", invalid, nil))
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/rules/1", invalid, nil))
+		spaced := rule
+		spaced.Tags = []string{"два слова"}
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/rules", spaced, nil), "тег проверяется как в PUT /photos")
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/api/rules/abc", nil, nil))
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/rules/apply?dry_run=maybe", nil, nil))
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/api/rules/99", nil, nil))
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/api/rules/99", rule, nil))
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/api/rules/99/apply", nil, nil))
+	})
+
+	t.Run("Удаление", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/api/rules/1", nil, nil))
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/api/rules/1", nil, nil))
+
+		photo, _ := env.repo.FindPhotoByID(1)
+		assert.Equal(t, []string{"студия"}, photo.Tags, "добавленные теги сохраняются")
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mpm/internal/service"
+	"net/http"
+)
+
+// ScrubHandler запускает проверку хранилища файлов и возвращает ее отчет
+type ScrubHandler struct {
+	scrubber *service.StorageScrubber
+}
+
+func NewScrubHandler(scrubber *service.StorageScrubber) *ScrubHandler {
+	return &ScrubHandler{scrubber: scrubber}
+}
+
+// GetReport godoc
+// @Summary Отчет проверки хранилища
+// @Description Возвращает ход текущей или итоги последней проверки хранилища файлов:
+// @Description отсутствующие и поврежденные файлы, фотографии удаленных альбомов и ничьи файлы
+// @Tags storage
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} service.ScrubReport
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /storage/scrub [get]
+func (h *ScrubHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/storage/scrub")
+
+	writeJSON(w, http.StatusOK, h.scrubber.Status())
+}
+
+// StartScrub godoc
+// @Summary Проверить хранилище файлов
+// @Description Запускает в фоне сверку фотографий с файлами во всех подключенных хранилищах.
+// @Description Ничьи файлы ищутся в хранилищах, которые умеют перечислять файлы (local, s3),
+// @Description и с quarantine_orphans переносятся в каталог quarantine/ вместо удаления.
+// @Description Тело запроса необязательно. Отчет возвращает GET /storage/scrub.
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param options body service.ScrubOptions false "Параметры проверки"
+// @Success 202 {object} service.ScrubReport
+// @Failure 400 {object} string "Неверный формат данных"
+// @Failure 409 {object} string "Проверка уже выполняется"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /storage/scrub [post]
+func (h *ScrubHandler) StartScrub(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/storage/scrub")
+
+	var opts service.ScrubOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.scrubber.Start(r.Context(), opts); err != nil {
+		if errors.Is(err, service.ErrScrubRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("Ошибка запуска проверки хранилища: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, h.scrubber.Status())
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/internal/storage"
+	"mpm/middleware"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrubHandler(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	handler := NewScrubHandler(service.NewStorageScrubber(env.handler.photoService, nil, time.Nanosecond))
+	env.mux.HandleFunc("GET /api/storage/scrub", handler.GetReport)
+	env.mux.HandleFunc("POST /api/storage/scrub", handler.StartScrub)
+
+	user := &models.User{ID: 1, Username: "admin"}
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/storage/scrub", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, user))
+		rec := httptest.NewRecorder()
+		env.mux.ServeHTTP(rec, req)
+		return rec
+	}
+	report := func() service.ScrubReport {
+		rec := do(http.MethodGet, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var r service.ScrubReport
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&r))
+		return r
+	}
+
+	data := testPNG(t)
+	photo, err := env.handler.photoService.Upload(context.Background(), 1, user, service.UploadFile{
+		File:     storage.NewBytesFile(data),
+		Filename: "sea.png",
+		Size:     int64(len(data)),
+	})
+	require.NoError(t, err)
+	// Фотография ссылается на несуществующий альбом
+	photo.Album = &models.Album{ID: 2}
+	require.NoError(t, env.repo.UpdatePhoto(context.Background(), photo.ID, photo))
+	_, err = env.storage.Save(storage.NewBytesFile([]byte("ничей")), "albums/1/orphan.jpg")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "{").Code)
+
+	rec := do(http.MethodPost, `{"verify_checksums":true,"quarantine_orphans":true}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	require.Eventually(t, func() bool { return !report().Running }, 5*time.Second, 10*time.Millisecond)
+
+	result := report()
+	assert.Empty(t, result.Error)
+	assert.Equal(t, 1, result.Photos)
+	assert.Zero(t, result.Missing)
+	assert.Zero(t, result.ChecksumMismatches)
+	assert.Equal(t, 1, result.AlbumMissing)
+	assert.Equal(t, 1, result.Quarantined)
+	require.Len(t, result.Issues, 2)
+	assert.Equal(t, service.ScrubIssue{Kind: service.ScrubAlbumMissing, PhotoID: photo.ID, AlbumID: 2}, result.Issues[0])
+	assert.Equal(t, "albums/1/orphan.jpg", result.Issues[1].Path)
+
+	_, err = os.Stat(filepath.Join(env.filesDir, "albums/1/orphan.jpg"))
+	assert.True(t, os.IsNotExist(err), "ничий файл перенесен в карантин")
+	_, err = os.Stat(filepath.Join(env.filesDir, photo.Path))
+	assert.NoError(t, err, "файлы фотографии не трогаются")
+
+	rec = do(http.MethodPost, "")
+	require.Equal(t, http.StatusAccepted, rec.Code, "параметры необязательны")
+	require.Eventually(t, func() bool { return !report().Running }, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mpm/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TimelineHandler отдает ленту фотографий по дате съемки и воспоминания "в этот день"
+type TimelineHandler struct {
+	timeline *service.TimelineService
+}
+
+func NewTimelineHandler(timeline *service.TimelineService) *TimelineHandler {
+	return &TimelineHandler{timeline: timeline}
+}
+
+// GetTimeline godoc
+// @Summary Лента фотографий по дате съемки
+// @Description Возвращает фотографии от новых к старым, сгруппированные по дням, месяцам или годам.
+// @Description Для фотографий без даты съемки используется дата загрузки. Count периода учитывает
+// @Description всю выборку, а не только текущую страницу. Для следующей страницы передайте next_cursor
+// @Description в параметре cursor с теми же фильтрами; период на границе страниц повторяется с тем же ключом.
+// @Tags timeline
+// @Produce json
+// @Param group query string false "Группировка" Enums(day, month, year) default(day)
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Количество фотографий на странице, не больше 500" default(100)
+// @Param album_id query int false "ID альбома"
+// @Param user_id query int false "ID пользователя"
+// @Param tag query []string false "Теги (фотография должна содержать все)" collectionFormat(multi)
+// @Param camera query string false "Производитель или модель камеры"
+// @Param q query string false "Подстрока названия, тега, города, региона или страны съемки"
+// @Param media query string false "Тип медиафайла" Enums(photo, video, live_photo)
+// @Param from query string false "Начало диапазона дат съемки (YYYY-MM-DD или RFC3339)"
+// @Param to query string false "Конец диапазона дат съемки (YYYY-MM-DD или RFC3339)"
+// @Security Bearer
+// @Success 200 {object} service.TimelinePage
+// @Failure 400 {object} string "Некорректные параметры запроса"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /timeline [get]
+func (h *TimelineHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/timeline")
+
+	query := r.URL.Query()
+	group := query.Get("group")
+	if group == "" {
+		group = service.TimelineDay
+	}
+	if !service.ValidTimelineGroup(group) {
+		http.Error(w, "Группировка group должна быть day, month или year", http.StatusBadRequest)
+		return
+	}
+
+	limit := service.DefaultTimelineLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > service.MaxTimelineLimit {
+			http.Error(w, "Параметр limit должен быть целым числом от 1 до 500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	filter, err := parsePhotoFilter(query)
+	if err != nil {
+		http.Error(w, "Некорректный параметр фильтра: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.timeline.Timeline(r.Context(), filter, group, query.Get("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			http.Error(w, "Некорректный курсор", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Ошибка при построении ленты фотографий: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+	log.Printf("Отправлена страница ленты: периодов %d, всего фотографий %d", len(page.Buckets), page.Total)
+}
+
+// GetMemoriesToday godoc
+// @Summary Воспоминания "в этот день"
+// @Description Возвращает фотографии, снятые в тот же календарный день в предыдущие годы,
+// @Description сгруппированные по годам от ближайшего к самому давнему. В невисокосный год
+// @Description 28 февраля включает снимки, сделанные 29 февраля.
+// @Tags timeline
+// @Produce json
+// @Param date query string false "День в формате YYYY-MM-DD, по умолчанию сегодня"
+// @Param album_id query int false "ID альбома"
+// @Param user_id query int false "ID пользователя"
+// @Param media query string false "Тип медиафайла" Enums(photo, video, live_photo)
+// @Security Bearer
+// @Success 200 {array} service.MemoryYear
+// @Failure 400 {object} string "Некорректные параметры запроса"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Router /memories/today [get]
+func (h *TimelineHandler) GetMemoriesToday(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/memories/today")
+
+	query := r.URL.Query()
+	date := time.Now()
+	if v := query.Get("date"); v != "" {
+		parsed, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			http.Error(w, "Некорректная дата date, ожидается YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = parsed
+	}
+
+	filter, err := parsePhotoFilter(query)
+	if err != nil {
+		http.Error(w, "Некорректный параметр фильтра: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	memories, err := h.timeline.Memories(r.Context(), filter, date)
+	if err != nil {
+		log.Printf("Ошибка при поиске воспоминаний: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, memories)
+	log.Printf("Найдены воспоминания за %d лет на %s", len(memories), date.Format(time.DateOnly))
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimelineHandler(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	handler := NewTimelineHandler(service.NewTimelineService(env.repo))
+	env.mux.HandleFunc("GET /api/timeline", handler.GetTimeline)
+	env.mux.HandleFunc("GET /api/memories/today", handler.GetMemoriesToday)
+
+	for i, date := range []string{"2024-06-01", "2024-06-01", "2024-05-20", "2023-06-01"} {
+		takenAt, err := time.Parse(time.DateOnly, date)
+		require.NoError(t, err)
+		require.NoError(t, env.repo.SaveEntity(models.Photo{
+			ID:      i + 1,
+			Name:    date + ".jpg",
+			Album:   &models.Album{ID: 1 + i%2},
+			Tags:    []string{},
+			TakenAt: &takenAt,
+		}))
+	}
+
+	get := func(url string, v interface{}) int {
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(v))
+		}
+		return w.Code
+	}
+
+	t.Run("Лента по дням с курсором", func(t *testing.T) {
+		var page service.TimelinePage
+		require.Equal(t, http.StatusOK, get("/api/timeline?limit=1", &page))
+		assert.Equal(t, service.TimelineDay, page.Group)
+		assert.Equal(t, 4, page.Total)
+		require.Len(t, page.Buckets, 1)
+		assert.Equal(t, "2024-06-01", page.Buckets[0].Key)
+		assert.Equal(t, 2, page.Buckets[0].Count)
+		require.NotEmpty(t, page.NextCursor)
+
+		var next service.TimelinePage
+		require.Equal(t, http.StatusOK, get("/api/timeline?limit=2&cursor="+page.NextCursor, &next))
+		require.Len(t, next.Buckets, 2)
+		assert.Equal(t, "2024-06-01", next.Buckets[0].Key, "период продолжается на следующей странице")
+		assert.Equal(t, 1, next.Buckets[0].Photos[0].ID)
+		assert.Equal(t, "2024-05-20", next.Buckets[1].Key)
+	})
+
+	t.Run("Лента по годам с фильтром", func(t *testing.T) {
+		var page service.TimelinePage
+		require.Equal(t, http.StatusOK, get("/api/timeline?group=year&album_id=1", &page))
+		require.Len(t, page.Buckets, 1)
+		assert.Equal(t, "2024", page.Buckets[0].Key)
+		assert.Equal(t, 2, page.Buckets[0].Count)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("В этот день", func(t *testing.T) {
+		var memories []service.MemoryYear
+		require.Equal(t, http.StatusOK, get("/api/memories/today?date=2025-06-01", &memories))
+		require.Len(t, memories, 2)
+		assert.Equal(t, 2024, memories[0].Year)
+		assert.Len(t, memories[0].Photos, 2)
+		assert.Equal(t, 2, memories[1].YearsAgo)
+
+		require.Equal(t, http.StatusOK, get("/api/memories/today", &memories))
+	})
+
+	t.Run("Некорректные параметры", func(t *testing.T) {
+		for _, url := range []string{
+			"/api/timeline?group=week",
+			"/api/timeline?limit=0",
+			"/api/timeline?limit=1000",
+			"/api/timeline?cursor=%21%21",
+			"/api/timeline?media=audio",
+			"/api/memories/today?date=01.06.2025",
+		} {
+			assert.Equal(t, http.StatusBadRequest, get(url, nil), url)
+		}
+	})
+}
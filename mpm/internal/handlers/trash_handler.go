@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mpm/internal/repository"
+	"mpm/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TrashHandler показывает содержимое корзины, восстанавливает и окончательно удаляет альбомы и фотографии
+type TrashHandler struct {
+	trash *service.TrashService
+}
+
+func NewTrashHandler(trash *service.TrashService) *TrashHandler {
+	return &TrashHandler{trash: trash}
+}
+
+// GetTrash godoc
+// @Summary Содержимое корзины
+// @Description Возвращает удаленные альбомы с количеством удаленных вместе с ними фотографий
+// @Description и фотографии, удаленные отдельно. purge_at - время окончательного удаления.
+// @Tags trash
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} service.Trash
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /trash [get]
+func (h *TrashHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос GET /api/trash")
+
+	trash, err := h.trash.List(r.Context())
+	if err != nil {
+		log.Printf("Ошибка при получении содержимого корзины: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, trash)
+}
+
+// RestoreAlbum godoc
+// @Summary Восстановить альбом
+// @Description Возвращает альбом из корзины вместе с фотографиями, удаленными вместе с ним
+// @Tags trash
+// @Param id path int true "ID альбома"
+// @Security Bearer
+// @Success 204 "Альбом восстановлен"
+// @Failure 400 {object} string "Некорректный ID альбома"
+// @Failure 404 {object} string "Альбом не найден в корзине"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /trash/albums/{id}/restore [post]
+func (h *TrashHandler) RestoreAlbum(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/trash/albums/{id}/restore")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID альбома", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.trash.RestoreAlbum(r.Context(), id); err != nil {
+		h.writeError(w, err, "Альбом не найден в корзине")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestorePhoto godoc
+// @Summary Восстановить фотографию
+// @Description Возвращает фотографию из корзины. Фотографию удаленного альбома
+// @Description можно восстановить только вместе с альбомом.
+// @Tags trash
+// @Param id path int true "ID фотографии"
+// @Security Bearer
+// @Success 204 "Фотография восстановлена"
+// @Failure 400 {object} string "Некорректный ID фотографии"
+// @Failure 404 {object} string "Фотография не найдена в корзине"
+// @Failure 409 {object} string "Альбом фотографии находится в корзине"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /trash/photos/{id}/restore [post]
+func (h *TrashHandler) RestorePhoto(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/trash/photos/{id}/restore")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.trash.RestorePhoto(r.Context(), id); err != nil {
+		h.writeError(w, err, "Фотография не найдена в корзине")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PurgeAlbum godoc
+// @Summary Удалить альбом навсегда
+// @Description Окончательно удаляет альбом из корзины вместе со всеми его фотографиями и их файлами
+// @Tags trash
+// @Param id path int true "ID альбома"
+// @Security Bearer
+// @Success 204 "Альбом удален"
+// @Failure 400 {object} string "Некорректный ID альбома"
+// @Failure 404 {object} string "Альбом не найден в корзине"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /trash/albums/{id} [delete]
+func (h *TrashHandler) PurgeAlbum(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос DELETE /api/trash/albums/{id}")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID альбома", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.trash.PurgeAlbum(r.Context(), id); err != nil {
+		h.writeError(w, err, "Альбом не найден в корзине")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PurgePhoto godoc
+// @Summary Удалить фотографию навсегда
+// @Description Окончательно удаляет фотографию из корзины вместе с ее файлами
+// @Tags trash
+// @Param id path int true "ID фотографии"
+// @Security Bearer
+// @Success 204 "Фотография удалена"
+// @Failure 400 {object} string "Некорректный ID фотографии"
+// @Failure 404 {object} string "Фотография не найдена в корзине"
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /trash/photos/{id} [delete]
+func (h *TrashHandler) PurgePhoto(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос DELETE /api/trash/photos/{id}")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Некорректный ID фотографии", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.trash.PurgePhoto(r.Context(), id); err != nil {
+		h.writeError(w, err, "Фотография не найдена в корзине")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EmptyTrash godoc
+// @Summary Очистить корзину
+// @Description Окончательно удаляет все альбомы и фотографии из корзины вместе с их файлами
+// @Tags trash
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} service.TrashPurgeResult
+// @Failure 500 {object} string "Внутренняя ошибка сервера"
+// @Failure 403 {object} string "Операция доступна только администраторам"
+// @Router /trash [delete]
+func (h *TrashHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос DELETE /api/trash")
+
+	result, err := h.trash.Empty(r.Context())
+	if err != nil {
+		log.Printf("Ошибка при очистке корзины: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// writeError преобразует ошибку корзины в HTTP-ответ
+func (h *TrashHandler) writeError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, repository.ErrAlbumInTrash):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.Contains(err.Error(), "не найден"):
+		http.Error(w, notFound, http.StatusNotFound)
+	default:
+		log.Printf("Ошибка при работе с корзиной: %v", err)
+		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+	}
+}
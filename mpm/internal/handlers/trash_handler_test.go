@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashHandler(t *testing.T) {
+	env := newPhotoTestEnv(t, 1<<20)
+	handler := NewTrashHandler(service.NewTrashService(env.repo, env.handler.photoService, 24*time.Hour))
+	env.mux.HandleFunc("GET /api/trash", handler.GetTrash)
+	env.mux.HandleFunc("DELETE /api/trash", handler.EmptyTrash)
+	env.mux.HandleFunc("POST /api/trash/albums/{id}/restore", handler.RestoreAlbum)
+	env.mux.HandleFunc("DELETE /api/trash/albums/{id}", handler.PurgeAlbum)
+	env.mux.HandleFunc("POST /api/trash/photos/{id}/restore", handler.RestorePhoto)
+	env.mux.HandleFunc("DELETE /api/trash/photos/{id}", handler.PurgePhoto)
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		env.mux.ServeHTTP(rec, httptest.NewRequest(method, url, nil))
+		return rec
+	}
+	list := func() service.Trash {
+		rec := do(http.MethodGet, "/api/trash")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var trash service.Trash
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&trash))
+		return trash
+	}
+
+	user := &models.User{ID: 1, Username: "admin"}
+	upload := func(name string) models.Photo {
+		data := testPNG(t)
+		photo, err := env.handler.photoService.Upload(context.Background(), 1, user, service.UploadFile{
+			File:     storage.NewBytesFile(data),
+			Filename: name,
+			Size:     int64(len(data)),
+		})
+		require.NoError(t, err)
+		return photo
+	}
+	sea, forest := upload("sea.png"), upload("forest.png")
+
+	t.Run("Удаленная фотография попадает в корзину", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, do(http.MethodDelete, fmt.Sprintf("/api/photos/%d", sea.ID)).Code)
+
+		trash := list()
+		assert.Empty(t, trash.Albums)
+		require.Len(t, trash.Photos, 1)
+		assert.Equal(t, sea.ID, trash.Photos[0].ID)
+		assert.NotNil(t, trash.Photos[0].DeletedAt)
+		assert.NotNil(t, trash.Photos[0].PurgeAt)
+
+		assert.Equal(t, http.StatusNoContent, do(http.MethodPost, fmt.Sprintf("/api/trash/photos/%d/restore", sea.ID)).Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, fmt.Sprintf("/api/photos/%d", sea.ID)).Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, fmt.Sprintf("/api/trash/photos/%d/restore", sea.ID)).Code)
+	})
+
+	t.Run("Фотографию удаленного альбома нельзя восстановить отдельно", func(t *testing.T) {
+		require.NoError(t, env.repo.DeleteAlbum(context.Background(), 1))
+
+		trash := list()
+		require.Len(t, trash.Albums, 1)
+		assert.Equal(t, 2, trash.Albums[0].PhotoCount)
+		assert.Empty(t, trash.Photos)
+
+		assert.Equal(t, http.StatusConflict, do(http.MethodPost, fmt.Sprintf("/api/trash/photos/%d/restore", sea.ID)).Code)
+		assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/api/trash/albums/1/restore").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, fmt.Sprintf("/api/photos/%d", forest.ID)).Code)
+	})
+
+	t.Run("Окончательное удаление", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodDelete, "/api/trash/albums/abc").Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/api/trash/albums/1").Code, "альбом не в корзине")
+
+		require.NoError(t, env.repo.DeleteAlbum(context.Background(), 1))
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/api/trash/albums/1").Code)
+		for _, photo := range []models.Photo{sea, forest} {
+			_, err := os.Stat(filepath.Join(env.filesDir, photo.Path))
+			assert.True(t, os.IsNotExist(err), "файл %s должен быть удален", photo.Path)
+		}
+
+		rec := do(http.MethodDelete, "/api/trash")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var result service.TrashPurgeResult
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		assert.Equal(t, service.TrashPurgeResult{}, result)
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mpm/internal/models"
+	"mpm/internal/service"
+	"mpm/internal/tus"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TusValidateFunc проверяет альбом новой tus-загрузки до получения данных
+func TusValidateFunc(photoService *service.PhotoService) tus.ValidateFunc {
+	return func(ctx context.Context, info tus.Info) error {
+		_, err := tusAlbumID(ctx, photoService, info)
+		return err
+	}
+}
+
+// TusCompleteFunc сохраняет полностью полученную tus-загрузку как фотографию
+func TusCompleteFunc(photoService *service.PhotoService) tus.CompleteFunc {
+	return func(ctx context.Context, info tus.Info, file *os.File) (string, error) {
+		albumID, err := tusAlbumID(ctx, photoService, info)
+		if err != nil {
+			return "", err
+		}
+
+		filename := info.Metadata["filename"]
+		if filename == "" {
+			filename = info.ID
+		}
+
+		var user *models.User
+		if info.UserID != 0 {
+			user = &models.User{ID: info.UserID, Username: info.Username}
+		}
+
+		photo, err := photoService.Ingest(ctx, albumID, user, service.UploadFile{
+			File:     file,
+			Filename: filename,
+			Size:     info.Length,
+		})
+		if err != nil {
+			if errors.Is(err, service.ErrNotAnImage) || errors.Is(err, service.ErrSmartAlbum) {
+				return "", tus.Reject(http.StatusUnprocessableEntity, err)
+			}
+			return "", err
+		}
+		return fmt.Sprintf("/api/photos/%d", photo.ID), nil
+	}
+}
+
+// tusAlbumID возвращает альбом загрузки из Upload-Metadata, проверяя, что в него можно добавлять фотографии
+func tusAlbumID(ctx context.Context, photoService *service.PhotoService, info tus.Info) (int, error) {
+	albumID, err := strconv.Atoi(info.Metadata["album_id"])
+	if err != nil {
+		return 0, tus.Reject(http.StatusBadRequest, errors.New("в Upload-Metadata не указан album_id"))
+	}
+
+	if err := photoService.CheckUploadAlbum(ctx, albumID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrSmartAlbum):
+			return 0, tus.Reject(http.StatusBadRequest, err)
+		case strings.Contains(err.Error(), "не найден"):
+			return 0, tus.Reject(http.StatusNotFound, err)
+		}
+		return 0, err
+	}
+	return albumID, nil
+}
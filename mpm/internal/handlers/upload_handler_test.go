@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mpm/internal/models"
+	"mpm/internal/tus"
+	"mpm/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTusUpload_CreatesPhoto(t *testing.T) {
+	// Лимит обычной загрузки меньше файла: возобновляемая загрузка его не применяет
+	env := newPhotoTestEnv(t, 16)
+	store, err := tus.NewStore(t.TempDir())
+	require.NoError(t, err)
+	uploads := tus.NewHandler(store, "/api/uploads", 0, time.Hour, TusCompleteFunc(env.handler.photoService))
+	uploads.SetValidateFunc(TusValidateFunc(env.handler.photoService))
+	env.mux.HandleFunc("POST /api/uploads", uploads.Create)
+	env.mux.HandleFunc("PATCH /api/uploads/{id}", uploads.Patch)
+
+	user := &models.User{ID: 3, Username: "carol"}
+	do := func(method, path string, headers map[string]string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Tus-Resumable", tus.Version)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, user))
+		w := httptest.NewRecorder()
+		env.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Фотография создается после последнего блока", func(t *testing.T) {
+		data := testPNG(t)
+		w := do(http.MethodPost, "/api/uploads", map[string]string{
+			"Upload-Length":   strconv.Itoa(len(data)),
+			"Upload-Metadata": tus.FormatMetadata(map[string]string{"filename": "big.png", "album_id": "1"}),
+		}, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		location := w.Header().Get("Location")
+
+		half := len(data) / 2
+		for _, chunk := range [][2]int{{0, half}, {half, len(data)}} {
+			w = do(http.MethodPatch, location, map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": strconv.Itoa(chunk[0]),
+			}, data[chunk[0]:chunk[1]])
+			require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+		}
+
+		photoURL := w.Header().Get("Content-Location")
+		require.NotEmpty(t, photoURL)
+
+		w = do(http.MethodGet, photoURL, nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var photo models.Photo
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&photo))
+		assert.Equal(t, "big.png", photo.Name)
+		require.NotNil(t, photo.Album)
+		assert.Equal(t, 1, photo.Album.ID)
+		require.NotNil(t, photo.User)
+		assert.Equal(t, "carol", photo.User.Username)
+		assert.Equal(t, "image/png", photo.MimeType)
+		assert.Equal(t, int64(len(data)), photo.Size)
+	})
+
+	t.Run("Альбом проверяется до передачи данных", func(t *testing.T) {
+		for _, tc := range []struct {
+			metadata map[string]string
+			status   int
+		}{
+			{map[string]string{"filename": "big.png"}, http.StatusBadRequest},
+			{map[string]string{"filename": "big.png", "album_id": "999"}, http.StatusNotFound},
+		} {
+			w := do(http.MethodPost, "/api/uploads", map[string]string{
+				"Upload-Length":   "1000",
+				"Upload-Metadata": tus.FormatMetadata(tc.metadata),
+			}, nil)
+
+			assert.Equal(t, tc.status, w.Code, tc.metadata)
+			assert.Empty(t, w.Header().Get("Location"))
+		}
+	})
+
+	t.Run("Не изображение отклоняется окончательно", func(t *testing.T) {
+		data := []byte("это не изображение")
+		w := do(http.MethodPost, "/api/uploads", map[string]string{
+			"Upload-Length":   strconv.Itoa(len(data)),
+			"Upload-Metadata": tus.FormatMetadata(map[string]string{"filename": "big.png", "album_id": "1"}),
+			"Content-Type":    "application/offset+octet-stream",
+		}, data)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
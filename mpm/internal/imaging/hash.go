@@ -0,0 +1,29 @@
+package imaging
+
+import "image"
+
+// DHash вычисляет разностный перцептивный хеш изображения с учетом EXIF-ориентации
+func DHash(src image.Image, orientation int) uint64 {
+	// Уменьшаем до 9x8 в координатах развернутого изображения
+	w, h := 9, 8
+	if orientation >= 5 && orientation <= 8 {
+		w, h = h, w
+	}
+	small := orient(resize(src, w, h), orientation)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if luminance(small, x, y) > luminance(small, x+1, y) {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+	return hash
+}
+
+// luminance возвращает яркость пикселя по формуле ITU-R BT.601
+func luminance(img *image.RGBA, x, y int) float32 {
+	p := img.Pix[img.PixOffset(x, y):]
+	return 0.299*float32(p[0]) + 0.587*float32(p[1]) + 0.114*float32(p[2])
+}
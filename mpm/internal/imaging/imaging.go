@@ -0,0 +1,261 @@
+// Package imaging создает уменьшенные копии изображений средствами стандартной библиотеки
+package imaging
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+
+	// Регистрируем декодеры поддерживаемых форматов
+	_ "image/gif"
+	_ "image/png"
+)
+
+// MaxPixels ограничивает размер декодируемого изображения, защищая от переполнения памяти
+const MaxPixels = 120_000_000
+
+// JPEGQuality качество сжатия уменьшенных копий
+const JPEGQuality = 85
+
+// ErrTooLarge возвращается, если изображение превышает MaxPixels
+var ErrTooLarge = errors.New("изображение слишком большое для обработки")
+
+// Decode декодирует изображение, предварительно проверяя его размеры
+func Decode(r io.ReadSeeker) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения заголовка изображения: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка декодирования изображения: %w", err)
+	}
+	return img, nil
+}
+
+// EncodeJPEG сохраняет изображение в формате JPEG
+func EncodeJPEG(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: JPEGQuality})
+}
+
+// OrientedSize возвращает размеры изображения после применения EXIF-ориентации
+func OrientedSize(width, height, orientation int) (int, int) {
+	if orientation >= 5 && orientation <= 8 {
+		return height, width
+	}
+	return width, height
+}
+
+// Thumbnail уменьшает изображение до maxSide по большей стороне с учетом EXIF-ориентации
+func Thumbnail(src image.Image, maxSide, orientation int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	dw, dh := sw, sh
+	if maxSide > 0 && (sw > maxSide || sh > maxSide) {
+		if sw >= sh {
+			dw = maxSide
+			dh = int(math.Round(float64(sh) * float64(maxSide) / float64(sw)))
+		} else {
+			dh = maxSide
+			dw = int(math.Round(float64(sw) * float64(maxSide) / float64(sh)))
+		}
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	return orient(resize(src, dw, dh), orientation)
+}
+
+// resize уменьшает изображение усреднением по площади, читая исходник построчно
+func resize(src image.Image, dw, dh int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	scaleX := float64(sw) / float64(dw)
+	scaleY := float64(sh) / float64(dh)
+
+	// Веса горизонтального усреднения для каждой колонки результата
+	type weight struct {
+		x int
+		w float32
+	}
+	columns := make([][]weight, dw)
+	for dx := range columns {
+		x0, x1 := float64(dx)*scaleX, float64(dx+1)*scaleX
+		for sx := int(x0); sx < sw && float64(sx) < x1; sx++ {
+			overlap := math.Min(x1, float64(sx+1)) - math.Max(x0, float64(sx))
+			if overlap > 0 {
+				columns[dx] = append(columns[dx], weight{sx, float32(overlap / scaleX)})
+			}
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	fetch := rowFetcher(src)
+	row := make([]uint8, sw*3)
+	hrow := make([]float32, dw*3)
+	acc := make([]float32, dw*3)
+	next := make([]float32, dw*3)
+
+	flush := func(dy int) {
+		pix := dst.Pix[dy*dst.Stride:]
+		for dx := 0; dx < dw; dx++ {
+			for c := 0; c < 3; c++ {
+				pix[dx*4+c] = clamp(acc[dx*3+c] / float32(scaleY))
+			}
+			pix[dx*4+3] = 0xFF
+		}
+	}
+
+	dy := 0
+	for sy := 0; sy < sh && dy < dh; sy++ {
+		fetch(b.Min.Y+sy, row)
+
+		for dx, ws := range columns {
+			var r, g, bl float32
+			for _, w := range ws {
+				r += float32(row[w.x*3]) * w.w
+				g += float32(row[w.x*3+1]) * w.w
+				bl += float32(row[w.x*3+2]) * w.w
+			}
+			hrow[dx*3], hrow[dx*3+1], hrow[dx*3+2] = r, g, bl
+		}
+
+		y0, y1 := float64(sy), float64(sy+1)
+		boundary := float64(dy+1) * scaleY
+		if y1 <= boundary+1e-9 {
+			addRow(acc, hrow, 1)
+		} else {
+			part := float32(boundary - y0)
+			addRow(acc, hrow, part)
+			addRow(next, hrow, 1-part)
+		}
+
+		if y1 >= boundary-1e-9 {
+			flush(dy)
+			acc, next = next, acc
+			clear(next)
+			dy++
+		}
+	}
+	// Из-за погрешности вычислений последняя строка может остаться незаписанной
+	if dy < dh {
+		flush(dy)
+	}
+
+	return dst
+}
+
+// addRow прибавляет строку к аккумулятору с указанным весом
+func addRow(acc, row []float32, w float32) {
+	for i, v := range row {
+		acc[i] += v * w
+	}
+}
+
+// clamp приводит значение к диапазону байта
+func clamp(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// rowFetcher возвращает функцию чтения строки изображения в формате RGB
+func rowFetcher(src image.Image) func(y int, dst []uint8) {
+	b := src.Bounds()
+
+	switch img := src.(type) {
+	case *image.YCbCr:
+		return func(y int, dst []uint8) {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				yi := img.YOffset(x, y)
+				ci := img.COffset(x, y)
+				r, g, bl := color.YCbCrToRGB(img.Y[yi], img.Cb[ci], img.Cr[ci])
+				i := (x - b.Min.X) * 3
+				dst[i], dst[i+1], dst[i+2] = r, g, bl
+			}
+		}
+	case *image.Gray:
+		return func(y int, dst []uint8) {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				v := img.Pix[img.PixOffset(x, y)]
+				i := (x - b.Min.X) * 3
+				dst[i], dst[i+1], dst[i+2] = v, v, v
+			}
+		}
+	case *image.NRGBA:
+		return func(y int, dst []uint8) {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				p := img.Pix[img.PixOffset(x, y):]
+				a := uint32(p[3])
+				i := (x - b.Min.X) * 3
+				for c := 0; c < 3; c++ {
+					// Накладываем на белый фон
+					dst[i+c] = uint8((uint32(p[c])*a + 255*(255-a)) / 255)
+				}
+			}
+		}
+	default:
+		return func(y int, dst []uint8) {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, g, bl, a := src.At(x, y).RGBA()
+				// Цвета уже умножены на альфу, добавляем белый фон
+				bg := 0xFFFF - a
+				i := (x - b.Min.X) * 3
+				dst[i] = uint8((r + bg) >> 8)
+				dst[i+1] = uint8((g + bg) >> 8)
+				dst[i+2] = uint8((bl + bg) >> 8)
+			}
+		}
+	}
+}
+
+// orient поворачивает и отражает изображение согласно EXIF-ориентации (1-8)
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := OrientedSize(w, h, orientation)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // отражение по горизонтали
+				sx, sy = w-1-x, y
+			case 3: // поворот на 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // отражение по вертикали
+				sx, sy = x, h-1-y
+			case 5: // отражение относительно главной диагонали
+				sx, sy = y, x
+			case 6: // поворот на 90° по часовой стрелке
+				sx, sy = y, h-1-x
+			case 7: // отражение относительно побочной диагонали
+				sx, sy = w-1-y, h-1-x
+			case 8: // поворот на 90° против часовой стрелки
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
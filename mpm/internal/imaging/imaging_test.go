@@ -0,0 +1,157 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quadrants создает изображение с четырьмя цветными четвертями:
+// красная слева сверху, зеленая справа сверху, синяя слева снизу, белая справа снизу
+func quadrants(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{A: 255}
+			switch {
+			case x < w/2 && y < h/2:
+				c.R = 255
+			case y < h/2:
+				c.G = 255
+			case x < w/2:
+				c.B = 255
+			default:
+				c = color.NRGBA{255, 255, 255, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func colorAt(img image.Image, x, y int) color.RGBA {
+	r, g, b, a := img.At(x, y).RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
+
+func TestThumbnail_Size(t *testing.T) {
+	tests := []struct {
+		name          string
+		w, h, maxSide int
+		wantW, wantH  int
+	}{
+		{"landscape", 400, 300, 100, 100, 75},
+		{"portrait", 300, 400, 100, 75, 100},
+		{"small image is not enlarged", 50, 40, 100, 50, 40},
+		{"very thin image", 1000, 1, 10, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb := Thumbnail(quadrants(tt.w, tt.h), tt.maxSide, 1)
+			assert.Equal(t, tt.wantW, thumb.Bounds().Dx())
+			assert.Equal(t, tt.wantH, thumb.Bounds().Dy())
+		})
+	}
+}
+
+func TestThumbnail_AveragesColors(t *testing.T) {
+	thumb := Thumbnail(quadrants(64, 64), 8, 1)
+
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, colorAt(thumb, 0, 0))
+	assert.Equal(t, color.RGBA{0, 255, 0, 255}, colorAt(thumb, 7, 0))
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, colorAt(thumb, 0, 7))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, colorAt(thumb, 7, 7))
+
+	// Изображение из двух столбцов сжимается в один пиксель среднего цвета
+	half := image.NewGray(image.Rect(0, 0, 2, 1))
+	half.Pix[0], half.Pix[1] = 0, 200
+	assert.Equal(t, color.RGBA{100, 100, 100, 255}, colorAt(Thumbnail(half, 1, 1), 0, 0))
+}
+
+func TestThumbnail_Orientation(t *testing.T) {
+	src := quadrants(40, 20)
+
+	tests := []struct {
+		orientation int
+		topLeft     color.RGBA
+		w, h        int
+	}{
+		{1, color.RGBA{255, 0, 0, 255}, 40, 20},
+		{2, color.RGBA{0, 255, 0, 255}, 40, 20},
+		{3, color.RGBA{255, 255, 255, 255}, 40, 20},
+		{4, color.RGBA{0, 0, 255, 255}, 40, 20},
+		{5, color.RGBA{255, 0, 0, 255}, 20, 40},
+		{6, color.RGBA{0, 0, 255, 255}, 20, 40},
+		{7, color.RGBA{255, 255, 255, 255}, 20, 40},
+		{8, color.RGBA{0, 255, 0, 255}, 20, 40},
+	}
+
+	for _, tt := range tests {
+		thumb := Thumbnail(src, 100, tt.orientation)
+		assert.Equal(t, tt.w, thumb.Bounds().Dx(), "ориентация %d", tt.orientation)
+		assert.Equal(t, tt.h, thumb.Bounds().Dy(), "ориентация %d", tt.orientation)
+		assert.Equal(t, tt.topLeft, colorAt(thumb, 0, 0), "ориентация %d", tt.orientation)
+	}
+}
+
+func TestThumbnail_TransparentBackground(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	thumb := Thumbnail(img, 2, 1)
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, colorAt(thumb, 0, 0), "прозрачные области заливаются белым")
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, quadrants(10, 6)))
+
+	img, err := Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 10, 6), img.Bounds())
+
+	_, err = Decode(bytes.NewReader([]byte("not an image")))
+	assert.Error(t, err)
+}
+
+func TestDHash(t *testing.T) {
+	// Плавный градиент с несколькими деталями
+	src := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			v := uint8((x*255/640 + y*128/480) % 256)
+			if (x/80+y/60)%3 == 0 {
+				v = 255 - v
+			}
+			src.Set(x, y, color.RGBA{v, v / 2, 255 - v, 255})
+		}
+	}
+
+	original := DHash(src, 1)
+
+	// Уменьшенная и пережатая в JPEG копия
+	var buf bytes.Buffer
+	require.NoError(t, EncodeJPEG(&buf, Thumbnail(src, 200, 1)))
+	resaved, err := Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, bitsDiff(original, DHash(resaved, 1)), 4)
+
+	// Повернутая копия с EXIF-ориентацией дает тот же хеш после разворота
+	rotated := Thumbnail(src, 640, 8)
+	assert.LessOrEqual(t, bitsDiff(original, DHash(rotated, 6)), 4)
+
+	// Совсем другое изображение
+	assert.Greater(t, bitsDiff(original, DHash(quadrants(640, 480), 1)), 10)
+}
+
+func bitsDiff(a, b uint64) int {
+	n := 0
+	for x := a ^ b; x != 0; x &= x - 1 {
+		n++
+	}
+	return n
+}
@@ -7,13 +7,94 @@ import (
 )
 
 type Album struct {
-	ID          int       `json:"id" db:"id"` // Уникальный идентификатор альбома
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"` // Название альбома
-	User        *User     `json:"user,omitempty" db:"user"`     // Пользователь, который создал альбом
-	Photos      []Photo   `json:"photos,omitempty" db:"photos"` // Фотографии в альбоме
-	Tags        []string  `json:"tags,omitempty" db:"tags"`     // Теги альбома
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`   // Дата создания альбома
+	ID          int        `json:"id" db:"id"` // Уникальный идентификатор альбома
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`         // Название альбома
+	User        *User      `json:"user,omitempty" db:"user"`             // Пользователь, который создал альбом
+	Photos      []Photo    `json:"photos,omitempty" db:"photos"`         // Фотографии в альбоме
+	Tags        []string   `json:"tags,omitempty" db:"tags"`             // Теги альбома
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`           // Дата создания альбома
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Дата перемещения в корзину
+
+	// Filter сохраненный запрос, по которому подбираются фотографии умного альбома
+	Filter *PhotoFilter `json:"filter,omitempty" db:"filter"`
+}
+
+// AlbumSummary облегченное представление альбома для списков
+type AlbumSummary struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	User        *User          `json:"user,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Smart       bool           `json:"smart"`
+	Filter      *PhotoFilter   `json:"filter,omitempty"`
+	PhotoCount  int            `json:"photo_count"`
+	Cover       *PhotoPreview  `json:"cover,omitempty"`
+	Previews    []PhotoPreview `json:"previews"`
+}
+
+// Summary формирует облегченное представление альбома с не более чем limit превью
+func (a Album) Summary(photos []Photo, limit int) AlbumSummary {
+	summary := AlbumSummary{
+		ID:          a.ID,
+		Name:        a.Name,
+		Description: a.Description,
+		User:        a.User,
+		Tags:        a.Tags,
+		CreatedAt:   a.CreatedAt,
+		Smart:       a.IsSmart(),
+		Filter:      a.Filter,
+		PhotoCount:  len(photos),
+		Previews:    []PhotoPreview{},
+	}
+
+	for i, photo := range photos {
+		if i >= limit {
+			break
+		}
+		summary.Previews = append(summary.Previews, photo.Preview(DefaultPreviewSize))
+	}
+	if len(photos) > 0 {
+		cover := photos[0].Preview(DefaultPreviewSize)
+		summary.Cover = &cover
+	}
+
+	return summary
+}
+
+// IsSmart проверяет, подбираются ли фотографии альбома по сохраненному запросу
+func (a Album) IsSmart() bool {
+	return a.Filter != nil
+}
+
+// ValidateFilter проверяет сохраненный запрос умного альбома. Для обычного альбома всегда возвращает nil.
+func (a Album) ValidateFilter() error {
+	if a.Filter == nil {
+		return nil
+	}
+	f := a.Filter
+	if f.Trashed {
+		return fmt.Errorf("умный альбом не может отбирать фотографии из корзины")
+	}
+	if f.AlbumID != nil && a.ID != 0 && *f.AlbumID == a.ID {
+		return fmt.Errorf("умный альбом не может отбирать фотографии из самого себя")
+	}
+	switch f.Media {
+	case "", MediaTypePhoto, MediaTypeVideo, MediaTypeLivePhoto:
+	default:
+		return fmt.Errorf("некорректный тип медиафайла %q", f.Media)
+	}
+	if f.From != nil && f.To != nil && f.From.After(*f.To) {
+		return fmt.Errorf("начало диапазона дат позже конца")
+	}
+	if f.BBox != nil {
+		if err := f.BBox.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (a Album) GetID() int {
@@ -41,5 +122,5 @@ func (a Album) Validate() error {
 		}
 	}
 
-	return nil
+	return a.ValidateFilter()
 }
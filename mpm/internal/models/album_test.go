@@ -3,11 +3,18 @@ package models
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"mpm/internal/geo"
 )
 
 func TestAlbum_Validate(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC)
+	selfID := 3
+
 	tests := []struct {
 		name     string
 		album    Album
@@ -63,6 +70,60 @@ func TestAlbum_Validate(t *testing.T) {
 			wantErr:  true,
 			errorMsg: "теги содержат недопустимые символы",
 		},
+		{
+			name: "Valid smart album",
+			album: Album{
+				Name:   "Портреты 2024",
+				Filter: &PhotoFilter{Tags: []string{"портрет"}, From: &from, To: &to},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Smart album from trash",
+			album: Album{
+				Name:   "Корзина",
+				Filter: &PhotoFilter{Trashed: true},
+			},
+			wantErr:  true,
+			errorMsg: "из корзины",
+		},
+		{
+			name: "Smart album from itself",
+			album: Album{
+				ID:     3,
+				Name:   "Сам в себе",
+				Filter: &PhotoFilter{AlbumID: &selfID},
+			},
+			wantErr:  true,
+			errorMsg: "из самого себя",
+		},
+		{
+			name: "Smart album with unknown media",
+			album: Album{
+				Name:   "Аудио",
+				Filter: &PhotoFilter{Media: "audio"},
+			},
+			wantErr:  true,
+			errorMsg: "некорректный тип медиафайла",
+		},
+		{
+			name: "Smart album with reversed dates",
+			album: Album{
+				Name:   "Наоборот",
+				Filter: &PhotoFilter{From: &to, To: &from},
+			},
+			wantErr:  true,
+			errorMsg: "начало диапазона дат позже конца",
+		},
+		{
+			name: "Smart album with invalid area",
+			album: Album{
+				Name:   "Вне карты",
+				Filter: &PhotoFilter{BBox: &geo.BBox{MinLon: 0, MinLat: -100, MaxLon: 10, MaxLat: 10}},
+			},
+			wantErr:  true,
+			errorMsg: "широта",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// ManifestFilename имя файла с описанием альбома внутри архива
+const ManifestFilename = "manifest.json"
+
+// ManifestVersion текущая версия формата manifest.json
+const ManifestVersion = 1
+
+// AlbumManifest описывает альбом и его фотографии в архиве экспорта
+type AlbumManifest struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Album      ManifestAlbum   `json:"album"`
+	Photos     []ManifestPhoto `json:"photos"`
+}
+
+// ManifestAlbum поля альбома, сохраняемые в архиве
+type ManifestAlbum struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ManifestPhoto описание фотографии в архиве. File - имя записи архива с оригиналом.
+type ManifestPhoto struct {
+	File      string     `json:"file"`
+	ID        int        `json:"id,omitempty"`
+	Name      string     `json:"name"`
+	Tags      []string   `json:"tags,omitempty"`
+	Metadata  []Metadata `json:"metadata,omitempty"`
+	MimeType  string     `json:"mime_type,omitempty"`
+	Size      int64      `json:"size,omitempty"`
+	Checksum  string     `json:"checksum,omitempty"`
+	TakenAt   *time.Time `json:"taken_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewManifestAlbum формирует описание альбома для архива
+func NewManifestAlbum(album Album) ManifestAlbum {
+	return ManifestAlbum{
+		ID:          album.ID,
+		Name:        album.Name,
+		Description: album.Description,
+		Tags:        album.Tags,
+		CreatedAt:   album.CreatedAt,
+	}
+}
+
+// NewManifestPhoto формирует описание фотографии, сохраненной в архиве под именем file
+func NewManifestPhoto(photo Photo, file string) ManifestPhoto {
+	return ManifestPhoto{
+		File:      file,
+		ID:        photo.ID,
+		Name:      photo.Name,
+		Tags:      photo.Tags,
+		Metadata:  photo.Metadata,
+		MimeType:  photo.MimeType,
+		Size:      photo.Size,
+		Checksum:  photo.Checksum,
+		TakenAt:   photo.TakenAt,
+		CreatedAt: photo.CreatedAt,
+	}
+}
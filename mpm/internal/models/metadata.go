@@ -5,3 +5,30 @@ type Metadata struct {
 	Key   string `json:"key" db:"key"`     // Тип метаданных (например, "camera", "location", "date_taken")
 	Value string `json:"value" db:"value"` // Значение метаданных
 }
+
+// Ключи метаданных, извлекаемых из EXIF при загрузке фотографии
+const (
+	MetadataCameraMake      = "camera_make"
+	MetadataCameraModel     = "camera_model"
+	MetadataCameraSerial    = "camera_serial"
+	MetadataLensMake        = "lens_make"
+	MetadataLensModel       = "lens_model"
+	MetadataExposureTime    = "exposure_time"
+	MetadataFNumber         = "f_number"
+	MetadataISO             = "iso"
+	MetadataFocalLength     = "focal_length"
+	MetadataFocalLength35mm = "focal_length_35mm"
+	MetadataDateTaken       = "date_taken"
+	MetadataOrientation     = "orientation"
+	MetadataGPSLatitude     = "gps_latitude"
+	MetadataGPSLongitude    = "gps_longitude"
+	MetadataGPSAltitude     = "gps_altitude"
+)
+
+// Ключи метаданных места съемки, определяемого по координатам GPS
+const (
+	MetadataCountry     = "country"
+	MetadataCountryCode = "country_code"
+	MetadataRegion      = "region"
+	MetadataCity        = "city"
+)
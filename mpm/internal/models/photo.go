@@ -1,17 +1,33 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Photo struct {
-	ID          int        `json:"id" db:"id"`                 // Уникальный идентификатор фотографии
-	Name        string     `json:"name" db:"name"`             // Название фотографии
-	Path        string     `json:"path" db:"path"`             // Путь к фотографии (локальный или url)
-	Album       *Album     `json:"album,omitempty" db:"album"` // Альбом, к которому принадлежит фотография
-	User        *User      `json:"user,omitempty" db:"user"`   // Пользователь, который загрузил фотографию
-	Tags        []string   `json:"tags" db:"tags"`             // Теги фотографии
-	Metadata    []Metadata `json:"metadata" db:"metadata"`
-	StorageType string     `json:"storage_type" db:"storage_type"` // Тип хранения фотографии (local, google, dropbox)
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ID          int         `json:"id" db:"id"`                 // Уникальный идентификатор фотографии
+	Name        string      `json:"name" db:"name"`             // Название фотографии
+	Path        string      `json:"path" db:"path"`             // Путь к фотографии (локальный или url)
+	Album       *Album      `json:"album,omitempty" db:"album"` // Альбом, к которому принадлежит фотография
+	User        *User       `json:"user,omitempty" db:"user"`   // Пользователь, который загрузил фотографию
+	Tags        []string    `json:"tags" db:"tags"`             // Теги фотографии
+	Metadata    []Metadata  `json:"metadata" db:"metadata"`
+	StorageType string      `json:"storage_type" db:"storage_type"`       // Тип хранения фотографии (local, google, dropbox)
+	MimeType    string      `json:"mime_type,omitempty" db:"mime_type"`   // MIME-тип файла, определенный по содержимому
+	Size        int64       `json:"size,omitempty" db:"size"`             // Размер файла в байтах
+	Checksum    string      `json:"checksum,omitempty" db:"checksum"`     // SHA-256 содержимого файла
+	PHash       string      `json:"phash,omitempty" db:"phash"`           // Перцептивный хеш (dHash) в шестнадцатеричном виде
+	Width       int         `json:"width,omitempty" db:"width"`           // Ширина с учетом ориентации
+	Height      int         `json:"height,omitempty" db:"height"`         // Высота с учетом ориентации
+	Renditions  []Rendition `json:"renditions,omitempty" db:"renditions"` // Уменьшенные копии
+	TakenAt     *time.Time  `json:"taken_at,omitempty" db:"taken_at"`     // Дата съемки из EXIF
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	DeletedAt   *time.Time  `json:"deleted_at,omitempty" db:"deleted_at"` // Дата перемещения в корзину
+	MediaType   string      `json:"media_type,omitempty" db:"media_type"` // photo, video или live_photo; пусто у загруженных ранее снимков
+	Video       *VideoInfo  `json:"video,omitempty" db:"video"`           // Параметры видео для MediaType video
+	Motion      *MotionClip `json:"motion,omitempty" db:"motion"`         // Видеоролик Live Photo
 }
 
 func (p Photo) GetID() int {
@@ -21,3 +37,47 @@ func (p Photo) GetID() int {
 func (p Photo) GetType() string {
 	return "photo"
 }
+
+// HasTag проверяет, отмечена ли фотография указанным тегом
+func (p Photo) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// MetadataValue возвращает значение метаданных по ключу
+func (p Photo) MetadataValue(key string) (string, bool) {
+	for _, m := range p.Metadata {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+// Location возвращает координаты съемки из метаданных GPS
+func (p Photo) Location() (lat, lon float64, ok bool) {
+	latValue, okLat := p.MetadataValue(MetadataGPSLatitude)
+	lonValue, okLon := p.MetadataValue(MetadataGPSLongitude)
+	if !okLat || !okLon {
+		return 0, 0, false
+	}
+
+	lat, errLat := strconv.ParseFloat(latValue, 64)
+	lon, errLon := strconv.ParseFloat(lonValue, 64)
+	if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// CapturedAt возвращает дату съемки, а если она неизвестна - дату загрузки
+func (p Photo) CapturedAt() time.Time {
+	if p.TakenAt != nil {
+		return *p.TakenAt
+	}
+	return p.CreatedAt
+}
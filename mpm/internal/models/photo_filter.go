@@ -0,0 +1,179 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mpm/internal/geo"
+)
+
+// PhotoFilter описывает условия отбора фотографий
+type PhotoFilter struct {
+	AlbumID  *int       `json:"album_id,omitempty"` // Фотографии из указанного альбома
+	UserID   *int       `json:"user_id,omitempty"`  // Фотографии, загруженные пользователем
+	Tags     []string   `json:"tags,omitempty"`     // Фотография должна содержать все указанные теги
+	From     *time.Time `json:"from,omitempty"`     // Начало диапазона дат (включительно)
+	To       *time.Time `json:"to,omitempty"`       // Конец диапазона дат (включительно)
+	Camera   string     `json:"camera,omitempty"`   // Подстрока производителя или модели камеры
+	Checksum string     `json:"checksum,omitempty"` // Фотографии с указанной контрольной суммой
+	Trashed  bool       `json:"trashed,omitempty"`  // Фотографии в корзине вместо обычных
+	Media    string     `json:"media,omitempty"`    // Тип медиафайла: photo, video или live_photo
+	Query    string     `json:"q,omitempty"`        // Подстрока названия, тега или места съемки
+	BBox     *geo.BBox  `json:"bbox,omitempty"`     // Область карты, в которой сделан снимок
+}
+
+// UnmarshalJSON принимает границы диапазона дат в формате RFC3339 или YYYY-MM-DD
+func (f *PhotoFilter) UnmarshalJSON(data []byte) error {
+	type plain PhotoFilter
+	var raw struct {
+		plain
+		From string `json:"from,omitempty"`
+		To   string `json:"to,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*f = PhotoFilter(raw.plain)
+	if raw.From != "" {
+		from, err := ParseFilterTime(raw.From, false)
+		if err != nil {
+			return fmt.Errorf("некорректная дата from: %s", raw.From)
+		}
+		f.From = &from
+	}
+	if raw.To != "" {
+		to, err := ParseFilterTime(raw.To, true)
+		if err != nil {
+			return fmt.Errorf("некорректная дата to: %s", raw.To)
+		}
+		f.To = &to
+	}
+	return nil
+}
+
+// Match проверяет, удовлетворяет ли фотография условиям фильтра
+func (f PhotoFilter) Match(p Photo) bool {
+	if (p.DeletedAt != nil) != f.Trashed {
+		return false
+	}
+	if f.Media != "" && p.Kind() != f.Media {
+		return false
+	}
+	if f.AlbumID != nil && (p.Album == nil || p.Album.ID != *f.AlbumID) {
+		return false
+	}
+	if f.UserID != nil && (p.User == nil || p.User.ID != *f.UserID) {
+		return false
+	}
+
+	for _, tag := range f.Tags {
+		if !p.HasTag(tag) {
+			return false
+		}
+	}
+
+	if f.Checksum != "" && !strings.EqualFold(p.Checksum, f.Checksum) {
+		return false
+	}
+	if f.Camera != "" && !p.matchCamera(f.Camera) {
+		return false
+	}
+	if f.Query != "" && !p.matchQuery(f.Query) {
+		return false
+	}
+	if f.BBox != nil {
+		lat, lon, ok := p.Location()
+		if !ok || !f.BBox.Contains(lat, lon) {
+			return false
+		}
+	}
+
+	// Дата съемки из EXIF точнее даты загрузки
+	date := p.CapturedAt()
+	if f.From != nil && date.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && date.After(*f.To) {
+		return false
+	}
+
+	return true
+}
+
+// matchCamera проверяет, содержит ли производитель или модель камеры указанную строку
+func (p Photo) matchCamera(camera string) bool {
+	camera = strings.ToLower(camera)
+	for _, key := range []string{MetadataCameraMake, MetadataCameraModel} {
+		if value, ok := p.MetadataValue(key); ok && strings.Contains(strings.ToLower(value), camera) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchQuery проверяет, содержат ли название, теги или место съемки указанную строку
+func (p Photo) matchQuery(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if strings.Contains(strings.ToLower(p.Name), query) {
+		return true
+	}
+	for _, tag := range p.Tags {
+		if strings.Contains(strings.ToLower(tag), query) {
+			return true
+		}
+	}
+	for _, key := range []string{MetadataCity, MetadataRegion, MetadataCountry} {
+		if value, ok := p.MetadataValue(key); ok && strings.Contains(strings.ToLower(value), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFilterTime разбирает дату RFC3339 или YYYY-MM-DD, для конца диапазона - до конца дня
+func ParseFilterTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// SortPhotos сортирует фотографии по taken_at, created_at или name, префикс "-" меняет порядок
+func SortPhotos(photos []Photo, order string) error {
+	field := strings.TrimPrefix(order, "-")
+	desc := field != order
+
+	var less func(a, b Photo) bool
+	switch field {
+	case "":
+		return nil
+	case "taken_at":
+		less = func(a, b Photo) bool { return a.CapturedAt().Before(b.CapturedAt()) }
+	case "created_at":
+		less = func(a, b Photo) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case "name":
+		less = func(a, b Photo) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	default:
+		return fmt.Errorf("неизвестное поле сортировки: %s", field)
+	}
+
+	sort.SliceStable(photos, func(i, j int) bool {
+		if desc {
+			return less(photos[j], photos[i])
+		}
+		return less(photos[i], photos[j])
+	})
+	return nil
+}
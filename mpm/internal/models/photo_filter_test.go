@@ -0,0 +1,152 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mpm/internal/geo"
+)
+
+func TestPhotoFilter_Match(t *testing.T) {
+	created := time.Date(2024, 7, 15, 10, 0, 0, 0, time.UTC)
+	photo := Photo{
+		ID:        1,
+		Album:     &Album{ID: 2},
+		User:      &User{ID: 3},
+		Name:      "IMG_0042.jpg",
+		Tags:      []string{"море", "Закат"},
+		Metadata:  []Metadata{{Key: MetadataCity, Value: "Sochi"}, {Key: MetadataCountry, Value: "Russia"}},
+		CreatedAt: created,
+	}
+
+	intPtr := func(v int) *int { return &v }
+	timePtr := func(v time.Time) *time.Time { return &v }
+
+	tests := []struct {
+		name   string
+		filter PhotoFilter
+		want   bool
+	}{
+		{"empty filter", PhotoFilter{}, true},
+		{"album matches", PhotoFilter{AlbumID: intPtr(2)}, true},
+		{"album differs", PhotoFilter{AlbumID: intPtr(5)}, false},
+		{"user matches", PhotoFilter{UserID: intPtr(3)}, true},
+		{"user differs", PhotoFilter{UserID: intPtr(4)}, false},
+		{"all tags present", PhotoFilter{Tags: []string{"море", "закат"}}, true},
+		{"tag missing", PhotoFilter{Tags: []string{"море", "горы"}}, false},
+		{"inside date range", PhotoFilter{From: timePtr(created.Add(-time.Hour)), To: timePtr(created.Add(time.Hour))}, true},
+		{"before range", PhotoFilter{From: timePtr(created.Add(time.Hour))}, false},
+		{"after range", PhotoFilter{To: timePtr(created.Add(-time.Hour))}, false},
+		{"legacy photo is a photo", PhotoFilter{Media: MediaTypePhoto}, true},
+		{"media differs", PhotoFilter{Media: MediaTypeVideo}, false},
+		{"query matches city", PhotoFilter{Query: "sochi"}, true},
+		{"query matches country", PhotoFilter{Query: "RUSS"}, true},
+		{"query matches tag", PhotoFilter{Query: "закат"}, true},
+		{"query matches name", PhotoFilter{Query: "img_0042"}, true},
+		{"query differs", PhotoFilter{Query: "Paris"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(photo))
+		})
+	}
+
+	t.Run("photo without album", func(t *testing.T) {
+		assert.False(t, PhotoFilter{AlbumID: intPtr(0)}.Match(Photo{}))
+	})
+
+	t.Run("live photo", func(t *testing.T) {
+		live := Photo{MediaType: MediaTypeLivePhoto}
+		assert.True(t, PhotoFilter{Media: MediaTypeLivePhoto}.Match(live))
+		assert.False(t, PhotoFilter{Media: MediaTypePhoto}.Match(live))
+	})
+
+	t.Run("bbox", func(t *testing.T) {
+		located := Photo{Metadata: []Metadata{
+			{Key: MetadataGPSLatitude, Value: "55.752000"},
+			{Key: MetadataGPSLongitude, Value: "37.617500"},
+		}}
+		moscow := &geo.BBox{MinLon: 37.3, MinLat: 55.5, MaxLon: 37.9, MaxLat: 55.9}
+		spb := &geo.BBox{MinLon: 30, MinLat: 59, MaxLon: 31, MaxLat: 60}
+		assert.True(t, PhotoFilter{BBox: moscow}.Match(located))
+		assert.False(t, PhotoFilter{BBox: spb}.Match(located))
+		assert.False(t, PhotoFilter{BBox: moscow}.Match(photo), "фотография без координат")
+	})
+}
+
+func TestPhotoFilter_MatchExif(t *testing.T) {
+	created := time.Date(2024, 7, 15, 10, 0, 0, 0, time.UTC)
+	taken := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+	photo := Photo{
+		Metadata:  []Metadata{{Key: MetadataCameraMake, Value: "FUJIFILM"}, {Key: MetadataCameraModel, Value: "X-T4"}},
+		TakenAt:   &taken,
+		CreatedAt: created,
+	}
+
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, PhotoFilter{Camera: "fujifilm"}.Match(photo))
+	assert.True(t, PhotoFilter{Camera: "x-t4"}.Match(photo))
+	assert.False(t, PhotoFilter{Camera: "canon"}.Match(photo))
+	assert.True(t, PhotoFilter{From: &from, To: &to}.Match(photo), "диапазон дат проверяется по дате съемки")
+}
+
+func TestPhotoFilter_UnmarshalJSON(t *testing.T) {
+	var filter PhotoFilter
+	require.NoError(t, json.Unmarshal([]byte(`{"media":"photo","from":"2024-01-01","to":"2024-12-31"}`), &filter))
+	assert.Equal(t, MediaTypePhoto, filter.Media)
+	require.NotNil(t, filter.From)
+	require.NotNil(t, filter.To)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *filter.From)
+
+	taken := time.Date(2024, 12, 31, 18, 30, 0, 0, time.UTC)
+	assert.True(t, filter.Match(Photo{TakenAt: &taken}), "дата без времени в to включает весь день")
+
+	t.Run("RFC3339 сохраняется без изменений", func(t *testing.T) {
+		data, err := json.Marshal(filter)
+		require.NoError(t, err)
+
+		var decoded PhotoFilter
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.True(t, filter.To.Equal(*decoded.To))
+	})
+
+	t.Run("Некорректная дата", func(t *testing.T) {
+		var invalid PhotoFilter
+		assert.Error(t, json.Unmarshal([]byte(`{"from":"01.01.2024"}`), &invalid))
+	})
+}
+
+func TestSortPhotos(t *testing.T) {
+	taken := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	photos := []Photo{
+		{ID: 1, Name: "b.jpg", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Name: "A.jpg", CreatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), TakenAt: &taken},
+		{ID: 3, Name: "c.jpg", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	ids := func() []int {
+		result := []int{}
+		for _, p := range photos {
+			result = append(result, p.ID)
+		}
+		return result
+	}
+
+	assert.NoError(t, SortPhotos(photos, "taken_at"))
+	assert.Equal(t, []int{2, 3, 1}, ids())
+
+	assert.NoError(t, SortPhotos(photos, "-created_at"))
+	assert.Equal(t, []int{2, 1, 3}, ids())
+
+	assert.NoError(t, SortPhotos(photos, "name"))
+	assert.Equal(t, []int{2, 1, 3}, ids())
+
+	assert.Error(t, SortPhotos(photos, "size"))
+}
@@ -0,0 +1,60 @@
+package models
+
+import "fmt"
+
+// DefaultPreviewSize размер уменьшенной копии для превью в списках
+const DefaultPreviewSize = 256
+
+// Rendition уменьшенная копия фотографии
+type Rendition struct {
+	Size   int    `json:"size" db:"size"`     // Максимальная сторона, для которой создана копия
+	Path   string `json:"path" db:"path"`     // Путь к файлу копии в хранилище
+	Width  int    `json:"width" db:"width"`   // Фактическая ширина копии
+	Height int    `json:"height" db:"height"` // Фактическая высота копии
+}
+
+// PhotoPreview облегченное представление фотографии для галерей и списков альбомов
+type PhotoPreview struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+}
+
+// Rendition выбирает наименьшую копию не меньше запрошенного размера или самую большую
+func (p Photo) Rendition(size int) (Rendition, bool) {
+	var best, largest *Rendition
+	for i := range p.Renditions {
+		r := &p.Renditions[i]
+		if r.Size >= size && (best == nil || r.Size < best.Size) {
+			best = r
+		}
+		if largest == nil || r.Size > largest.Size {
+			largest = r
+		}
+	}
+
+	if best != nil {
+		return *best, true
+	}
+	if largest != nil {
+		return *largest, true
+	}
+	return Rendition{}, false
+}
+
+// Preview возвращает облегченное представление фотографии со ссылкой на уменьшенную копию
+func (p Photo) Preview(size int) PhotoPreview {
+	preview := PhotoPreview{
+		ID:           p.ID,
+		Name:         p.Name,
+		ThumbnailURL: fmt.Sprintf("/api/photos/%d/thumb?size=%d", p.ID, size),
+		Width:        p.Width,
+		Height:       p.Height,
+	}
+	if r, ok := p.Rendition(size); ok {
+		preview.Width, preview.Height = r.Width, r.Height
+	}
+	return preview
+}
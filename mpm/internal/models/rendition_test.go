@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhoto_Rendition(t *testing.T) {
+	photo := Photo{Renditions: []Rendition{
+		{Size: 1024, Path: "m.jpg"},
+		{Size: 256, Path: "s.jpg"},
+		{Size: 2048, Path: "l.jpg"},
+	}}
+
+	tests := []struct {
+		size int
+		want string
+	}{
+		{100, "s.jpg"},
+		{256, "s.jpg"},
+		{300, "m.jpg"},
+		{2048, "l.jpg"},
+		{4096, "l.jpg"},
+	}
+
+	for _, tt := range tests {
+		r, ok := photo.Rendition(tt.size)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, r.Path, "размер %d", tt.size)
+	}
+
+	_, ok := Photo{}.Rendition(256)
+	assert.False(t, ok)
+}
+
+func TestAlbum_Summary(t *testing.T) {
+	album := Album{ID: 3, Name: "Trip", Photos: []Photo{{ID: 100}}}
+	photos := []Photo{
+		{ID: 1, Name: "a.jpg", Width: 4000, Height: 3000, Renditions: []Rendition{{Size: 256, Width: 256, Height: 192}}},
+		{ID: 2, Name: "b.jpg"},
+		{ID: 3, Name: "c.jpg"},
+	}
+
+	summary := album.Summary(photos, 2)
+
+	assert.Equal(t, 3, summary.ID)
+	assert.Equal(t, 3, summary.PhotoCount)
+	assert.Len(t, summary.Previews, 2)
+	if assert.NotNil(t, summary.Cover) {
+		assert.Equal(t, 1, summary.Cover.ID)
+		assert.Equal(t, "/api/photos/1/thumb?size=256", summary.Cover.ThumbnailURL)
+		assert.Equal(t, 256, summary.Cover.Width)
+		assert.Equal(t, 192, summary.Cover.Height)
+	}
+
+	empty := album.Summary(nil, 4)
+	assert.Nil(t, empty.Cover)
+	assert.NotNil(t, empty.Previews)
+}
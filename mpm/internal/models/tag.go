@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+	"unicode"
+)
 
 type Tag struct {
 	ID        int       `json:"id" db:"id"`     // Уникальный идентификатор тега
@@ -15,3 +19,13 @@ func (t Tag) GetID() int {
 func (t Tag) GetType() string {
 	return "tag"
 }
+
+// ValidTag проверяет, что тег не пустой и не содержит пробелов
+func ValidTag(tag string) bool {
+	return tag != "" && !strings.ContainsFunc(tag, unicode.IsSpace)
+}
+
+// NormalizeTag приводит название к виду тега: нижний регистр, пробелы заменены дефисами
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Поля фотографии, по которым проверяются условия правил
+const (
+	RuleFieldName     = "name"     // Имя файла
+	RuleFieldAlbum    = "album_id" // ID альбома
+	RuleFieldUser     = "user_id"  // ID пользователя, загрузившего фотографию
+	RuleFieldMedia    = "media"    // Тип медиафайла: photo, video или live_photo
+	RuleFieldHour     = "hour"     // Час съемки от 0 до 23
+	RuleFieldLocation = "location" // Координаты съемки из метаданных GPS
+	RuleFieldMetadata = "metadata" // Значение метаданных с ключом Key
+)
+
+// Операторы сравнения в условиях правил. Строки сравниваются без учета регистра, кроме regex.
+const (
+	RuleOpEquals   = "equals"   // Совпадает со значением
+	RuleOpContains = "contains" // Содержит значение
+	RuleOpPrefix   = "prefix"   // Начинается со значения
+	RuleOpGlob     = "glob"     // Соответствует шаблону вида DSC_*.NEF
+	RuleOpRegex    = "regex"    // Соответствует регулярному выражению
+	RuleOpExists   = "exists"   // Метаданные с ключом Key заданы
+	RuleOpBetween  = "between"  // Час в диапазоне "9-18" включительно, "22-4" переходит через полночь
+	RuleOpWithin   = "within"   // Точка внутри области "minLon,minLat,maxLon,maxLat"
+)
+
+// RuleCondition условие правила автоматической расстановки тегов
+type RuleCondition struct {
+	Field    string `json:"field"`         // Поле фотографии
+	Key      string `json:"key,omitempty"` // Ключ метаданных для поля metadata
+	Operator string `json:"op"`            // Оператор сравнения
+	Value    string `json:"value,omitempty"`
+}
+
+// TagRule правило автоматической расстановки тегов
+type TagRule struct {
+	ID         int             `json:"id" db:"id"`
+	Name       string          `json:"name" db:"name"`
+	Conditions []RuleCondition `json:"conditions" db:"conditions"`
+	Tags       []string        `json:"tags" db:"tags"`                       // Добавляемые теги
+	Disabled   bool            `json:"disabled,omitempty" db:"disabled"`     // Отключенное правило не применяется
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`           // Дата создания правила
+	UpdatedAt  *time.Time      `json:"updated_at,omitempty" db:"updated_at"` // Дата последнего изменения
+}
+
+func (r TagRule) GetID() int {
+	return r.ID
+}
+
+func (r TagRule) GetType() string {
+	return "tag_rule"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Sochi", "sochi"},
+		{"Krasnodar Krai", "krasnodar-krai"},
+		{"  Rio de  Janeiro ", "rio-de-janeiro"},
+		{"Île-de-France", "île-de-france"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeTag(tt.name))
+		})
+	}
+}
+
+func TestValidTag(t *testing.T) {
+	assert.True(t, ValidTag("море"))
+	assert.True(t, ValidTag(NormalizeTag("Krasnodar Krai")))
+	assert.False(t, ValidTag(""))
+	assert.False(t, ValidTag("два слова"))
+	assert.False(t, ValidTag("tab\tseparated"))
+}
@@ -0,0 +1,47 @@
+package models
+
+// Типы медиафайлов
+const (
+	MediaTypePhoto     = "photo"
+	MediaTypeVideo     = "video"
+	MediaTypeLivePhoto = "live_photo" // Снимок с коротким видеороликом
+)
+
+// VideoInfo параметры видео, извлеченные из контейнера MP4 или MOV
+type VideoInfo struct {
+	Duration   float64 `json:"duration" db:"duration"`                       // Длительность в секундах
+	Width      int     `json:"width,omitempty" db:"width"`                   // Ширина кадра с учетом поворота
+	Height     int     `json:"height,omitempty" db:"height"`                 // Высота кадра с учетом поворота
+	FrameRate  float64 `json:"frame_rate,omitempty" db:"frame_rate"`         // Кадров в секунду
+	VideoCodec string  `json:"video_codec,omitempty" db:"video_codec"`       // FourCC видеодорожки, например avc1 или hvc1
+	AudioCodec string  `json:"audio_codec,omitempty" db:"audio_codec"`       // FourCC звуковой дорожки, например mp4a
+	ContentID  string  `json:"content_id,omitempty" db:"content_identifier"` // Идентификатор Live Photo от камеры Apple
+}
+
+// MotionClip видеоролик Live Photo, хранящийся рядом со снимком
+type MotionClip struct {
+	Path     string    `json:"path" db:"path"`           // Путь к файлу ролика в хранилище
+	MimeType string    `json:"mime_type" db:"mime_type"` // MIME-тип ролика
+	Size     int64     `json:"size" db:"size"`           // Размер файла в байтах
+	Video    VideoInfo `json:"video" db:"video"`
+}
+
+// Kind возвращает тип медиафайла. Фотографии, загруженные до появления видео, считаются снимками.
+func (p Photo) Kind() string {
+	if p.MediaType == "" {
+		return MediaTypePhoto
+	}
+	return p.MediaType
+}
+
+// FilePaths возвращает пути всех файлов фотографии, начиная с оригинала
+func (p Photo) FilePaths() []string {
+	paths := []string{p.Path}
+	for _, r := range p.Renditions {
+		paths = append(paths, r.Path)
+	}
+	if p.Motion != nil && p.Motion.Path != "" {
+		paths = append(paths, p.Motion.Path)
+	}
+	return paths
+}
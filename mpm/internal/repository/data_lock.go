@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// dataLockFile имя файла блокировки в директории данных
+const dataLockFile = ".mpm.lock"
+
+// ErrDataDirLocked возвращается, если директорию данных уже использует другой процесс
+var ErrDataDirLocked = errors.New("директория данных используется другим процессом")
+
+// DataDirLock блокировка директории данных JSON-хранилища
+type DataDirLock struct {
+	file *os.File
+}
+
+// LockDataDir захватывает директорию данных, чтобы JSON-файлы не изменяли два процесса сразу
+func LockDataDir(dataDir string) (*DataDirLock, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("ошибка создания директории данных: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(dataDir, dataLockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла блокировки: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &DataDirLock{file: file}, nil
+}
+
+// Release снимает блокировку
+func (l *DataDirLock) Release() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
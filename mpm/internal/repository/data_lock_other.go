@@ -0,0 +1,10 @@
+//go:build !unix
+
+package repository
+
+import "os"
+
+// lockFile на системах без flock не блокирует файл
+func lockFile(_ *os.File) error {
+	return nil
+}
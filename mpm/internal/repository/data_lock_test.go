@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockDataDir(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := LockDataDir(dir)
+	require.NoError(t, err)
+
+	_, err = LockDataDir(dir)
+	assert.ErrorIs(t, err, ErrDataDirLocked)
+
+	require.NoError(t, lock.Release())
+	again, err := LockDataDir(dir)
+	require.NoError(t, err)
+	assert.NoError(t, again.Release())
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile захватывает файл без ожидания
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return ErrDataDirLocked
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка блокировки директории данных: %w", err)
+	}
+	return nil
+}
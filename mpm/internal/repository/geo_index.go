@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"sync"
+
+	"mpm/internal/geo"
+	"mpm/internal/models"
+)
+
+// geoIndex пространственный индекс координат съемки фотографий
+type geoIndex struct {
+	mu      sync.Mutex
+	version uint64
+	built   bool
+	index   *geo.Index
+	// positions позиции фотографий в s.photos по ID, действительны для версии version
+	positions map[int]int
+}
+
+// PhotosInBox возвращает фотографии не из корзины, снятые внутри области
+func (s *JSONStorage) PhotosInBox(bbox geo.BBox) []models.Photo {
+	s.geoIndex.mu.Lock()
+	defer s.geoIndex.mu.Unlock()
+
+	s.photosMutex.RLock()
+	defer s.photosMutex.RUnlock()
+
+	if !s.geoIndex.built || s.geoIndex.version != s.photosVersion {
+		points := []geo.Point{}
+		positions := make(map[int]int)
+		for i, photo := range s.photos {
+			if photo.DeletedAt != nil {
+				continue
+			}
+			if lat, lon, ok := photo.Location(); ok {
+				points = append(points, geo.Point{ID: photo.ID, Lat: lat, Lon: lon})
+				positions[photo.ID] = i
+			}
+		}
+
+		s.geoIndex.index = geo.NewIndex(points)
+		s.geoIndex.positions = positions
+		s.geoIndex.version = s.photosVersion
+		s.geoIndex.built = true
+	}
+
+	points := s.geoIndex.index.Within(bbox)
+	photos := make([]models.Photo, 0, len(points))
+	for _, p := range points {
+		photos = append(photos, s.photos[s.geoIndex.positions[p.ID]])
+	}
+	return photos
+}
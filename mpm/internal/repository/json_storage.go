@@ -20,6 +20,7 @@ type JSONStorage struct {
 	photosMutex sync.RWMutex // Мьютекс для доступа к фотографиям
 	albumsMutex sync.RWMutex // Мьютекс для доступа к альбомам
 	tagsMutex   sync.RWMutex // Мьютекс для доступа к тегам
+	rulesMutex  sync.RWMutex // Мьютекс для доступа к правилам расстановки тегов
 
 	// Общий мьютекс для метаданных (dirtyFlag, lastSaveTime)
 	metaMutex sync.RWMutex
@@ -32,6 +33,7 @@ type JSONStorage struct {
 	photos []models.Photo
 	albums []models.Album
 	tags   []models.Tag
+	rules  []models.TagRule
 
 	// Счетчики для определения новых сущностей
 	lastPhotoIndex int
@@ -42,6 +44,14 @@ type JSONStorage struct {
 	photosModified bool
 	albumsModified bool
 	tagsModified   bool
+	rulesModified  bool
+
+	// Версия списка фотографий, увеличивается при каждом изменении под photosMutex
+	photosVersion uint64
+	// Индекс перцептивных хешей, перестраивается при изменении фотографий
+	phashIndex phashIndex
+	// Пространственный индекс координат съемки, перестраивается при изменении фотографий
+	geoIndex geoIndex
 }
 
 // NewJSONStorage создает новое хранилище с сохранением в JSON
@@ -57,6 +67,7 @@ func NewJSONStorage(dataDir string, saveInterval time.Duration) *JSONStorage {
 		photos:       make([]models.Photo, 0),
 		albums:       make([]models.Album, 0),
 		tags:         make([]models.Tag, 0),
+		rules:        make([]models.TagRule, 0),
 		lastSaveTime: time.Now(),
 	}
 }
@@ -73,6 +84,7 @@ func (s *JSONStorage) Save(entity models.Entity) error {
 	case models.Photo:
 		s.photosMutex.Lock()
 		s.photos = append(s.photos, e)
+		s.photosVersion++
 		s.photosModified = true
 		s.photosMutex.Unlock()
 		log.Printf("Добавлена фотография: ID=%d, Название=%s", e.ID, e.Name)
@@ -91,6 +103,13 @@ func (s *JSONStorage) Save(entity models.Entity) error {
 		s.tagsMutex.Unlock()
 		log.Printf("Добавлен тег: ID=%d, Название=%s", e.ID, e.Name)
 
+	case models.TagRule:
+		s.rulesMutex.Lock()
+		s.rules = append(s.rules, e)
+		s.rulesModified = true
+		s.rulesMutex.Unlock()
+		log.Printf("Добавлено правило: ID=%d, Название=%s", e.ID, e.Name)
+
 	default:
 		return fmt.Errorf("неизвестный тип сущности: %T", entity)
 	}
@@ -134,6 +153,7 @@ func (s *JSONStorage) SaveBatch(entities []models.Entity) error {
 	if len(photos) > 0 {
 		s.photosMutex.Lock()
 		s.photos = append(s.photos, photos...)
+		s.photosVersion++
 		s.photosModified = true
 		s.photosMutex.Unlock()
 		log.Printf("Добавлено %d фотографий", len(photos))
@@ -177,6 +197,7 @@ func (s *JSONStorage) Load() error {
 	photosPath := filepath.Join(s.dataDir, "photos.json")
 	s.photosMutex.Lock()
 	photosErr := s.loadFile(photosPath, &s.photos)
+	s.photosVersion++
 	s.photosMutex.Unlock()
 	if photosErr != nil {
 		return fmt.Errorf("ошибка при загрузке фотографий: %v", photosErr)
@@ -200,6 +221,15 @@ func (s *JSONStorage) Load() error {
 		return fmt.Errorf("ошибка при загрузке тегов: %v", tagsErr)
 	}
 
+	// Загружаем правила расстановки тегов
+	rulesPath := filepath.Join(s.dataDir, "rules.json")
+	s.rulesMutex.Lock()
+	rulesErr := s.loadFile(rulesPath, &s.rules)
+	s.rulesMutex.Unlock()
+	if rulesErr != nil {
+		return fmt.Errorf("ошибка при загрузке правил: %v", rulesErr)
+	}
+
 	// Устанавливаем индексы для отслеживания новых сущностей
 	s.photosMutex.Lock()
 	s.lastPhotoIndex = len(s.photos)
@@ -229,6 +259,7 @@ func (s *JSONStorage) Persist() error {
 	photosModified := s.photosModified
 	albumsModified := s.albumsModified
 	tagsModified := s.tagsModified
+	rulesModified := s.rulesModified
 	s.metaMutex.Unlock()
 
 	// Создаём функцию разблокировки
@@ -242,6 +273,9 @@ func (s *JSONStorage) Persist() error {
 		if tagsModified {
 			s.tagsMutex.Unlock()
 		}
+		if rulesModified {
+			s.rulesMutex.Unlock()
+		}
 	}
 
 	// Блокируем только нужные мьютексы
@@ -257,6 +291,10 @@ func (s *JSONStorage) Persist() error {
 		s.tagsMutex.Lock()
 	}
 
+	if rulesModified {
+		s.rulesMutex.Lock()
+	}
+
 	// Гарантируем разблокировку при выходе
 	defer unlock()
 
@@ -331,6 +369,18 @@ func (s *JSONStorage) persistData() error {
 		log.Printf("Сохранены теги (%d)", len(s.tags))
 	}
 
+	// Сохраняем правила расстановки тегов, если они изменились
+	if s.rulesModified {
+		rulesPath := filepath.Join(s.dataDir, "rules.json")
+		if err := s.saveFile(rulesPath, s.rules); err != nil {
+			return fmt.Errorf("ошибка при сохранении правил: %v", err)
+		}
+		s.metaMutex.Lock()
+		s.rulesModified = false
+		s.metaMutex.Unlock()
+		log.Printf("Сохранены правила (%d)", len(s.rules))
+	}
+
 	s.metaMutex.Lock()
 	s.dirtyFlag = false
 	s.lastSaveTime = time.Now()
@@ -362,6 +412,30 @@ func (s *JSONStorage) GetPhotos() []models.Photo {
 	return result
 }
 
+// updatePhotos применяет изменение к списку фотографий под блокировкой и помечает их измененными
+func (s *JSONStorage) updatePhotos(update func(photos []models.Photo) ([]models.Photo, error)) error {
+	s.photosMutex.Lock()
+	photos, err := update(s.photos)
+	if err != nil {
+		s.photosMutex.Unlock()
+		return err
+	}
+	s.photos = photos
+	s.photosVersion++
+	// После удаления индекс новых фотографий не должен выходить за пределы списка
+	if s.lastPhotoIndex > len(photos) {
+		s.lastPhotoIndex = len(photos)
+	}
+	s.photosMutex.Unlock()
+
+	s.metaMutex.Lock()
+	s.photosModified = true
+	s.dirtyFlag = true
+	s.metaMutex.Unlock()
+
+	return nil
+}
+
 // GetAlbums возвращает копию всех альбомов
 func (s *JSONStorage) GetAlbums() []models.Album {
 	s.albumsMutex.RLock()
@@ -382,6 +456,52 @@ func (s *JSONStorage) GetTags() []models.Tag {
 	return result
 }
 
+// GetTagRules возвращает копию всех правил расстановки тегов
+func (s *JSONStorage) GetTagRules() []models.TagRule {
+	s.rulesMutex.RLock()
+	defer s.rulesMutex.RUnlock()
+
+	result := make([]models.TagRule, len(s.rules))
+	copy(result, s.rules)
+	return result
+}
+
+// updateRules применяет изменение к списку правил под блокировкой и помечает правила как измененные
+func (s *JSONStorage) updateRules(update func(rules []models.TagRule) ([]models.TagRule, error)) error {
+	s.rulesMutex.Lock()
+	rules, err := update(s.rules)
+	if err != nil {
+		s.rulesMutex.Unlock()
+		return err
+	}
+	s.rules = rules
+	s.rulesMutex.Unlock()
+
+	s.metaMutex.Lock()
+	s.rulesModified = true
+	s.dirtyFlag = true
+	s.metaMutex.Unlock()
+
+	return nil
+}
+
+// updateTags применяет изменение к списку тегов под блокировкой
+func (s *JSONStorage) updateTags(update func(tags []models.Tag) ([]models.Tag, bool)) bool {
+	s.tagsMutex.Lock()
+	tags, changed := update(s.tags)
+	s.tags = tags
+	s.tagsMutex.Unlock()
+	if !changed {
+		return false
+	}
+
+	s.metaMutex.Lock()
+	s.tagsModified = true
+	s.dirtyFlag = true
+	s.metaMutex.Unlock()
+	return true
+}
+
 // GetNewPhotos возвращает новые фотографии с момента последнего вызова
 func (s *JSONStorage) GetNewPhotos() []models.Photo {
 	s.photosMutex.Lock()
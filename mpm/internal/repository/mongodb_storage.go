@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"mpm/internal/models"
@@ -14,12 +15,14 @@ import (
 type MongoDBStorage struct {
 	client       *mongodb.Client
 	albumStorage *mongodb.AlbumStorage
+	photoStorage *mongodb.PhotoStorage
 
 	// Кэш для совместимости с существующей архитектурой
-	albums []models.Album
-	// photos и tags пока не используются, но зарезервированы для будущего использования
-	_ []models.Photo // nolint:unused
-	_ []models.Tag   // nolint:unused
+	albums      []models.Album
+	photos      []models.Photo
+	photosMutex sync.RWMutex
+	// tags пока не используются, но зарезервированы для будущего использования
+	_ []models.Tag // nolint:unused
 }
 
 // NewMongoDBStorage создает новое MongoDB хранилище
@@ -31,7 +34,9 @@ func NewMongoDBStorage(client *mongodb.Client) (EntityStorage, error) {
 	storage := &MongoDBStorage{
 		client:       client,
 		albumStorage: mongodb.NewAlbumStorage(client),
+		photoStorage: mongodb.NewPhotoStorage(client),
 		albums:       make([]models.Album, 0),
+		photos:       make([]models.Photo, 0),
 	}
 
 	log.Println("MongoDB хранилище инициализировано")
@@ -52,6 +57,10 @@ func (s *MongoDBStorage) Save(entity models.Entity) error {
 	case models.Album:
 		_, err := s.albumStorage.Create(ctx, &e)
 		return err
+	case *models.Photo:
+		return s.savePhoto(ctx, *e)
+	case models.Photo:
+		return s.savePhoto(ctx, e)
 	default:
 		return fmt.Errorf("неподдерживаемый тип сущности: %T", entity)
 	}
@@ -88,10 +97,67 @@ func (s *MongoDBStorage) Load() error {
 		s.albums[i] = *album
 	}
 
-	log.Printf("Загружено из MongoDB: %d альбомов", len(s.albums))
+	photos, err := s.photoStorage.List(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки фотографий: %w", err)
+	}
+
+	s.photosMutex.Lock()
+	s.photos = photos
+	s.photosMutex.Unlock()
+
+	log.Printf("Загружено из MongoDB: %d альбомов, %d фотографий", len(s.albums), len(photos))
 	return nil
 }
 
+// GetPhotos возвращает копию кэша фотографий
+func (s *MongoDBStorage) GetPhotos() []models.Photo {
+	s.photosMutex.RLock()
+	defer s.photosMutex.RUnlock()
+
+	result := make([]models.Photo, len(s.photos))
+	copy(result, s.photos)
+	return result
+}
+
+// savePhoto сохраняет фотографию в MongoDB и обновляет кэш
+func (s *MongoDBStorage) savePhoto(ctx context.Context, photo models.Photo) error {
+	s.photosMutex.Lock()
+	defer s.photosMutex.Unlock()
+
+	if err := s.photoStorage.Save(ctx, &photo); err != nil {
+		return err
+	}
+	for i := range s.photos {
+		if s.photos[i].ID == photo.ID {
+			s.photos[i] = photo
+			return nil
+		}
+	}
+	s.photos = append(s.photos, photo)
+	return nil
+}
+
+// updatePhoto применяет изменение к актуальной копии фотографии под блокировкой и сохраняет ее
+func (s *MongoDBStorage) updatePhoto(ctx context.Context, id int, update func(photo *models.Photo)) error {
+	s.photosMutex.Lock()
+	defer s.photosMutex.Unlock()
+
+	for i := range s.photos {
+		if s.photos[i].ID != id {
+			continue
+		}
+		photo := s.photos[i]
+		update(&photo)
+		if err := s.photoStorage.Save(ctx, &photo); err != nil {
+			return err
+		}
+		s.photos[i] = photo
+		return nil
+	}
+	return fmt.Errorf("фотография с ID=%d не найдена", id)
+}
+
 // Persist сохраняет текущее состояние кэша в MongoDB
 func (s *MongoDBStorage) Persist() error {
 	// MongoDB сохраняет данные автоматически при каждой операции
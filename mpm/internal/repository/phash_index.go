@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"sync"
+
+	"mpm/internal/similarity"
+)
+
+// phashIndex кэш перцептивных хешей фотографий для поиска похожих изображений
+type phashIndex struct {
+	mu      sync.Mutex
+	version uint64
+	built   bool
+	entries []similarity.Entry
+}
+
+// PHashEntries возвращает перцептивные хеши фотографий не из корзины, срез нельзя изменять
+func (s *JSONStorage) PHashEntries() []similarity.Entry {
+	s.phashIndex.mu.Lock()
+	defer s.phashIndex.mu.Unlock()
+
+	s.photosMutex.RLock()
+	defer s.photosMutex.RUnlock()
+
+	if s.phashIndex.built && s.phashIndex.version == s.photosVersion {
+		return s.phashIndex.entries
+	}
+
+	entries := make([]similarity.Entry, 0, len(s.photos))
+	for _, photo := range s.photos {
+		if photo.PHash == "" || photo.DeletedAt != nil {
+			continue
+		}
+		hash, err := similarity.ParseHash(photo.PHash)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, similarity.Entry{ID: photo.ID, Hash: hash})
+	}
+
+	s.phashIndex.entries = entries
+	s.phashIndex.version = s.photosVersion
+	s.phashIndex.built = true
+	return entries
+}
@@ -2,12 +2,18 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"mpm/internal/geo"
 	"mpm/internal/models"
+	"mpm/internal/similarity"
 	"time"
 )
 
+// ErrAlbumInTrash возвращается при восстановлении фотографии, альбом которой находится в корзине
+var ErrAlbumInTrash = errors.New("альбом фотографии находится в корзине")
+
 // Repository объединяет все хранилища сущностей
 // Repository объединяет доступ к хранилищу сущностей
 type Repository struct {
@@ -63,19 +69,49 @@ func (r *Repository) LoadData() error {
 	return r.storage.Load()
 }
 
-// GetAllPhotos возвращает все фотографии
+// GetAllPhotos возвращает все фотографии, кроме находящихся в корзине
 func (r *Repository) GetAllPhotos() []models.Photo {
+	photos := []models.Photo{}
+	for _, photo := range r.allPhotos() {
+		if photo.DeletedAt == nil {
+			photos = append(photos, photo)
+		}
+	}
+	return photos
+}
+
+// allPhotos возвращает все фотографии вместе с находящимися в корзине
+func (r *Repository) allPhotos() []models.Photo {
 	// Проверяем тип хранилища
-	if jsonStorage, ok := r.storage.(*JSONStorage); ok {
-		return jsonStorage.GetPhotos()
+	switch storage := r.storage.(type) {
+	case *JSONStorage:
+		return storage.GetPhotos()
+	case *MongoDBStorage:
+		return storage.GetPhotos()
 	}
 
 	// В будущем здесь будут проверки других типов хранилищ
 	return []models.Photo{}
 }
 
-// GetAllAlbums возвращает все альбомы, исключая дубликаты и оставляя только один дефолтный альбом
+// GetAllAlbums возвращает все альбомы, кроме находящихся в корзине
 func (r *Repository) GetAllAlbums(ctx context.Context) ([]models.Album, error) {
+	albums, err := r.allAlbums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Album, 0, len(albums))
+	for _, album := range albums {
+		if album.DeletedAt == nil {
+			result = append(result, album)
+		}
+	}
+	return result, nil
+}
+
+// allAlbums возвращает все альбомы вместе с находящимися в корзине
+func (r *Repository) allAlbums(ctx context.Context) ([]models.Album, error) {
 	// Проверяем отмену контекста
 	select {
 	case <-ctx.Done():
@@ -224,7 +260,315 @@ func (r *Repository) FindPhotoByID(id int) (models.Photo, error) {
 	return models.Photo{}, fmt.Errorf("фотография с ID=%d не найдена", id)
 }
 
-// FindAlbumByID находит альбом по ID
+// AddPhoto добавляет новую фотографию с уникальным ID
+func (r *Repository) AddPhoto(ctx context.Context, photo models.Photo) (int, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return 0, fmt.Errorf("добавление фотографий не поддерживается текущим хранилищем")
+	}
+
+	err := jsonStorage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+		// Находим максимальный ID
+		maxID := 0
+		for _, p := range photos {
+			if p.ID > maxID {
+				maxID = p.ID
+			}
+		}
+
+		// Всегда генерируем новый ID
+		photo.ID = maxID + 1
+
+		// Устанавливаем дату создания
+		if photo.CreatedAt.IsZero() {
+			photo.CreatedAt = time.Now()
+		}
+
+		return append(photos, photo), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return photo.ID, jsonStorage.Persist()
+}
+
+// FindPhotos возвращает фотографии, удовлетворяющие фильтру
+func (r *Repository) FindPhotos(ctx context.Context, filter models.PhotoFilter) ([]models.Photo, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	result := []models.Photo{}
+	for _, photo := range r.allPhotos() {
+		if filter.Match(photo) {
+			result = append(result, photo)
+		}
+	}
+	return result, nil
+}
+
+// FindPhotosInBox возвращает фотографии внутри области по фильтру
+func (r *Repository) FindPhotosInBox(ctx context.Context, bbox geo.BBox, filter models.PhotoFilter) ([]models.Photo, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	var photos []models.Photo
+	switch storage := r.storage.(type) {
+	case *JSONStorage:
+		photos = storage.PhotosInBox(bbox)
+	case *MongoDBStorage:
+		var err error
+		if photos, err = storage.photoStorage.FindInBox(ctx, bbox); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("поиск по координатам не поддерживается текущим хранилищем")
+	}
+
+	result := []models.Photo{}
+	for _, photo := range photos {
+		if filter.Match(photo) {
+			result = append(result, photo)
+		}
+	}
+	return result, nil
+}
+
+// FindSimilarPhotos находит фотографии с похожим перцептивным хешем
+func (r *Repository) FindSimilarPhotos(ctx context.Context, hash uint64, threshold, excludeID int) ([]similarity.Match, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	switch storage := r.storage.(type) {
+	case *JSONStorage:
+		return similarity.Similar(storage.PHashEntries(), hash, threshold, excludeID), nil
+	case *MongoDBStorage:
+		return storage.photoStorage.FindSimilar(ctx, hash, threshold, excludeID)
+	}
+	return nil, fmt.Errorf("поиск похожих фотографий не поддерживается текущим хранилищем")
+}
+
+// FindPhotoClusters группирует визуально похожие фотографии
+func (r *Repository) FindPhotoClusters(ctx context.Context, threshold int) ([][]int, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	switch storage := r.storage.(type) {
+	case *JSONStorage:
+		return similarity.Clusters(storage.PHashEntries(), threshold), nil
+	case *MongoDBStorage:
+		return storage.photoStorage.Clusters(ctx, threshold)
+	}
+	return nil, fmt.Errorf("поиск похожих фотографий не поддерживается текущим хранилищем")
+}
+
+// UpdatePhoto обновляет данные фотографии по ID
+func (r *Repository) UpdatePhoto(ctx context.Context, id int, updatedPhoto models.Photo) error {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("обновление фотографий не поддерживается текущим хранилищем")
+	}
+
+	err := jsonStorage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+		for i, photo := range photos {
+			if photo.ID == id {
+				updatedPhoto.ID = id                     // Сохраняем ID
+				updatedPhoto.CreatedAt = photo.CreatedAt // Сохраняем дату создания
+				updatedPhoto.DeletedAt = photo.DeletedAt // В корзину и из нее фотографии перемещаются отдельно
+				photos[i] = updatedPhoto
+				return photos, nil
+			}
+		}
+		return nil, fmt.Errorf("фотография с ID=%d не найдена", id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return jsonStorage.Persist()
+}
+
+// UpdatePhotoFields изменяет актуальную копию фотографии под блокировкой, не затирая другие поля
+func (r *Repository) UpdatePhotoFields(ctx context.Context, id int, update func(photo *models.Photo)) error {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	switch storage := r.storage.(type) {
+	case *JSONStorage:
+		err := storage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+			for i := range photos {
+				if photos[i].ID == id {
+					update(&photos[i])
+					return photos, nil
+				}
+			}
+			return nil, fmt.Errorf("фотография с ID=%d не найдена", id)
+		})
+		if err != nil {
+			return err
+		}
+		return storage.Persist()
+	case *MongoDBStorage:
+		return storage.updatePhoto(ctx, id, update)
+	}
+	return fmt.Errorf("обновление фотографий не поддерживается текущим хранилищем")
+}
+
+// DeletePhoto перемещает фотографию в корзину. Окончательно фотография удаляется PurgePhoto.
+func (r *Repository) DeletePhoto(ctx context.Context, id int) error {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("удаление фотографий не поддерживается текущим хранилищем")
+	}
+
+	now := time.Now()
+	err := jsonStorage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+		for i, photo := range photos {
+			if photo.ID == id && photo.DeletedAt == nil {
+				photos[i].DeletedAt = &now
+				return photos, nil
+			}
+		}
+		return nil, fmt.Errorf("фотография с ID=%d не найдена", id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return jsonStorage.Persist()
+}
+
+// RestorePhoto возвращает фотографию из корзины
+func (r *Repository) RestorePhoto(ctx context.Context, id int) error {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("восстановление фотографий не поддерживается текущим хранилищем")
+	}
+
+	albums, err := r.allAlbums(ctx)
+	if err != nil {
+		return err
+	}
+	trashedAlbums := make(map[int]bool)
+	for _, album := range albums {
+		if album.DeletedAt != nil {
+			trashedAlbums[album.ID] = true
+		}
+	}
+
+	err = jsonStorage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+		for i, photo := range photos {
+			if photo.ID != id || photo.DeletedAt == nil {
+				continue
+			}
+			if photo.Album != nil && trashedAlbums[photo.Album.ID] {
+				return nil, fmt.Errorf("%w: сначала восстановите альбом ID=%d", ErrAlbumInTrash, photo.Album.ID)
+			}
+			photos[i].DeletedAt = nil
+			return photos, nil
+		}
+		return nil, fmt.Errorf("фотография с ID=%d не найдена в корзине", id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return jsonStorage.Persist()
+}
+
+// PurgePhoto окончательно удаляет фотографию из корзины. Файлы фотографии не удаляются.
+func (r *Repository) PurgePhoto(ctx context.Context, id int) error {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("удаление фотографий не поддерживается текущим хранилищем")
+	}
+
+	err := jsonStorage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+		// Создаем новый слайс без удаляемой фотографии
+		newPhotos := make([]models.Photo, 0, len(photos))
+		for _, photo := range photos {
+			if photo.ID != id || photo.DeletedAt == nil {
+				newPhotos = append(newPhotos, photo)
+			}
+		}
+		if len(newPhotos) == len(photos) {
+			return nil, fmt.Errorf("фотография с ID=%d не найдена в корзине", id)
+		}
+		return newPhotos, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return jsonStorage.Persist()
+}
+
+// FindAlbumByID находит альбом по ID, фотографии умного альбома подбираются по запросу
 func (r *Repository) FindAlbumByID(ctx context.Context, id int) (models.Album, error) {
 	// Проверяем отмену контекста
 	select {
@@ -240,9 +584,20 @@ func (r *Repository) FindAlbumByID(ctx context.Context, id int) (models.Album, e
 	}
 
 	for _, album := range albums {
-		if album.ID == id {
-			return album, nil
+		if album.ID != id {
+			continue
+		}
+		if album.IsSmart() {
+			photos, err := r.FindPhotos(ctx, *album.Filter)
+			if err != nil {
+				return models.Album{}, err
+			}
+			if err := models.SortPhotos(photos, "-taken_at"); err != nil {
+				return models.Album{}, err
+			}
+			album.Photos = photos
 		}
+		return album, nil
 	}
 	return models.Album{}, fmt.Errorf("альбом с ID=%d не найден", id)
 }
@@ -268,7 +623,8 @@ func (r *Repository) AddAlbum(ctx context.Context, album models.Album) (int, err
 		// Продолжаем выполнение
 	}
 
-	albums, err := r.GetAllAlbums(ctx)
+	// Альбомы в корзине учитываются, чтобы их ID не достались новым альбомам
+	albums, err := r.allAlbums(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -289,6 +645,11 @@ func (r *Repository) AddAlbum(ctx context.Context, album models.Album) (int, err
 		album.CreatedAt = time.Now()
 	}
 
+	// Фотографии умного альбома не хранятся, а подбираются по запросу
+	if album.IsSmart() {
+		album.Photos = nil
+	}
+
 	// Добавляем альбом к существующим
 	albums = append(albums, album)
 
@@ -313,8 +674,8 @@ func (r *Repository) UpdateAlbum(ctx context.Context, id int, updatedAlbum model
 		// Продолжаем выполнение
 	}
 
-	// Получаем текущий список альбомов
-	albums, err := r.GetAllAlbums(ctx)
+	// Получаем текущий список альбомов вместе с альбомами в корзине, чтобы не потерять их при сохранении
+	albums, err := r.allAlbums(ctx)
 	if err != nil {
 		return err // Исправлено: возвращаем только ошибку
 	}
@@ -324,9 +685,13 @@ func (r *Repository) UpdateAlbum(ctx context.Context, id int, updatedAlbum model
 
 	// Обновляем данные альбома
 	for i, album := range albums {
-		if album.ID == id {
+		if album.ID == id && album.DeletedAt == nil {
 			updatedAlbum.ID = id                     // Сохраняем ID
 			updatedAlbum.CreatedAt = album.CreatedAt // Сохраняем дату создания
+			updatedAlbum.DeletedAt = nil
+			if updatedAlbum.IsSmart() {
+				updatedAlbum.Photos = nil
+			}
 			albums[i] = updatedAlbum
 			found = true
 			break
@@ -348,7 +713,7 @@ func (r *Repository) UpdateAlbum(ctx context.Context, id int, updatedAlbum model
 	return fmt.Errorf("обновление альбомов не поддерживается текущим хранилищем")
 }
 
-// DeleteAlbum Удалить альбом по ID
+// DeleteAlbum перемещает альбом и его фотографии в корзину
 func (r *Repository) DeleteAlbum(ctx context.Context, id int) error {
 	// Проверяем отмену контекста
 	select {
@@ -364,30 +729,139 @@ func (r *Repository) DeleteAlbum(ctx context.Context, id int) error {
 		return err
 	}
 
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("удаление альбомов не поддерживается текущим хранилищем")
+	}
+
 	// Получаем текущий список альбомов
-	albums, err := r.GetAllAlbums(ctx)
+	albums, err := r.allAlbums(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i, album := range albums {
+		if album.ID == id {
+			albums[i].DeletedAt = &now
+		}
+	}
+
+	err = jsonStorage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+		for i, photo := range photos {
+			if photo.Album != nil && photo.Album.ID == id && photo.DeletedAt == nil {
+				photos[i].DeletedAt = &now
+			}
+		}
+		return photos, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Обновляем кэш альбомов и флаги
+	jsonStorage.albums = albums
+	jsonStorage.albumsModified = true
+	jsonStorage.dirtyFlag = true
+
+	// Сохраняем изменения на диск
+	return jsonStorage.Persist()
+}
+
+// FindDeletedAlbums возвращает альбомы, находящиеся в корзине
+func (r *Repository) FindDeletedAlbums(ctx context.Context) ([]models.Album, error) {
+	albums, err := r.allAlbums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.Album{}
+	for _, album := range albums {
+		if album.DeletedAt != nil {
+			result = append(result, album)
+		}
+	}
+	return result, nil
+}
+
+// RestoreAlbum возвращает альбом из корзины вместе с фотографиями, удаленными вместе с ним
+func (r *Repository) RestoreAlbum(ctx context.Context, id int) error {
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("восстановление альбомов не поддерживается текущим хранилищем")
+	}
+
+	albums, err := r.allAlbums(ctx)
+	if err != nil {
+		return err
+	}
+
+	var deletedAt *time.Time
+	for i, album := range albums {
+		if album.ID == id && album.DeletedAt != nil {
+			deletedAt = album.DeletedAt
+			albums[i].DeletedAt = nil
+		}
+	}
+	if deletedAt == nil {
+		return fmt.Errorf("альбом с ID=%d не найден в корзине", id)
+	}
+
+	err = jsonStorage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+		for i, photo := range photos {
+			if photo.Album != nil && photo.Album.ID == id && photo.DeletedAt != nil && photo.DeletedAt.Equal(*deletedAt) {
+				photos[i].DeletedAt = nil
+			}
+		}
+		return photos, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	jsonStorage.albums = albums
+	jsonStorage.albumsModified = true
+	jsonStorage.dirtyFlag = true
+	return jsonStorage.Persist()
+}
+
+// PurgeAlbum окончательно удаляет альбом из корзины вместе с фотографиями
+func (r *Repository) PurgeAlbum(ctx context.Context, id int) error {
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("удаление альбомов не поддерживается текущим хранилищем")
+	}
+
+	albums, err := r.allAlbums(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Создаем новый слайс без удаляемого альбома
 	newAlbums := make([]models.Album, 0, len(albums))
 	for _, album := range albums {
-		if album.ID != id {
+		if album.ID != id || album.DeletedAt == nil {
 			newAlbums = append(newAlbums, album)
 		}
 	}
+	if len(newAlbums) == len(albums) {
+		return fmt.Errorf("альбом с ID=%d не найден в корзине", id)
+	}
 
-	// Обновляем состояние в JSON-хранилище
-	if jsonStorage, ok := r.storage.(*JSONStorage); ok {
-		// Обновляем кэш альбомов и флаги
-		jsonStorage.albums = newAlbums
-		jsonStorage.albumsModified = true
-		jsonStorage.dirtyFlag = true
-
-		// Сохраняем изменения на диск
-		return jsonStorage.Persist()
+	err = jsonStorage.updatePhotos(func(photos []models.Photo) ([]models.Photo, error) {
+		newPhotos := make([]models.Photo, 0, len(photos))
+		for _, photo := range photos {
+			if photo.Album == nil || photo.Album.ID != id {
+				newPhotos = append(newPhotos, photo)
+			}
+		}
+		return newPhotos, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return fmt.Errorf("удаление альбомов не поддерживается текущим хранилищем")
+	jsonStorage.albums = newAlbums
+	jsonStorage.albumsModified = true
+	jsonStorage.dirtyFlag = true
+	return jsonStorage.Persist()
 }
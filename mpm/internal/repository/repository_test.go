@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"mpm/internal/geo"
 	"mpm/internal/models"
 	"testing"
 	"time"
@@ -397,6 +399,154 @@ func TestRepository_AddAlbum(t *testing.T) {
 	}
 }
 
+func TestRepository_AddPhoto(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	_ = repo.SaveEntity(models.Photo{ID: 5, Name: "existing.jpg"})
+
+	newID, err := repo.AddPhoto(ctx, models.Photo{ID: 1, Name: "new.jpg"})
+	if err != nil {
+		t.Errorf("AddPhoto() error = %v", err)
+	}
+
+	if newID != 6 {
+		t.Errorf("Expected ID 6, got %d", newID)
+	}
+
+	foundPhoto, err := repo.FindPhotoByID(newID)
+	if err != nil {
+		t.Errorf("Failed to find added photo: %v", err)
+	}
+
+	if foundPhoto.CreatedAt.IsZero() {
+		t.Error("Expected CreatedAt to be set")
+	}
+
+	// Фотография должна сохраниться на диск
+	reloaded := NewRepository("json", tempDir, time.Hour)
+	if _, err := reloaded.FindPhotoByID(newID); err != nil {
+		t.Errorf("Expected photo to be persisted: %v", err)
+	}
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repo.AddPhoto(canceledCtx, models.Photo{Name: "cancelled.jpg"})
+	if err == nil {
+		t.Error("Expected error when context is cancelled")
+	}
+}
+
+func TestRepository_FindPhotos(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	_ = repo.SaveEntity(models.Photo{ID: 1, Name: "a.jpg", Album: &models.Album{ID: 1}, Tags: []string{"море"}})
+	_ = repo.SaveEntity(models.Photo{ID: 2, Name: "b.jpg", Album: &models.Album{ID: 2}, Tags: []string{"море"}})
+	_ = repo.SaveEntity(models.Photo{ID: 3, Name: "c.jpg", Album: &models.Album{ID: 1}})
+
+	albumID := 1
+	photos, err := repo.FindPhotos(ctx, models.PhotoFilter{AlbumID: &albumID, Tags: []string{"море"}})
+	if err != nil {
+		t.Errorf("FindPhotos() error = %v", err)
+	}
+	if len(photos) != 1 || photos[0].ID != 1 {
+		t.Errorf("Expected only photo 1, got %v", photos)
+	}
+
+	all, _ := repo.FindPhotos(ctx, models.PhotoFilter{})
+	if len(all) != 3 {
+		t.Errorf("Expected 3 photos, got %d", len(all))
+	}
+}
+
+func TestRepository_UpdatePhoto(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	createdAt := time.Now().Add(-time.Hour)
+	_ = repo.SaveEntity(models.Photo{ID: 1, Name: "old.jpg", CreatedAt: createdAt})
+
+	err := repo.UpdatePhoto(ctx, 1, models.Photo{Name: "new.jpg"})
+	if err != nil {
+		t.Errorf("UpdatePhoto() error = %v", err)
+	}
+
+	photo, _ := repo.FindPhotoByID(1)
+	if photo.Name != "new.jpg" {
+		t.Errorf("Expected name new.jpg, got %s", photo.Name)
+	}
+	if !photo.CreatedAt.Equal(createdAt) {
+		t.Error("Expected CreatedAt to be preserved")
+	}
+
+	err = repo.UpdatePhoto(ctx, 999, models.Photo{Name: "missing.jpg"})
+	if err == nil {
+		t.Error("Expected error when updating non-existent photo")
+	}
+}
+
+func TestRepository_UpdatePhotoFields(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	_ = repo.SaveEntity(models.Photo{ID: 1, Name: "old.jpg"})
+
+	// Снимок сделан до переименования и удаления в корзину
+	stale, _ := repo.FindPhotoByID(1)
+	_ = repo.UpdatePhoto(ctx, 1, models.Photo{Name: "new.jpg"})
+	_ = repo.DeletePhoto(ctx, 1)
+
+	err := repo.UpdatePhotoFields(ctx, stale.ID, func(photo *models.Photo) {
+		photo.PHash = "00000000000000ff"
+	})
+	if err != nil {
+		t.Errorf("UpdatePhotoFields() error = %v", err)
+	}
+
+	photos, _ := repo.FindPhotos(ctx, models.PhotoFilter{Trashed: true})
+	if len(photos) != 1 {
+		t.Fatalf("Expected photo to stay in trash, got %d photos", len(photos))
+	}
+	if photos[0].Name != "new.jpg" || photos[0].PHash != "00000000000000ff" {
+		t.Errorf("Expected only PHash to change, got name %s, phash %s", photos[0].Name, photos[0].PHash)
+	}
+
+	err = repo.UpdatePhotoFields(ctx, 999, func(photo *models.Photo) {})
+	if err == nil {
+		t.Error("Expected error when updating non-existent photo")
+	}
+}
+
+func TestRepository_DeletePhoto(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	_ = repo.SaveEntity(models.Photo{ID: 1, Name: "a.jpg"})
+	_ = repo.SaveEntity(models.Photo{ID: 2, Name: "b.jpg"})
+
+	if err := repo.DeletePhoto(ctx, 1); err != nil {
+		t.Errorf("DeletePhoto() error = %v", err)
+	}
+
+	if _, err := repo.FindPhotoByID(1); err == nil {
+		t.Error("Expected photo to be deleted")
+	}
+	if len(repo.GetAllPhotos()) != 1 {
+		t.Errorf("Expected 1 photo left, got %d", len(repo.GetAllPhotos()))
+	}
+
+	if err := repo.DeletePhoto(ctx, 1); err == nil {
+		t.Error("Expected error when deleting non-existent photo")
+	}
+}
+
 func TestRepository_UpdateAlbum(t *testing.T) {
 	tempDir := t.TempDir()
 	repo := NewRepository("json", tempDir, time.Hour)
@@ -478,6 +628,334 @@ func TestRepository_DeleteAlbum(t *testing.T) {
 	}
 }
 
+func TestRepository_Trash(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	album := models.Album{ID: 1, Name: "Trip"}
+	_ = repo.SaveEntity(album)
+	_ = repo.SaveEntity(models.Album{ID: 2, Name: "Other"})
+	_ = repo.SaveEntity(models.Photo{ID: 1, Name: "a.jpg", Album: &album})
+	_ = repo.SaveEntity(models.Photo{ID: 2, Name: "b.jpg", Album: &album})
+	_ = repo.SaveEntity(models.Photo{ID: 3, Name: "c.jpg", Album: &models.Album{ID: 2}})
+
+	// Фотография удалена из альбома раньше самого альбома
+	if err := repo.DeletePhoto(ctx, 2); err != nil {
+		t.Fatalf("DeletePhoto() error = %v", err)
+	}
+	if err := repo.DeleteAlbum(ctx, 1); err != nil {
+		t.Fatalf("DeleteAlbum() error = %v", err)
+	}
+
+	albums, _ := repo.GetAllAlbums(ctx)
+	if len(albums) != 1 || albums[0].ID != 2 {
+		t.Errorf("Expected only album 2 outside trash, got %v", albums)
+	}
+	deleted, err := repo.FindDeletedAlbums(ctx)
+	if err != nil || len(deleted) != 1 || deleted[0].DeletedAt == nil {
+		t.Fatalf("FindDeletedAlbums() = %v, %v", deleted, err)
+	}
+	trashed, _ := repo.FindPhotos(ctx, models.PhotoFilter{Trashed: true})
+	if len(trashed) != 2 {
+		t.Errorf("Expected 2 photos in trash, got %d", len(trashed))
+	}
+	if _, err := repo.FindPhotoByID(1); err == nil {
+		t.Error("Expected photo of deleted album to be hidden")
+	}
+
+	// ID альбома в корзине не достается новому альбому
+	id, err := repo.AddAlbum(ctx, models.Album{Name: "New"})
+	if err != nil || id != 3 {
+		t.Errorf("AddAlbum() = %d, %v, want 3", id, err)
+	}
+	if err := repo.UpdateAlbum(ctx, 1, models.Album{Name: "Renamed"}); err == nil {
+		t.Error("Expected error when updating album in trash")
+	}
+
+	if err := repo.RestorePhoto(ctx, 1); !errors.Is(err, ErrAlbumInTrash) {
+		t.Errorf("RestorePhoto() error = %v, want ErrAlbumInTrash", err)
+	}
+	if err := repo.RestoreAlbum(ctx, 1); err != nil {
+		t.Fatalf("RestoreAlbum() error = %v", err)
+	}
+	if _, err := repo.FindPhotoByID(1); err != nil {
+		t.Error("Expected photo deleted with album to be restored")
+	}
+	if _, err := repo.FindPhotoByID(2); err == nil {
+		t.Error("Expected photo deleted before album to stay in trash")
+	}
+	if err := repo.RestoreAlbum(ctx, 1); err == nil {
+		t.Error("Expected error when restoring album not in trash")
+	}
+
+	if err := repo.PurgePhoto(ctx, 1); err == nil {
+		t.Error("Expected error when purging photo not in trash")
+	}
+	if err := repo.PurgePhoto(ctx, 2); err != nil {
+		t.Errorf("PurgePhoto() error = %v", err)
+	}
+	if trashed, _ := repo.FindPhotos(ctx, models.PhotoFilter{Trashed: true}); len(trashed) != 0 {
+		t.Errorf("Expected empty trash, got %d photos", len(trashed))
+	}
+
+	if err := repo.DeleteAlbum(ctx, 1); err != nil {
+		t.Fatalf("DeleteAlbum() error = %v", err)
+	}
+	if err := repo.PurgeAlbum(ctx, 1); err != nil {
+		t.Fatalf("PurgeAlbum() error = %v", err)
+	}
+	if deleted, _ := repo.FindDeletedAlbums(ctx); len(deleted) != 0 {
+		t.Errorf("Expected no albums in trash, got %d", len(deleted))
+	}
+	if photos := repo.allPhotos(); len(photos) != 1 || photos[0].ID != 3 {
+		t.Errorf("Expected only photo 3 to remain, got %v", photos)
+	}
+}
+
+func TestRepository_FindPhotosInBox(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	located := func(id, albumID int, lat, lon string) models.Photo {
+		return models.Photo{
+			ID:    id,
+			Album: &models.Album{ID: albumID},
+			Metadata: []models.Metadata{
+				{Key: models.MetadataGPSLatitude, Value: lat},
+				{Key: models.MetadataGPSLongitude, Value: lon},
+			},
+		}
+	}
+	_ = repo.SaveEntity(located(1, 1, "55.755800", "37.617300"))
+	_ = repo.SaveEntity(located(2, 2, "55.752000", "37.617500"))
+	_ = repo.SaveEntity(located(3, 1, "59.934300", "30.335100"))
+	_ = repo.SaveEntity(located(4, 1, "abc", "37.6"))
+	_ = repo.SaveEntity(models.Photo{ID: 5, Album: &models.Album{ID: 1}})
+
+	moscow, _ := geo.ParseBBox("37.3,55.5,37.9,55.9")
+
+	photos, err := repo.FindPhotosInBox(ctx, moscow, models.PhotoFilter{})
+	if err != nil {
+		t.Fatalf("FindPhotosInBox() error = %v", err)
+	}
+	if len(photos) != 2 {
+		t.Errorf("Expected 2 photos in Moscow, got %d", len(photos))
+	}
+
+	albumID := 1
+	photos, _ = repo.FindPhotosInBox(ctx, moscow, models.PhotoFilter{AlbumID: &albumID})
+	if len(photos) != 1 || photos[0].ID != 1 {
+		t.Errorf("Expected photo 1 from album 1, got %v", photos)
+	}
+
+	photos, _ = repo.FindPhotosInBox(ctx, geo.World, models.PhotoFilter{})
+	if len(photos) != 3 {
+		t.Errorf("Expected 3 photos with valid coordinates, got %d", len(photos))
+	}
+
+	t.Run("Индекс обновляется после изменений", func(t *testing.T) {
+		if err := repo.DeletePhoto(ctx, 1); err != nil {
+			t.Fatalf("DeletePhoto() error = %v", err)
+		}
+		if err := repo.UpdatePhoto(ctx, 3, located(3, 1, "55.8", "37.5")); err != nil {
+			t.Fatalf("UpdatePhoto() error = %v", err)
+		}
+
+		photos, _ := repo.FindPhotosInBox(ctx, moscow, models.PhotoFilter{})
+		ids := []int{}
+		for _, photo := range photos {
+			ids = append(ids, photo.ID)
+		}
+		if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+			t.Errorf("Expected photos [2 3] after update, got %v", ids)
+		}
+	})
+
+	t.Run("Отмененный контекст", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := repo.FindPhotosInBox(cancelled, moscow, models.PhotoFilter{}); err == nil {
+			t.Error("Expected error for cancelled context")
+		}
+	})
+}
+
+func TestRepository_TagRules(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	rule := models.TagRule{
+		Name:       "Студия",
+		Conditions: []models.RuleCondition{{Field: models.RuleFieldName, Operator: models.RuleOpPrefix, Value: "DSC_"}},
+		Tags:       []string{"студия"},
+	}
+
+	t.Run("Добавление и поиск", func(t *testing.T) {
+		id, err := repo.AddTagRule(ctx, rule)
+		if err != nil {
+			t.Fatalf("AddTagRule() error = %v", err)
+		}
+		second, _ := repo.AddTagRule(ctx, rule)
+		if id != 1 || second != 2 {
+			t.Errorf("Expected IDs 1 and 2, got %d and %d", id, second)
+		}
+
+		found, err := repo.FindTagRuleByID(ctx, id)
+		if err != nil {
+			t.Fatalf("FindTagRuleByID() error = %v", err)
+		}
+		if found.Name != "Студия" || found.CreatedAt.IsZero() {
+			t.Errorf("Unexpected rule: %+v", found)
+		}
+	})
+
+	t.Run("Обновление", func(t *testing.T) {
+		updated := rule
+		updated.Disabled = true
+		if err := repo.UpdateTagRule(ctx, 1, updated); err != nil {
+			t.Fatalf("UpdateTagRule() error = %v", err)
+		}
+
+		found, _ := repo.FindTagRuleByID(ctx, 1)
+		if !found.Disabled || found.UpdatedAt == nil || found.CreatedAt.IsZero() {
+			t.Errorf("Unexpected rule after update: %+v", found)
+		}
+		if err := repo.UpdateTagRule(ctx, 99, rule); err == nil {
+			t.Error("Expected error for missing rule")
+		}
+	})
+
+	t.Run("Удаление", func(t *testing.T) {
+		if err := repo.DeleteTagRule(ctx, 2); err != nil {
+			t.Fatalf("DeleteTagRule() error = %v", err)
+		}
+		if _, err := repo.FindTagRuleByID(ctx, 2); err == nil {
+			t.Error("Expected error for deleted rule")
+		}
+		if err := repo.DeleteTagRule(ctx, 2); err == nil {
+			t.Error("Expected error for missing rule")
+		}
+	})
+
+	t.Run("Правила сохраняются на диск", func(t *testing.T) {
+		reloaded := NewRepository("json", tempDir, time.Hour)
+		rules, err := reloaded.GetTagRules(ctx)
+		if err != nil {
+			t.Fatalf("GetTagRules() error = %v", err)
+		}
+		if len(rules) != 1 || rules[0].ID != 1 || !rules[0].Disabled {
+			t.Errorf("Unexpected rules after reload: %+v", rules)
+		}
+	})
+
+	t.Run("Справочник тегов", func(t *testing.T) {
+		_ = repo.SaveEntity(models.Tag{ID: 5, Name: "Портрет"})
+		if err := repo.EnsureTags(ctx, []string{"портрет", "студия", "Студия"}); err != nil {
+			t.Fatalf("EnsureTags() error = %v", err)
+		}
+
+		tags := repo.GetAllTags()
+		if len(tags) != 2 || tags[1].Name != "студия" || tags[1].ID != 6 {
+			t.Errorf("Expected tag студия with ID=6 to be added, got %+v", tags)
+		}
+	})
+}
+
+func TestRepository_SmartAlbums(t *testing.T) {
+	tempDir := t.TempDir()
+	repo := NewRepository("json", tempDir, time.Hour)
+	ctx := context.Background()
+
+	at := func(month int) time.Time { return time.Date(2024, time.Month(month), 1, 12, 0, 0, 0, time.UTC) }
+	_ = repo.SaveEntity(models.Photo{ID: 1, Name: "a.jpg", Album: &models.Album{ID: 1}, Tags: []string{"портрет"}, CreatedAt: at(3)})
+	_ = repo.SaveEntity(models.Photo{ID: 2, Name: "b.jpg", Album: &models.Album{ID: 1}, Tags: []string{"пейзаж"}, CreatedAt: at(4)})
+	_ = repo.SaveEntity(models.Photo{ID: 3, Name: "c.jpg", Album: &models.Album{ID: 2}, Tags: []string{"Портрет"}, CreatedAt: at(5)})
+	_ = repo.SaveEntity(models.Photo{ID: 4, Name: "d.jpg", Album: &models.Album{ID: 2}, Tags: []string{"портрет"},
+		CreatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)})
+
+	from, to := at(1), time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC)
+	smart := models.Album{
+		Name:   "Портреты 2024",
+		Filter: &models.PhotoFilter{Tags: []string{"портрет"}, From: &from, To: &to},
+		Photos: []models.Photo{{ID: 2}},
+	}
+
+	photoIDs := func(album models.Album) []int {
+		ids := []int{}
+		for _, photo := range album.Photos {
+			ids = append(ids, photo.ID)
+		}
+		return ids
+	}
+
+	id, err := repo.AddAlbum(ctx, smart)
+	if err != nil {
+		t.Fatalf("AddAlbum() error = %v", err)
+	}
+
+	t.Run("Фотографии подбираются по запросу", func(t *testing.T) {
+		album, err := repo.FindAlbumByID(ctx, id)
+		if err != nil {
+			t.Fatalf("FindAlbumByID() error = %v", err)
+		}
+		if !album.IsSmart() {
+			t.Error("Expected album to be marked as smart")
+		}
+		if ids := photoIDs(album); len(ids) != 2 || ids[0] != 3 || ids[1] != 1 {
+			t.Errorf("Expected photos [3 1], got %v", ids)
+		}
+	})
+
+	t.Run("Новые и удаленные фотографии", func(t *testing.T) {
+		_ = repo.SaveEntity(models.Photo{ID: 5, Name: "e.jpg", Album: &models.Album{ID: 1}, Tags: []string{"портрет"}, CreatedAt: at(6)})
+		if err := repo.DeletePhoto(ctx, 3); err != nil {
+			t.Fatalf("DeletePhoto() error = %v", err)
+		}
+
+		album, _ := repo.FindAlbumByID(ctx, id)
+		if ids := photoIDs(album); len(ids) != 2 || ids[0] != 5 || ids[1] != 1 {
+			t.Errorf("Expected photos [5 1], got %v", ids)
+		}
+	})
+
+	t.Run("Запрос сохраняется на диск", func(t *testing.T) {
+		reloaded := NewRepository("json", tempDir, time.Hour)
+		album, err := reloaded.FindAlbumByID(ctx, id)
+		if err != nil {
+			t.Fatalf("FindAlbumByID() error = %v", err)
+		}
+		if album.Filter == nil || len(album.Filter.Tags) != 1 || album.Filter.From == nil {
+			t.Errorf("Unexpected filter after reload: %+v", album.Filter)
+		}
+		if ids := photoIDs(album); len(ids) != 2 {
+			t.Errorf("Expected 2 photos after reload, got %v", ids)
+		}
+	})
+
+	t.Run("Обычный альбом становится умным и обратно", func(t *testing.T) {
+		regular, _ := repo.AddAlbum(ctx, models.Album{Name: "Обычный"})
+		albumID := 1
+		if err := repo.UpdateAlbum(ctx, regular, models.Album{Name: "Из альбома 1", Filter: &models.PhotoFilter{AlbumID: &albumID}}); err != nil {
+			t.Fatalf("UpdateAlbum() error = %v", err)
+		}
+		album, _ := repo.FindAlbumByID(ctx, regular)
+		if !album.IsSmart() || len(album.Photos) != 3 {
+			t.Errorf("Expected smart album with 3 photos, got smart=%v photos=%v", album.IsSmart(), photoIDs(album))
+		}
+
+		if err := repo.UpdateAlbum(ctx, regular, models.Album{Name: "Обычный"}); err != nil {
+			t.Fatalf("UpdateAlbum() error = %v", err)
+		}
+		album, _ = repo.FindAlbumByID(ctx, regular)
+		if album.IsSmart() || len(album.Photos) != 0 {
+			t.Errorf("Expected regular album without photos, got smart=%v photos=%v", album.IsSmart(), photoIDs(album))
+		}
+	})
+}
+
 func TestRepository_PersistData(t *testing.T) {
 	tempDir := t.TempDir()
 	repo := NewRepository("json", tempDir, time.Hour)
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"mpm/internal/models"
+	"strings"
+	"time"
+)
+
+// GetTagRules возвращает все правила автоматической расстановки тегов
+func (r *Repository) GetTagRules(ctx context.Context) ([]models.TagRule, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return nil, fmt.Errorf("правила расстановки тегов не поддерживаются текущим хранилищем")
+	}
+	return jsonStorage.GetTagRules(), nil
+}
+
+// FindTagRuleByID находит правило расстановки тегов по ID
+func (r *Repository) FindTagRuleByID(ctx context.Context, id int) (models.TagRule, error) {
+	rules, err := r.GetTagRules(ctx)
+	if err != nil {
+		return models.TagRule{}, err
+	}
+
+	for _, rule := range rules {
+		if rule.ID == id {
+			return rule, nil
+		}
+	}
+	return models.TagRule{}, fmt.Errorf("правило с ID=%d не найдено", id)
+}
+
+// AddTagRule добавляет новое правило с уникальным ID
+func (r *Repository) AddTagRule(ctx context.Context, rule models.TagRule) (int, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return 0, fmt.Errorf("правила расстановки тегов не поддерживаются текущим хранилищем")
+	}
+
+	err := jsonStorage.updateRules(func(rules []models.TagRule) ([]models.TagRule, error) {
+		maxID := 0
+		for _, existing := range rules {
+			if existing.ID > maxID {
+				maxID = existing.ID
+			}
+		}
+
+		// Всегда генерируем новый ID
+		rule.ID = maxID + 1
+		if rule.CreatedAt.IsZero() {
+			rule.CreatedAt = time.Now()
+		}
+		rule.UpdatedAt = nil
+		return append(rules, rule), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rule.ID, jsonStorage.Persist()
+}
+
+// UpdateTagRule обновляет правило по ID
+func (r *Repository) UpdateTagRule(ctx context.Context, id int, updatedRule models.TagRule) error {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("правила расстановки тегов не поддерживаются текущим хранилищем")
+	}
+
+	err := jsonStorage.updateRules(func(rules []models.TagRule) ([]models.TagRule, error) {
+		for i, rule := range rules {
+			if rule.ID == id {
+				now := time.Now()
+				updatedRule.ID = id                    // Сохраняем ID
+				updatedRule.CreatedAt = rule.CreatedAt // Сохраняем дату создания
+				updatedRule.UpdatedAt = &now
+				rules[i] = updatedRule
+				return rules, nil
+			}
+		}
+		return nil, fmt.Errorf("правило с ID=%d не найдено", id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return jsonStorage.Persist()
+}
+
+// DeleteTagRule удаляет правило по ID. Теги, уже добавленные правилом, остаются у фотографий.
+func (r *Repository) DeleteTagRule(ctx context.Context, id int) error {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("правила расстановки тегов не поддерживаются текущим хранилищем")
+	}
+
+	err := jsonStorage.updateRules(func(rules []models.TagRule) ([]models.TagRule, error) {
+		for i, rule := range rules {
+			if rule.ID == id {
+				return append(rules[:i], rules[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("правило с ID=%d не найдено", id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return jsonStorage.Persist()
+}
+
+// EnsureTags добавляет в справочник тегов отсутствующие в нем названия
+func (r *Repository) EnsureTags(ctx context.Context, names []string) error {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	jsonStorage, ok := r.storage.(*JSONStorage)
+	if !ok {
+		return fmt.Errorf("справочник тегов не поддерживается текущим хранилищем")
+	}
+
+	added := jsonStorage.updateTags(func(tags []models.Tag) ([]models.Tag, bool) {
+		known := make(map[string]bool, len(tags))
+		maxID := 0
+		for _, tag := range tags {
+			known[strings.ToLower(tag.Name)] = true
+			maxID = max(maxID, tag.ID)
+		}
+
+		now := time.Now()
+		added := false
+		for _, name := range names {
+			if known[strings.ToLower(name)] {
+				continue
+			}
+			maxID++
+			known[strings.ToLower(name)] = true
+			tags = append(tags, models.Tag{ID: maxID, Name: name, CreatedAt: now})
+			added = true
+		}
+		return tags, added
+	})
+	if !added {
+		return nil
+	}
+
+	return jsonStorage.Persist()
+}
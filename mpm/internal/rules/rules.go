@@ -0,0 +1,246 @@
+// Package rules проверяет правила автоматической расстановки тегов по полям и метаданным фотографии
+package rules
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mpm/internal/geo"
+	"mpm/internal/models"
+)
+
+// matcher проверяет одно условие правила
+type matcher func(photo models.Photo) bool
+
+type compiledRule struct {
+	rule       models.TagRule
+	conditions []matcher
+}
+
+// Engine набор проверенных правил. Не изменяется после создания и безопасен для одновременного использования.
+type Engine struct {
+	rules []compiledRule
+}
+
+// NewEngine проверяет правила и подготавливает их к применению. Отключенные правила пропускаются.
+func NewEngine(rules []models.TagRule) (*Engine, error) {
+	e := &Engine{}
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		compiled, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("правило %q: %w", rule.Name, err)
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// Validate проверяет правило перед сохранением
+func Validate(rule models.TagRule) error {
+	_, err := compile(rule)
+	return err
+}
+
+// Len возвращает количество действующих правил
+func (e *Engine) Len() int {
+	return len(e.rules)
+}
+
+// Tags возвращает теги сработавших правил, которых еще нет у фотографии, в порядке правил
+func (e *Engine) Tags(photo models.Photo) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, rule := range e.rules {
+		if !rule.match(photo) {
+			continue
+		}
+		for _, tag := range rule.rule.Tags {
+			key := strings.ToLower(tag)
+			if photo.HasTag(tag) || seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+func (r compiledRule) match(photo models.Photo) bool {
+	for _, condition := range r.conditions {
+		if !condition(photo) {
+			return false
+		}
+	}
+	return true
+}
+
+func compile(rule models.TagRule) (compiledRule, error) {
+	if strings.TrimSpace(rule.Name) == "" {
+		return compiledRule{}, fmt.Errorf("не указано название правила")
+	}
+	if len(rule.Conditions) == 0 {
+		return compiledRule{}, fmt.Errorf("правило должно содержать хотя бы одно условие")
+	}
+	if len(rule.Tags) == 0 {
+		return compiledRule{}, fmt.Errorf("правило должно добавлять хотя бы один тег")
+	}
+	for _, tag := range rule.Tags {
+		if !models.ValidTag(tag) {
+			return compiledRule{}, fmt.Errorf("тег %q пустой или содержит пробелы", tag)
+		}
+	}
+
+	compiled := compiledRule{rule: rule}
+	for i, condition := range rule.Conditions {
+		m, err := compileCondition(condition)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("условие %d: %w", i+1, err)
+		}
+		compiled.conditions = append(compiled.conditions, m)
+	}
+	return compiled, nil
+}
+
+func compileCondition(c models.RuleCondition) (matcher, error) {
+	switch c.Field {
+	case models.RuleFieldName:
+		return compileString(c, func(p models.Photo) (string, bool) { return p.Name, true })
+
+	case models.RuleFieldMedia:
+		return compileString(c, func(p models.Photo) (string, bool) { return p.Kind(), true })
+
+	case models.RuleFieldMetadata:
+		if c.Key == "" {
+			return nil, fmt.Errorf("не указан ключ метаданных key")
+		}
+		if c.Operator == models.RuleOpExists {
+			return func(p models.Photo) bool {
+				_, ok := p.MetadataValue(c.Key)
+				return ok
+			}, nil
+		}
+		return compileString(c, func(p models.Photo) (string, bool) { return p.MetadataValue(c.Key) })
+
+	case models.RuleFieldAlbum, models.RuleFieldUser:
+		if c.Operator != models.RuleOpEquals {
+			return nil, fmt.Errorf("для поля %s поддерживается только оператор equals", c.Field)
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil {
+			return nil, fmt.Errorf("некорректный ID: %q", c.Value)
+		}
+		if c.Field == models.RuleFieldAlbum {
+			return func(p models.Photo) bool { return p.Album != nil && p.Album.ID == id }, nil
+		}
+		return func(p models.Photo) bool { return p.User != nil && p.User.ID == id }, nil
+
+	case models.RuleFieldHour:
+		return compileHour(c)
+
+	case models.RuleFieldLocation:
+		if c.Operator != models.RuleOpWithin {
+			return nil, fmt.Errorf("для поля location поддерживается только оператор within")
+		}
+		bbox, err := geo.ParseBBox(c.Value)
+		if err != nil {
+			return nil, err
+		}
+		return func(p models.Photo) bool {
+			lat, lon, ok := p.Location()
+			return ok && bbox.Contains(lat, lon)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("неизвестное поле: %q", c.Field)
+	}
+}
+
+// compileString строит условие для строкового поля
+func compileString(c models.RuleCondition, value func(p models.Photo) (string, bool)) (matcher, error) {
+	want := strings.ToLower(c.Value)
+	var test func(s string) bool
+
+	switch c.Operator {
+	case models.RuleOpEquals:
+		test = func(s string) bool { return strings.ToLower(s) == want }
+	case models.RuleOpContains:
+		test = func(s string) bool { return strings.Contains(strings.ToLower(s), want) }
+	case models.RuleOpPrefix:
+		test = func(s string) bool { return strings.HasPrefix(strings.ToLower(s), want) }
+	case models.RuleOpGlob:
+		if _, err := path.Match(want, ""); err != nil {
+			return nil, fmt.Errorf("некорректный шаблон: %q", c.Value)
+		}
+		test = func(s string) bool {
+			ok, _ := path.Match(want, strings.ToLower(s))
+			return ok
+		}
+	case models.RuleOpRegex:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("некорректное регулярное выражение: %w", err)
+		}
+		test = re.MatchString
+	default:
+		return nil, fmt.Errorf("оператор %q не поддерживается для поля %s", c.Operator, c.Field)
+	}
+
+	if c.Value == "" && c.Operator != models.RuleOpEquals {
+		return nil, fmt.Errorf("не указано значение value")
+	}
+
+	return func(p models.Photo) bool {
+		s, ok := value(p)
+		return ok && test(s)
+	}, nil
+}
+
+// compileHour строит условие для часа съемки. Для фотографий без даты съемки используется час загрузки.
+func compileHour(c models.RuleCondition) (matcher, error) {
+	parseHour := func(s string) (int, error) {
+		h, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || h < 0 || h > 23 {
+			return 0, fmt.Errorf("час должен быть числом от 0 до 23: %q", s)
+		}
+		return h, nil
+	}
+
+	var from, to int
+	var err error
+	switch c.Operator {
+	case models.RuleOpEquals:
+		if from, err = parseHour(c.Value); err != nil {
+			return nil, err
+		}
+		to = from
+	case models.RuleOpBetween:
+		start, end, ok := strings.Cut(c.Value, "-")
+		if !ok {
+			return nil, fmt.Errorf("диапазон часов задается в виде \"9-18\": %q", c.Value)
+		}
+		if from, err = parseHour(start); err != nil {
+			return nil, err
+		}
+		if to, err = parseHour(end); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("для поля hour поддерживаются операторы equals и between")
+	}
+
+	return func(p models.Photo) bool {
+		h := p.CapturedAt().Hour()
+		if from <= to {
+			return h >= from && h <= to
+		}
+		// Диапазон переходит через полночь
+		return h >= from || h <= to
+	}, nil
+}
@@ -0,0 +1,114 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mpm/internal/models"
+)
+
+func TestEngine_Tags(t *testing.T) {
+	takenAt := time.Date(2024, 3, 15, 23, 30, 0, 0, time.UTC)
+	photo := models.Photo{
+		Name:    "DSC_0042.NEF",
+		Album:   &models.Album{ID: 7},
+		User:    &models.User{ID: 3},
+		Tags:    []string{"Студия"},
+		TakenAt: &takenAt,
+		Metadata: []models.Metadata{
+			{Key: models.MetadataCameraModel, Value: "NIKON D850"},
+			{Key: models.MetadataCameraSerial, Value: "6012345"},
+			{Key: models.MetadataGPSLatitude, Value: "55.752000"},
+			{Key: models.MetadataGPSLongitude, Value: "37.617500"},
+		},
+	}
+
+	rule := func(tag string, conditions ...models.RuleCondition) models.TagRule {
+		return models.TagRule{Name: tag, Conditions: conditions, Tags: []string{tag}}
+	}
+	tests := []struct {
+		name      string
+		condition models.RuleCondition
+		want      bool
+	}{
+		{"name glob", models.RuleCondition{Field: "name", Operator: "glob", Value: "dsc_*.nef"}, true},
+		{"name prefix differs", models.RuleCondition{Field: "name", Operator: "prefix", Value: "IMG_"}, false},
+		{"name regex", models.RuleCondition{Field: "name", Operator: "regex", Value: `^DSC_\d+`}, true},
+		{"camera contains", models.RuleCondition{Field: "metadata", Key: "camera_model", Operator: "contains", Value: "d850"}, true},
+		{"serial equals", models.RuleCondition{Field: "metadata", Key: "camera_serial", Operator: "equals", Value: "6012345"}, true},
+		{"metadata missing", models.RuleCondition{Field: "metadata", Key: "lens_model", Operator: "contains", Value: "50"}, false},
+		{"metadata exists", models.RuleCondition{Field: "metadata", Key: "camera_serial", Operator: "exists"}, true},
+		{"album", models.RuleCondition{Field: "album_id", Operator: "equals", Value: "7"}, true},
+		{"user differs", models.RuleCondition{Field: "user_id", Operator: "equals", Value: "4"}, false},
+		{"media", models.RuleCondition{Field: "media", Operator: "equals", Value: "photo"}, true},
+		{"hour equals", models.RuleCondition{Field: "hour", Operator: "equals", Value: "23"}, true},
+		{"hour between", models.RuleCondition{Field: "hour", Operator: "between", Value: "9-18"}, false},
+		{"hour across midnight", models.RuleCondition{Field: "hour", Operator: "between", Value: "22-4"}, true},
+		{"location within", models.RuleCondition{Field: "location", Operator: "within", Value: "37.3,55.5,37.9,55.9"}, true},
+		{"location outside", models.RuleCondition{Field: "location", Operator: "within", Value: "30,59,31,60"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := NewEngine([]models.TagRule{rule("тег", tt.condition)})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, len(engine.Tags(photo)) == 1)
+		})
+	}
+
+	t.Run("Все условия правила должны выполняться", func(t *testing.T) {
+		engine, err := NewEngine([]models.TagRule{
+			rule("ночь", tests[10].condition, tests[0].condition),
+			rule("день", tests[11].condition, tests[0].condition),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ночь"}, engine.Tags(photo))
+	})
+
+	t.Run("Имеющиеся теги и отключенные правила пропускаются", func(t *testing.T) {
+		disabled := rule("отключено", tests[0].condition)
+		disabled.Disabled = true
+		engine, err := NewEngine([]models.TagRule{
+			{Name: "студия", Conditions: []models.RuleCondition{tests[0].condition}, Tags: []string{"студия", "Nikon", "nikon"}},
+			disabled,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, engine.Len())
+		assert.Equal(t, []string{"Nikon"}, engine.Tags(photo))
+	})
+}
+
+func TestValidate(t *testing.T) {
+	valid := models.RuleCondition{Field: "name", Operator: "contains", Value: "DSC"}
+	require.NoError(t, Validate(models.TagRule{Name: "студия", Conditions: []models.RuleCondition{valid}, Tags: []string{"студия"}}))
+
+	invalid := []models.TagRule{
+		{Conditions: []models.RuleCondition{valid}, Tags: []string{"тег"}},
+		{Name: "без условий", Tags: []string{"тег"}},
+		{Name: "без тегов", Conditions: []models.RuleCondition{valid}},
+		{Name: "пустой тег", Conditions: []models.RuleCondition{valid}, Tags: []string{" "}},
+		{Name: "тег с пробелом", Conditions: []models.RuleCondition{valid}, Tags: []string{"два слова"}},
+	}
+	for _, c := range []models.RuleCondition{
+		{Field: "size", Operator: "equals", Value: "1"},
+		{Field: "name", Operator: "between", Value: "1-2"},
+		{Field: "name", Operator: "contains"},
+		{Field: "name", Operator: "regex", Value: "("},
+		{Field: "name", Operator: "glob", Value: "["},
+		{Field: "metadata", Operator: "equals", Value: "x"},
+		{Field: "album_id", Operator: "equals", Value: "abc"},
+		{Field: "album_id", Operator: "contains", Value: "1"},
+		{Field: "hour", Operator: "equals", Value: "24"},
+		{Field: "hour", Operator: "between", Value: "9"},
+		{Field: "location", Operator: "within", Value: "1,2,3"},
+	} {
+		invalid = append(invalid, models.TagRule{Name: c.Field + " " + c.Operator, Conditions: []models.RuleCondition{c}, Tags: []string{"тег"}})
+	}
+
+	for _, rule := range invalid {
+		assert.Error(t, Validate(rule), rule.Name)
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mpm/internal/models"
+)
+
+// AlbumExport альбом и фотографии, которые попадут в архив
+type AlbumExport struct {
+	Album  models.Album
+	Photos []models.Photo
+}
+
+// Filename возвращает имя архива для скачивания
+func (e AlbumExport) Filename() string {
+	name := sanitizeFilename(e.Album.Name)
+	if name == "photo" || e.Album.Name == "" {
+		name = "album-" + strconv.Itoa(e.Album.ID)
+	}
+	return name + ".zip"
+}
+
+// PrepareAlbumExport находит альбом и фотографии, файлы которых есть в хранилище
+func (s *PhotoService) PrepareAlbumExport(ctx context.Context, albumID int) (AlbumExport, error) {
+	album, err := s.repo.FindAlbumByID(ctx, albumID)
+	if err != nil {
+		return AlbumExport{}, err
+	}
+
+	uploaded, err := s.repo.FindPhotos(ctx, models.PhotoFilter{AlbumID: &albumID})
+	if err != nil {
+		return AlbumExport{}, err
+	}
+
+	// Фотографии могут храниться и внутри альбома, и отдельными записями
+	seen := make(map[int]bool)
+	var photos []models.Photo
+	for _, photo := range append(append([]models.Photo(nil), album.Photos...), uploaded...) {
+		if photo.Path == "" || seen[photo.ID] {
+			continue
+		}
+		seen[photo.ID] = true
+		photos = append(photos, photo)
+	}
+
+	sort.SliceStable(photos, func(i, j int) bool {
+		ti, tj := photos[i].CapturedAt(), photos[j].CapturedAt()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return photos[i].ID < photos[j].ID
+	})
+
+	return AlbumExport{Album: album, Photos: photos}, nil
+}
+
+// WriteAlbumZip передает в w архив с оригиналами фотографий и manifest.json
+func (s *PhotoService) WriteAlbumZip(ctx context.Context, w io.Writer, export AlbumExport) error {
+	zw := zip.NewWriter(w)
+
+	manifest := models.AlbumManifest{
+		Version:    models.ManifestVersion,
+		ExportedAt: time.Now().UTC(),
+		Album:      models.NewManifestAlbum(export.Album),
+		Photos:     make([]models.ManifestPhoto, 0, len(export.Photos)),
+	}
+
+	names := make(map[string]bool)
+	for _, photo := range export.Photos {
+		// Проверяем отмену контекста, например при отключении клиента
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			// Продолжаем выполнение
+		}
+
+		name := uniqueEntryName(names, photo)
+		written, err := s.writeZipEntry(zw, photo, name)
+		if err != nil {
+			return fmt.Errorf("ошибка записи фотографии ID=%d в архив: %w", photo.ID, err)
+		}
+		if !written {
+			continue
+		}
+
+		names[strings.ToLower(name)] = true
+		manifest.Photos = append(manifest.Photos, models.NewManifestPhoto(photo, name))
+	}
+
+	// Описание пишется последним: в нем перечислены только реально добавленные файлы
+	entry, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     models.ManifestFilename,
+		Method:   zip.Deflate,
+		Modified: manifest.ExportedAt,
+	})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeZipEntry копирует оригинал фотографии в архив. Возвращает false, если файла нет в хранилище.
+func (s *PhotoService) writeZipEntry(zw *zip.Writer, photo models.Photo, name string) (bool, error) {
+	reader, err := s.providerFor(photo).GetReader(photo.Path)
+	if err != nil {
+		log.Printf("Фотография ID=%d пропущена при экспорте: %v", photo.ID, err)
+		return false, nil
+	}
+	defer reader.Close()
+
+	// Изображения уже сжаты, поэтому сохраняем их без повторного сжатия
+	entry, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: photo.CapturedAt(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := io.Copy(entry, reader); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// uniqueEntryName подбирает имя записи архива, не совпадающее с уже использованными
+func uniqueEntryName(used map[string]bool, photo models.Photo) string {
+	name := sanitizeFilename(photo.Name)
+	if strings.EqualFold(name, models.ManifestFilename) {
+		name = "photo-" + name
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	return candidate
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"mpm/internal/models"
+)
+
+var (
+	// ErrInvalidArchive возвращается, если архив не удается прочитать или он превышает ограничения
+	ErrInvalidArchive = errors.New("некорректный архив")
+	// errUnsafePath запись архива с путем, выходящим за пределы архива
+	errUnsafePath = errors.New("небезопасный путь в архиве")
+	// errEntryTooLarge запись архива превышает допустимый размер или степень сжатия
+	errEntryTooLarge = errors.New("файл в архиве превышает допустимый размер")
+)
+
+// maxManifestSize максимальный размер manifest.json
+const maxManifestSize = 16 << 20
+
+// ImportLimits ограничения при импорте архива, защищающие от zip-бомб
+type ImportLimits struct {
+	MaxArchiveSize int64 // максимальный размер самого архива
+	MaxEntries     int   // максимальное количество записей в архиве
+	MaxEntrySize   int64 // максимальный размер распакованного файла
+	MaxTotalSize   int64 // максимальный суммарный размер распакованных файлов
+	MaxRatio       int64 // максимальная степень сжатия одной записи
+}
+
+// DefaultImportLimits ограничения импорта по умолчанию
+var DefaultImportLimits = ImportLimits{
+	MaxArchiveSize: 2 << 30,
+	MaxEntries:     10000,
+	MaxEntrySize:   512 << 20,
+	MaxTotalSize:   8 << 30,
+	MaxRatio:       100,
+}
+
+// ImportError описывает ошибку импорта отдельной записи архива
+type ImportError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// ImportOptions параметры импорта архива
+type ImportOptions struct {
+	Name        string       // Название альбома, имеет приоритет над manifest.json
+	ArchiveName string       // Имя файла архива, используется, если название не задано
+	User        *models.User // Пользователь, выполняющий импорт
+}
+
+// ImportResult результат импорта архива
+type ImportResult struct {
+	Album  models.Album   `json:"album"`
+	Photos []models.Photo `json:"photos"`
+	Errors []ImportError  `json:"errors,omitempty"`
+}
+
+// SetImportLimits задает ограничения импорта архивов
+func (s *PhotoService) SetImportLimits(limits ImportLimits) {
+	s.importLimits = limits
+}
+
+// SetImportDir задает каталог для распаковки записей архива, пустая строка - системный временный каталог
+func (s *PhotoService) SetImportDir(dir string) {
+	s.importDir = dir
+}
+
+// removeAlbum окончательно удаляет альбом, минуя корзину
+func (s *PhotoService) removeAlbum(ctx context.Context, id int) error {
+	if err := s.repo.DeleteAlbum(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.PurgeAlbum(ctx, id)
+}
+
+// ImportLimits возвращает ограничения импорта архивов
+func (s *PhotoService) ImportLimits() ImportLimits {
+	return s.importLimits
+}
+
+// ImportAlbum создает альбом из ZIP-архива, теги и описания берутся из manifest.json
+func (s *PhotoService) ImportAlbum(ctx context.Context, archive io.ReaderAt, size int64, opts ImportOptions) (ImportResult, error) {
+	limits := s.importLimits
+	if limits.MaxArchiveSize > 0 && size > limits.MaxArchiveSize {
+		return ImportResult{}, fmt.Errorf("%w: размер архива превышает %d байт", ErrInvalidArchive, limits.MaxArchiveSize)
+	}
+
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if limits.MaxEntries > 0 && len(reader.File) > limits.MaxEntries {
+		return ImportResult{}, fmt.Errorf("%w: больше %d записей", ErrInvalidArchive, limits.MaxEntries)
+	}
+
+	var result ImportResult
+	manifest, err := readManifest(reader, limits)
+	if err != nil {
+		result.Errors = append(result.Errors, ImportError{File: models.ManifestFilename, Error: err.Error()})
+	}
+
+	album := models.Album{
+		Name:        strings.TrimSpace(opts.Name),
+		User:        publicUser(opts.User),
+		Description: manifest.Album.Description,
+		Tags:        manifest.Album.Tags,
+	}
+	if album.Name == "" {
+		album.Name = manifest.Album.Name
+	}
+	if album.Name == "" {
+		archiveName := path.Base(strings.ReplaceAll(opts.ArchiveName, "\\", "/"))
+		album.Name = strings.TrimSuffix(archiveName, path.Ext(archiveName))
+	}
+	if album.Name == "" || album.Name == "." || album.Name == "/" {
+		album.Name = "Импорт " + time.Now().Format("2006-01-02 15:04")
+	}
+
+	albumID, err := s.repo.AddAlbum(ctx, album)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("ошибка создания альбома: %w", err)
+	}
+	if result.Album, err = s.repo.FindAlbumByID(ctx, albumID); err != nil {
+		return ImportResult{}, err
+	}
+
+	// Альбом, в который не попало ни одной фотографии, удаляется
+	defer func() {
+		if len(result.Photos) > 0 {
+			return
+		}
+		if err := s.removeAlbum(context.WithoutCancel(ctx), albumID); err != nil {
+			log.Printf("Ошибка удаления пустого альбома ID=%d: %v", albumID, err)
+		}
+	}()
+
+	photos := make(map[string]models.ManifestPhoto, len(manifest.Photos))
+	for _, photo := range manifest.Photos {
+		photos[photo.File] = photo
+	}
+
+	result.Photos = []models.Photo{}
+	var total int64
+	for _, file := range reader.File {
+		// Проверяем отмену контекста
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+			// Продолжаем выполнение
+		}
+
+		if skipArchiveEntry(file) {
+			continue
+		}
+
+		photo, n, err := s.importEntry(ctx, result.Album, opts.User, file, photos[file.Name], limits, limits.MaxTotalSize-total)
+		total += n
+		if err != nil {
+			log.Printf("Ошибка импорта файла %s: %v", file.Name, err)
+			result.Errors = append(result.Errors, ImportError{File: file.Name, Error: importErrorMessage(err)})
+			continue
+		}
+		result.Photos = append(result.Photos, photo)
+	}
+
+	if len(result.Photos) == 0 {
+		if len(result.Errors) == 0 {
+			return ImportResult{}, fmt.Errorf("%w: архив не содержит изображений", ErrInvalidArchive)
+		}
+		first := result.Errors[0]
+		return ImportResult{}, fmt.Errorf("%w: не импортировано ни одного файла, %s: %s", ErrInvalidArchive, first.File, first.Error)
+	}
+
+	log.Printf("Импортирован альбом: ID=%d, Название=%s, фотографий: %d, ошибок: %d",
+		result.Album.ID, result.Album.Name, len(result.Photos), len(result.Errors))
+	return result, nil
+}
+
+// importEntry распаковывает запись архива и сохраняет ее как фотографию
+func (s *PhotoService) importEntry(ctx context.Context, album models.Album, user *models.User, file *zip.File,
+	meta models.ManifestPhoto, limits ImportLimits, remaining int64) (models.Photo, int64, error) {
+	if !safeArchivePath(file.Name) {
+		return models.Photo{}, 0, errUnsafePath
+	}
+	if err := checkEntrySize(file, limits, remaining); err != nil {
+		return models.Photo{}, 0, err
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return models.Photo{}, 0, fmt.Errorf("ошибка чтения архива: %w", err)
+	}
+	defer rc.Close()
+
+	if s.importDir != "" {
+		if err := os.MkdirAll(s.importDir, 0755); err != nil {
+			return models.Photo{}, 0, err
+		}
+	}
+	tmp, err := os.CreateTemp(s.importDir, "import-*")
+	if err != nil {
+		return models.Photo{}, 0, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	// Заголовкам записи не доверяем: ограничиваем реально распакованный объем
+	limit := int64(file.UncompressedSize64)
+	n, err := io.Copy(tmp, io.LimitReader(rc, limit+1))
+	if err != nil {
+		return models.Photo{}, n, fmt.Errorf("ошибка распаковки: %w", err)
+	}
+	if n > limit {
+		return models.Photo{}, n, errEntryTooLarge
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return models.Photo{}, n, err
+	}
+
+	filename := path.Base(file.Name)
+	if meta.Name != "" {
+		filename = meta.Name
+	}
+
+	photo, err := s.ingest(ctx, album, user, UploadFile{
+		File:     tmp,
+		Filename: filename,
+		Size:     n,
+		Tags:     meta.Tags,
+		Metadata: meta.Metadata,
+		TakenAt:  meta.TakenAt,
+	})
+	return photo, n, err
+}
+
+// readManifest читает manifest.json из архива. Отсутствие файла не является ошибкой.
+func readManifest(reader *zip.Reader, limits ImportLimits) (models.AlbumManifest, error) {
+	for _, file := range reader.File {
+		if file.Name != models.ManifestFilename {
+			continue
+		}
+		if file.UncompressedSize64 > maxManifestSize {
+			return models.AlbumManifest{}, errors.New("manifest.json слишком большой")
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return models.AlbumManifest{}, fmt.Errorf("ошибка чтения manifest.json: %w", err)
+		}
+		defer rc.Close()
+
+		var manifest models.AlbumManifest
+		if err := json.NewDecoder(io.LimitReader(rc, int64(file.UncompressedSize64))).Decode(&manifest); err != nil {
+			return models.AlbumManifest{}, fmt.Errorf("ошибка разбора manifest.json: %w", err)
+		}
+		return manifest, nil
+	}
+	return models.AlbumManifest{}, nil
+}
+
+// checkEntrySize проверяет заявленный размер и степень сжатия записи
+func checkEntrySize(file *zip.File, limits ImportLimits, remaining int64) error {
+	size := file.UncompressedSize64
+	if size > uint64(1<<62) {
+		return errEntryTooLarge
+	}
+	if limits.MaxEntrySize > 0 && int64(size) > limits.MaxEntrySize {
+		return errEntryTooLarge
+	}
+	if limits.MaxTotalSize > 0 && int64(size) > remaining {
+		return fmt.Errorf("%w: превышен суммарный размер импорта", errEntryTooLarge)
+	}
+	if limits.MaxRatio > 0 && size > 1<<20 && size/max(file.CompressedSize64, 1) > uint64(limits.MaxRatio) {
+		return fmt.Errorf("%w: подозрительно высокая степень сжатия", errEntryTooLarge)
+	}
+	return nil
+}
+
+// safeArchivePath проверяет, что путь записи не выходит за пределы архива (zip slip)
+func safeArchivePath(name string) bool {
+	if name == "" || strings.Contains(name, "\\") || strings.HasPrefix(name, "/") {
+		return false
+	}
+	// Буква диска Windows, например C:
+	if len(name) >= 2 && name[1] == ':' {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// skipArchiveEntry пропускает каталоги, manifest.json и служебные файлы систем
+func skipArchiveEntry(file *zip.File) bool {
+	if file.FileInfo().IsDir() || file.Name == models.ManifestFilename {
+		return true
+	}
+	if strings.HasPrefix(file.Name, "__MACOSX/") {
+		return true
+	}
+	base := path.Base(file.Name)
+	return strings.HasPrefix(base, ".") || base == "Thumbs.db"
+}
+
+// importErrorMessage формирует сообщение об ошибке для клиента без внутренних подробностей
+func importErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrNotAnImage):
+		return ErrNotAnImage.Error()
+	case errors.Is(err, errUnsafePath):
+		return errUnsafePath.Error()
+	case errors.Is(err, errEntryTooLarge):
+		return err.Error()
+	}
+	return "ошибка при сохранении файла"
+}
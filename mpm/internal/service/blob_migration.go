@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mpm/internal/models"
+	"mpm/internal/storage"
+	"mpm/internal/storage/google"
+)
+
+// maxMigrationErrors ограничивает количество ошибок, сохраняемых в состоянии переноса
+const maxMigrationErrors = 100
+
+var (
+	// ErrMigrationRunning возвращается при попытке запустить перенос, пока выполняется предыдущий
+	ErrMigrationRunning = errors.New("перенос файлов уже выполняется")
+	// ErrInvalidMigration возвращается для неизвестных или совпадающих хранилищ
+	ErrInvalidMigration = errors.New("некорректные параметры переноса файлов")
+
+	// errMigrationState ошибка записи состояния, при которой перенос нельзя продолжать
+	errMigrationState = errors.New("ошибка сохранения состояния переноса файлов")
+)
+
+// MigrationOptions параметры переноса файлов между хранилищами
+type MigrationOptions struct {
+	From         string `json:"from"`          // Тип хранилища, из которого переносятся файлы
+	To           string `json:"to"`            // Тип хранилища, в которое переносятся файлы
+	DeleteSource bool   `json:"delete_source"` // Удалять файлы из исходного хранилища после переноса
+}
+
+// MigrationError ошибка переноса файлов одной фотографии
+type MigrationError struct {
+	PhotoID int    `json:"photo_id"`
+	Path    string `json:"path"`
+	Error   string `json:"error"`
+}
+
+// MigrationStatus состояние задачи переноса файлов
+type MigrationStatus struct {
+	Running    bool             `json:"running"`
+	From       string           `json:"from,omitempty"`
+	To         string           `json:"to,omitempty"`
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	Total      int              `json:"total"`    // Фотографий в исходном хранилище на момент запуска
+	Migrated   int              `json:"migrated"` // Перенесено фотографий
+	Bytes      int64            `json:"bytes"`    // Скопировано байт
+	Errors     []MigrationError `json:"errors,omitempty"`
+	Error      string           `json:"error,omitempty"` // Ошибка, прервавшая перенос
+}
+
+// blobMigrationState скопированные и проверенные файлы для продолжения переноса
+type blobMigrationState struct {
+	From  string            `json:"from"`
+	To    string            `json:"to"`
+	Blobs map[string]string `json:"blobs"` // Путь в исходном хранилище -> путь в новом
+}
+
+// BlobMigrator переносит файлы фотографий между хранилищами без остановки сервиса
+type BlobMigrator struct {
+	photos    *PhotoService
+	statePath string
+
+	mutex  sync.Mutex
+	status MigrationStatus
+	state  blobMigrationState
+	now    func() time.Time
+}
+
+// NewBlobMigrator создает задачу переноса. Состояние загружается из statePath.
+func NewBlobMigrator(photos *PhotoService, statePath string) (*BlobMigrator, error) {
+	m := &BlobMigrator{
+		photos:    photos,
+		statePath: statePath,
+		state:     blobMigrationState{Blobs: map[string]string{}},
+		now:       time.Now,
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения состояния переноса файлов: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &m.state); err != nil {
+			return nil, fmt.Errorf("ошибка разбора состояния переноса файлов: %w", err)
+		}
+		if m.state.Blobs == nil {
+			m.state.Blobs = map[string]string{}
+		}
+	}
+	return m, nil
+}
+
+// Status возвращает состояние текущего или последнего переноса
+func (m *BlobMigrator) Status() MigrationStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	status := m.status
+	status.Errors = append([]MigrationError(nil), m.status.Errors...)
+	return status
+}
+
+// copiedBlobs возвращает пути копий, сделанных переносом в хранилище storageType
+func (m *BlobMigrator) copiedBlobs(storageType string) map[string]bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	copied := make(map[string]bool)
+	if m.state.To == storageType {
+		for _, dest := range m.state.Blobs {
+			copied[dest] = true
+		}
+	}
+	return copied
+}
+
+// Start запускает перенос в фоне
+func (m *BlobMigrator) Start(ctx context.Context, opts MigrationOptions) error {
+	from, to, err := m.begin(opts)
+	if err != nil {
+		return err
+	}
+	go m.run(context.WithoutCancel(ctx), opts, from, to, nil)
+	return nil
+}
+
+// Run выполняет перенос синхронно. progress вызывается после обработки каждой фотографии.
+func (m *BlobMigrator) Run(ctx context.Context, opts MigrationOptions, progress func(MigrationStatus)) (MigrationStatus, error) {
+	from, to, err := m.begin(opts)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	err = m.run(ctx, opts, from, to, progress)
+	return m.Status(), err
+}
+
+// begin проверяет параметры и отмечает начало переноса
+func (m *BlobMigrator) begin(opts MigrationOptions) (storage.Provider, storage.Provider, error) {
+	if opts.From == opts.To {
+		return nil, nil, fmt.Errorf("%w: исходное и новое хранилища совпадают", ErrInvalidMigration)
+	}
+	from, ok := m.photos.providers[opts.From]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: хранилище %q не настроено", ErrInvalidMigration, opts.From)
+	}
+	to, ok := m.photos.providers[opts.To]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: хранилище %q не настроено", ErrInvalidMigration, opts.To)
+	}
+	if opts.To == google.StorageType {
+		// Уменьшенные копии попали бы в библиотеку отдельными медиафайлами, а удалить их нельзя
+		return nil, nil, fmt.Errorf("%w: перенос в Google Photos не поддерживается", ErrInvalidMigration)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.status.Running {
+		return nil, nil, ErrMigrationRunning
+	}
+	now := m.now()
+	m.status = MigrationStatus{Running: true, From: opts.From, To: opts.To, StartedAt: &now}
+	if m.state.From != opts.From || m.state.To != opts.To {
+		m.state = blobMigrationState{From: opts.From, To: opts.To, Blobs: map[string]string{}}
+	}
+	return from, to, nil
+}
+
+func (m *BlobMigrator) run(ctx context.Context, opts MigrationOptions, from, to storage.Provider, progress func(MigrationStatus)) error {
+	log.Printf("Запуск переноса файлов из хранилища %s в %s", opts.From, opts.To)
+
+	err := m.migrate(ctx, opts, from, to, progress)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := m.now()
+	m.status.Running = false
+	m.status.FinishedAt = &now
+	if err != nil {
+		m.status.Error = err.Error()
+		log.Printf("Перенос файлов прерван: %v", err)
+	} else {
+		log.Printf("Перенос файлов завершен: перенесено %d из %d фотографий, %d байт, ошибок %d",
+			m.status.Migrated, m.status.Total, m.status.Bytes, len(m.status.Errors))
+	}
+	return err
+}
+
+// currentPhoto перечитывает фотографию перед переключением записи, в том числе находящуюся в корзине
+func (m *BlobMigrator) currentPhoto(ctx context.Context, id int) (models.Photo, error) {
+	photo, err := m.photos.repo.FindPhotoByID(id)
+	if err == nil {
+		return photo, nil
+	}
+
+	trashed, trashErr := m.photos.repo.FindPhotos(ctx, models.PhotoFilter{Trashed: true})
+	if trashErr != nil {
+		return models.Photo{}, trashErr
+	}
+	for _, photo := range trashed {
+		if photo.ID == id {
+			return photo, nil
+		}
+	}
+	return models.Photo{}, err
+}
+
+func (m *BlobMigrator) migrate(ctx context.Context, opts MigrationOptions, from, to storage.Provider, progress func(MigrationStatus)) error {
+	// Фотографии в корзине тоже переносятся: после восстановления их файлы должны быть доступны
+	all, err := m.photos.allPhotos(ctx)
+	if err != nil {
+		return err
+	}
+
+	var photos []models.Photo
+	for _, photo := range all {
+		if photo.Path != "" && m.photos.storageTypeOf(photo) == opts.From {
+			photos = append(photos, photo)
+		}
+	}
+
+	m.mutex.Lock()
+	m.status.Total = len(photos)
+	m.mutex.Unlock()
+
+	for _, photo := range photos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := m.migratePhoto(ctx, photo, opts, from, to)
+
+		m.mutex.Lock()
+		if err == nil {
+			m.status.Migrated++
+		} else if len(m.status.Errors) < maxMigrationErrors {
+			m.status.Errors = append(m.status.Errors, MigrationError{PhotoID: photo.ID, Path: photo.Path, Error: err.Error()})
+		}
+		m.mutex.Unlock()
+
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errMigrationState) {
+			return err
+		}
+		if err != nil {
+			log.Printf("Ошибка переноса файлов фотографии ID=%d: %v", photo.ID, err)
+		}
+		if progress != nil {
+			progress(m.Status())
+		}
+	}
+	return nil
+}
+
+// migratePhoto копирует файлы фотографии и переключает ее запись на новое хранилище
+func (m *BlobMigrator) migratePhoto(ctx context.Context, photo models.Photo, opts MigrationOptions, from, to storage.Provider) error {
+	paths := photo.FilePaths()
+
+	copied := make(map[string]string, len(paths))
+	for i, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m.mutex.Lock()
+		dest, ok := m.state.Blobs[p]
+		m.mutex.Unlock()
+		if !ok {
+			// Контрольная сумма в записи известна только для оригинала
+			checksum := ""
+			if i == 0 {
+				checksum = photo.Checksum
+			}
+			var size int64
+			var err error
+			dest, size, err = copyBlob(from, to, p, p, checksum)
+			if err != nil {
+				return err
+			}
+
+			m.mutex.Lock()
+			m.state.Blobs[p] = dest
+			m.status.Bytes += size
+			err = m.saveState()
+			m.mutex.Unlock()
+			if err != nil {
+				return fmt.Errorf("%w: %v", errMigrationState, err)
+			}
+		}
+		copied[p] = dest
+	}
+
+	current, err := m.currentPhoto(ctx, photo.ID)
+	if err != nil {
+		return err
+	}
+	if current.Path != photo.Path || m.photos.storageTypeOf(current) != opts.From {
+		return fmt.Errorf("файлы фотографии изменились во время переноса")
+	}
+
+	current.Path = copied[current.Path]
+	current.StorageType = opts.To
+	current.Renditions = append([]models.Rendition(nil), current.Renditions...)
+	for i, r := range current.Renditions {
+		dest, ok := copied[r.Path]
+		if !ok {
+			return fmt.Errorf("уменьшенные копии фотографии изменились во время переноса")
+		}
+		current.Renditions[i].Path = dest
+	}
+	if current.Motion != nil {
+		dest, ok := copied[current.Motion.Path]
+		if !ok {
+			return fmt.Errorf("видеоролик фотографии изменился во время переноса")
+		}
+		motion := *current.Motion
+		motion.Path = dest
+		current.Motion = &motion
+	}
+	if err := m.photos.repo.UpdatePhoto(ctx, photo.ID, current); err != nil {
+		return err
+	}
+
+	if opts.DeleteSource {
+		for _, p := range paths {
+			if err := from.Delete(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("Ошибка при удалении перенесенного файла %s: %v", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyBlob копирует файл под именем destKey с проверкой содержимого
+func copyBlob(from, to storage.Provider, key, destKey, checksum string) (string, int64, error) {
+	reader, err := from.GetReader(key)
+	if err != nil {
+		return "", 0, fmt.Errorf("ошибка чтения файла %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp("", "mpm-migrate-*")
+	if err != nil {
+		reader.Close()
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), reader)
+	reader.Close()
+	if err != nil {
+		return "", 0, fmt.Errorf("ошибка чтения файла %s: %w", key, err)
+	}
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	if checksum != "" && sum != checksum {
+		return "", 0, fmt.Errorf("контрольная сумма файла %s не совпадает с сохраненной при загрузке", key)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+	dest, err := to.Save(tmp, destKey)
+	if err != nil {
+		return "", 0, fmt.Errorf("ошибка сохранения файла %s: %w", key, err)
+	}
+
+	copiedSum, err := blobChecksum(to, dest)
+	if err != nil {
+		return "", 0, fmt.Errorf("ошибка проверки файла %s: %w", dest, err)
+	}
+	if copiedSum != sum {
+		if err := to.Delete(dest); err != nil {
+			log.Printf("Ошибка при удалении поврежденной копии %s: %v", dest, err)
+		}
+		return "", 0, fmt.Errorf("контрольная сумма копии файла %s не совпадает с исходной", key)
+	}
+	return dest, size, nil
+}
+
+// blobChecksum вычисляет SHA-256 файла в хранилище
+func blobChecksum(provider storage.Provider, key string) (string, error) {
+	reader, err := provider.GetReader(key)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// saveState записывает состояние во временный файл и переименовывает его. Вызывается под mutex.
+func (m *BlobMigrator) saveState() error {
+	data, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.statePath), 0755); err != nil {
+		return err
+	}
+	tmp := m.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.statePath)
+}
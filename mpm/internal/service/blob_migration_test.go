@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mpm/internal/models"
+	"mpm/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStorage хранилище, которое считает сохранения и может отказать в записи
+type flakyStorage struct {
+	storage.Provider
+	saves  int
+	failOn string // Путь, сохранение которого завершится ошибкой
+}
+
+func (f *flakyStorage) Save(file multipart.File, filename string) (string, error) {
+	if filename == f.failOn {
+		return "", errors.New("хранилище недоступно")
+	}
+	f.saves++
+	return f.Provider.Save(file, filename)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestBlobMigrator_Run(t *testing.T) {
+	ctx := context.Background()
+	local := storage.NewLocalStorage(t.TempDir(), "/files")
+	targetDir := t.TempDir()
+	target := &flakyStorage{Provider: storage.NewLocalStorage(targetDir, "/files"), failOn: "albums/1/b_256px.jpg"}
+
+	mockRepo := &MockPhotoRepository{}
+	service := NewPhotoService(mockRepo, local, "local", 0)
+	service.RegisterProvider("s3", target)
+
+	original := []byte("original-a")
+	_, err := local.Save(writeTempFile(t, original), "albums/1/a.jpg")
+	require.NoError(t, err)
+	_, err = local.Save(writeTempFile(t, []byte("thumb-a")), "albums/1/a_256px.jpg")
+	require.NoError(t, err)
+	_, err = local.Save(writeTempFile(t, []byte("original-b")), "albums/1/b.jpg")
+	require.NoError(t, err)
+	_, err = local.Save(writeTempFile(t, []byte("thumb-b")), "albums/1/b_256px.jpg")
+	require.NoError(t, err)
+	_, err = local.Save(writeTempFile(t, []byte("damaged")), "albums/1/c.jpg")
+	require.NoError(t, err)
+
+	photoA := models.Photo{ID: 1, Path: "albums/1/a.jpg", StorageType: "local", Checksum: sha256Hex(original), Tags: []string{"море"},
+		Renditions: []models.Rendition{{Size: 256, Path: "albums/1/a_256px.jpg"}}}
+	photoB := models.Photo{ID: 2, Path: "albums/1/b.jpg", // Загружена до появления StorageType
+		Renditions: []models.Rendition{{Size: 256, Path: "albums/1/b_256px.jpg"}}}
+	photoC := models.Photo{ID: 3, Path: "albums/1/c.jpg", StorageType: "local", Checksum: sha256Hex([]byte("original-c"))}
+	photoS3 := models.Photo{ID: 4, Path: "albums/1/d.jpg", StorageType: "s3"}
+
+	mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{}).Return([]models.Photo{photoA, photoB, photoC, photoS3}, nil)
+	mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{Trashed: true}).Return([]models.Photo{}, nil)
+	mockRepo.On("FindPhotoByID", 1).Return(photoA, nil).Once()
+	mockRepo.On("UpdatePhoto", mock.Anything, 1, mock.MatchedBy(func(p models.Photo) bool {
+		return p.StorageType == "s3" && p.Path == "albums/1/a.jpg" && p.Renditions[0].Path == "albums/1/a_256px.jpg" &&
+			len(p.Tags) == 1
+	})).Return(nil).Once()
+
+	statePath := filepath.Join(t.TempDir(), "blob_migration.json")
+	migrator, err := NewBlobMigrator(service, statePath)
+	require.NoError(t, err)
+
+	var reports []MigrationStatus
+	status, err := migrator.Run(ctx, MigrationOptions{From: "local", To: "s3"}, func(s MigrationStatus) {
+		reports = append(reports, s)
+	})
+	require.NoError(t, err)
+	assert.False(t, status.Running)
+	assert.Equal(t, 3, status.Total)
+	assert.Equal(t, 1, status.Migrated)
+	assert.Len(t, reports, 3, "прогресс сообщается после каждой фотографии")
+	require.Len(t, status.Errors, 2)
+	assert.Equal(t, 2, status.Errors[0].PhotoID, "копия не сохранена, запись не переключается")
+	assert.Equal(t, 3, status.Errors[1].PhotoID)
+	assert.Contains(t, status.Errors[1].Error, "контрольная сумма")
+
+	data, err := os.ReadFile(filepath.Join(targetDir, "albums/1/a.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, original, data)
+	_, err = local.Get("albums/1/a.jpg")
+	assert.NoError(t, err, "без delete_source исходные файлы остаются")
+	_, err = os.Stat(filepath.Join(targetDir, "albums/1/c.jpg"))
+	assert.True(t, os.IsNotExist(err), "поврежденный файл не копируется")
+
+	t.Run("Продолжение после сбоя", func(t *testing.T) {
+		target.failOn = ""
+		saves := target.saves
+
+		migrated := photoA
+		migrated.StorageType = "s3"
+		mockRepo.ExpectedCalls = nil
+		mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{}).Return([]models.Photo{migrated, photoB, photoS3}, nil)
+		mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{Trashed: true}).Return([]models.Photo{}, nil)
+		mockRepo.On("FindPhotoByID", 2).Return(photoB, nil).Once()
+		mockRepo.On("UpdatePhoto", mock.Anything, 2, mock.MatchedBy(func(p models.Photo) bool {
+			return p.StorageType == "s3" && p.Path == "albums/1/b.jpg"
+		})).Return(nil).Once()
+
+		// Состояние читается из файла, как после перезапуска
+		restarted, err := NewBlobMigrator(service, statePath)
+		require.NoError(t, err)
+
+		status, err := restarted.Run(ctx, MigrationOptions{From: "local", To: "s3", DeleteSource: true}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, status.Total)
+		assert.Equal(t, 1, status.Migrated)
+		assert.Empty(t, status.Errors)
+		assert.Equal(t, saves+1, target.saves, "оригинал, скопированный до сбоя, не загружается повторно")
+
+		_, err = local.Get("albums/1/b.jpg")
+		assert.Error(t, err, "исходные файлы удалены после переноса")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Перенос фотографии из корзины", func(t *testing.T) {
+		trashedData := []byte("original-e")
+		_, err := local.Save(writeTempFile(t, trashedData), "albums/1/e.jpg")
+		require.NoError(t, err)
+
+		deletedAt := time.Now()
+		photoE := models.Photo{ID: 5, Path: "albums/1/e.jpg", StorageType: "local", Checksum: sha256Hex(trashedData), DeletedAt: &deletedAt}
+		mockRepo.ExpectedCalls = nil
+		mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{}).Return([]models.Photo{}, nil)
+		mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{Trashed: true}).Return([]models.Photo{photoE}, nil)
+		mockRepo.On("FindPhotoByID", 5).Return(models.Photo{}, errors.New("фотография с ID=5 не найдена"))
+		mockRepo.On("UpdatePhoto", mock.Anything, 5, mock.MatchedBy(func(p models.Photo) bool {
+			return p.StorageType == "s3" && p.DeletedAt != nil
+		})).Return(nil).Once()
+
+		status, err := migrator.Run(ctx, MigrationOptions{From: "local", To: "s3", DeleteSource: true}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, status.Total)
+		assert.Equal(t, 1, status.Migrated)
+		assert.Empty(t, status.Errors)
+
+		data, err := os.ReadFile(filepath.Join(targetDir, "albums/1/e.jpg"))
+		require.NoError(t, err)
+		assert.Equal(t, trashedData, data, "файл фотографии из корзины доступен в новом хранилище")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Некорректные параметры", func(t *testing.T) {
+		_, err := migrator.Run(ctx, MigrationOptions{From: "local", To: "local"}, nil)
+		assert.ErrorIs(t, err, ErrInvalidMigration)
+		_, err = migrator.Run(ctx, MigrationOptions{From: "local", To: "dropbox"}, nil)
+		assert.ErrorIs(t, err, ErrInvalidMigration)
+	})
+}
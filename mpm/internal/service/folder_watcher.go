@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mpm/internal/models"
+)
+
+// DefaultAlbumName название альбома, в который попадают фотографии из отслеживаемых каталогов
+const DefaultAlbumName = "Default"
+
+// Паузы перед повторным импортом файла после временной ошибки
+const (
+	importRetryDelay    = time.Minute
+	maxImportRetryDelay = 24 * time.Hour
+)
+
+// watchedExtensions расширения файлов, которые импортируются из отслеживаемых каталогов
+var watchedExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".bmp": true,
+	".tif": true, ".tiff": true, ".heic": true, ".heif": true, ".avif": true,
+	".dng": true, ".cr2": true, ".cr3": true, ".nef": true, ".arw": true, ".raf": true, ".orf": true, ".rw2": true,
+	".mp4": true, ".mov": true,
+}
+
+// AlbumStore описывает методы репозитория для поиска и создания альбома по умолчанию
+type AlbumStore interface {
+	GetAllAlbums(ctx context.Context) ([]models.Album, error)
+	AddAlbum(ctx context.Context, album models.Album) (int, error)
+}
+
+// WatchedFile состояние обработанного файла из отслеживаемого каталога
+type WatchedFile struct {
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	PhotoID    int       `json:"photo_id,omitempty"`
+	Error      string    `json:"error,omitempty"`     // Причина, по которой файл не импортирован
+	Permanent  bool      `json:"permanent,omitempty"` // Ошибка в содержимом файла, повторять импорт бессмысленно
+	Attempts   int       `json:"attempts,omitempty"`  // Количество неудачных попыток импорта подряд
+	ImportedAt time.Time `json:"imported_at"`
+}
+
+// retryAt возвращает время следующей попытки импорта файла после ошибки
+func (f WatchedFile) retryAt() time.Time {
+	delay := maxImportRetryDelay
+	if f.Attempts > 0 && f.Attempts <= 20 {
+		delay = min(importRetryDelay<<(f.Attempts-1), maxImportRetryDelay)
+	}
+	return f.ImportedAt.Add(delay)
+}
+
+// fileObservation размер и время изменения файла при последнем просмотре каталога
+type fileObservation struct {
+	size    int64
+	modTime time.Time
+	since   time.Time // Когда файл впервые замечен в этом состоянии
+}
+
+// FolderWatcher импортирует новые изображения из отслеживаемых каталогов в альбом "Default"
+type FolderWatcher struct {
+	photos    *PhotoService
+	albums    AlbumStore
+	dirs      []string
+	statePath string
+	interval  time.Duration
+	stableFor time.Duration
+
+	mutex   sync.Mutex
+	state   map[string]WatchedFile
+	pending map[string]fileObservation
+	now     func() time.Time
+}
+
+// NewFolderWatcher создает обработчик отслеживаемых каталогов. Состояние загружается из statePath.
+func NewFolderWatcher(photos *PhotoService, albums AlbumStore, dirs []string, statePath string, interval, stableFor time.Duration) (*FolderWatcher, error) {
+	w := &FolderWatcher{
+		photos:    photos,
+		albums:    albums,
+		dirs:      dirs,
+		statePath: statePath,
+		interval:  interval,
+		stableFor: stableFor,
+		state:     make(map[string]WatchedFile),
+		pending:   make(map[string]fileObservation),
+		now:       time.Now,
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения состояния отслеживаемых каталогов: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &w.state); err != nil {
+			return nil, fmt.Errorf("ошибка разбора состояния отслеживаемых каталогов: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// Start запускает периодический просмотр каталогов с поддержкой отмены через контекст
+func (w *FolderWatcher) Start(ctx context.Context) {
+	go w.run(ctx)
+}
+
+// run просматривает каталоги до отмены контекста
+func (w *FolderWatcher) run(ctx context.Context) {
+	log.Printf("Запуск отслеживания каталогов: %s", strings.Join(w.dirs, ", "))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Scan(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("Ошибка при просмотре отслеживаемых каталогов: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Отслеживание каталогов остановлено")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan просматривает каталоги и возвращает количество импортированных фотографий
+func (w *FolderWatcher) Scan(ctx context.Context) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	now := w.now()
+	seen := make(map[string]bool)
+	var ready []string
+
+	for _, dir := range w.dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Недоступный каталог не должен останавливать просмотр остальных
+				log.Printf("Ошибка чтения %s: %v", path, err)
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") && path != dir {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !watchedExtensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			seen[path] = true
+
+			// Уже обработанный файл импортируется снова, только если он изменился.
+			// После временной ошибки импорт повторяется с нарастающей паузой: файл уже дописан,
+			// поэтому ожидание stableFor не требуется.
+			if done, ok := w.state[path]; ok && done.Size == info.Size() && done.ModTime.Equal(info.ModTime()) {
+				if done.Error != "" && !done.Permanent && !now.Before(done.retryAt()) {
+					w.pending[path] = fileObservation{size: done.Size, modTime: done.ModTime, since: now}
+					ready = append(ready, path)
+				}
+				return nil
+			}
+
+			if w.isStable(path, info, now) {
+				ready = append(ready, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Забываем о файлах, которые удалили до завершения записи
+	for path := range w.pending {
+		if !seen[path] {
+			delete(w.pending, path)
+		}
+	}
+
+	if len(ready) == 0 {
+		return 0, nil
+	}
+
+	albumID, err := w.defaultAlbumID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	for _, path := range ready {
+		// Проверяем отмену контекста
+		select {
+		case <-ctx.Done():
+			return imported, ctx.Err()
+		default:
+			// Продолжаем выполнение
+		}
+
+		if w.importFile(ctx, albumID, path) {
+			imported++
+		}
+
+		// Состояние сохраняется после каждого файла: если процесс завершится посреди
+		// большого каталога, уже импортированные файлы не будут импортированы повторно
+		if err := w.saveState(); err != nil {
+			return imported, err
+		}
+	}
+
+	return imported, ctx.Err()
+}
+
+// isStable проверяет, что размер и время изменения файла не менялись в течение stableFor
+func (w *FolderWatcher) isStable(path string, info fs.FileInfo, now time.Time) bool {
+	observation, ok := w.pending[path]
+	if !ok || observation.size != info.Size() || !observation.modTime.Equal(info.ModTime()) {
+		w.pending[path] = fileObservation{size: info.Size(), modTime: info.ModTime(), since: now}
+		return false
+	}
+	return now.Sub(observation.since) >= w.stableFor && now.Sub(info.ModTime()) >= w.stableFor
+}
+
+// importFile сохраняет файл как фотографию и запоминает результат импорта
+func (w *FolderWatcher) importFile(ctx context.Context, albumID int, path string) bool {
+	observation := w.pending[path]
+	delete(w.pending, path)
+
+	record := WatchedFile{Size: observation.size, ModTime: observation.modTime, ImportedAt: w.now()}
+	photo, err := w.ingestFile(ctx, albumID, path, observation.size)
+	if err != nil && ctx.Err() != nil {
+		log.Printf("Импорт файла %s прерван: %v", path, err)
+		return false
+	}
+	if err != nil && permanentImportError(err) {
+		record.Error = err.Error()
+		record.Permanent = true
+		log.Printf("Файл %s не импортирован и будет пропускаться, пока не изменится: %v", path, err)
+	} else if err != nil {
+		record.Error = err.Error()
+		if previous, ok := w.state[path]; ok && previous.Error != "" &&
+			previous.Size == record.Size && previous.ModTime.Equal(record.ModTime) {
+			record.Attempts = previous.Attempts
+		}
+		record.Attempts++
+		log.Printf("Ошибка импорта файла %s (попытка %d, следующая после %s): %v",
+			path, record.Attempts, record.retryAt().Format(time.DateTime), err)
+	} else {
+		record.PhotoID = photo.ID
+		log.Printf("Импортирован файл %s: фотография ID=%d", path, photo.ID)
+	}
+
+	w.state[path] = record
+	return err == nil
+}
+
+// permanentImportError сообщает, что ошибка вызвана содержимым файла и повтор импорта ее не исправит
+func permanentImportError(err error) bool {
+	return errors.Is(err, ErrNotAnImage)
+}
+
+func (w *FolderWatcher) ingestFile(ctx context.Context, albumID int, path string, size int64) (models.Photo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return models.Photo{}, err
+	}
+	defer file.Close()
+
+	return w.photos.Ingest(ctx, albumID, nil, UploadFile{
+		File:     file,
+		Filename: filepath.Base(path),
+		Size:     size,
+	})
+}
+
+// defaultAlbumID находит альбом "Default" и создает его, если генерация сущностей еще не выполнялась
+func (w *FolderWatcher) defaultAlbumID(ctx context.Context) (int, error) {
+	albums, err := w.albums.GetAllAlbums(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	found := false
+	id := 0
+	for _, album := range albums {
+		if album.Name == DefaultAlbumName && (!found || album.ID < id) {
+			found = true
+			id = album.ID
+		}
+	}
+	if found {
+		return id, nil
+	}
+
+	return w.albums.AddAlbum(ctx, models.Album{
+		Name:        DefaultAlbumName,
+		Description: "Альбом по умолчанию для всех фотографий",
+	})
+}
+
+// saveState атомарно записывает состояние обработанных файлов
+func (w *FolderWatcher) saveState() error {
+	data, err := json.MarshalIndent(w.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.statePath), 0755); err != nil {
+		return err
+	}
+	tmp := w.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("ошибка сохранения состояния отслеживаемых каталогов: %w", err)
+	}
+	return os.Rename(tmp, w.statePath)
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"mpm/internal/models"
+	"mpm/internal/storage"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAlbumStore хранит альбомы в памяти
+type fakeAlbumStore struct {
+	albums []models.Album
+}
+
+func (f *fakeAlbumStore) GetAllAlbums(ctx context.Context) ([]models.Album, error) {
+	return f.albums, nil
+}
+
+func (f *fakeAlbumStore) AddAlbum(ctx context.Context, album models.Album) (int, error) {
+	album.ID = len(f.albums) + 1
+	f.albums = append(f.albums, album)
+	return album.ID, nil
+}
+
+func testPNGBytes(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))))
+	return buf.Bytes()
+}
+
+func TestFolderWatcher_Scan(t *testing.T) {
+	ctx := context.Background()
+	watchDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "watch_state.json")
+	defaultAlbum := models.Album{ID: 0, Name: DefaultAlbumName}
+	albums := &fakeAlbumStore{albums: []models.Album{{ID: 5, Name: "Другой"}, defaultAlbum}}
+
+	mockRepo := &MockPhotoRepository{}
+	mockRepo.On("FindAlbumByID", mock.Anything, 0).Return(defaultAlbum, nil)
+	mockRepo.On("AddPhoto", mock.Anything, mock.MatchedBy(func(p models.Photo) bool {
+		return p.Name == "scan.png" && p.Album.ID == 0
+	})).Return(1, nil).Once()
+	photoService := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+
+	clock := time.Now().Add(time.Hour)
+	newWatcher := func() *FolderWatcher {
+		w, err := NewFolderWatcher(photoService, albums, []string{watchDir}, statePath, time.Second, 5*time.Second)
+		require.NoError(t, err)
+		w.now = func() time.Time { return clock }
+		return w
+	}
+	watcher := newWatcher()
+
+	require.NoError(t, os.WriteFile(filepath.Join(watchDir, "scan.png"), testPNGBytes(t), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(watchDir, "notes.txt"), []byte("текст"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(watchDir, ".hidden.png"), testPNGBytes(t), 0644))
+
+	t.Run("Новый файл не импортируется сразу", func(t *testing.T) {
+		imported, err := watcher.Scan(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, imported)
+	})
+
+	t.Run("Файл дописывается - ожидание начинается заново", func(t *testing.T) {
+		partial := filepath.Join(watchDir, "partial.jpg")
+		require.NoError(t, os.WriteFile(partial, []byte{0xFF, 0xD8}, 0644))
+		_, err := watcher.Scan(ctx)
+		require.NoError(t, err)
+
+		clock = clock.Add(10 * time.Second)
+		require.NoError(t, os.WriteFile(partial, []byte{0xFF, 0xD8, 0xFF}, 0644))
+
+		imported, err := watcher.Scan(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, imported, "импортируется только scan.png")
+		require.NoError(t, os.Remove(partial))
+	})
+
+	t.Run("После перезапуска файл не импортируется повторно", func(t *testing.T) {
+		restarted := newWatcher()
+		clock = clock.Add(10 * time.Second)
+
+		for i := 0; i < 2; i++ {
+			imported, err := restarted.Scan(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 0, imported)
+			clock = clock.Add(10 * time.Second)
+		}
+
+		assert.Equal(t, 1, restarted.state[filepath.Join(watchDir, "scan.png")].PhotoID)
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFolderWatcher_RetriesFailedImport(t *testing.T) {
+	watchDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "watch_state.json")
+	defaultAlbum := models.Album{ID: 1, Name: DefaultAlbumName}
+	albums := &fakeAlbumStore{albums: []models.Album{defaultAlbum}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockRepo := &MockPhotoRepository{}
+	mockRepo.On("FindAlbumByID", mock.Anything, 1).Return(defaultAlbum, nil)
+	mockRepo.On("AddPhoto", mock.Anything, mock.Anything).Return(0, errors.New("хранилище недоступно")).Once()
+	mockRepo.On("AddPhoto", mock.Anything, mock.Anything).Run(func(mock.Arguments) { cancel() }).
+		Return(0, context.Canceled).Once()
+	mockRepo.On("AddPhoto", mock.Anything, mock.Anything).Return(7, nil).Once()
+	photoService := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+
+	clock := time.Now().Add(time.Hour)
+	newWatcher := func() *FolderWatcher {
+		w, err := NewFolderWatcher(photoService, albums, []string{watchDir}, statePath, time.Second, 5*time.Second)
+		require.NoError(t, err)
+		w.now = func() time.Time { return clock }
+		return w
+	}
+	watcher := newWatcher()
+	path := filepath.Join(watchDir, "retry.png")
+	require.NoError(t, os.WriteFile(path, testPNGBytes(t), 0644))
+
+	t.Run("Ошибка импорта запоминается", func(t *testing.T) {
+		_, err := watcher.Scan(ctx)
+		require.NoError(t, err)
+		clock = clock.Add(10 * time.Second)
+
+		imported, err := watcher.Scan(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, imported)
+		assert.Equal(t, 1, watcher.state[path].Attempts)
+		assert.NotEmpty(t, watcher.state[path].Error)
+
+		imported, _ = watcher.Scan(ctx)
+		assert.Equal(t, 0, imported, "повтор только после паузы")
+	})
+
+	t.Run("Прерванный импорт не запоминается", func(t *testing.T) {
+		clock = clock.Add(importRetryDelay)
+		_, err := watcher.Scan(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, watcher.state[path].Attempts, "отмена не считается неудачной попыткой")
+	})
+
+	t.Run("Повторная попытка после перезапуска", func(t *testing.T) {
+		restarted := newWatcher()
+		imported, err := restarted.Scan(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, imported)
+		assert.Equal(t, 7, restarted.state[path].PhotoID)
+		assert.Empty(t, restarted.state[path].Error)
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFolderWatcher_RestartMidBatch(t *testing.T) {
+	watchDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "watch_state.json")
+	defaultAlbum := models.Album{ID: 1, Name: DefaultAlbumName}
+	albums := &fakeAlbumStore{albums: []models.Album{defaultAlbum}}
+
+	// Процесс завершается во время импорта второго файла
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	named := func(name string) any {
+		return mock.MatchedBy(func(p models.Photo) bool { return p.Name == name })
+	}
+	mockRepo := &MockPhotoRepository{}
+	mockRepo.On("FindAlbumByID", mock.Anything, 1).Return(defaultAlbum, nil)
+	mockRepo.On("AddPhoto", mock.Anything, named("a.png")).Return(1, nil).Once()
+	mockRepo.On("AddPhoto", mock.Anything, named("b.png")).Run(func(mock.Arguments) { cancel() }).
+		Return(0, context.Canceled).Once()
+	mockRepo.On("AddPhoto", mock.Anything, named("b.png")).Return(2, nil).Once()
+	photoService := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+
+	clock := time.Now().Add(time.Hour)
+	newWatcher := func() *FolderWatcher {
+		w, err := NewFolderWatcher(photoService, albums, []string{watchDir}, statePath, time.Second, 5*time.Second)
+		require.NoError(t, err)
+		w.now = func() time.Time { return clock }
+		return w
+	}
+	for _, name := range []string{"a.png", "b.png"} {
+		require.NoError(t, os.WriteFile(filepath.Join(watchDir, name), testPNGBytes(t), 0644))
+	}
+
+	watcher := newWatcher()
+	_, err := watcher.Scan(ctx)
+	require.NoError(t, err)
+	clock = clock.Add(10 * time.Second)
+	imported, err := watcher.Scan(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, imported)
+
+	restarted := newWatcher()
+	assert.Equal(t, 1, restarted.state[filepath.Join(watchDir, "a.png")].PhotoID, "импортированный файл сохранен в состоянии")
+	assert.NotContains(t, restarted.state, filepath.Join(watchDir, "b.png"))
+
+	_, err = restarted.Scan(context.Background())
+	require.NoError(t, err)
+	clock = clock.Add(10 * time.Second)
+	imported, err = restarted.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported, "после перезапуска импортируется только оставшийся файл")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFolderWatcher_SkipsInvalidFile(t *testing.T) {
+	watchDir := t.TempDir()
+	defaultAlbum := models.Album{ID: 1, Name: DefaultAlbumName}
+	albums := &fakeAlbumStore{albums: []models.Album{defaultAlbum}}
+
+	mockRepo := &MockPhotoRepository{}
+	mockRepo.On("FindAlbumByID", mock.Anything, 1).Return(defaultAlbum, nil)
+	photoService := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+
+	clock := time.Now().Add(time.Hour)
+	watcher, err := NewFolderWatcher(photoService, albums, []string{watchDir}, filepath.Join(t.TempDir(), "state.json"), time.Second, 5*time.Second)
+	require.NoError(t, err)
+	watcher.now = func() time.Time { return clock }
+
+	path := filepath.Join(watchDir, "notes.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("это текст, а не фотография"), 0644))
+
+	_, err = watcher.Scan(context.Background())
+	require.NoError(t, err)
+	clock = clock.Add(10 * time.Second)
+	_, err = watcher.Scan(context.Background())
+	require.NoError(t, err)
+
+	record := watcher.state[path]
+	assert.True(t, record.Permanent)
+	assert.Equal(t, ErrNotAnImage.Error(), record.Error)
+	assert.Zero(t, record.Attempts)
+
+	clock = clock.Add(2 * maxImportRetryDelay)
+	imported, err := watcher.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, imported)
+	assert.Equal(t, record, watcher.state[path], "файл с некорректным содержимым не импортируется повторно")
+	mockRepo.AssertNotCalled(t, "AddPhoto", mock.Anything, mock.Anything)
+}
+
+func TestWatchedFile_RetryAt(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, at.Add(time.Minute), WatchedFile{ImportedAt: at, Attempts: 1}.retryAt())
+	assert.Equal(t, at.Add(4*time.Minute), WatchedFile{ImportedAt: at, Attempts: 3}.retryAt())
+	assert.Equal(t, at.Add(maxImportRetryDelay), WatchedFile{ImportedAt: at, Attempts: 12}.retryAt())
+	assert.Equal(t, at.Add(maxImportRetryDelay), WatchedFile{ImportedAt: at, Attempts: 100}.retryAt())
+}
+
+func TestFolderWatcher_CreatesDefaultAlbum(t *testing.T) {
+	albums := &fakeAlbumStore{}
+	watcher, err := NewFolderWatcher(nil, albums, nil, filepath.Join(t.TempDir(), "state.json"), time.Second, 0)
+	require.NoError(t, err)
+
+	id, err := watcher.defaultAlbumID(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, albums.albums, 1)
+	assert.Equal(t, DefaultAlbumName, albums.albums[0].Name)
+	assert.Equal(t, albums.albums[0].ID, id)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"mpm/internal/geo"
+	"mpm/internal/models"
+)
+
+// GeoRepositoryInterface определяет методы репозитория для поиска фотографий на карте
+type GeoRepositoryInterface interface {
+	FindPhotosInBox(ctx context.Context, bbox geo.BBox, filter models.PhotoFilter) ([]models.Photo, error)
+	FindAlbumByID(ctx context.Context, id int) (models.Album, error)
+}
+
+// GeoSearchResult фотографии в области карты, сгруппированные для указанного масштаба
+type GeoSearchResult struct {
+	BBox     geo.BBox      `json:"bbox"`
+	Zoom     int           `json:"zoom"`
+	Total    int           `json:"total"` // Количество фотографий во всех группах
+	Clusters []geo.Cluster `json:"clusters"`
+}
+
+// GeoService ищет фотографии по координатам съемки из метаданных GPS
+type GeoService struct {
+	repo GeoRepositoryInterface
+}
+
+// NewGeoService создает сервис поиска фотографий на карте
+func NewGeoService(repo GeoRepositoryInterface) *GeoService {
+	return &GeoService{repo: repo}
+}
+
+// Search возвращает группы фотографий, снятых внутри области и удовлетворяющих фильтру
+func (s *GeoService) Search(ctx context.Context, bbox geo.BBox, zoom int, filter models.PhotoFilter) (GeoSearchResult, error) {
+	photos, err := s.repo.FindPhotosInBox(ctx, bbox, filter)
+	if err != nil {
+		return GeoSearchResult{}, err
+	}
+
+	zoom = geo.ClampZoom(zoom)
+	return GeoSearchResult{
+		BBox:     bbox,
+		Zoom:     zoom,
+		Total:    len(photos),
+		Clusters: geo.ClusterPoints(geoPoints(photos), zoom),
+	}, nil
+}
+
+// AlbumMap возвращает фотографии альбома в виде точек GeoJSON, сгруппированных для масштаба
+func (s *GeoService) AlbumMap(ctx context.Context, albumID, zoom int) (geo.FeatureCollection, error) {
+	album, err := s.repo.FindAlbumByID(ctx, albumID)
+	if err != nil {
+		return geo.FeatureCollection{}, err
+	}
+
+	filter := models.PhotoFilter{AlbumID: &albumID}
+	if album.IsSmart() {
+		filter = *album.Filter
+	}
+	photos, err := s.repo.FindPhotosInBox(ctx, geo.World, filter)
+	if err != nil {
+		return geo.FeatureCollection{}, err
+	}
+
+	byID := make(map[int]models.Photo, len(photos))
+	for _, photo := range photos {
+		byID[photo.ID] = photo
+	}
+
+	collection := geo.NewFeatureCollection(geo.ClusterPoints(geoPoints(photos), geo.ClampZoom(zoom)))
+	for _, feature := range collection.Features {
+		ids, _ := feature.Properties["photo_ids"].([]int)
+		if len(ids) != 1 {
+			continue
+		}
+		photo := byID[ids[0]]
+		feature.Properties["photo_id"] = photo.ID
+		feature.Properties["name"] = photo.Name
+		feature.Properties["taken_at"] = photo.CapturedAt().Format(time.RFC3339)
+	}
+	return collection, nil
+}
+
+// geoPoints извлекает координаты съемки фотографий
+func geoPoints(photos []models.Photo) []geo.Point {
+	result := make([]geo.Point, 0, len(photos))
+	for _, photo := range photos {
+		if lat, lon, ok := photo.Location(); ok {
+			result = append(result, geo.Point{ID: photo.ID, Lat: lat, Lon: lon})
+		}
+	}
+	return result
+}
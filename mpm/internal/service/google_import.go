@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mpm/internal/models"
+	"mpm/internal/storage/google"
+)
+
+// maxGoogleImportErrors ограничивает количество ошибок, сохраняемых в состоянии импорта
+const maxGoogleImportErrors = 100
+
+// ErrImportRunning возвращается при попытке запустить импорт, пока выполняется предыдущий
+var ErrImportRunning = errors.New("импорт уже выполняется")
+
+// GoogleLibrary описывает методы клиента Google Photos, необходимые для импорта
+type GoogleLibrary interface {
+	ListAlbums(ctx context.Context) ([]google.Album, error)
+	ListAlbumItems(ctx context.Context, albumID string, fn func(google.MediaItem) error) error
+}
+
+// GoogleImportStatus состояние задачи импорта из Google Photos
+type GoogleImportStatus struct {
+	Running    bool          `json:"running"`
+	StartedAt  *time.Time    `json:"started_at,omitempty"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	Albums     int           `json:"albums"`   // Обработано альбомов
+	Imported   int           `json:"imported"` // Добавлено фотографий
+	Skipped    int           `json:"skipped"`  // Пропущено уже импортированных
+	Errors     []ImportError `json:"errors,omitempty"`
+	Error      string        `json:"error,omitempty"` // Ошибка, прервавшая импорт
+}
+
+// googleImportState соответствие объектов Google Photos локальным записям
+type googleImportState struct {
+	Albums map[string]int `json:"albums"` // Идентификатор альбома Google -> ID альбома
+	Items  map[string]int `json:"items"`  // Идентификатор медиафайла -> ID фотографии
+}
+
+// GoogleImporter импортирует альбомы Google Photos без скачивания файлов
+type GoogleImporter struct {
+	library   GoogleLibrary
+	repo      PhotoRepositoryInterface
+	statePath string
+
+	mutex  sync.Mutex
+	status GoogleImportStatus
+	state  googleImportState
+	now    func() time.Time
+}
+
+// NewGoogleImporter создает задачу импорта. Состояние загружается из statePath.
+func NewGoogleImporter(library GoogleLibrary, repo PhotoRepositoryInterface, statePath string) (*GoogleImporter, error) {
+	g := &GoogleImporter{
+		library:   library,
+		repo:      repo,
+		statePath: statePath,
+		state:     googleImportState{Albums: map[string]int{}, Items: map[string]int{}},
+		now:       time.Now,
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения состояния импорта Google Photos: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &g.state); err != nil {
+			return nil, fmt.Errorf("ошибка разбора состояния импорта Google Photos: %w", err)
+		}
+	}
+	return g, nil
+}
+
+// Status возвращает состояние текущего или последнего импорта
+func (g *GoogleImporter) Status() GoogleImportStatus {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	status := g.status
+	status.Errors = append([]ImportError(nil), g.status.Errors...)
+	return status
+}
+
+// Start запускает импорт в фоне от имени user
+func (g *GoogleImporter) Start(ctx context.Context, user *models.User) error {
+	if err := g.begin(); err != nil {
+		return err
+	}
+	go g.run(context.WithoutCancel(ctx), user)
+	return nil
+}
+
+// Run выполняет импорт синхронно и возвращает его итоговое состояние
+func (g *GoogleImporter) Run(ctx context.Context, user *models.User) (GoogleImportStatus, error) {
+	if err := g.begin(); err != nil {
+		return GoogleImportStatus{}, err
+	}
+	err := g.run(ctx, user)
+	return g.Status(), err
+}
+
+// begin отмечает начало импорта или возвращает ErrImportRunning
+func (g *GoogleImporter) begin() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.status.Running {
+		return ErrImportRunning
+	}
+	now := g.now()
+	g.status = GoogleImportStatus{Running: true, StartedAt: &now}
+	return nil
+}
+
+func (g *GoogleImporter) run(ctx context.Context, user *models.User) error {
+	log.Println("Запуск импорта из Google Photos")
+
+	err := g.importAlbums(ctx, user)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	now := g.now()
+	g.status.Running = false
+	g.status.FinishedAt = &now
+	if err != nil {
+		g.status.Error = err.Error()
+		log.Printf("Импорт из Google Photos прерван: %v", err)
+	} else {
+		log.Printf("Импорт из Google Photos завершен: альбомов %d, добавлено %d, пропущено %d, ошибок %d",
+			g.status.Albums, g.status.Imported, g.status.Skipped, len(g.status.Errors))
+	}
+	return err
+}
+
+func (g *GoogleImporter) importAlbums(ctx context.Context, user *models.User) error {
+	albums, err := g.library.ListAlbums(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, album := range albums {
+		if err := g.importAlbum(ctx, album, user); err != nil {
+			return err
+		}
+
+		g.mutex.Lock()
+		g.status.Albums++
+		err := g.saveState()
+		g.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importAlbum добавляет в локальный альбом медиафайлы, которые еще не импортированы
+func (g *GoogleImporter) importAlbum(ctx context.Context, source google.Album, user *models.User) error {
+	album, err := g.localAlbum(ctx, source, user)
+	if err != nil {
+		return err
+	}
+
+	return g.library.ListAlbumItems(ctx, source.ID, func(item google.MediaItem) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if g.imported(item.ID) {
+			g.mutex.Lock()
+			g.status.Skipped++
+			g.mutex.Unlock()
+			return nil
+		}
+
+		photo := item.ToPhoto()
+		photo.Album = &album
+		photo.User = publicUser(user)
+		photo.CreatedAt = g.now()
+
+		id, err := g.repo.AddPhoto(ctx, photo)
+
+		g.mutex.Lock()
+		defer g.mutex.Unlock()
+		if err != nil {
+			if len(g.status.Errors) < maxGoogleImportErrors {
+				g.status.Errors = append(g.status.Errors, ImportError{File: item.Filename, Error: err.Error()})
+			}
+			return nil
+		}
+		g.state.Items[item.ID] = id
+		g.status.Imported++
+		return nil
+	})
+}
+
+// imported проверяет, что медиафайл уже импортирован и фотография не удалена
+func (g *GoogleImporter) imported(itemID string) bool {
+	g.mutex.Lock()
+	photoID, ok := g.state.Items[itemID]
+	g.mutex.Unlock()
+	if !ok {
+		return false
+	}
+	_, err := g.repo.FindPhotoByID(photoID)
+	return err == nil
+}
+
+// localAlbum возвращает альбом, созданный при прошлом импорте, или создает новый
+func (g *GoogleImporter) localAlbum(ctx context.Context, source google.Album, user *models.User) (models.Album, error) {
+	g.mutex.Lock()
+	albumID, ok := g.state.Albums[source.ID]
+	g.mutex.Unlock()
+	if ok {
+		if album, err := g.repo.FindAlbumByID(ctx, albumID); err == nil {
+			album.Photos = nil
+			return album, nil
+		}
+	}
+
+	album := models.Album{
+		Name:        source.Title,
+		Description: "Импортировано из Google Photos",
+		User:        publicUser(user),
+		Tags:        []string{},
+		CreatedAt:   g.now(),
+	}
+	if album.Name == "" {
+		album.Name = "Google Photos"
+	}
+
+	id, err := g.repo.AddAlbum(ctx, album)
+	if err != nil {
+		return models.Album{}, fmt.Errorf("ошибка создания альбома %q: %w", album.Name, err)
+	}
+	album.ID = id
+
+	g.mutex.Lock()
+	g.state.Albums[source.ID] = id
+	g.mutex.Unlock()
+	return album, nil
+}
+
+// saveState записывает состояние во временный файл и переименовывает его. Вызывается под mutex.
+func (g *GoogleImporter) saveState() error {
+	data, err := json.MarshalIndent(g.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(g.statePath), 0755); err != nil {
+		return err
+	}
+	tmp := g.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("ошибка сохранения состояния импорта Google Photos: %w", err)
+	}
+	return os.Rename(tmp, g.statePath)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mpm/internal/models"
+	"mpm/internal/storage/google"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGoogleLibrary библиотека Google Photos в памяти
+type fakeGoogleLibrary struct {
+	albums []google.Album
+	items  map[string][]google.MediaItem
+}
+
+func (f *fakeGoogleLibrary) ListAlbums(ctx context.Context) ([]google.Album, error) {
+	return f.albums, nil
+}
+
+func (f *fakeGoogleLibrary) ListAlbumItems(ctx context.Context, albumID string, fn func(google.MediaItem) error) error {
+	for _, item := range f.items[albumID] {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGoogleImporter_Run(t *testing.T) {
+	ctx := context.Background()
+	statePath := filepath.Join(t.TempDir(), "google_import.json")
+	user := &models.User{ID: 1, Username: "admin", Password: "secret"}
+	library := &fakeGoogleLibrary{
+		albums: []google.Album{{ID: "g1", Title: "Отпуск"}, {ID: "g2", Title: "Семья"}},
+		items: map[string][]google.MediaItem{
+			"g1": {
+				{ID: "m1", Filename: "sea.jpg", MimeType: "image/jpeg"},
+				{ID: "m2", Filename: "broken.jpg", MimeType: "image/jpeg"},
+			},
+			"g2": {{ID: "m3", Filename: "mom.jpg", MimeType: "image/jpeg"}},
+		},
+	}
+
+	mockRepo := &MockPhotoRepository{}
+	mockRepo.On("AddAlbum", mock.Anything, mock.MatchedBy(func(a models.Album) bool { return a.Name == "Отпуск" })).Return(10, nil).Once()
+	mockRepo.On("AddAlbum", mock.Anything, mock.MatchedBy(func(a models.Album) bool { return a.Name == "Семья" })).Return(11, nil).Once()
+	mockRepo.On("AddPhoto", mock.Anything, mock.MatchedBy(func(p models.Photo) bool {
+		return p.Path == "m1" && p.StorageType == google.StorageType && p.Album.ID == 10 && p.User.Password == ""
+	})).Return(100, nil).Once()
+	mockRepo.On("AddPhoto", mock.Anything, mock.MatchedBy(func(p models.Photo) bool { return p.Path == "m2" })).
+		Return(0, errors.New("ошибка записи")).Once()
+	mockRepo.On("AddPhoto", mock.Anything, mock.MatchedBy(func(p models.Photo) bool {
+		return p.Path == "m3" && p.Album.ID == 11
+	})).Return(101, nil).Once()
+
+	importer, err := NewGoogleImporter(library, mockRepo, statePath)
+	require.NoError(t, err)
+
+	status, err := importer.Run(ctx, user)
+	require.NoError(t, err)
+	assert.False(t, status.Running)
+	assert.NotNil(t, status.FinishedAt)
+	assert.Equal(t, 2, status.Albums)
+	assert.Equal(t, 2, status.Imported)
+	require.Len(t, status.Errors, 1)
+	assert.Equal(t, "broken.jpg", status.Errors[0].File)
+
+	t.Run("Повторный импорт добавляет только новые медиафайлы", func(t *testing.T) {
+		library.items["g1"] = append(library.items["g1"], google.MediaItem{ID: "m4", Filename: "new.jpg"})
+
+		mockRepo.On("FindAlbumByID", mock.Anything, 10).Return(models.Album{ID: 10, Name: "Отпуск"}, nil)
+		mockRepo.On("FindAlbumByID", mock.Anything, 11).Return(models.Album{ID: 11, Name: "Семья"}, nil)
+		mockRepo.On("FindPhotoByID", 100).Return(models.Photo{ID: 100}, nil)
+		mockRepo.On("FindPhotoByID", 101).Return(models.Photo{ID: 101}, nil)
+		mockRepo.On("AddPhoto", mock.Anything, mock.MatchedBy(func(p models.Photo) bool { return p.Path == "m2" })).Return(102, nil).Once()
+		mockRepo.On("AddPhoto", mock.Anything, mock.MatchedBy(func(p models.Photo) bool { return p.Path == "m4" })).Return(103, nil).Once()
+
+		// Состояние читается из файла, как после перезапуска
+		restarted, err := NewGoogleImporter(library, mockRepo, statePath)
+		require.NoError(t, err)
+
+		status, err := restarted.Run(ctx, user)
+		require.NoError(t, err)
+		assert.Equal(t, 2, status.Imported)
+		assert.Equal(t, 2, status.Skipped)
+		assert.Empty(t, status.Errors)
+	})
+
+	mockRepo.AssertExpectations(t)
+}
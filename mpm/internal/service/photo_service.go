@@ -0,0 +1,798 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"log"
+	"mime/multipart"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mpm/internal/exif"
+	"mpm/internal/geocode"
+	"mpm/internal/imaging"
+	"mpm/internal/models"
+	"mpm/internal/similarity"
+	"mpm/internal/storage"
+)
+
+var (
+	// ErrNotAnImage возвращается, если содержимое файла не является изображением или видео
+	ErrNotAnImage = errors.New("файл не является изображением или видео")
+	// ErrFileTooLarge возвращается, если файл превышает допустимый размер
+	ErrFileTooLarge = errors.New("файл превышает максимальный размер")
+	// ErrInvalidPhoto возвращается при некорректных данных фотографии
+	ErrInvalidPhoto = errors.New("некорректные данные фотографии")
+	// ErrSmartAlbum возвращается при попытке добавить фотографию в умный альбом
+	ErrSmartAlbum = errors.New("фотографии умного альбома подбираются по запросу, добавить их нельзя")
+)
+
+// PhotoRepositoryInterface описывает методы репозитория, необходимые для работы с фотографиями
+type PhotoRepositoryInterface interface {
+	FindAlbumByID(ctx context.Context, id int) (models.Album, error)
+	AddAlbum(ctx context.Context, album models.Album) (int, error)
+	DeleteAlbum(ctx context.Context, id int) error
+	PurgeAlbum(ctx context.Context, id int) error
+	FindPhotoByID(id int) (models.Photo, error)
+	FindPhotos(ctx context.Context, filter models.PhotoFilter) ([]models.Photo, error)
+	AddPhoto(ctx context.Context, photo models.Photo) (int, error)
+	UpdatePhoto(ctx context.Context, id int, photo models.Photo) error
+	UpdatePhotoFields(ctx context.Context, id int, update func(photo *models.Photo)) error
+	DeletePhoto(ctx context.Context, id int) error
+	FindSimilarPhotos(ctx context.Context, hash uint64, threshold, excludeID int) ([]similarity.Match, error)
+	FindPhotoClusters(ctx context.Context, threshold int) ([][]int, error)
+}
+
+// UploadFile описывает загружаемый файл
+type UploadFile struct {
+	File     multipart.File
+	Filename string
+	Size     int64
+
+	// Дополнительные данные, например восстановленные из manifest.json при импорте
+	Tags     []string
+	Metadata []models.Metadata // Дополняют и заменяют значения из EXIF
+	TakenAt  *time.Time        // Используется, если дата съемки не найдена в EXIF
+}
+
+// PhotoUpdate описывает изменяемые поля фотографии. Поля со значением nil не меняются.
+type PhotoUpdate struct {
+	Name     *string           `json:"name,omitempty"`
+	AlbumID  *int              `json:"album_id,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Metadata []models.Metadata `json:"metadata,omitempty"`
+}
+
+// DefaultRenditionSizes размеры уменьшенных копий по умолчанию
+var DefaultRenditionSizes = []int{256, 1024, 2048}
+
+// SimilarPhoto фотография, визуально похожая на исходную
+type SimilarPhoto struct {
+	models.Photo
+	Distance int `json:"distance"` // Расстояние Хэмминга между перцептивными хешами
+}
+
+// DuplicateCluster группа визуально похожих фотографий
+type DuplicateCluster struct {
+	Photos []models.Photo `json:"photos"`
+}
+
+// PhotoService отвечает за прием фотографий и их сохранение в хранилище файлов
+type PhotoService struct {
+	repo           PhotoRepositoryInterface
+	storage        storage.Provider
+	storageType    string
+	providers      map[string]storage.Provider // Хранилища фотографий, сохраненных ранее в другом месте
+	maxUploadSize  int64
+	renditionSizes []int
+	importLimits   ImportLimits
+	importDir      string            // Каталог распаковки импортируемых архивов; пусто - системный
+	geocoder       *geocode.Geocoder // Определяет место съемки по координатам; nil отключает
+	rules          *RuleService      // Правила автоматической расстановки тегов; nil отключает
+}
+
+// NewPhotoService создает новый сервис для работы с фотографиями
+func NewPhotoService(repo PhotoRepositoryInterface, provider storage.Provider, storageType string, maxUploadSize int64) *PhotoService {
+	return &PhotoService{
+		repo:           repo,
+		storage:        provider,
+		storageType:    storageType,
+		providers:      map[string]storage.Provider{storageType: provider},
+		maxUploadSize:  maxUploadSize,
+		renditionSizes: DefaultRenditionSizes,
+		importLimits:   DefaultImportLimits,
+	}
+}
+
+// RegisterProvider подключает хранилище для фотографий с указанным StorageType
+func (s *PhotoService) RegisterProvider(storageType string, provider storage.Provider) {
+	s.providers[storageType] = provider
+}
+
+// providerFor возвращает хранилище, в котором лежат файлы фотографии
+func (s *PhotoService) providerFor(photo models.Photo) storage.Provider {
+	if provider, ok := s.providers[photo.StorageType]; ok {
+		return provider
+	}
+	return s.storage
+}
+
+// storageTypeOf возвращает тип хранилища, из которого читаются файлы фотографии
+func (s *PhotoService) storageTypeOf(photo models.Photo) string {
+	if _, ok := s.providers[photo.StorageType]; ok {
+		return photo.StorageType
+	}
+	return s.storageType
+}
+
+// SetRenditionSizes задает размеры уменьшенных копий. Пустой список отключает их создание.
+func (s *PhotoService) SetRenditionSizes(sizes []int) {
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+	s.renditionSizes = sorted
+}
+
+// MaxUploadSize возвращает максимальный размер одного файла в байтах
+func (s *PhotoService) MaxUploadSize() int64 {
+	return s.maxUploadSize
+}
+
+// Upload проверяет файл, сохраняет его в хранилище и создает запись о фотографии в альбоме
+func (s *PhotoService) Upload(ctx context.Context, albumID int, user *models.User, upload UploadFile) (models.Photo, error) {
+	album, err := s.repo.FindAlbumByID(ctx, albumID)
+	if err != nil {
+		return models.Photo{}, err
+	}
+	if album.IsSmart() {
+		return models.Photo{}, ErrSmartAlbum
+	}
+
+	if s.maxUploadSize > 0 && upload.Size > s.maxUploadSize {
+		return models.Photo{}, ErrFileTooLarge
+	}
+
+	return s.ingest(ctx, album, user, upload)
+}
+
+// Ingest сохраняет файл как фотографию альбома без ограничения размера загрузки
+func (s *PhotoService) Ingest(ctx context.Context, albumID int, user *models.User, upload UploadFile) (models.Photo, error) {
+	album, err := s.repo.FindAlbumByID(ctx, albumID)
+	if err != nil {
+		return models.Photo{}, err
+	}
+	if album.IsSmart() {
+		return models.Photo{}, ErrSmartAlbum
+	}
+
+	return s.ingest(ctx, album, user, upload)
+}
+
+// CheckUploadAlbum проверяет, что альбом существует и в него можно добавлять фотографии
+func (s *PhotoService) CheckUploadAlbum(ctx context.Context, albumID int) error {
+	album, err := s.repo.FindAlbumByID(ctx, albumID)
+	if err != nil {
+		return err
+	}
+	if album.IsSmart() {
+		return ErrSmartAlbum
+	}
+	return nil
+}
+
+// ingest проверяет содержимое файла, сохраняет его в хранилище и создает запись о фотографии
+func (s *PhotoService) ingest(ctx context.Context, album models.Album, user *models.User, upload UploadFile) (models.Photo, error) {
+	// Проверяем сигнатуру файла, расширению и Content-Type клиента не доверяем
+	mimeType, err := storage.SniffMedia(upload.File)
+	if err != nil {
+		return models.Photo{}, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	if mimeType == "" {
+		return models.Photo{}, ErrNotAnImage
+	}
+
+	checksum, err := storage.Checksum(upload.File)
+	if err != nil {
+		return models.Photo{}, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+
+	mediaType := models.MediaTypePhoto
+	var videoInfo *models.VideoInfo
+	var metadata []models.Metadata
+	var takenAt *time.Time
+	if storage.IsVideo(mimeType) {
+		mediaType = models.MediaTypeVideo
+		videoInfo, metadata, takenAt = probeVideo(upload)
+	} else {
+		metadata, takenAt = extractMetadata(upload, mimeType)
+	}
+	metadata = s.placeMetadata(mergeMetadata(metadata, upload.Metadata))
+	if takenAt == nil {
+		takenAt = upload.TakenAt
+	}
+
+	tags := append([]string{}, upload.Tags...)
+
+	name := sanitizeFilename(upload.Filename)
+	key := path.Join("albums", fmt.Sprint(album.ID), fmt.Sprintf("%d_%s", time.Now().UnixNano(), name))
+
+	storedPath, err := s.storage.Save(upload.File, key)
+	if err != nil {
+		return models.Photo{}, fmt.Errorf("ошибка сохранения файла: %w", err)
+	}
+
+	orientation := metadataOrientation(metadata)
+	var renditions []models.Rendition
+	var width, height int
+	var phash string
+	if videoInfo != nil {
+		// Кадры видео не декодируются, поэтому уменьшенных копий и перцептивного хеша у видео нет
+		width, height = videoInfo.Width, videoInfo.Height
+	} else if mediaType == models.MediaTypePhoto {
+		if img := decodeImage(upload.File, storedPath); img != nil {
+			width, height = imaging.OrientedSize(img.Bounds().Dx(), img.Bounds().Dy(), orientation)
+			renditions = s.createRenditions(img, storedPath, orientation)
+			phash = similarity.FormatHash(imaging.DHash(img, orientation))
+		}
+	}
+
+	// В фотографии храним только ссылку на альбом, без вложенных фотографий
+	albumRef := album
+	albumRef.Photos = nil
+
+	photo := models.Photo{
+		Name:        name,
+		Path:        storedPath,
+		Album:       &albumRef,
+		User:        publicUser(user),
+		Tags:        tags,
+		Metadata:    metadata,
+		StorageType: s.storageType,
+		MimeType:    mimeType,
+		Size:        upload.Size,
+		Checksum:    checksum,
+		PHash:       phash,
+		Width:       width,
+		Height:      height,
+		Renditions:  renditions,
+		TakenAt:     takenAt,
+		CreatedAt:   time.Now(),
+		MediaType:   mediaType,
+		Video:       videoInfo,
+	}
+	if s.rules != nil {
+		photo.Tags = append(photo.Tags, s.rules.Tags(ctx, photo)...)
+	}
+
+	id, err := s.repo.AddPhoto(ctx, photo)
+	if err != nil {
+		// Не оставляем в хранилище файлы без записи о фотографии
+		s.deleteFiles(photo)
+		return models.Photo{}, fmt.Errorf("ошибка сохранения фотографии: %w", err)
+	}
+	photo.ID = id
+
+	log.Printf("Загружена фотография: ID=%d, Название=%s, Альбом=%d", photo.ID, photo.Name, album.ID)
+	return photo, nil
+}
+
+// ListPhotos возвращает фотографии, удовлетворяющие фильтру
+func (s *PhotoService) ListPhotos(ctx context.Context, filter models.PhotoFilter) ([]models.Photo, error) {
+	return s.repo.FindPhotos(ctx, filter)
+}
+
+// FindDuplicates возвращает другие фотографии с тем же содержимым
+func (s *PhotoService) FindDuplicates(ctx context.Context, photo models.Photo) ([]models.Photo, error) {
+	if photo.Checksum == "" {
+		return nil, nil
+	}
+
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{Checksum: photo.Checksum})
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := make([]models.Photo, 0, len(photos))
+	for _, p := range photos {
+		if p.ID != photo.ID {
+			duplicates = append(duplicates, p)
+		}
+	}
+	return duplicates, nil
+}
+
+// SimilarPhotos возвращает фотографии, визуально похожие на фотографию с указанным ID
+func (s *PhotoService) SimilarPhotos(ctx context.Context, id, threshold int) ([]SimilarPhoto, error) {
+	photo, err := s.GetPhoto(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if photo.PHash == "" {
+		return []SimilarPhoto{}, nil
+	}
+
+	hash, err := similarity.ParseHash(photo.PHash)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный перцептивный хеш фотографии с ID=%d: %w", id, err)
+	}
+
+	matches, err := s.repo.FindSimilarPhotos(ctx, hash, threshold, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SimilarPhoto, 0, len(matches))
+	for _, match := range matches {
+		similar, err := s.repo.FindPhotoByID(match.ID)
+		if err != nil {
+			// Фотография могла быть удалена после построения индекса
+			continue
+		}
+		result = append(result, SimilarPhoto{Photo: similar, Distance: match.Distance})
+	}
+	return result, nil
+}
+
+// DuplicateClusters возвращает группы визуально похожих фотографий
+func (s *PhotoService) DuplicateClusters(ctx context.Context, threshold int) ([]DuplicateCluster, error) {
+	clusters, err := s.repo.FindPhotoClusters(ctx, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]DuplicateCluster, 0, len(clusters))
+	for _, ids := range clusters {
+		cluster := DuplicateCluster{Photos: make([]models.Photo, 0, len(ids))}
+		for _, id := range ids {
+			if photo, err := s.repo.FindPhotoByID(id); err == nil {
+				cluster.Photos = append(cluster.Photos, photo)
+			}
+		}
+		if len(cluster.Photos) > 1 {
+			result = append(result, cluster)
+		}
+	}
+	return result, nil
+}
+
+// BackfillPerceptualHashes вычисляет перцептивные хеши фотографий, загруженных без них
+func (s *PhotoService) BackfillPerceptualHashes(ctx context.Context) (int, error) {
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{})
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, photo := range photos {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+		if photo.PHash != "" || photo.Path == "" || photo.Kind() == models.MediaTypeVideo {
+			continue
+		}
+
+		phash, err := s.computePHash(photo)
+		if err != nil {
+			log.Printf("Не удалось вычислить перцептивный хеш фотографии ID=%d: %v", photo.ID, err)
+			continue
+		}
+
+		err = s.repo.UpdatePhotoFields(ctx, photo.ID, func(photo *models.Photo) {
+			photo.PHash = phash
+		})
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	if updated > 0 {
+		log.Printf("Вычислены перцептивные хеши для %d фотографий", updated)
+	}
+	return updated, nil
+}
+
+// RewrapEncryptionKeys перешифровывает ключи файлов текущим мастер-ключом
+func (s *PhotoService) RewrapEncryptionKeys(ctx context.Context) (int, error) {
+	// Ключи фотографий в корзине тоже перешифровываются, иначе после удаления старого
+	// мастер-ключа восстановленные фотографии нельзя будет расшифровать
+	photos, err := s.allPhotos(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, photo := range photos {
+		rewrapper, ok := s.providerFor(photo).(storage.KeyRewrapper)
+		if !ok || photo.Path == "" {
+			continue
+		}
+
+		for _, p := range photo.FilePaths() {
+			if err := ctx.Err(); err != nil {
+				return rewrapped, err
+			}
+			changed, err := rewrapper.RewrapKey(p)
+			if err != nil {
+				log.Printf("Ошибка при смене ключа файла %s фотографии ID=%d: %v", p, photo.ID, err)
+				continue
+			}
+			if changed {
+				rewrapped++
+			}
+		}
+	}
+
+	if rewrapped > 0 {
+		log.Printf("Ключи %d файлов зашифрованы новым мастер-ключом", rewrapped)
+	}
+	return rewrapped, nil
+}
+
+// computePHash читает файл фотографии из хранилища и вычисляет его перцептивный хеш
+func (s *PhotoService) computePHash(photo models.Photo) (string, error) {
+	data, err := s.providerFor(photo).Get(photo.Path)
+	if err != nil {
+		return "", err
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	orientation := metadataOrientation(photo.Metadata)
+	return similarity.FormatHash(imaging.DHash(img, orientation)), nil
+}
+
+// GetPhoto возвращает фотографию по ID
+func (s *PhotoService) GetPhoto(ctx context.Context, id int) (models.Photo, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return models.Photo{}, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	return s.repo.FindPhotoByID(id)
+}
+
+// UpdatePhoto изменяет название, альбом, теги и метаданные фотографии
+func (s *PhotoService) UpdatePhoto(ctx context.Context, id int, update PhotoUpdate) (models.Photo, error) {
+	photo, err := s.GetPhoto(ctx, id)
+	if err != nil {
+		return models.Photo{}, err
+	}
+
+	if update.Name != nil {
+		if *update.Name == "" {
+			return models.Photo{}, fmt.Errorf("%w: название фотографии не может быть пустым", ErrInvalidPhoto)
+		}
+		photo.Name = *update.Name
+	}
+
+	if update.AlbumID != nil {
+		album, err := s.repo.FindAlbumByID(ctx, *update.AlbumID)
+		if err != nil {
+			return models.Photo{}, err
+		}
+		if album.IsSmart() {
+			return models.Photo{}, fmt.Errorf("%w: %v", ErrInvalidPhoto, ErrSmartAlbum)
+		}
+		album.Photos = nil
+		photo.Album = &album
+	}
+
+	if update.Tags != nil {
+		for _, tag := range update.Tags {
+			if !models.ValidTag(tag) {
+				return models.Photo{}, fmt.Errorf("%w: теги содержат недопустимые символы", ErrInvalidPhoto)
+			}
+		}
+		photo.Tags = update.Tags
+	}
+
+	if update.Metadata != nil {
+		photo.Metadata = update.Metadata
+	}
+
+	if err := s.repo.UpdatePhoto(ctx, id, photo); err != nil {
+		return models.Photo{}, err
+	}
+
+	return photo, nil
+}
+
+// DeletePhoto перемещает фотографию в корзину. Файлы удаляются при очистке корзины.
+func (s *PhotoService) DeletePhoto(ctx context.Context, id int) error {
+	photo, err := s.GetPhoto(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeletePhoto(ctx, id); err != nil {
+		return err
+	}
+
+	log.Printf("Фотография перемещена в корзину: ID=%d, Название=%s", photo.ID, photo.Name)
+	return nil
+}
+
+// allPhotos возвращает все фотографии вместе с находящимися в корзине
+func (s *PhotoService) allPhotos(ctx context.Context) ([]models.Photo, error) {
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{})
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := s.repo.FindPhotos(ctx, models.PhotoFilter{Trashed: true})
+	if err != nil {
+		return nil, err
+	}
+	return append(photos, trashed...), nil
+}
+
+// OpenContent возвращает фотографию и поток ее содержимого, который закрывает вызывающий код
+func (s *PhotoService) OpenContent(ctx context.Context, id int) (models.Photo, io.ReadCloser, error) {
+	// Проверяем отмену контекста
+	select {
+	case <-ctx.Done():
+		return models.Photo{}, nil, ctx.Err()
+	default:
+		// Продолжаем выполнение
+	}
+
+	photo, err := s.repo.FindPhotoByID(id)
+	if err != nil {
+		return models.Photo{}, nil, err
+	}
+
+	reader, err := s.providerFor(photo).GetReader(photo.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return models.Photo{}, nil, fmt.Errorf("файл фотографии с ID=%d не найден", id)
+		}
+		return models.Photo{}, nil, fmt.Errorf("ошибка открытия файла фотографии: %w", err)
+	}
+
+	return photo, reader, nil
+}
+
+// OpenThumbnail возвращает наиболее подходящую уменьшенную копию или оригинал, если копий нет
+func (s *PhotoService) OpenThumbnail(ctx context.Context, id, size int) (models.Photo, *models.Rendition, io.ReadCloser, error) {
+	photo, err := s.GetPhoto(ctx, id)
+	if err != nil {
+		return models.Photo{}, nil, nil, err
+	}
+
+	rendition, ok := photo.Rendition(size)
+	if !ok && photo.Kind() == models.MediaTypeVideo {
+		return models.Photo{}, nil, nil, fmt.Errorf("уменьшенная копия видео с ID=%d не найдена", id)
+	}
+	if !ok {
+		_, reader, err := s.OpenContent(ctx, id)
+		return photo, nil, reader, err
+	}
+
+	reader, err := s.providerFor(photo).GetReader(rendition.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return models.Photo{}, nil, nil, fmt.Errorf("уменьшенная копия фотографии с ID=%d не найдена", id)
+		}
+		return models.Photo{}, nil, nil, fmt.Errorf("ошибка открытия уменьшенной копии: %w", err)
+	}
+	return photo, &rendition, reader, nil
+}
+
+// SignedURL подписанная ссылка на файл фотографии
+type SignedURL struct {
+	URL       string    `json:"url"`
+	Scope     string    `json:"scope"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SignedURL выдает подписанную ссылку на оригинал или уменьшенную копию фотографии
+func (s *PhotoService) SignedURL(ctx context.Context, id int, scope string, size int, ttl time.Duration) (SignedURL, error) {
+	photo, err := s.GetPhoto(ctx, id)
+	if err != nil {
+		return SignedURL{}, err
+	}
+
+	signer, ok := s.providerFor(photo).(storage.SignedURLProvider)
+	if !ok {
+		return SignedURL{}, storage.ErrSignedURLsDisabled
+	}
+
+	filePath := photo.Path
+	switch scope {
+	case storage.ScopeOriginal:
+	case storage.ScopeThumbnail:
+		rendition, ok := photo.Rendition(size)
+		if !ok {
+			return SignedURL{}, fmt.Errorf("уменьшенная копия фотографии с ID=%d не найдена", id)
+		}
+		filePath = rendition.Path
+	default:
+		return SignedURL{}, fmt.Errorf("%w: неизвестная область действия ссылки %q", ErrInvalidPhoto, scope)
+	}
+
+	url, expires, err := signer.GetSignedURL(filePath, scope, ttl)
+	if err != nil {
+		return SignedURL{}, err
+	}
+	return SignedURL{URL: url, Scope: scope, ExpiresAt: expires}, nil
+}
+
+// FileScope возвращает область действия ссылки для файла хранилища: оригинал или уменьшенная копия фотографии
+func (s *PhotoService) FileScope(ctx context.Context, filePath string) (string, error) {
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{})
+	if err != nil {
+		return "", err
+	}
+
+	for _, photo := range photos {
+		if photo.Path == filePath {
+			return storage.ScopeOriginal, nil
+		}
+		for _, rendition := range photo.Renditions {
+			if rendition.Path == filePath {
+				return storage.ScopeThumbnail, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("файл %s не принадлежит ни одной фотографии", filePath)
+}
+
+// sanitizeFilename оставляет только имя файла без пути и небезопасных символов
+func sanitizeFilename(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r <= ' ', r == '/', r == ':', r == '*', r == '?', r == '"', r == '<', r == '>', r == '|':
+			return '_'
+		}
+		return r
+	}, name)
+
+	if name == "" || name == "." || name == ".." {
+		return "photo"
+	}
+	return name
+}
+
+// publicUser возвращает копию пользователя без пароля
+func publicUser(user *models.User) *models.User {
+	if user == nil {
+		return nil
+	}
+	u := *user
+	u.Password = ""
+	return &u
+}
+
+// extractMetadata читает EXIF из JPEG и TIFF файлов. Отсутствие EXIF не является ошибкой загрузки.
+func extractMetadata(upload UploadFile, mimeType string) ([]models.Metadata, *time.Time) {
+	if mimeType != "image/jpeg" && mimeType != "image/tiff" {
+		return []models.Metadata{}, nil
+	}
+
+	data, err := exif.Decode(upload.File, upload.Size)
+	if err != nil {
+		if !errors.Is(err, exif.ErrNoExif) {
+			log.Printf("Ошибка чтения EXIF из файла %s: %v", upload.Filename, err)
+		}
+		return []models.Metadata{}, nil
+	}
+
+	var takenAt *time.Time
+	if !data.DateTaken.IsZero() {
+		takenAt = &data.DateTaken
+	}
+	return data.Metadata(), takenAt
+}
+
+// mergeMetadata дополняет метаданные из файла переданными значениями
+func mergeMetadata(base, extra []models.Metadata) []models.Metadata {
+	if len(extra) == 0 {
+		return base
+	}
+
+	override := make(map[string]bool, len(extra))
+	for _, m := range extra {
+		override[m.Key] = true
+	}
+
+	merged := make([]models.Metadata, 0, len(base)+len(extra))
+	for _, m := range base {
+		if !override[m.Key] {
+			merged = append(merged, m)
+		}
+	}
+	return append(merged, extra...)
+}
+
+// decodeImage декодирует загруженный файл, неподдерживаемый формат не считается ошибкой
+func decodeImage(file multipart.File, storedPath string) image.Image {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		log.Printf("Ошибка чтения файла %s: %v", storedPath, err)
+		return nil
+	}
+
+	img, err := imaging.Decode(file)
+	if err != nil {
+		log.Printf("Изображение %s не обработано: %v", storedPath, err)
+		return nil
+	}
+	return img
+}
+
+// createRenditions создает уменьшенные копии изображения и сохраняет их рядом с оригиналом
+func (s *PhotoService) createRenditions(img image.Image, storedPath string, orientation int) []models.Rendition {
+	width, height := imaging.OrientedSize(img.Bounds().Dx(), img.Bounds().Dy(), orientation)
+	longest := max(width, height)
+
+	var renditions []models.Rendition
+	for _, size := range s.renditionSizes {
+		thumb := imaging.Thumbnail(img, size, orientation)
+
+		var buf bytes.Buffer
+		if err := imaging.EncodeJPEG(&buf, thumb); err != nil {
+			log.Printf("Ошибка кодирования копии %dpx для %s: %v", size, storedPath, err)
+			break
+		}
+
+		key := renditionKey(storedPath, size)
+		savedPath, err := s.storage.Save(storage.NewBytesFile(buf.Bytes()), key)
+		if err != nil {
+			log.Printf("Ошибка сохранения копии %dpx для %s: %v", size, storedPath, err)
+			break
+		}
+
+		renditions = append(renditions, models.Rendition{
+			Size:   size,
+			Path:   savedPath,
+			Width:  thumb.Bounds().Dx(),
+			Height: thumb.Bounds().Dy(),
+		})
+
+		// Изображение не увеличиваем: большие копии совпадали бы с этой
+		if size >= longest {
+			break
+		}
+	}
+
+	return renditions
+}
+
+// deleteFiles удаляет из хранилища оригинал фотографии, ее уменьшенные копии и видеоролик
+func (s *PhotoService) deleteFiles(photo models.Photo) {
+	provider := s.providerFor(photo)
+	for _, p := range photo.FilePaths() {
+		if err := provider.Delete(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Ошибка при удалении файла %s фотографии ID=%d: %v", p, photo.ID, err)
+		}
+	}
+}
+
+// renditionKey формирует путь уменьшенной копии рядом с оригиналом
+func renditionKey(original string, size int) string {
+	return fmt.Sprintf("%s_%dpx.jpg", strings.TrimSuffix(original, path.Ext(original)), size)
+}
+
+// metadataOrientation возвращает EXIF-ориентацию из метаданных фотографии
+func metadataOrientation(metadata []models.Metadata) int {
+	for _, m := range metadata {
+		if m.Key == models.MetadataOrientation {
+			orientation, _ := strconv.Atoi(m.Value)
+			return orientation
+		}
+	}
+	return 0
+}
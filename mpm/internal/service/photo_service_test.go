@@ -0,0 +1,403 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"mpm/internal/models"
+	"mpm/internal/similarity"
+	"mpm/internal/storage"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPhotoRepository struct {
+	mock.Mock
+}
+
+func (m *MockPhotoRepository) FindAlbumByID(ctx context.Context, id int) (models.Album, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Album), args.Error(1)
+}
+
+func (m *MockPhotoRepository) AddAlbum(ctx context.Context, album models.Album) (int, error) {
+	args := m.Called(ctx, album)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPhotoRepository) DeleteAlbum(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPhotoRepository) PurgeAlbum(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPhotoRepository) FindPhotoByID(id int) (models.Photo, error) {
+	args := m.Called(id)
+	return args.Get(0).(models.Photo), args.Error(1)
+}
+
+func (m *MockPhotoRepository) AddPhoto(ctx context.Context, photo models.Photo) (int, error) {
+	args := m.Called(ctx, photo)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPhotoRepository) FindPhotos(ctx context.Context, filter models.PhotoFilter) ([]models.Photo, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]models.Photo), args.Error(1)
+}
+
+func (m *MockPhotoRepository) UpdatePhoto(ctx context.Context, id int, photo models.Photo) error {
+	args := m.Called(ctx, id, photo)
+	return args.Error(0)
+}
+
+func (m *MockPhotoRepository) UpdatePhotoFields(ctx context.Context, id int, update func(photo *models.Photo)) error {
+	args := m.Called(ctx, id, update)
+	return args.Error(0)
+}
+
+func (m *MockPhotoRepository) DeletePhoto(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPhotoRepository) FindSimilarPhotos(ctx context.Context, hash uint64, threshold, excludeID int) ([]similarity.Match, error) {
+	args := m.Called(ctx, hash, threshold, excludeID)
+	return args.Get(0).([]similarity.Match), args.Error(1)
+}
+
+func (m *MockPhotoRepository) FindPhotoClusters(ctx context.Context, threshold int) ([][]int, error) {
+	args := m.Called(ctx, threshold)
+	return args.Get(0).([][]int), args.Error(1)
+}
+
+// writeTempFile создает временный файл с указанным содержимым
+func writeTempFile(t *testing.T, data []byte) *os.File {
+	t.Helper()
+
+	file, err := os.CreateTemp(t.TempDir(), "upload-*")
+	require.NoError(t, err)
+	_, err = file.Write(data)
+	require.NoError(t, err)
+	_, err = file.Seek(0, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = file.Close() })
+	return file
+}
+
+var jpegHeader = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+
+// jpegWithExif собирает минимальный JPEG с EXIF-сегментом, содержащим камеру и дату съемки
+func jpegWithExif(cameraMake, cameraModel, dateTime string) []byte {
+	entries := []struct {
+		tag   uint16
+		value string
+	}{{0x010F, cameraMake}, {0x0110, cameraModel}, {0x0132, dateTime}}
+
+	ifdSize := 2 + 12*len(entries) + 4
+	ifd := binary.LittleEndian.AppendUint16(nil, uint16(len(entries)))
+	var values []byte
+	for _, e := range entries {
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.tag)
+		ifd = binary.LittleEndian.AppendUint16(ifd, 2)
+		ifd = binary.LittleEndian.AppendUint32(ifd, uint32(len(e.value)+1))
+		ifd = binary.LittleEndian.AppendUint32(ifd, uint32(8+ifdSize+len(values)))
+		values = append(values, append([]byte(e.value), 0)...)
+	}
+	ifd = binary.LittleEndian.AppendUint32(ifd, 0)
+
+	tiff := append([]byte("II\x2A\x00\x08\x00\x00\x00"), ifd...)
+	tiff = append(tiff, values...)
+
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(tiff)+8))
+	data = append(data, "Exif\x00\x00"...)
+	data = append(data, tiff...)
+	return append(data, 0xFF, 0xD9)
+}
+
+func TestPhotoService_Upload(t *testing.T) {
+	t.Run("successful upload", func(t *testing.T) {
+		mockRepo := &MockPhotoRepository{}
+		filesDir := t.TempDir()
+		service := NewPhotoService(mockRepo, storage.NewLocalStorage(filesDir, "/files"), "local", 1024)
+
+		mockRepo.On("FindAlbumByID", mock.Anything, 3).Return(models.Album{ID: 3, Name: "Trip", Photos: []models.Photo{{ID: 1}}}, nil)
+		mockRepo.On("AddPhoto", mock.Anything, mock.MatchedBy(func(p models.Photo) bool {
+			return p.Album != nil && p.Album.ID == 3 && p.Album.Photos == nil && p.MimeType == "image/jpeg"
+		})).Return(42, nil)
+
+		file := writeTempFile(t, jpegHeader)
+		photo, err := service.Upload(context.Background(), 3, &models.User{ID: 1, Password: "secret"}, UploadFile{
+			File:     file,
+			Filename: "../../etc/my photo.jpg",
+			Size:     int64(len(jpegHeader)),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 42, photo.ID)
+		assert.Equal(t, "my_photo.jpg", photo.Name)
+		assert.Empty(t, photo.User.Password)
+
+		data, err := os.ReadFile(filepath.Join(filesDir, photo.Path))
+		require.NoError(t, err)
+		assert.Equal(t, jpegHeader, data, "файл должен сохраняться целиком, начиная с первого байта")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("exif metadata is extracted", func(t *testing.T) {
+		mockRepo := &MockPhotoRepository{}
+		service := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+		mockRepo.On("FindAlbumByID", mock.Anything, 1).Return(models.Album{ID: 1}, nil)
+		mockRepo.On("AddPhoto", mock.Anything, mock.Anything).Return(1, nil)
+
+		data := jpegWithExif("Apple", "iPhone 15 Pro", "2024:06:01 09:15:00")
+		file := writeTempFile(t, data)
+		photo, err := service.Upload(context.Background(), 1, nil, UploadFile{File: file, Filename: "img.jpg", Size: int64(len(data))})
+
+		require.NoError(t, err)
+		cameraMake, _ := photo.MetadataValue(models.MetadataCameraMake)
+		cameraModel, _ := photo.MetadataValue(models.MetadataCameraModel)
+		assert.Equal(t, "Apple", cameraMake)
+		assert.Equal(t, "iPhone 15 Pro", cameraModel)
+		require.NotNil(t, photo.TakenAt)
+		assert.Equal(t, 2024, photo.TakenAt.Year())
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		mockRepo := &MockPhotoRepository{}
+		service := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 1024)
+		mockRepo.On("FindAlbumByID", mock.Anything, 1).Return(models.Album{ID: 1}, nil)
+
+		file := writeTempFile(t, []byte("plain text"))
+		_, err := service.Upload(context.Background(), 1, nil, UploadFile{File: file, Filename: "a.jpg", Size: 10})
+
+		assert.ErrorIs(t, err, ErrNotAnImage)
+		mockRepo.AssertNotCalled(t, "AddPhoto", mock.Anything, mock.Anything)
+	})
+
+	t.Run("file too large", func(t *testing.T) {
+		mockRepo := &MockPhotoRepository{}
+		service := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 4)
+		mockRepo.On("FindAlbumByID", mock.Anything, 1).Return(models.Album{ID: 1}, nil)
+
+		file := writeTempFile(t, jpegHeader)
+		_, err := service.Upload(context.Background(), 1, nil, UploadFile{File: file, Filename: "a.jpg", Size: int64(len(jpegHeader))})
+
+		assert.ErrorIs(t, err, ErrFileTooLarge)
+	})
+
+	t.Run("repository error removes stored file", func(t *testing.T) {
+		mockRepo := &MockPhotoRepository{}
+		filesDir := t.TempDir()
+		service := NewPhotoService(mockRepo, storage.NewLocalStorage(filesDir, "/files"), "local", 1024)
+		mockRepo.On("FindAlbumByID", mock.Anything, 1).Return(models.Album{ID: 1}, nil)
+		mockRepo.On("AddPhoto", mock.Anything, mock.Anything).Return(0, errors.New("save error"))
+
+		file := writeTempFile(t, jpegHeader)
+		_, err := service.Upload(context.Background(), 1, nil, UploadFile{File: file, Filename: "a.jpg", Size: int64(len(jpegHeader))})
+
+		assert.Error(t, err)
+		entries, _ := os.ReadDir(filepath.Join(filesDir, "albums", "1"))
+		assert.Empty(t, entries)
+	})
+}
+
+func TestPhotoService_UpdatePhoto(t *testing.T) {
+	t.Run("update name, tags and album", func(t *testing.T) {
+		mockRepo := &MockPhotoRepository{}
+		service := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+
+		mockRepo.On("FindPhotoByID", 5).Return(models.Photo{ID: 5, Name: "old.jpg", Path: "albums/1/old.jpg"}, nil)
+		mockRepo.On("FindAlbumByID", mock.Anything, 2).Return(models.Album{ID: 2, Name: "Other", Photos: []models.Photo{{ID: 9}}}, nil)
+		mockRepo.On("UpdatePhoto", mock.Anything, 5, mock.MatchedBy(func(p models.Photo) bool {
+			return p.Name == "new.jpg" && p.Path == "albums/1/old.jpg" && p.Album.ID == 2 && p.Album.Photos == nil && len(p.Tags) == 1
+		})).Return(nil)
+
+		name := "new.jpg"
+		albumID := 2
+		photo, err := service.UpdatePhoto(context.Background(), 5, PhotoUpdate{Name: &name, AlbumID: &albumID, Tags: []string{"море"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "new.jpg", photo.Name)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid tags", func(t *testing.T) {
+		mockRepo := &MockPhotoRepository{}
+		service := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+		mockRepo.On("FindPhotoByID", 5).Return(models.Photo{ID: 5}, nil)
+
+		_, err := service.UpdatePhoto(context.Background(), 5, PhotoUpdate{Tags: []string{"two words"}})
+
+		assert.ErrorIs(t, err, ErrInvalidPhoto)
+		mockRepo.AssertNotCalled(t, "UpdatePhoto", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPhotoService_DeletePhoto(t *testing.T) {
+	mockRepo := &MockPhotoRepository{}
+	filesDir := t.TempDir()
+	provider := storage.NewLocalStorage(filesDir, "/files")
+	service := NewPhotoService(mockRepo, provider, "local", 0)
+
+	storedPath, err := provider.Save(writeTempFile(t, jpegHeader), "albums/1/photo.jpg")
+	require.NoError(t, err)
+
+	mockRepo.On("FindPhotoByID", 3).Return(models.Photo{ID: 3, Path: storedPath}, nil)
+	mockRepo.On("DeletePhoto", mock.Anything, 3).Return(nil)
+
+	require.NoError(t, service.DeletePhoto(context.Background(), 3))
+
+	_, err = os.Stat(filepath.Join(filesDir, storedPath))
+	assert.NoError(t, err, "файл фотографии в корзине сохраняется")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPhotoService_RegisterProvider(t *testing.T) {
+	mockRepo := &MockPhotoRepository{}
+	localDir := t.TempDir()
+	local := storage.NewLocalStorage(localDir, "/files")
+	service := NewPhotoService(mockRepo, storage.NewLocalStorage(t.TempDir(), "/files"), "s3", 0)
+	service.RegisterProvider("local", local)
+
+	storedPath, err := local.Save(writeTempFile(t, jpegHeader), "albums/1/old.jpg")
+	require.NoError(t, err)
+	photo := models.Photo{ID: 4, Path: storedPath, StorageType: "local"}
+	mockRepo.On("FindPhotoByID", 4).Return(photo, nil)
+
+	_, reader, err := service.OpenContent(context.Background(), 4)
+	require.NoError(t, err, "файл читается из хранилища, в котором был сохранен")
+	reader.Close()
+
+	service.deleteFiles(photo)
+	_, err = os.Stat(filepath.Join(localDir, storedPath))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDetectImageType(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"jpeg", jpegHeader, "image/jpeg"},
+		{"png", []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x00}, "image/png"},
+		{"gif", []byte("GIF89a......"), "image/gif"},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"tiff", []byte{'I', 'I', 0x2A, 0x00, 0x08}, "image/tiff"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "image/heic"},
+		{"cr3", []byte("\x00\x00\x00\x18ftypcrx \x00\x00\x00\x01"), "image/x-canon-cr3"},
+		{"raf", []byte("FUJIFILMCCD-RAW 0201"), "image/x-fuji-raf"},
+		{"rw2", []byte{'I', 'I', 'U', 0x00, 0x18}, "image/x-panasonic-rw2"},
+		{"text", []byte("hello world"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, storage.DetectImageType(tt.header))
+		})
+	}
+}
+
+func TestDetectVideoType(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"mov", []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00"), "video/quicktime"},
+		{"mp4", []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00"), "video/mp4"},
+		{"mp4 from camera", []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00"), "video/mp4"},
+		{"old quicktime", []byte("\x00\x00\x00\x08wide\x00\x00\x00\x00"), "video/quicktime"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), ""},
+		{"text", []byte("hello world"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, storage.DetectVideoType(tt.header))
+		})
+	}
+}
+
+func TestPhotoService_BackfillPerceptualHashes(t *testing.T) {
+	mockRepo := &MockPhotoRepository{}
+	provider := storage.NewLocalStorage(t.TempDir(), "/files")
+	service := NewPhotoService(mockRepo, provider, "local", 0)
+
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	storedPath, err := provider.Save(storage.NewBytesFile(buf.Bytes()), "albums/1/old.png")
+	require.NoError(t, err)
+
+	mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{}).Return([]models.Photo{
+		{ID: 1, Path: storedPath},
+		{ID: 2, Path: "albums/1/hashed.png", PHash: "00000000000000ff"},
+	}, nil)
+	mockRepo.On("UpdatePhotoFields", mock.Anything, 1, mock.MatchedBy(func(update func(*models.Photo)) bool {
+		// Меняется только хеш, остальные поля берутся из актуальной копии
+		photo := models.Photo{ID: 1, Name: "renamed", Tags: []string{"new"}}
+		update(&photo)
+		return len(photo.PHash) == 16 && photo.Name == "renamed" && len(photo.Tags) == 1
+	})).Return(nil)
+
+	updated, err := service.BackfillPerceptualHashes(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPhotoService_RewrapEncryptionKeys(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, storage.MasterKeySize), bytes.Repeat([]byte{2}, storage.MasterKeySize)
+	dir := t.TempDir()
+	oldRing, err := storage.NewKeyRing(oldKey)
+	require.NoError(t, err)
+	_, err = storage.NewEncryptedStorage(storage.NewLocalStorage(dir, "/files"), oldRing).
+		Save(writeTempFile(t, jpegHeader), "albums/1/a.jpg")
+	require.NoError(t, err)
+
+	rotated, err := storage.NewKeyRing(newKey, oldKey)
+	require.NoError(t, err)
+	mockRepo := &MockPhotoRepository{}
+	service := NewPhotoService(mockRepo, storage.NewEncryptedStorage(storage.NewLocalStorage(dir, "/files"), rotated), "local", 0)
+	deletedAt := time.Now()
+	mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{}).Return([]models.Photo{}, nil)
+	mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{Trashed: true}).Return([]models.Photo{
+		{ID: 1, Path: "albums/1/a.jpg", StorageType: "local", DeletedAt: &deletedAt},
+	}, nil)
+
+	rewrapped, err := service.RewrapEncryptionKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, rewrapped)
+
+	rewrapped, err = service.RewrapEncryptionKeys(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, rewrapped, "повторный запуск ничего не меняет")
+
+	newRing, err := storage.NewKeyRing(newKey)
+	require.NoError(t, err)
+	data, err := storage.NewEncryptedStorage(storage.NewLocalStorage(dir, "/files"), newRing).Get("albums/1/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, jpegHeader, data)
+}
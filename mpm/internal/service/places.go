@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"mpm/internal/geocode"
+	"mpm/internal/models"
+)
+
+// SetGeocoder включает определение страны, региона и города по координатам съемки. nil отключает его.
+func (s *PhotoService) SetGeocoder(geocoder *geocode.Geocoder) {
+	s.geocoder = geocoder
+}
+
+// placeMetadata дополняет метаданные местом съемки, найденным по координатам GPS
+func (s *PhotoService) placeMetadata(metadata []models.Metadata) []models.Metadata {
+	if s.geocoder == nil {
+		return metadata
+	}
+
+	photo := models.Photo{Metadata: metadata}
+	if _, ok := photo.MetadataValue(models.MetadataCountry); ok {
+		return metadata
+	}
+	lat, lon, ok := photo.Location()
+	if !ok {
+		return metadata
+	}
+	place, ok := s.geocoder.Reverse(lat, lon)
+	if !ok {
+		return metadata
+	}
+
+	result := append([]models.Metadata(nil), metadata...)
+	for _, m := range []models.Metadata{
+		{Key: models.MetadataCountry, Value: place.Country},
+		{Key: models.MetadataCountryCode, Value: place.CountryCode},
+		{Key: models.MetadataRegion, Value: place.Region},
+		{Key: models.MetadataCity, Value: place.City},
+	} {
+		if m.Value != "" {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// BackfillPlaces определяет место съемки фотографий, загруженных до включения геокодирования
+func (s *PhotoService) BackfillPlaces(ctx context.Context) (int, error) {
+	if s.geocoder == nil {
+		return 0, nil
+	}
+
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{})
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, photo := range photos {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+
+		if len(s.placeMetadata(photo.Metadata)) == len(photo.Metadata) {
+			continue
+		}
+
+		// Место определяется заново по актуальной копии, чтобы не затереть параллельные изменения
+		err := s.repo.UpdatePhotoFields(ctx, photo.ID, func(photo *models.Photo) {
+			photo.Metadata = s.placeMetadata(photo.Metadata)
+		})
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	if updated > 0 {
+		log.Printf("Определено место съемки для %d фотографий", updated)
+	}
+	return updated, nil
+}
+
+// SuggestTags предлагает теги места съемки в виде, допустимом для тегов, которыми фотография еще не отмечена
+func (s *PhotoService) SuggestTags(ctx context.Context, id int) ([]string, error) {
+	photo, err := s.GetPhoto(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Место определяется заново, если фотография загружена до включения геокодирования
+	photo.Metadata = s.placeMetadata(photo.Metadata)
+
+	suggestions := []string{}
+	seen := make(map[string]bool)
+	for _, key := range []string{models.MetadataCity, models.MetadataRegion, models.MetadataCountry} {
+		value, _ := photo.MetadataValue(key)
+		tag := models.NormalizeTag(value)
+		if tag == "" || photo.HasTag(tag) || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		suggestions = append(suggestions, tag)
+	}
+	return suggestions, nil
+}
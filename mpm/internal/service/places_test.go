@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"mpm/internal/geocode"
+	"mpm/internal/models"
+	"mpm/internal/storage"
+)
+
+// gpsMetadata возвращает метаданные с координатами съемки
+func gpsMetadata(lat, lon string) []models.Metadata {
+	return []models.Metadata{
+		{Key: models.MetadataGPSLatitude, Value: lat},
+		{Key: models.MetadataGPSLongitude, Value: lon},
+	}
+}
+
+func TestPhotoService_Places(t *testing.T) {
+	geocoder, err := geocode.New(geocode.DefaultMaxDistance)
+	require.NoError(t, err)
+
+	newService := func(repo *MockPhotoRepository) *PhotoService {
+		service := NewPhotoService(repo, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+		service.SetGeocoder(geocoder)
+		return service
+	}
+
+	t.Run("Место съемки добавляется в метаданные", func(t *testing.T) {
+		service := newService(&MockPhotoRepository{})
+		photo := models.Photo{Metadata: service.placeMetadata(gpsMetadata("43.585500", "39.723100"))}
+
+		city, _ := photo.MetadataValue(models.MetadataCity)
+		region, _ := photo.MetadataValue(models.MetadataRegion)
+		country, _ := photo.MetadataValue(models.MetadataCountry)
+		code, _ := photo.MetadataValue(models.MetadataCountryCode)
+		assert.Equal(t, []string{"Sochi", "Krasnodar Krai", "Russia", "RU"}, []string{city, region, country, code})
+	})
+
+	t.Run("Заданное место не заменяется", func(t *testing.T) {
+		service := newService(&MockPhotoRepository{})
+		metadata := append(gpsMetadata("43.585500", "39.723100"), models.Metadata{Key: models.MetadataCountry, Value: "Абхазия"})
+		assert.Equal(t, metadata, service.placeMetadata(metadata))
+		assert.Empty(t, service.placeMetadata(nil))
+	})
+
+	t.Run("Без геокодера метаданные не меняются", func(t *testing.T) {
+		service := NewPhotoService(&MockPhotoRepository{}, storage.NewLocalStorage(t.TempDir(), "/files"), "local", 0)
+		assert.Len(t, service.placeMetadata(gpsMetadata("43.585500", "39.723100")), 2)
+
+		updated, err := service.BackfillPlaces(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, updated)
+	})
+
+	t.Run("Место определяется для ранее загруженных фотографий", func(t *testing.T) {
+		repo := &MockPhotoRepository{}
+		service := newService(repo)
+		located := models.Photo{ID: 1, Metadata: gpsMetadata("48.858400", "2.294500")}
+		repo.On("FindPhotos", mock.Anything, models.PhotoFilter{}).Return([]models.Photo{
+			located,
+			{ID: 2},
+			{ID: 3, Metadata: service.placeMetadata(gpsMetadata("43.585500", "39.723100"))},
+			{ID: 4, Metadata: gpsMetadata("0.000000", "-30.000000")},
+		}, nil)
+		repo.On("UpdatePhotoFields", mock.Anything, 1, mock.MatchedBy(func(update func(*models.Photo)) bool {
+			// Место определяется по актуальной копии, остальные поля не меняются
+			photo := located
+			photo.Name = "renamed"
+			update(&photo)
+			city, _ := photo.MetadataValue(models.MetadataCity)
+			return city == "Paris" && photo.Name == "renamed"
+		})).Return(nil)
+
+		updated, err := service.BackfillPlaces(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, updated)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Предложенные теги", func(t *testing.T) {
+		repo := &MockPhotoRepository{}
+		service := newService(repo)
+		repo.On("FindPhotoByID", 1).Return(models.Photo{ID: 1, Tags: []string{"sochi"}, Metadata: gpsMetadata("43.585500", "39.723100")}, nil)
+		repo.On("FindPhotoByID", 2).Return(models.Photo{ID: 2, Tags: []string{}}, nil)
+
+		tags, err := service.SuggestTags(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"krasnodar-krai", "russia"}, tags, "тег города уже есть")
+
+		tags, err = service.SuggestTags(context.Background(), 2)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	})
+}
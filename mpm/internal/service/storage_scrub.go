@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mpm/internal/models"
+	"mpm/internal/storage"
+)
+
+// Виды проблем, которые находит проверка хранилища
+const (
+	ScrubMissing          = "missing"           // Файл фотографии отсутствует в хранилище
+	ScrubChecksumMismatch = "checksum_mismatch" // Содержимое оригинала не совпадает с сохраненным при загрузке
+	ScrubOrphan           = "orphan"            // Файл не принадлежит ни одной фотографии
+	ScrubAlbumMissing     = "album_missing"     // Фотография ссылается на удаленный альбом
+	ScrubUnreadable       = "unreadable"        // Файл не удалось прочитать
+)
+
+const (
+	// DefaultOrphanMinAge возраст, после которого файл без фотографии считается ничьим
+	DefaultOrphanMinAge = 24 * time.Hour
+	// maxScrubIssues ограничивает количество проблем, сохраняемых в отчете
+	maxScrubIssues = 1000
+)
+
+// ErrScrubRunning возвращается при попытке запустить проверку, пока выполняется предыдущая
+var ErrScrubRunning = errors.New("проверка хранилища уже выполняется")
+
+// ScrubOptions параметры проверки хранилища
+type ScrubOptions struct {
+	VerifyChecksums   bool `json:"verify_checksums"`   // Сверять SHA-256 оригиналов, требует чтения всех файлов
+	QuarantineOrphans bool `json:"quarantine_orphans"` // Переносить ничьи файлы в каталог quarantine/
+}
+
+// ScrubIssue проблема, найденная при проверке хранилища
+type ScrubIssue struct {
+	Kind        string `json:"kind"`
+	StorageType string `json:"storage_type,omitempty"`
+	Path        string `json:"path,omitempty"`
+	PhotoID     int    `json:"photo_id,omitempty"`
+	AlbumID     int    `json:"album_id,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Quarantined string `json:"quarantined,omitempty"` // Путь ничьего файла в карантине
+	Error       string `json:"error,omitempty"`
+}
+
+// ScrubReport ход текущей или итоги последней проверки хранилища
+type ScrubReport struct {
+	Running    bool         `json:"running"`
+	Options    ScrubOptions `json:"options"`
+	StartedAt  *time.Time   `json:"started_at,omitempty"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+
+	Photos             int   `json:"photos"`  // Проверено фотографий
+	Files              int   `json:"files"`   // Проверено файлов фотографий
+	Listed             int   `json:"listed"`  // Файлов в хранилищах, которые умеют перечислять файлы
+	Missing            int   `json:"missing"` // Отсутствующих файлов
+	ChecksumMismatches int   `json:"checksum_mismatches"`
+	Unreadable         int   `json:"unreadable"`
+	AlbumMissing       int   `json:"album_missing"` // Фотографий удаленных альбомов
+	Orphans            int   `json:"orphans"`       // Ничьих файлов
+	OrphanBytes        int64 `json:"orphan_bytes"`
+	Quarantined        int   `json:"quarantined"` // Ничьих файлов, перенесенных в карантин
+
+	Unlisted []string     `json:"unlisted,omitempty"` // Хранилища, в которых ничьи файлы не ищутся
+	Issues   []ScrubIssue `json:"issues,omitempty"`
+	Error    string       `json:"error,omitempty"` // Ошибка, прервавшая проверку
+}
+
+// StorageScrubber сверяет записи о фотографиях с файлами в хранилищах
+type StorageScrubber struct {
+	photos       *PhotoService
+	migrator     *BlobMigrator // Копии незавершенного переноса не считаются ничьими
+	orphanMinAge time.Duration
+
+	mutex  sync.Mutex
+	report ScrubReport
+	now    func() time.Time
+}
+
+// NewStorageScrubber создает проверку хранилища, migrator может быть nil
+func NewStorageScrubber(photos *PhotoService, migrator *BlobMigrator, orphanMinAge time.Duration) *StorageScrubber {
+	if orphanMinAge <= 0 {
+		orphanMinAge = DefaultOrphanMinAge
+	}
+	return &StorageScrubber{photos: photos, migrator: migrator, orphanMinAge: orphanMinAge, now: time.Now}
+}
+
+// Status возвращает отчет текущей или последней проверки
+func (s *StorageScrubber) Status() ScrubReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	report := s.report
+	report.Unlisted = append([]string(nil), s.report.Unlisted...)
+	report.Issues = append([]ScrubIssue(nil), s.report.Issues...)
+	return report
+}
+
+// Start запускает проверку в фоне
+func (s *StorageScrubber) Start(ctx context.Context, opts ScrubOptions) error {
+	if err := s.begin(opts); err != nil {
+		return err
+	}
+	go s.run(context.WithoutCancel(ctx), opts)
+	return nil
+}
+
+// Run выполняет проверку синхронно и возвращает отчет
+func (s *StorageScrubber) Run(ctx context.Context, opts ScrubOptions) (ScrubReport, error) {
+	if err := s.begin(opts); err != nil {
+		return ScrubReport{}, err
+	}
+	err := s.run(ctx, opts)
+	return s.Status(), err
+}
+
+// RunPeriodically запускает проверку с интервалом interval до отмены ctx
+func (s *StorageScrubber) RunPeriodically(ctx context.Context, interval time.Duration, opts ScrubOptions) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Println("Запланированная проверка хранилища файлов")
+			if _, err := s.Run(ctx, opts); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Ошибка проверки хранилища файлов: %v", err)
+			}
+		}
+	}
+}
+
+// begin отмечает начало проверки
+func (s *StorageScrubber) begin(opts ScrubOptions) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.report.Running {
+		return ErrScrubRunning
+	}
+	now := s.now()
+	s.report = ScrubReport{Running: true, Options: opts, StartedAt: &now}
+	return nil
+}
+
+func (s *StorageScrubber) run(ctx context.Context, opts ScrubOptions) error {
+	log.Println("Запуск проверки хранилища файлов")
+
+	err := s.scrub(ctx, opts)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	s.report.Running = false
+	s.report.FinishedAt = &now
+	if err != nil {
+		s.report.Error = err.Error()
+		log.Printf("Проверка хранилища файлов прервана: %v", err)
+	} else {
+		log.Printf("Проверка хранилища файлов завершена: фотографий %d, отсутствует файлов %d, поврежденных %d, "+
+			"фотографий удаленных альбомов %d, ничьих файлов %d (%d байт), перенесено в карантин %d",
+			s.report.Photos, s.report.Missing, s.report.ChecksumMismatches, s.report.AlbumMissing,
+			s.report.Orphans, s.report.OrphanBytes, s.report.Quarantined)
+	}
+	return err
+}
+
+// scrubFile файл, на который ссылается фотография
+type scrubFile struct {
+	photo    models.Photo
+	original bool
+}
+
+func (s *StorageScrubber) scrub(ctx context.Context, opts ScrubOptions) error {
+	photos, err := s.photos.allPhotos(ctx)
+	if err != nil {
+		return err
+	}
+	// Файлы моложе границы могли быть сохранены после получения списка фотографий
+	orphanBefore := s.now().Add(-s.orphanMinAge)
+
+	// Ссылки на файлы по хранилищам
+	referenced := make(map[string]map[string]scrubFile)
+	albums := make(map[int]bool)
+	for _, photo := range photos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.checkAlbum(ctx, photo, albums); err != nil {
+			return err
+		}
+		if photo.Path == "" {
+			continue
+		}
+
+		storageType := s.photos.storageTypeOf(photo)
+		if referenced[storageType] == nil {
+			referenced[storageType] = make(map[string]scrubFile)
+		}
+		referenced[storageType][photo.Path] = scrubFile{photo: photo, original: true}
+		for _, p := range photo.FilePaths()[1:] {
+			referenced[storageType][p] = scrubFile{photo: photo}
+		}
+	}
+
+	s.mutex.Lock()
+	s.report.Photos = len(photos)
+	s.mutex.Unlock()
+
+	storageTypes := make([]string, 0, len(s.photos.providers))
+	for storageType := range s.photos.providers {
+		storageTypes = append(storageTypes, storageType)
+	}
+	sort.Strings(storageTypes)
+
+	for _, storageType := range storageTypes {
+		provider := s.photos.providers[storageType]
+		if err := s.scrubProvider(ctx, storageType, provider, referenced[storageType], orphanBefore, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAlbum отмечает фотографию, альбом которой удален. albums кэширует результаты поиска альбомов.
+func (s *StorageScrubber) checkAlbum(ctx context.Context, photo models.Photo, albums map[int]bool) error {
+	// Фотография в корзине могла быть удалена вместе с альбомом
+	if photo.Album == nil || photo.DeletedAt != nil {
+		return nil
+	}
+	albumID := photo.Album.ID
+	exists, ok := albums[albumID]
+	if !ok {
+		_, err := s.photos.repo.FindAlbumByID(ctx, albumID)
+		switch {
+		case err == nil:
+			exists = true
+		case strings.Contains(err.Error(), "не найден"):
+			exists = false
+		default:
+			return err
+		}
+		albums[albumID] = exists
+	}
+	if !exists {
+		s.addIssue(ScrubIssue{Kind: ScrubAlbumMissing, PhotoID: photo.ID, AlbumID: albumID})
+	}
+	return nil
+}
+
+// scrubProvider проверяет файлы одного хранилища
+func (s *StorageScrubber) scrubProvider(ctx context.Context, storageType string, provider storage.Provider,
+	referenced map[string]scrubFile, orphanBefore time.Time, opts ScrubOptions) error {
+	// Список файлов заменяет отдельную проверку существования каждого файла
+	var listed map[string]storage.BlobInfo
+	if lister, ok := provider.(storage.Lister); ok {
+		listed = make(map[string]storage.BlobInfo)
+		err := lister.List(ctx, func(blob storage.BlobInfo) error {
+			listed[blob.Path] = blob
+			return nil
+		})
+		if errors.Is(err, errors.ErrUnsupported) {
+			listed = nil
+		} else if err != nil {
+			return fmt.Errorf("ошибка получения списка файлов хранилища %s: %w", storageType, err)
+		}
+	}
+
+	s.mutex.Lock()
+	s.report.Listed += len(listed)
+	if listed == nil {
+		s.report.Unlisted = append(s.report.Unlisted, storageType)
+	}
+	s.mutex.Unlock()
+
+	paths := make([]string, 0, len(referenced))
+	for p := range referenced {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		file := referenced[p]
+		issue := ScrubIssue{StorageType: storageType, Path: p, PhotoID: file.photo.ID}
+
+		s.mutex.Lock()
+		s.report.Files++
+		s.mutex.Unlock()
+
+		if opts.VerifyChecksums && file.original && file.photo.Checksum != "" {
+			sum, err := blobChecksum(provider, p)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				issue.Kind = ScrubMissing
+			case err != nil:
+				issue.Kind, issue.Error = ScrubUnreadable, err.Error()
+			case sum != file.photo.Checksum:
+				issue.Kind = ScrubChecksumMismatch
+			}
+		} else if listed != nil {
+			if _, ok := listed[p]; !ok {
+				issue.Kind = ScrubMissing
+			}
+		} else if err := blobExists(provider, p); errors.Is(err, fs.ErrNotExist) {
+			issue.Kind = ScrubMissing
+		} else if err != nil {
+			issue.Kind, issue.Error = ScrubUnreadable, err.Error()
+		}
+
+		if issue.Kind != "" {
+			s.addIssue(issue)
+		}
+	}
+
+	if listed == nil {
+		return nil
+	}
+	var copied map[string]bool
+	if s.migrator != nil {
+		copied = s.migrator.copiedBlobs(storageType)
+	}
+	var orphans []string
+	for p, blob := range listed {
+		if _, ok := referenced[p]; !ok && !copied[p] && blob.ModTime.Before(orphanBefore) {
+			orphans = append(orphans, p)
+		}
+	}
+	sort.Strings(orphans)
+
+	quarantineDir := storage.QuarantinePrefix + s.now().UTC().Format("20060102-150405") + "/"
+	for _, p := range orphans {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		issue := ScrubIssue{Kind: ScrubOrphan, StorageType: storageType, Path: p, Size: listed[p].Size}
+		if opts.QuarantineOrphans {
+			dest, err := quarantineBlob(provider, p, quarantineDir+p)
+			if err != nil {
+				issue.Error = err.Error()
+				log.Printf("Ошибка переноса ничьего файла %s хранилища %s в карантин: %v", p, storageType, err)
+			} else {
+				issue.Quarantined = dest
+			}
+		}
+		s.addIssue(issue)
+	}
+	return nil
+}
+
+// addIssue учитывает проблему в отчете
+func (s *StorageScrubber) addIssue(issue ScrubIssue) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch issue.Kind {
+	case ScrubMissing:
+		s.report.Missing++
+	case ScrubChecksumMismatch:
+		s.report.ChecksumMismatches++
+	case ScrubUnreadable:
+		s.report.Unreadable++
+	case ScrubAlbumMissing:
+		s.report.AlbumMissing++
+	case ScrubOrphan:
+		s.report.Orphans++
+		s.report.OrphanBytes += issue.Size
+		if issue.Quarantined != "" {
+			s.report.Quarantined++
+		}
+	}
+	if len(s.report.Issues) < maxScrubIssues {
+		s.report.Issues = append(s.report.Issues, issue)
+	}
+}
+
+// blobExists проверяет наличие файла в хранилище, которое не умеет перечислять файлы
+func blobExists(provider storage.Provider, key string) error {
+	reader, err := provider.GetReader(key)
+	if err != nil {
+		return err
+	}
+	return reader.Close()
+}
+
+// quarantineBlob переносит файл в карантин и возвращает его новый путь
+func quarantineBlob(provider storage.Provider, key, dest string) (string, error) {
+	if mover, ok := provider.(storage.Mover); ok {
+		err := mover.Move(key, dest)
+		if err == nil {
+			return dest, nil
+		}
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return "", err
+		}
+	}
+
+	copied, _, err := copyBlob(provider, provider, key, dest, "")
+	if err != nil {
+		return "", err
+	}
+	if err := provider.Delete(key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return copied, err
+	}
+	return copied, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mpm/internal/models"
+	"mpm/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageScrubber_Run(t *testing.T) {
+	ctx := context.Background()
+	localDir := t.TempDir()
+	local := storage.NewLocalStorage(localDir, "/files")
+	// flakyStorage скрывает List вложенного хранилища, как у Google Photos
+	remote := &flakyStorage{Provider: storage.NewLocalStorage(t.TempDir(), "/files")}
+
+	mockRepo := &MockPhotoRepository{}
+	service := NewPhotoService(mockRepo, local, "local", 0)
+	service.RegisterProvider("s3", remote)
+
+	for name, content := range map[string]string{
+		"albums/1/a.jpg":       "original-a",
+		"albums/1/a_256px.jpg": "thumb-a",
+		"albums/1/b.jpg":       "original-b",
+		"albums/1/c.jpg":       "damaged",
+		"albums/5/old.jpg":     "файл удаленного альбома",
+		"albums/1/new.jpg":     "загружается",
+		"albums/6/trashed.jpg": "в корзине",
+	} {
+		_, err := local.Save(writeTempFile(t, []byte(content)), name)
+		require.NoError(t, err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(localDir, "albums/5/old.jpg"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(localDir, "albums/6/trashed.jpg"), old, old))
+
+	album := &models.Album{ID: 1}
+	photos := []models.Photo{
+		{ID: 1, Album: album, Path: "albums/1/a.jpg", StorageType: "local", Checksum: sha256Hex([]byte("original-a")),
+			Renditions: []models.Rendition{{Size: 256, Path: "albums/1/a_256px.jpg"}}},
+		{ID: 2, Album: album, Path: "albums/1/b.jpg",
+			Renditions: []models.Rendition{{Size: 256, Path: "albums/1/b_256px.jpg"}}},
+		{ID: 3, Album: album, Path: "albums/1/c.jpg", StorageType: "local", Checksum: sha256Hex([]byte("original-c"))},
+		{ID: 4, Album: &models.Album{ID: 9}, Path: "albums/9/d.jpg", StorageType: "s3"},
+	}
+	// Альбом фотографии в корзине тоже может быть в корзине, поэтому он не проверяется
+	trashed := models.Photo{ID: 5, Album: &models.Album{ID: 6}, Path: "albums/6/trashed.jpg", StorageType: "local", DeletedAt: &old}
+	mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{}).Return(photos, nil)
+	mockRepo.On("FindPhotos", mock.Anything, models.PhotoFilter{Trashed: true}).Return([]models.Photo{trashed}, nil)
+	mockRepo.On("FindAlbumByID", mock.Anything, 1).Return(*album, nil).Once()
+	mockRepo.On("FindAlbumByID", mock.Anything, 9).Return(models.Album{}, fmt.Errorf("альбом с ID=9 не найден")).Once()
+
+	scrubber := NewStorageScrubber(service, nil, time.Hour)
+	report, err := scrubber.Run(ctx, ScrubOptions{VerifyChecksums: true, QuarantineOrphans: true})
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	assert.False(t, report.Running)
+	assert.Equal(t, 5, report.Photos)
+	assert.Equal(t, 7, report.Files)
+	assert.Equal(t, 2, report.Missing, "уменьшенная копия в local и оригинал в s3")
+	assert.Equal(t, 1, report.ChecksumMismatches)
+	assert.Equal(t, 1, report.AlbumMissing)
+	assert.Equal(t, 1, report.Orphans, "свежий файл и файл фотографии в корзине не считаются ничьими")
+	assert.Equal(t, 1, report.Quarantined)
+	assert.Equal(t, []string{"s3"}, report.Unlisted)
+
+	issues := map[string][]ScrubIssue{}
+	for _, issue := range report.Issues {
+		issues[issue.Kind] = append(issues[issue.Kind], issue)
+	}
+	require.Len(t, issues[ScrubMissing], 2)
+	assert.Equal(t, "albums/1/b_256px.jpg", issues[ScrubMissing][0].Path)
+	assert.Equal(t, ScrubIssue{Kind: ScrubMissing, StorageType: "s3", Path: "albums/9/d.jpg", PhotoID: 4}, issues[ScrubMissing][1])
+	assert.Equal(t, 3, issues[ScrubChecksumMismatch][0].PhotoID)
+	assert.Equal(t, ScrubIssue{Kind: ScrubAlbumMissing, PhotoID: 4, AlbumID: 9}, issues[ScrubAlbumMissing][0])
+
+	require.Len(t, issues[ScrubOrphan], 1)
+	orphan := issues[ScrubOrphan][0]
+	assert.Equal(t, "albums/5/old.jpg", orphan.Path)
+	assert.True(t, strings.HasPrefix(orphan.Quarantined, storage.QuarantinePrefix))
+	data, err := local.Get(orphan.Quarantined)
+	require.NoError(t, err)
+	assert.Equal(t, "файл удаленного альбома", string(data))
+	_, err = os.Stat(filepath.Join(localDir, "albums/5/old.jpg"))
+	assert.True(t, os.IsNotExist(err), "ничий файл перенесен в карантин")
+
+	t.Run("Повторная проверка не видит файлы в карантине", func(t *testing.T) {
+		mockRepo.On("FindAlbumByID", mock.Anything, mock.Anything).Return(*album, nil)
+
+		report, err := scrubber.Run(ctx, ScrubOptions{})
+		require.NoError(t, err)
+		assert.Zero(t, report.Orphans)
+		assert.Equal(t, 2, report.Missing)
+		assert.Zero(t, report.ChecksumMismatches, "без verify_checksums содержимое не читается")
+	})
+
+	t.Run("Копии незавершенного переноса", func(t *testing.T) {
+		_, err := local.Save(writeTempFile(t, []byte("копия")), "albums/7/e.jpg")
+		require.NoError(t, err)
+		require.NoError(t, os.Chtimes(filepath.Join(localDir, "albums/7/e.jpg"), old, old))
+
+		statePath := filepath.Join(t.TempDir(), "blob_migration.json")
+		state := `{"from":"s3","to":"local","blobs":{"albums/7/e.jpg":"albums/7/e.jpg"}}`
+		require.NoError(t, os.WriteFile(statePath, []byte(state), 0644))
+		migrator, err := NewBlobMigrator(service, statePath)
+		require.NoError(t, err)
+
+		report, err := NewStorageScrubber(service, migrator, time.Hour).Run(ctx, ScrubOptions{QuarantineOrphans: true})
+		require.NoError(t, err)
+		assert.Zero(t, report.Orphans, "фотография еще не переключена на скопированный файл")
+	})
+}
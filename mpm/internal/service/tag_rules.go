@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"mpm/internal/models"
+	"mpm/internal/rules"
+)
+
+// ErrInvalidRule правило содержит некорректные условия или не добавляет теги
+var ErrInvalidRule = errors.New("некорректное правило")
+
+// TagRuleRepositoryInterface определяет методы репозитория для правил расстановки тегов
+type TagRuleRepositoryInterface interface {
+	GetTagRules(ctx context.Context) ([]models.TagRule, error)
+	FindTagRuleByID(ctx context.Context, id int) (models.TagRule, error)
+	AddTagRule(ctx context.Context, rule models.TagRule) (int, error)
+	UpdateTagRule(ctx context.Context, id int, rule models.TagRule) error
+	DeleteTagRule(ctx context.Context, id int) error
+	EnsureTags(ctx context.Context, names []string) error
+	FindPhotos(ctx context.Context, filter models.PhotoFilter) ([]models.Photo, error)
+	UpdatePhotoFields(ctx context.Context, id int, update func(photo *models.Photo)) error
+}
+
+// RuleApplyResult результат применения правил к библиотеке
+type RuleApplyResult struct {
+	Checked int            `json:"checked"`           // Проверено фотографий
+	Updated int            `json:"updated"`           // Фотографий, получивших новые теги
+	Tags    map[string]int `json:"tags"`              // Количество фотографий, получивших каждый тег
+	DryRun  bool           `json:"dry_run,omitempty"` // Изменения не сохранены
+}
+
+// RuleService управляет правилами автоматической расстановки тегов и применяет их
+type RuleService struct {
+	repo TagRuleRepositoryInterface
+
+	mu     sync.Mutex
+	engine *rules.Engine // Действующие правила; nil, если их нужно перечитать из репозитория
+}
+
+// NewRuleService создает сервис правил расстановки тегов
+func NewRuleService(repo TagRuleRepositoryInterface) *RuleService {
+	return &RuleService{repo: repo}
+}
+
+// SetRuleService включает применение правил расстановки тегов к загружаемым фотографиям. nil отключает его.
+func (s *PhotoService) SetRuleService(rules *RuleService) {
+	s.rules = rules
+}
+
+// ListRules возвращает все правила
+func (s *RuleService) ListRules(ctx context.Context) ([]models.TagRule, error) {
+	return s.repo.GetTagRules(ctx)
+}
+
+// GetRule возвращает правило по ID
+func (s *RuleService) GetRule(ctx context.Context, id int) (models.TagRule, error) {
+	return s.repo.FindTagRuleByID(ctx, id)
+}
+
+// CreateRule проверяет и сохраняет новое правило. Теги правила добавляются в справочник тегов.
+func (s *RuleService) CreateRule(ctx context.Context, rule models.TagRule) (models.TagRule, error) {
+	rule = normalizeRule(rule)
+	if err := rules.Validate(rule); err != nil {
+		return models.TagRule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	if err := s.repo.EnsureTags(ctx, rule.Tags); err != nil {
+		return models.TagRule{}, err
+	}
+
+	id, err := s.repo.AddTagRule(ctx, rule)
+	if err != nil {
+		return models.TagRule{}, err
+	}
+	s.invalidate()
+
+	log.Printf("Создано правило расстановки тегов: ID=%d, Название=%s", id, rule.Name)
+	return s.repo.FindTagRuleByID(ctx, id)
+}
+
+// UpdateRule проверяет и заменяет правило с указанным ID
+func (s *RuleService) UpdateRule(ctx context.Context, id int, rule models.TagRule) (models.TagRule, error) {
+	rule = normalizeRule(rule)
+	if err := rules.Validate(rule); err != nil {
+		return models.TagRule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	if _, err := s.repo.FindTagRuleByID(ctx, id); err != nil {
+		return models.TagRule{}, err
+	}
+	if err := s.repo.EnsureTags(ctx, rule.Tags); err != nil {
+		return models.TagRule{}, err
+	}
+
+	if err := s.repo.UpdateTagRule(ctx, id, rule); err != nil {
+		return models.TagRule{}, err
+	}
+	s.invalidate()
+
+	return s.repo.FindTagRuleByID(ctx, id)
+}
+
+// DeleteRule удаляет правило. Уже добавленные им теги остаются у фотографий.
+func (s *RuleService) DeleteRule(ctx context.Context, id int) error {
+	if err := s.repo.DeleteTagRule(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Tags возвращает теги, которые действующие правила добавляют фотографии
+func (s *RuleService) Tags(ctx context.Context, photo models.Photo) []string {
+	engine, err := s.currentEngine(ctx)
+	if err != nil {
+		log.Printf("Ошибка при загрузке правил расстановки тегов: %v", err)
+		return nil
+	}
+	return engine.Tags(photo)
+}
+
+// Apply применяет правила ко всем фотографиям библиотеки, кроме находящихся в корзине
+func (s *RuleService) Apply(ctx context.Context, ruleID int, dryRun bool) (RuleApplyResult, error) {
+	var engine *rules.Engine
+	if ruleID == 0 {
+		var err error
+		if engine, err = s.currentEngine(ctx); err != nil {
+			return RuleApplyResult{}, err
+		}
+	} else {
+		rule, err := s.repo.FindTagRuleByID(ctx, ruleID)
+		if err != nil {
+			return RuleApplyResult{}, err
+		}
+		rule.Disabled = false
+		if engine, err = rules.NewEngine([]models.TagRule{rule}); err != nil {
+			return RuleApplyResult{}, err
+		}
+	}
+
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{})
+	if err != nil {
+		return RuleApplyResult{}, err
+	}
+
+	result := RuleApplyResult{Tags: map[string]int{}, DryRun: dryRun}
+	for _, photo := range photos {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		result.Checked++
+
+		added := engine.Tags(photo)
+		if len(added) == 0 {
+			continue
+		}
+		if !dryRun {
+			// Теги добавляются к актуальной копии фотографии, чтобы не затереть параллельные изменения
+			err := s.repo.UpdatePhotoFields(ctx, photo.ID, func(photo *models.Photo) {
+				added = engine.Tags(*photo)
+				photo.Tags = append(append([]string{}, photo.Tags...), added...)
+			})
+			if err != nil {
+				return result, err
+			}
+			if len(added) == 0 {
+				continue
+			}
+		}
+		result.Updated++
+		for _, tag := range added {
+			result.Tags[tag]++
+		}
+	}
+
+	if !dryRun && result.Updated > 0 {
+		log.Printf("Правила расстановки тегов обновили %d фотографий из %d", result.Updated, result.Checked)
+	}
+	return result, nil
+}
+
+// currentEngine возвращает действующие правила, при необходимости перечитывая их из репозитория
+func (s *RuleService) currentEngine(ctx context.Context) (*rules.Engine, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.engine != nil {
+		return s.engine, nil
+	}
+
+	stored, err := s.repo.GetTagRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	engine, err := rules.NewEngine(stored)
+	if err != nil {
+		return nil, err
+	}
+	s.engine = engine
+	return engine, nil
+}
+
+// invalidate сбрасывает кэш действующих правил после их изменения
+func (s *RuleService) invalidate() {
+	s.mu.Lock()
+	s.engine = nil
+	s.mu.Unlock()
+}
+
+// normalizeRule убирает лишние пробелы в названии и тегах правила
+func normalizeRule(rule models.TagRule) models.TagRule {
+	rule.Name = strings.TrimSpace(rule.Name)
+	tags := make([]string, 0, len(rule.Tags))
+	for _, tag := range rule.Tags {
+		tags = append(tags, strings.TrimSpace(tag))
+	}
+	rule.Tags = tags
+	return rule
+}
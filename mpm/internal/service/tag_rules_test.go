@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mpm/internal/models"
+	"mpm/internal/repository"
+)
+
+// concurrentEditRepo изменяет фотографию между чтением библиотеки и записью тегов
+type concurrentEditRepo struct {
+	*repository.Repository
+	edit func()
+}
+
+func (r *concurrentEditRepo) FindPhotos(ctx context.Context, filter models.PhotoFilter) ([]models.Photo, error) {
+	photos, err := r.Repository.FindPhotos(ctx, filter)
+	r.edit()
+	return photos, err
+}
+
+func TestRuleService_Apply_KeepsConcurrentEdits(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewRepository("json", t.TempDir(), time.Hour)
+	require.NoError(t, repo.SaveEntity(models.Photo{ID: 1, Name: "DSC_0001.jpg", Tags: []string{}}))
+
+	edited := &concurrentEditRepo{Repository: repo, edit: func() {
+		require.NoError(t, repo.UpdatePhoto(ctx, 1, models.Photo{Name: "DSC_0001 море.jpg", Tags: []string{"море"}}))
+	}}
+	rules := NewRuleService(edited)
+	_, err := rules.CreateRule(ctx, models.TagRule{
+		Name:       "Фотоаппарат",
+		Conditions: []models.RuleCondition{{Field: models.RuleFieldName, Operator: models.RuleOpContains, Value: "DSC"}},
+		Tags:       []string{"камера"},
+	})
+	require.NoError(t, err)
+
+	result, err := rules.Apply(ctx, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+
+	photo, err := repo.FindPhotoByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, "DSC_0001 море.jpg", photo.Name)
+	assert.Equal(t, []string{"море", "камера"}, photo.Tags)
+}
+
+func TestRuleService_CreateRule_RejectsInvalidTags(t *testing.T) {
+	rules := NewRuleService(repository.NewRepository("json", t.TempDir(), time.Hour))
+
+	_, err := rules.CreateRule(context.Background(), models.TagRule{
+		Name:       "Пробел в теге",
+		Conditions: []models.RuleCondition{{Field: models.RuleFieldName, Operator: models.RuleOpContains, Value: "DSC"}},
+		Tags:       []string{"два слова"},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidRule)
+}
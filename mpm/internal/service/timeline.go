@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mpm/internal/models"
+)
+
+// Группировка ленты фотографий по дате съемки
+const (
+	TimelineDay   = "day"
+	TimelineMonth = "month"
+	TimelineYear  = "year"
+)
+
+const (
+	// DefaultTimelineLimit количество фотографий на странице ленты по умолчанию
+	DefaultTimelineLimit = 100
+	// MaxTimelineLimit максимальное количество фотографий на странице ленты
+	MaxTimelineLimit = 500
+)
+
+// ErrInvalidCursor курсор ленты поврежден или создан для другой выборки
+var ErrInvalidCursor = errors.New("некорректный курсор")
+
+// TimelineRepositoryInterface определяет методы репозитория для построения ленты фотографий
+type TimelineRepositoryInterface interface {
+	FindPhotos(ctx context.Context, filter models.PhotoFilter) ([]models.Photo, error)
+}
+
+// TimelineBucket фотографии за один день, месяц или год
+type TimelineBucket struct {
+	Key    string         `json:"key"`   // 2024-06-01, 2024-06 или 2024
+	Start  time.Time      `json:"start"` // Начало периода
+	Count  int            `json:"count"` // Количество фотографий за период во всей выборке, а не только на странице
+	Photos []models.Photo `json:"photos"`
+}
+
+// TimelinePage страница ленты, периоды идут от новых к старым
+type TimelinePage struct {
+	Group      string           `json:"group"`
+	Total      int              `json:"total"` // Количество фотографий во всей выборке
+	Buckets    []TimelineBucket `json:"buckets"`
+	NextCursor string           `json:"next_cursor,omitempty"` // Пустой на последней странице
+}
+
+// MemoryYear фотографии, снятые в этот день несколько лет назад
+type MemoryYear struct {
+	Year     int            `json:"year"`
+	YearsAgo int            `json:"years_ago"`
+	Photos   []models.Photo `json:"photos"`
+}
+
+// TimelineService строит ленту фотографий по дате съемки, а если она неизвестна - по дате загрузки
+type TimelineService struct {
+	repo TimelineRepositoryInterface
+}
+
+// NewTimelineService создает сервис ленты фотографий
+func NewTimelineService(repo TimelineRepositoryInterface) *TimelineService {
+	return &TimelineService{repo: repo}
+}
+
+// ValidTimelineGroup проверяет способ группировки ленты
+func ValidTimelineGroup(group string) bool {
+	return group == TimelineDay || group == TimelineMonth || group == TimelineYear
+}
+
+// Timeline возвращает страницу ленты не более чем из limit фотографий после курсора
+func (s *TimelineService) Timeline(ctx context.Context, filter models.PhotoFilter, group, cursor string, limit int) (TimelinePage, error) {
+	if !ValidTimelineGroup(group) {
+		return TimelinePage{}, fmt.Errorf("неизвестная группировка ленты: %s", group)
+	}
+	if limit <= 0 {
+		limit = DefaultTimelineLimit
+	}
+	limit = min(limit, MaxTimelineLimit)
+
+	photos, err := s.repo.FindPhotos(ctx, filter)
+	if err != nil {
+		return TimelinePage{}, err
+	}
+	sortTimeline(photos)
+
+	start := 0
+	if cursor != "" {
+		after, afterID, err := decodeTimelineCursor(cursor)
+		if err != nil {
+			return TimelinePage{}, err
+		}
+		start = sort.Search(len(photos), func(i int) bool {
+			return timelineAfter(photos[i], after, afterID)
+		})
+	}
+
+	counts := make(map[string]int)
+	for _, photo := range photos {
+		counts[timelineKey(photo.CapturedAt(), group)]++
+	}
+
+	page := TimelinePage{Group: group, Total: len(photos), Buckets: []TimelineBucket{}}
+	end := min(start+limit, len(photos))
+	for _, photo := range photos[start:end] {
+		captured := photo.CapturedAt()
+		key := timelineKey(captured, group)
+		if n := len(page.Buckets); n == 0 || page.Buckets[n-1].Key != key {
+			page.Buckets = append(page.Buckets, TimelineBucket{
+				Key:   key,
+				Start: timelineStart(captured, group),
+				Count: counts[key],
+			})
+		}
+		bucket := &page.Buckets[len(page.Buckets)-1]
+		bucket.Photos = append(bucket.Photos, photo)
+	}
+
+	if end < len(photos) {
+		last := photos[end-1]
+		page.NextCursor = encodeTimelineCursor(last.CapturedAt(), last.ID)
+	}
+	return page, nil
+}
+
+// Memories возвращает фотографии, снятые в тот же день, что и date, в предыдущие годы
+func (s *TimelineService) Memories(ctx context.Context, filter models.PhotoFilter, date time.Time) ([]MemoryYear, error) {
+	photos, err := s.repo.FindPhotos(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	sortTimeline(photos)
+
+	leapDay := date.Month() == time.February && date.Day() == 28 && !isLeapYear(date.Year())
+	result := []MemoryYear{}
+	for _, photo := range photos {
+		captured := photo.CapturedAt()
+		if captured.Year() >= date.Year() || captured.Month() != date.Month() {
+			continue
+		}
+		if captured.Day() != date.Day() && !(leapDay && captured.Day() == 29) {
+			continue
+		}
+
+		if n := len(result); n == 0 || result[n-1].Year != captured.Year() {
+			result = append(result, MemoryYear{Year: captured.Year(), YearsAgo: date.Year() - captured.Year()})
+		}
+		memory := &result[len(result)-1]
+		memory.Photos = append(memory.Photos, photo)
+	}
+	return result, nil
+}
+
+// sortTimeline упорядочивает фотографии от новых к старым, при равной дате - по убыванию ID
+func sortTimeline(photos []models.Photo) {
+	sort.SliceStable(photos, func(i, j int) bool {
+		a, b := photos[i].CapturedAt(), photos[j].CapturedAt()
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return photos[i].ID > photos[j].ID
+	})
+}
+
+// timelineAfter проверяет, идет ли фотография в ленте после позиции курсора
+func timelineAfter(photo models.Photo, after time.Time, afterID int) bool {
+	captured := photo.CapturedAt()
+	if !captured.Equal(after) {
+		return captured.Before(after)
+	}
+	return photo.ID < afterID
+}
+
+// timelineKey возвращает ключ периода, к которому относится дата
+func timelineKey(t time.Time, group string) string {
+	switch group {
+	case TimelineYear:
+		return t.Format("2006")
+	case TimelineMonth:
+		return t.Format("2006-01")
+	default:
+		return t.Format(time.DateOnly)
+	}
+}
+
+// timelineStart возвращает начало периода, к которому относится дата
+func timelineStart(t time.Time, group string) time.Time {
+	switch group {
+	case TimelineYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	case TimelineMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// encodeTimelineCursor кодирует позицию последней фотографии страницы
+func encodeTimelineCursor(t time.Time, id int) string {
+	raw := t.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTimelineCursor разбирает курсор, созданный encodeTimelineCursor
+func decodeTimelineCursor(cursor string) (time.Time, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	value, idValue, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(idValue)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return t, id, nil
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
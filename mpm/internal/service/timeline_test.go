@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"mpm/internal/models"
+)
+
+func TestTimelineService(t *testing.T) {
+	at := func(value string) *time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return &parsed
+	}
+	photos := []models.Photo{
+		{ID: 1, TakenAt: at("2022-06-01T10:00:00Z")},
+		{ID: 2, TakenAt: at("2024-06-01T09:00:00Z")},
+		{ID: 3, TakenAt: at("2024-06-01T09:00:00Z")},
+		{ID: 4, TakenAt: at("2024-05-20T12:00:00Z")},
+		{ID: 5, CreatedAt: *at("2023-06-01T08:00:00Z")}, // Без даты съемки
+		{ID: 6, TakenAt: at("2020-02-29T12:00:00Z")},
+	}
+
+	newService := func() *TimelineService {
+		repo := &MockPhotoRepository{}
+		repo.On("FindPhotos", mock.Anything, models.PhotoFilter{}).
+			Return(append([]models.Photo(nil), photos...), nil)
+		return NewTimelineService(repo)
+	}
+	ids := func(photos []models.Photo) []int {
+		result := []int{}
+		for _, p := range photos {
+			result = append(result, p.ID)
+		}
+		return result
+	}
+
+	t.Run("Группировка по месяцам", func(t *testing.T) {
+		page, err := newService().Timeline(context.Background(), models.PhotoFilter{}, TimelineMonth, "", 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, 6, page.Total)
+		assert.Empty(t, page.NextCursor)
+		require.Len(t, page.Buckets, 5)
+		assert.Equal(t, "2024-06", page.Buckets[0].Key)
+		assert.Equal(t, *at("2024-06-01T00:00:00Z"), page.Buckets[0].Start)
+		assert.Equal(t, []int{3, 2}, ids(page.Buckets[0].Photos), "при равной дате - по убыванию ID")
+		assert.Equal(t, "2023-06", page.Buckets[2].Key, "дата загрузки вместо даты съемки")
+	})
+
+	t.Run("Постраничный вывод по курсору", func(t *testing.T) {
+		service := newService()
+		var pages [][]int
+		cursor := ""
+		for {
+			page, err := service.Timeline(context.Background(), models.PhotoFilter{}, TimelineYear, cursor, 2)
+			require.NoError(t, err)
+
+			var pageIDs []int
+			for _, bucket := range page.Buckets {
+				pageIDs = append(pageIDs, ids(bucket.Photos)...)
+				if bucket.Key == "2024" {
+					assert.Equal(t, 3, bucket.Count, "количество за период по всей выборке")
+				}
+			}
+			pages = append(pages, pageIDs)
+
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		assert.Equal(t, [][]int{{3, 2}, {4, 5}, {1, 6}}, pages)
+	})
+
+	t.Run("Некорректные параметры", func(t *testing.T) {
+		_, err := newService().Timeline(context.Background(), models.PhotoFilter{}, TimelineDay, "не курсор", 10)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = newService().Timeline(context.Background(), models.PhotoFilter{}, "week", "", 10)
+		assert.Error(t, err)
+	})
+
+	t.Run("В этот день", func(t *testing.T) {
+		memories, err := newService().Memories(context.Background(), models.PhotoFilter{}, *at("2025-06-01T15:00:00Z"))
+		require.NoError(t, err)
+
+		require.Len(t, memories, 3)
+		assert.Equal(t, MemoryYear{Year: 2024, YearsAgo: 1, Photos: memories[0].Photos}, memories[0])
+		assert.Equal(t, []int{3, 2}, ids(memories[0].Photos))
+		assert.Equal(t, []int{5}, ids(memories[1].Photos))
+		assert.Equal(t, 2022, memories[2].Year)
+
+		memories, err = newService().Memories(context.Background(), models.PhotoFilter{}, *at("2024-06-01T15:00:00Z"))
+		require.NoError(t, err)
+		assert.Len(t, memories, 2, "снимки текущего года не включаются")
+	})
+
+	t.Run("29 февраля в невисокосный год", func(t *testing.T) {
+		memories, err := newService().Memories(context.Background(), models.PhotoFilter{}, *at("2023-02-28T00:00:00Z"))
+		require.NoError(t, err)
+		require.Len(t, memories, 1)
+		assert.Equal(t, []int{6}, ids(memories[0].Photos))
+
+		memories, err = newService().Memories(context.Background(), models.PhotoFilter{}, *at("2024-02-28T00:00:00Z"))
+		require.NoError(t, err)
+		assert.Empty(t, memories)
+	})
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"mpm/internal/models"
+)
+
+// DefaultTrashRetention срок хранения альбомов и фотографий в корзине
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashRepositoryInterface определяет методы репозитория для работы с корзиной
+type TrashRepositoryInterface interface {
+	FindDeletedAlbums(ctx context.Context) ([]models.Album, error)
+	FindPhotos(ctx context.Context, filter models.PhotoFilter) ([]models.Photo, error)
+	RestoreAlbum(ctx context.Context, id int) error
+	RestorePhoto(ctx context.Context, id int) error
+	PurgeAlbum(ctx context.Context, id int) error
+	PurgePhoto(ctx context.Context, id int) error
+}
+
+// TrashedAlbum альбом в корзине
+type TrashedAlbum struct {
+	models.Album
+	PhotoCount int        `json:"photo_count"`        // Фотографии, удаленные вместе с альбомом
+	PurgeAt    *time.Time `json:"purge_at,omitempty"` // Время окончательного удаления
+}
+
+// TrashedPhoto фотография, удаленная отдельно от альбома
+type TrashedPhoto struct {
+	models.Photo
+	PurgeAt *time.Time `json:"purge_at,omitempty"` // Время окончательного удаления
+}
+
+// Trash содержимое корзины
+type Trash struct {
+	Albums []TrashedAlbum `json:"albums"`
+	Photos []TrashedPhoto `json:"photos"`
+}
+
+// TrashPurgeResult итоги окончательного удаления
+type TrashPurgeResult struct {
+	Albums int `json:"albums"`
+	Photos int `json:"photos"`
+}
+
+// TrashService управляет корзиной альбомов и фотографий
+type TrashService struct {
+	repo      TrashRepositoryInterface
+	photos    *PhotoService
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewTrashService создает сервис корзины. retention <= 0 отключает автоматическую очистку.
+func NewTrashService(repo TrashRepositoryInterface, photos *PhotoService, retention time.Duration) *TrashService {
+	return &TrashService{
+		repo:      repo,
+		photos:    photos,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// List возвращает содержимое корзины
+func (s *TrashService) List(ctx context.Context) (Trash, error) {
+	albums, err := s.repo.FindDeletedAlbums(ctx)
+	if err != nil {
+		return Trash{}, err
+	}
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{Trashed: true})
+	if err != nil {
+		return Trash{}, err
+	}
+
+	trash := Trash{Albums: []TrashedAlbum{}, Photos: []TrashedPhoto{}}
+	albumIndex := make(map[int]int, len(albums))
+	for _, album := range albums {
+		albumIndex[album.ID] = len(trash.Albums)
+		trash.Albums = append(trash.Albums, TrashedAlbum{Album: album, PurgeAt: s.purgeAt(album.DeletedAt)})
+	}
+	for _, photo := range photos {
+		if photo.Album != nil {
+			if i, ok := albumIndex[photo.Album.ID]; ok {
+				trash.Albums[i].PhotoCount++
+				continue
+			}
+		}
+		trash.Photos = append(trash.Photos, TrashedPhoto{Photo: photo, PurgeAt: s.purgeAt(photo.DeletedAt)})
+	}
+	return trash, nil
+}
+
+// RestoreAlbum возвращает альбом из корзины вместе с фотографиями, удаленными вместе с ним
+func (s *TrashService) RestoreAlbum(ctx context.Context, id int) error {
+	if err := s.repo.RestoreAlbum(ctx, id); err != nil {
+		return err
+	}
+	log.Printf("Альбом восстановлен из корзины: ID=%d", id)
+	return nil
+}
+
+// RestorePhoto возвращает фотографию из корзины
+func (s *TrashService) RestorePhoto(ctx context.Context, id int) error {
+	if err := s.repo.RestorePhoto(ctx, id); err != nil {
+		return err
+	}
+	log.Printf("Фотография восстановлена из корзины: ID=%d", id)
+	return nil
+}
+
+// PurgeAlbum окончательно удаляет альбом из корзины и возвращает количество удаленных фотографий
+func (s *TrashService) PurgeAlbum(ctx context.Context, id int) (int, error) {
+	var photos []models.Photo
+	for _, trashed := range []bool{true, false} {
+		found, err := s.repo.FindPhotos(ctx, models.PhotoFilter{AlbumID: &id, Trashed: trashed})
+		if err != nil {
+			return 0, err
+		}
+		photos = append(photos, found...)
+	}
+
+	if err := s.repo.PurgeAlbum(ctx, id); err != nil {
+		return 0, err
+	}
+
+	// Ошибки удаления файлов только логируем: записи о фотографиях уже удалены
+	for _, photo := range photos {
+		s.photos.deleteFiles(photo)
+	}
+
+	log.Printf("Альбом окончательно удален: ID=%d, фотографий %d", id, len(photos))
+	return len(photos), nil
+}
+
+// PurgePhoto окончательно удаляет фотографию из корзины вместе с ее файлами
+func (s *TrashService) PurgePhoto(ctx context.Context, id int) error {
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{Trashed: true})
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.PurgePhoto(ctx, id); err != nil {
+		return err
+	}
+
+	for _, photo := range photos {
+		if photo.ID == id {
+			s.photos.deleteFiles(photo)
+			break
+		}
+	}
+
+	log.Printf("Фотография окончательно удалена: ID=%d", id)
+	return nil
+}
+
+// Empty окончательно удаляет все содержимое корзины
+func (s *TrashService) Empty(ctx context.Context) (TrashPurgeResult, error) {
+	return s.purgeBefore(ctx, nil)
+}
+
+// PurgeExpired окончательно удаляет альбомы и фотографии, пролежавшие в корзине дольше срока хранения
+func (s *TrashService) PurgeExpired(ctx context.Context) (TrashPurgeResult, error) {
+	if s.retention <= 0 {
+		return TrashPurgeResult{}, nil
+	}
+	before := s.now().Add(-s.retention)
+	return s.purgeBefore(ctx, &before)
+}
+
+// RunPeriodically удаляет просроченное содержимое корзины с интервалом interval до отмены ctx
+func (s *TrashService) RunPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := s.PurgeExpired(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Ошибка очистки корзины: %v", err)
+			}
+			if result.Albums > 0 || result.Photos > 0 {
+				log.Printf("Очистка корзины: удалено альбомов %d, фотографий %d", result.Albums, result.Photos)
+			}
+		}
+	}
+}
+
+// purgeBefore окончательно удаляет содержимое корзины, удаленное раньше before
+func (s *TrashService) purgeBefore(ctx context.Context, before *time.Time) (TrashPurgeResult, error) {
+	var result TrashPurgeResult
+	expired := func(deletedAt *time.Time) bool {
+		return deletedAt != nil && (before == nil || deletedAt.Before(*before))
+	}
+
+	albums, err := s.repo.FindDeletedAlbums(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, album := range albums {
+		if !expired(album.DeletedAt) {
+			continue
+		}
+		count, err := s.PurgeAlbum(ctx, album.ID)
+		if err != nil {
+			return result, err
+		}
+		result.Albums++
+		result.Photos += count
+	}
+
+	// Фотографии удаленных альбомов уже удалены вместе с альбомами
+	photos, err := s.repo.FindPhotos(ctx, models.PhotoFilter{Trashed: true})
+	if err != nil {
+		return result, err
+	}
+	for _, photo := range photos {
+		if !expired(photo.DeletedAt) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := s.repo.PurgePhoto(ctx, photo.ID); err != nil {
+			return result, err
+		}
+		s.photos.deleteFiles(photo)
+		result.Photos++
+	}
+	return result, nil
+}
+
+// purgeAt вычисляет время окончательного удаления
+func (s *TrashService) purgeAt(deletedAt *time.Time) *time.Time {
+	if s.retention <= 0 || deletedAt == nil {
+		return nil
+	}
+	at := deletedAt.Add(s.retention)
+	return &at
+}
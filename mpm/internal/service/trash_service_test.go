@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mpm/internal/models"
+	"mpm/internal/repository"
+	"mpm/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashService(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewRepository("json", t.TempDir(), time.Hour)
+	filesDir := t.TempDir()
+	local := storage.NewLocalStorage(filesDir, "/files")
+	photos := NewPhotoService(repo, local, "local", 0)
+
+	trip := models.Album{ID: 1, Name: "Trip"}
+	home := models.Album{ID: 2, Name: "Home"}
+	require.NoError(t, repo.SaveEntity(trip))
+	require.NoError(t, repo.SaveEntity(home))
+	for _, photo := range []models.Photo{
+		{ID: 1, Name: "a.jpg", Album: &trip, Path: "albums/1/a.jpg", Renditions: []models.Rendition{{Size: 256, Path: "albums/1/a_256px.jpg"}}},
+		{ID: 2, Name: "b.jpg", Album: &trip, Path: "albums/1/b.jpg"},
+		{ID: 3, Name: "c.jpg", Album: &home, Path: "albums/2/c.jpg"},
+	} {
+		require.NoError(t, repo.SaveEntity(photo))
+		paths := []string{photo.Path}
+		for _, r := range photo.Renditions {
+			paths = append(paths, r.Path)
+		}
+		for _, path := range paths {
+			_, err := local.Save(writeTempFile(t, []byte(path)), path)
+			require.NoError(t, err)
+		}
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(filepath.Join(filesDir, path))
+		return err == nil
+	}
+
+	trash := NewTrashService(repo, photos, 24*time.Hour)
+	require.NoError(t, repo.DeleteAlbum(ctx, 1))
+	require.NoError(t, photos.DeletePhoto(ctx, 3))
+
+	list, err := trash.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list.Albums, 1)
+	assert.Equal(t, 1, list.Albums[0].ID)
+	assert.Equal(t, 2, list.Albums[0].PhotoCount, "фотографии альбома входят в альбом")
+	require.NotNil(t, list.Albums[0].PurgeAt)
+	assert.Equal(t, list.Albums[0].DeletedAt.Add(24*time.Hour), *list.Albums[0].PurgeAt)
+	require.Len(t, list.Photos, 1)
+	assert.Equal(t, 3, list.Photos[0].ID)
+	assert.True(t, exists("albums/2/c.jpg"), "файлы в корзине сохраняются")
+
+	t.Run("Срок хранения не истек", func(t *testing.T) {
+		result, err := trash.PurgeExpired(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, TrashPurgeResult{}, result)
+	})
+
+	t.Run("Восстановление фотографии", func(t *testing.T) {
+		require.NoError(t, trash.RestorePhoto(ctx, 3))
+		_, err := photos.GetPhoto(ctx, 3)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, trash.RestorePhoto(ctx, 1), repository.ErrAlbumInTrash)
+	})
+
+	t.Run("Очистка по истечении срока", func(t *testing.T) {
+		require.NoError(t, photos.DeletePhoto(ctx, 3))
+		trash.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+
+		result, err := trash.PurgeExpired(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, TrashPurgeResult{Albums: 1, Photos: 3}, result)
+		for _, path := range []string{"albums/1/a.jpg", "albums/1/a_256px.jpg", "albums/1/b.jpg", "albums/2/c.jpg"} {
+			assert.False(t, exists(path), "файл %s должен быть удален", path)
+		}
+
+		list, err := trash.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, list.Albums)
+		assert.Empty(t, list.Photos)
+	})
+
+	t.Run("Без срока хранения корзина очищается только вручную", func(t *testing.T) {
+		manual := NewTrashService(repo, photos, 0)
+		manual.now = func() time.Time { return time.Now().Add(1000 * time.Hour) }
+		require.NoError(t, repo.DeleteAlbum(ctx, 2))
+
+		list, err := manual.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, list.Albums, 1)
+		assert.Nil(t, list.Albums[0].PurgeAt)
+
+		result, err := manual.PurgeExpired(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, result.Albums)
+
+		result, err = manual.Empty(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, TrashPurgeResult{Albums: 1}, result)
+	})
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"mpm/internal/models"
+	"mpm/internal/storage"
+	"mpm/internal/video"
+)
+
+// ErrNotAVideo возвращается, если видеоролик Live Photo не является видео MP4 или MOV
+var ErrNotAVideo = errors.New("файл не является видео MP4 или MOV")
+
+// probeVideo читает параметры видео и метаданные съемки из контейнера MP4 или MOV
+func probeVideo(upload UploadFile) (*models.VideoInfo, []models.Metadata, *time.Time) {
+	metadata := []models.Metadata{}
+
+	info, err := video.Probe(upload.File, upload.Size)
+	if err != nil {
+		log.Printf("Параметры видео %s не прочитаны: %v", upload.Filename, err)
+		return nil, metadata, nil
+	}
+
+	add := func(key, value string) {
+		if value != "" {
+			metadata = append(metadata, models.Metadata{Key: key, Value: value})
+		}
+	}
+	add(models.MetadataCameraMake, info.Make)
+	add(models.MetadataCameraModel, info.Model)
+	if info.Location != nil {
+		add(models.MetadataGPSLatitude, fmt.Sprintf("%.6f", info.Location.Latitude))
+		add(models.MetadataGPSLongitude, fmt.Sprintf("%.6f", info.Location.Longitude))
+		if info.Location.Altitude != nil {
+			add(models.MetadataGPSAltitude, fmt.Sprintf("%.1f", *info.Location.Altitude))
+		}
+	}
+
+	var takenAt *time.Time
+	if !info.CreatedAt.IsZero() {
+		takenAt = &info.CreatedAt
+		add(models.MetadataDateTaken, info.CreatedAt.Format(time.RFC3339))
+	}
+
+	return &models.VideoInfo{
+		Duration:   math.Round(info.Duration.Seconds()*1000) / 1000,
+		Width:      info.Width,
+		Height:     info.Height,
+		FrameRate:  info.FrameRate,
+		VideoCodec: info.VideoCodec,
+		AudioCodec: info.AudioCodec,
+		ContentID:  info.ContentIdentifier,
+	}, metadata, takenAt
+}
+
+// AttachMotion сохраняет видеоролик Live Photo рядом со снимком
+func (s *PhotoService) AttachMotion(ctx context.Context, id int, upload UploadFile) (models.Photo, error) {
+	photo, err := s.GetPhoto(ctx, id)
+	if err != nil {
+		return models.Photo{}, err
+	}
+	if photo.Kind() == models.MediaTypeVideo {
+		return models.Photo{}, fmt.Errorf("%w: видеоролик прикрепляется только к снимку", ErrInvalidPhoto)
+	}
+	if s.maxUploadSize > 0 && upload.Size > s.maxUploadSize {
+		return models.Photo{}, ErrFileTooLarge
+	}
+
+	mimeType, err := storage.SniffMedia(upload.File)
+	if err != nil {
+		return models.Photo{}, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	if !storage.IsVideo(mimeType) {
+		return models.Photo{}, ErrNotAVideo
+	}
+
+	info, _, _ := probeVideo(upload)
+	if info == nil {
+		return models.Photo{}, ErrNotAVideo
+	}
+	if _, err := upload.File.Seek(0, io.SeekStart); err != nil {
+		return models.Photo{}, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+
+	// Ролик сохраняется в хранилище снимка, чтобы файлы фотографии не оказались в разных местах
+	provider := s.providerFor(photo)
+	storedPath, err := provider.Save(upload.File, motionKey(photo.Path, upload.Filename))
+	if err != nil {
+		return models.Photo{}, fmt.Errorf("ошибка сохранения файла: %w", err)
+	}
+
+	previous := photo.Motion
+	photo.MediaType = models.MediaTypeLivePhoto
+	photo.Motion = &models.MotionClip{Path: storedPath, MimeType: mimeType, Size: upload.Size, Video: *info}
+	if err := s.repo.UpdatePhoto(ctx, id, photo); err != nil {
+		if err := provider.Delete(storedPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Ошибка при удалении файла %s: %v", storedPath, err)
+		}
+		return models.Photo{}, err
+	}
+
+	if previous != nil && previous.Path != storedPath {
+		if err := provider.Delete(previous.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Ошибка при удалении файла %s фотографии ID=%d: %v", previous.Path, id, err)
+		}
+	}
+
+	log.Printf("К фотографии ID=%d прикреплен видеоролик Live Photo: %s", id, storedPath)
+	return photo, nil
+}
+
+// OpenMotion возвращает фотографию и reader видеоролика Live Photo. Закрыть reader должен вызывающий код.
+func (s *PhotoService) OpenMotion(ctx context.Context, id int) (models.Photo, io.ReadCloser, error) {
+	photo, err := s.GetPhoto(ctx, id)
+	if err != nil {
+		return models.Photo{}, nil, err
+	}
+	if photo.Motion == nil {
+		return models.Photo{}, nil, fmt.Errorf("видеоролик фотографии с ID=%d не найден", id)
+	}
+
+	reader, err := s.providerFor(photo).GetReader(photo.Motion.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return models.Photo{}, nil, fmt.Errorf("файл видеоролика фотографии с ID=%d не найден", id)
+		}
+		return models.Photo{}, nil, fmt.Errorf("ошибка открытия видеоролика: %w", err)
+	}
+	return photo, reader, nil
+}
+
+// motionKey формирует путь видеоролика Live Photo рядом со снимком
+func motionKey(original, filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext != ".mp4" {
+		ext = ".mov"
+	}
+	return strings.TrimSuffix(original, path.Ext(original)) + "_motion" + ext
+}
@@ -0,0 +1,150 @@
+// Package similarity ищет визуально похожие фотографии по перцептивным хешам
+package similarity
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// DefaultThreshold максимальное расстояние Хэмминга, при котором фотографии считаются похожими
+const DefaultThreshold = 8
+
+// MaxThreshold предельное допустимое расстояние для поиска
+const MaxThreshold = 20
+
+// Entry перцептивный хеш фотографии
+type Entry struct {
+	ID   int
+	Hash uint64
+}
+
+// Match найденная похожая фотография
+type Match struct {
+	ID       int `json:"id"`
+	Distance int `json:"distance"`
+}
+
+// FormatHash представляет хеш в виде шестнадцатеричной строки
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParseHash разбирает хеш из шестнадцатеричной строки
+func ParseHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// Distance возвращает расстояние Хэмминга между хешами
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Similar возвращает записи не дальше threshold бит от hash по возрастанию расстояния
+func Similar(entries []Entry, hash uint64, threshold, excludeID int) []Match {
+	matches := []Match{}
+	for _, e := range entries {
+		if e.ID == excludeID {
+			continue
+		}
+		if d := Distance(e.Hash, hash); d <= threshold {
+			matches = append(matches, Match{ID: e.ID, Distance: d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
+
+// Clusters группирует записи, связанные цепочкой пар с расстоянием не больше threshold
+func Clusters(entries []Entry, threshold int) [][]int {
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for _, mask := range bandMasks(threshold) {
+		buckets := make(map[uint64][]int)
+		for i, e := range entries {
+			key := e.Hash & mask
+			buckets[key] = append(buckets[key], i)
+		}
+
+		for _, bucket := range buckets {
+			for a := 0; a < len(bucket); a++ {
+				for b := a + 1; b < len(bucket); b++ {
+					i, j := bucket[a], bucket[b]
+					if find(i) == find(j) {
+						continue
+					}
+					if Distance(entries[i].Hash, entries[j].Hash) <= threshold {
+						parent[find(i)] = find(j)
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i, e := range entries {
+		root := find(i)
+		groups[root] = append(groups[root], e.ID)
+	}
+
+	clusters := [][]int{}
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Ints(group)
+		clusters = append(clusters, group)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0] < clusters[j][0]
+	})
+	return clusters
+}
+
+// BandKeys возвращает ключи полос хеша для внешнего индекса; похожие хеши имеют общий ключ
+func BandKeys(hash uint64, threshold int) []string {
+	masks := bandMasks(threshold)
+	keys := make([]string, len(masks))
+	for i, mask := range masks {
+		keys[i] = fmt.Sprintf("%d:%x", i, hash&mask)
+	}
+	return keys
+}
+
+// bandMasks делит 64 бита хеша на threshold+1 полос почти равной ширины
+func bandMasks(threshold int) []uint64 {
+	n := min(max(threshold+1, 1), 64)
+	masks := make([]uint64, n)
+	start := 0
+	for i := range masks {
+		width := 64 / n
+		if i < 64%n {
+			width++
+		}
+		for b := start; b < start+width; b++ {
+			masks[i] |= 1 << uint(b)
+		}
+		start += width
+	}
+	return masks
+}
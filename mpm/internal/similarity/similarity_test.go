@@ -0,0 +1,104 @@
+package similarity
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashFormat(t *testing.T) {
+	s := FormatHash(0xA)
+	assert.Equal(t, "000000000000000a", s)
+
+	hash, err := ParseHash(s)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0xA), hash)
+
+	_, err = ParseHash("zz")
+	assert.Error(t, err)
+}
+
+func TestSimilar(t *testing.T) {
+	entries := []Entry{
+		{ID: 1, Hash: 0b0000},
+		{ID: 2, Hash: 0b0011},
+		{ID: 3, Hash: 0b0001},
+		{ID: 4, Hash: 0xFFFF},
+	}
+
+	matches := Similar(entries, 0, 2, 1)
+	assert.Equal(t, []Match{{ID: 3, Distance: 1}, {ID: 2, Distance: 2}}, matches)
+	assert.Empty(t, Similar(entries, 0xFFFFFFFF00000000, 2, 0))
+}
+
+func TestClusters(t *testing.T) {
+	entries := []Entry{
+		{ID: 1, Hash: 0x0F0F0F0F0F0F0F0F},
+		{ID: 2, Hash: 0x0F0F0F0F0F0F0F0E}, // 1 бит от 1
+		{ID: 3, Hash: 0x0F0F0F0F0F0F0F0C}, // 1 бит от 2, 2 бита от 1
+		{ID: 4, Hash: 0xF0F0F0F0F0F0F0F0},
+		{ID: 5, Hash: 0xF0F0F0F0F0F0F0F1},
+		{ID: 6, Hash: 0x123456789ABCDEF0},
+	}
+
+	clusters := Clusters(entries, 1)
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5}}, clusters, "группы объединяются по цепочке похожих пар")
+
+	assert.Empty(t, Clusters(entries, 0))
+}
+
+func TestClusters_MatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var entries []Entry
+	for i := 0; i < 200; i++ {
+		base := rng.Uint64()
+		entries = append(entries, Entry{ID: len(entries) + 1, Hash: base})
+		// Добавляем копии с несколькими измененными битами
+		for j := 0; j < rng.Intn(3); j++ {
+			h := base
+			for k := 0; k < rng.Intn(9); k++ {
+				h ^= 1 << uint(rng.Intn(64))
+			}
+			entries = append(entries, Entry{ID: len(entries) + 1, Hash: h})
+		}
+	}
+
+	for _, threshold := range []int{0, 3, 8} {
+		// Для каждой пары на расстоянии не больше порога обе записи должны оказаться в одной группе
+		group := map[int]int{}
+		for i, cluster := range Clusters(entries, threshold) {
+			for _, id := range cluster {
+				group[id] = i + 1
+			}
+		}
+		for i := range entries {
+			for j := i + 1; j < len(entries); j++ {
+				if Distance(entries[i].Hash, entries[j].Hash) <= threshold {
+					require.NotZero(t, group[entries[i].ID])
+					require.Equal(t, group[entries[i].ID], group[entries[j].ID], "порог %d", threshold)
+				}
+			}
+		}
+	}
+}
+
+func TestBandKeys(t *testing.T) {
+	a := uint64(0x0123456789ABCDEF)
+	b := a ^ (1 << 3) ^ (1 << 40) ^ (1 << 63)
+
+	shared := 0
+	keysB := map[string]bool{}
+	for _, k := range BandKeys(b, 3) {
+		keysB[k] = true
+	}
+	for _, k := range BandKeys(a, 3) {
+		if keysB[k] {
+			shared++
+		}
+	}
+
+	assert.Len(t, BandKeys(a, 3), 4)
+	assert.GreaterOrEqual(t, shared, 1, "похожие хеши должны иметь общую полосу")
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"bytes"
+	"mime/multipart"
+)
+
+// bytesFile реализует multipart.File поверх данных в памяти
+type bytesFile struct {
+	*bytes.Reader
+}
+
+func (bytesFile) Close() error {
+	return nil
+}
+
+// NewBytesFile позволяет сохранить через Provider данные, сформированные в памяти
+func NewBytesFile(data []byte) multipart.File {
+	return bytesFile{bytes.NewReader(data)}
+}
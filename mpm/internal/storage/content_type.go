@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// sniffLen количество байт, достаточное для определения типа файла
+const sniffLen = 512
+
+// DetectImageType определяет MIME-тип изображения по сигнатуре, для остальных данных возвращает ""
+func DetectImageType(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(header, []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}):
+		return "image/png"
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return "image/gif"
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")):
+		return "image/webp"
+	case bytes.HasPrefix(header, []byte{'I', 'I', 0x2A, 0x00}), bytes.HasPrefix(header, []byte{'M', 'M', 0x00, 0x2A}):
+		return "image/tiff"
+	case bytes.HasPrefix(header, []byte("BM")) && len(header) >= 14:
+		return "image/bmp"
+	// RAW-форматы камер с собственными сигнатурами (CR2, NEF, DNG и ARW определяются как TIFF)
+	case bytes.HasPrefix(header, []byte("FUJIFILMCCD-RAW")):
+		return "image/x-fuji-raf"
+	case bytes.HasPrefix(header, []byte("IIRO")), bytes.HasPrefix(header, []byte("IIRS")), bytes.HasPrefix(header, []byte("MMOR")):
+		return "image/x-olympus-orf"
+	case bytes.HasPrefix(header, []byte{'I', 'I', 'U', 0x00}):
+		return "image/x-panasonic-rw2"
+	}
+
+	// HEIC/HEIF/AVIF хранятся в ISO BMFF контейнере: size(4) + "ftyp" + major brand(4)
+	if len(header) >= 12 && bytes.Equal(header[4:8], []byte("ftyp")) {
+		switch string(header[8:12]) {
+		case "heic", "heix", "hevc", "heim", "heis":
+			return "image/heic"
+		case "mif1", "msf1":
+			return "image/heif"
+		case "avif", "avis":
+			return "image/avif"
+		case "crx ":
+			return "image/x-canon-cr3"
+		}
+	}
+
+	return ""
+}
+
+// DetectVideoType определяет MIME-тип видео MP4 или MOV по сигнатуре
+func DetectVideoType(header []byte) string {
+	if len(header) < 12 {
+		return ""
+	}
+
+	switch string(header[4:8]) {
+	case "ftyp":
+		switch string(header[8:12]) {
+		case "qt  ":
+			return "video/quicktime"
+		case "isom", "iso2", "iso4", "iso5", "iso6", "mp41", "mp42", "avc1", "M4V ", "M4VH", "M4VP", "dash", "MSNV", "XAVC":
+			return "video/mp4"
+		}
+	// Старые файлы QuickTime начинаются сразу с атомов без ftyp
+	case "moov", "mdat", "wide", "pnot":
+		return "video/quicktime"
+	}
+	return ""
+}
+
+// IsVideo проверяет, относится ли MIME-тип к видео
+func IsVideo(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "video/")
+}
+
+// SniffMedia определяет тип изображения или видео и возвращает указатель чтения в начало файла
+func SniffMedia(file io.ReadSeeker) (string, error) {
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	if mimeType := DetectImageType(header[:n]); mimeType != "" {
+		return mimeType, nil
+	}
+	return DetectVideoType(header[:n]), nil
+}
@@ -0,0 +1,254 @@
+package dropbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAppKey       = "app-key"
+	testAppSecret    = "app-secret"
+	testRefreshToken = "refresh-token"
+)
+
+// fakeDropbox сервер в памяти, повторяющий методы Dropbox API v2, которые использует Storage:
+// выдачу токена, загрузку файлов и сессии загрузки, скачивание, удаление и временные ссылки.
+type fakeDropbox struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu          sync.Mutex
+	files       map[string][]byte
+	sessions    map[string][]byte
+	links       map[string]string
+	accessToken string
+	tokens      int      // Количество выданных токенов доступа
+	requests    []string // Вызванные методы API, кроме выдачи токена
+	rateLimit   int      // Сколько следующих запросов получат ответ 429
+}
+
+func newFakeDropbox(t *testing.T) *fakeDropbox {
+	t.Helper()
+
+	f := &fakeDropbox{
+		t:        t,
+		files:    map[string][]byte{},
+		sessions: map[string][]byte{},
+		links:    map[string]string{},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeDropbox) config() Config {
+	return Config{
+		AppKey:       testAppKey,
+		AppSecret:    testAppSecret,
+		RefreshToken: testRefreshToken,
+		Root:         "/mpm",
+		APIURL:       f.server.URL,
+		ContentURL:   f.server.URL,
+	}
+}
+
+func (f *fakeDropbox) file(path string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.files[path]
+	return data, ok
+}
+
+// revokeAccessToken делает выданный токен доступа недействительным
+func (f *fakeDropbox) revokeAccessToken() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accessToken = "revoked"
+}
+
+func (f *fakeDropbox) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func (f *fakeDropbox) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/link/") {
+		path, ok := f.links[strings.TrimPrefix(r.URL.Path, "/link/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(f.files[path])
+		return
+	}
+	if r.URL.Path == "/oauth2/token" {
+		f.issueToken(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+f.accessToken {
+		writeFakeError(w, http.StatusUnauthorized, "expired_access_token/")
+		return
+	}
+	if f.rateLimit > 0 {
+		f.rateLimit--
+		w.Header().Set("Retry-After", "0")
+		writeFakeError(w, http.StatusTooManyRequests, "too_many_requests/")
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var arg struct {
+		Path   string `json:"path"`
+		Cursor struct {
+			SessionID string `json:"session_id"`
+			Offset    int    `json:"offset"`
+		} `json:"cursor"`
+		Commit struct {
+			Path string `json:"path"`
+			Mode string `json:"mode"`
+		} `json:"commit"`
+		Mode string `json:"mode"`
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, "/2/")
+	switch method {
+	case "files/delete_v2", "files/get_temporary_link":
+		if r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "неверный Content-Type", http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(body, &arg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		header := r.Header.Get("Dropbox-API-Arg")
+		for _, c := range []byte(header) {
+			if c >= 0x80 {
+				http.Error(w, "Dropbox-API-Arg содержит символы вне ASCII", http.StatusBadRequest)
+				return
+			}
+		}
+		if err := json.Unmarshal([]byte(header), &arg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	f.requests = append(f.requests, method)
+
+	switch method {
+	case "files/upload":
+		if arg.Mode != "overwrite" {
+			http.Error(w, "ожидался режим overwrite", http.StatusBadRequest)
+			return
+		}
+		f.files[arg.Path] = body
+		writeFakeJSON(w, map[string]any{"path_display": arg.Path, "size": len(body)})
+
+	case "files/upload_session/start":
+		id := fmt.Sprintf("session-%d", len(f.sessions)+1)
+		f.sessions[id] = body
+		writeFakeJSON(w, map[string]string{"session_id": id})
+
+	case "files/upload_session/append_v2", "files/upload_session/finish":
+		data, ok := f.sessions[arg.Cursor.SessionID]
+		if !ok {
+			writeFakeError(w, http.StatusConflict, "lookup_failed/not_found/")
+			return
+		}
+		if arg.Cursor.Offset != len(data) {
+			writeFakeError(w, http.StatusConflict, "lookup_failed/incorrect_offset/")
+			return
+		}
+		data = append(data, body...)
+		if method == "files/upload_session/append_v2" {
+			f.sessions[arg.Cursor.SessionID] = data
+			writeFakeJSON(w, nil)
+			return
+		}
+		delete(f.sessions, arg.Cursor.SessionID)
+		f.files[arg.Commit.Path] = data
+		writeFakeJSON(w, map[string]any{"path_display": arg.Commit.Path, "size": len(data)})
+
+	case "files/download":
+		data, ok := f.files[arg.Path]
+		if !ok {
+			writeFakeError(w, http.StatusConflict, "path/not_found/")
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Dropbox-API-Result", fmt.Sprintf(`{"size":%d}`, len(data)))
+		http.ServeContent(w, r, arg.Path, time.Time{}, bytes.NewReader(data))
+
+	case "files/delete_v2":
+		if _, ok := f.files[arg.Path]; !ok {
+			writeFakeError(w, http.StatusConflict, "path_lookup/not_found/")
+			return
+		}
+		delete(f.files, arg.Path)
+		writeFakeJSON(w, map[string]any{"metadata": map[string]string{"path_display": arg.Path}})
+
+	case "files/get_temporary_link":
+		if _, ok := f.files[arg.Path]; !ok {
+			writeFakeError(w, http.StatusConflict, "path/not_found/")
+			return
+		}
+		id := fmt.Sprintf("%d", len(f.links)+1)
+		f.links[id] = arg.Path
+		writeFakeJSON(w, map[string]string{"link": f.server.URL + "/link/" + id})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeDropbox) issueToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("grant_type") != "refresh_token" ||
+		r.PostForm.Get("refresh_token") != testRefreshToken ||
+		r.PostForm.Get("client_id") != testAppKey ||
+		r.PostForm.Get("client_secret") != testAppSecret {
+		w.WriteHeader(http.StatusBadRequest)
+		writeFakeJSON(w, map[string]string{"error": "invalid_grant", "error_description": "refresh token is invalid"})
+		return
+	}
+
+	f.tokens++
+	f.accessToken = fmt.Sprintf("access-%d", f.tokens)
+	writeFakeJSON(w, map[string]any{
+		"access_token": f.accessToken,
+		"token_type":   "bearer",
+		"expires_in":   14400,
+	})
+}
+
+func writeFakeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeFakeError(w http.ResponseWriter, status int, summary string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error_summary": summary, "error": map[string]string{}})
+}
@@ -0,0 +1,484 @@
+// Package dropbox реализует storage.Provider поверх Dropbox API v2. Доступ выполняется
+// от имени приложения по токену обновления, крупные файлы загружаются сессиями.
+package dropbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"mpm/internal/storage"
+)
+
+const (
+	// StorageType значение models.Photo.StorageType для файлов в Dropbox
+	StorageType = "dropbox"
+
+	// DefaultAPIURL адрес RPC-методов Dropbox API
+	DefaultAPIURL = "https://api.dropboxapi.com"
+	// DefaultContentURL адрес методов загрузки и скачивания файлов
+	DefaultContentURL = "https://content.dropboxapi.com"
+
+	// DefaultChunkSize размер части загрузки сессией; файлы больше загружаются частями
+	DefaultChunkSize = 8 << 20
+	// maxChunkSize максимальный размер одного запроса загрузки
+	maxChunkSize = 150 << 20
+	// temporaryLinkLifetime срок действия временной ссылки Dropbox
+	temporaryLinkLifetime = 4 * time.Hour
+	// maxRetries количество повторов запроса при ограничении частоты
+	maxRetries = 3
+)
+
+// Config описывает подключение к Dropbox
+type Config struct {
+	AppKey       string // Ключ приложения
+	AppSecret    string // Секрет приложения
+	RefreshToken string // Токен обновления, полученный при авторизации приложения
+	Root         string // Каталог внутри Dropbox, в котором хранятся файлы
+	ChunkSize    int64  // Размер части загрузки сессией
+
+	APIURL     string // По умолчанию DefaultAPIURL; токены запрашиваются по APIURL + "/oauth2/token"
+	ContentURL string // По умолчанию DefaultContentURL
+
+	HTTPClient *http.Client // Клиент HTTP; по умолчанию storage.NewHTTPClient
+}
+
+// Error описывает ошибку, возвращенную Dropbox
+type Error struct {
+	StatusCode int
+	Summary    string `json:"error_summary"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ошибка Dropbox: %s, статус %d", e.Summary, e.StatusCode)
+}
+
+// notFound сообщает, что путь не существует
+func (e *Error) notFound() bool {
+	return e.StatusCode == http.StatusConflict && strings.Contains(e.Summary, "not_found")
+}
+
+// Storage реализует storage.Provider для Dropbox
+type Storage struct {
+	cfg    Config
+	root   string
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// New создает хранилище Dropbox по конфигурации
+func New(cfg Config) (*Storage, error) {
+	if cfg.AppKey == "" || cfg.AppSecret == "" || cfg.RefreshToken == "" {
+		return nil, errors.New("не заданы ключ, секрет или токен обновления Dropbox")
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+	cfg.ChunkSize = min(cfg.ChunkSize, maxChunkSize)
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultAPIURL
+	}
+	if cfg.ContentURL == "" {
+		cfg.ContentURL = DefaultContentURL
+	}
+	cfg.APIURL = strings.TrimSuffix(cfg.APIURL, "/")
+	cfg.ContentURL = strings.TrimSuffix(cfg.ContentURL, "/")
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = storage.NewHTTPClient()
+	}
+
+	root := "/" + strings.Trim(cfg.Root, "/")
+	if root == "/" {
+		root = ""
+	}
+
+	return &Storage{
+		cfg:    cfg,
+		root:   root,
+		client: cfg.HTTPClient,
+		now:    time.Now,
+	}, nil
+}
+
+// Save загружает файл в Dropbox, большие файлы - сессией загрузки
+func (s *Storage) Save(file multipart.File, filename string) (string, error) {
+	key := strings.TrimPrefix(path.Clean("/"+filename), "/")
+	if key == "" {
+		return "", errors.New("пустое имя файла")
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+	commit := commitInfo{Path: s.fullPath(key), Mode: "overwrite", Mute: true}
+	if size <= s.cfg.ChunkSize {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", err
+		}
+		err = s.content(ctx, "/2/files/upload", commit, data, nil)
+		if err != nil {
+			return "", fmt.Errorf("ошибка загрузки файла %s в Dropbox: %w", key, err)
+		}
+		return key, nil
+	}
+
+	if err := s.uploadSession(ctx, file, commit); err != nil {
+		return "", fmt.Errorf("ошибка загрузки файла %s в Dropbox: %w", key, err)
+	}
+	return key, nil
+}
+
+type commitInfo struct {
+	Path       string `json:"path"`
+	Mode       string `json:"mode"`
+	Autorename bool   `json:"autorename"`
+	Mute       bool   `json:"mute"`
+}
+
+type uploadCursor struct {
+	SessionID string `json:"session_id"`
+	Offset    int64  `json:"offset"`
+}
+
+// uploadSession загружает файл частями в сессии загрузки
+func (s *Storage) uploadSession(ctx context.Context, file io.Reader, commit commitInfo) error {
+	buf := make([]byte, s.cfg.ChunkSize)
+	readChunk := func() ([]byte, error) {
+		n, err := io.ReadFull(file, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	chunk, err := readChunk()
+	if err != nil {
+		return err
+	}
+	var started struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := s.content(ctx, "/2/files/upload_session/start", map[string]bool{"close": false}, chunk, &started); err != nil {
+		return fmt.Errorf("ошибка начала сессии загрузки: %w", err)
+	}
+	cursor := uploadCursor{SessionID: started.SessionID, Offset: int64(len(chunk))}
+
+	for {
+		chunk, err := readChunk()
+		if err != nil {
+			return err
+		}
+		if len(chunk) < len(buf) {
+			// Последняя часть передается вместе с фиксацией
+			arg := struct {
+				Cursor uploadCursor `json:"cursor"`
+				Commit commitInfo   `json:"commit"`
+			}{cursor, commit}
+			if err := s.content(ctx, "/2/files/upload_session/finish", arg, chunk, nil); err != nil {
+				return fmt.Errorf("ошибка завершения сессии загрузки: %w", err)
+			}
+			return nil
+		}
+
+		arg := struct {
+			Cursor uploadCursor `json:"cursor"`
+			Close  bool         `json:"close"`
+		}{Cursor: cursor}
+		if err := s.content(ctx, "/2/files/upload_session/append_v2", arg, chunk, nil); err != nil {
+			return fmt.Errorf("ошибка загрузки части со смещением %d: %w", cursor.Offset, err)
+		}
+		cursor.Offset += int64(len(chunk))
+	}
+}
+
+// Get скачивает файл целиком
+func (s *Storage) Get(path string) ([]byte, error) {
+	reader, err := s.GetReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// GetReader открывает файл для чтения с поддержкой Seek
+func (s *Storage) GetReader(path string) (io.ReadCloser, error) {
+	return storage.OpenRange(s, path)
+}
+
+// GetRange возвращает поток из length байт файла, начиная с offset, и размер файла
+func (s *Storage) GetRange(path string, offset, length int64) (io.ReadCloser, int64, error) {
+	header, err := apiArgHeader(map[string]string{"path": s.fullPath(path)})
+	if err != nil {
+		return nil, 0, err
+	}
+	if value := storage.RangeHeader(offset, length); value != "" {
+		header.Set("Range", value)
+	}
+	// Тело ответа читается потоком, поэтому время запроса не ограничивается
+	resp, err := s.do(context.Background(), http.MethodPost, s.cfg.ContentURL+"/2/files/download", header, nil)
+	if err != nil {
+		return nil, 0, s.mapNotFound(path, err)
+	}
+
+	body, size, err := storage.RangeResponse(resp, offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Метаданные файла точнее Content-Length, который при сжатом ответе неизвестен
+	var result struct {
+		Size int64 `json:"size"`
+	}
+	if err := json.Unmarshal([]byte(resp.Header.Get("Dropbox-API-Result")), &result); err == nil && result.Size > 0 {
+		size = result.Size
+	}
+	return body, size, nil
+}
+
+// Delete удаляет файл
+func (s *Storage) Delete(path string) error {
+	err := s.rpc(context.Background(), "/2/files/delete_v2", map[string]string{"path": s.fullPath(path)}, nil)
+	return s.mapNotFound(path, err)
+}
+
+// GetPublicURL возвращает временную ссылку Dropbox
+func (s *Storage) GetPublicURL(path string) string {
+	link, _, err := s.GetSignedURL(path, "", temporaryLinkLifetime)
+	if err != nil {
+		log.Printf("Ошибка получения временной ссылки Dropbox на %s: %v", path, err)
+		return ""
+	}
+	return link
+}
+
+// GetSignedURL возвращает временную ссылку на файл
+func (s *Storage) GetSignedURL(path, scope string, ttl time.Duration) (string, time.Time, error) {
+	var result struct {
+		Link string `json:"link"`
+	}
+	err := s.rpc(context.Background(), "/2/files/get_temporary_link", map[string]string{"path": s.fullPath(path)}, &result)
+	if err != nil {
+		return "", time.Time{}, s.mapNotFound(path, err)
+	}
+	expires := s.now().Add(min(ttl, temporaryLinkLifetime)).Truncate(time.Second)
+	return result.Link, expires, nil
+}
+
+// fullPath возвращает путь в Dropbox с учетом корневого каталога
+func (s *Storage) fullPath(key string) string {
+	return s.root + "/" + strings.TrimPrefix(key, "/")
+}
+
+func (s *Storage) mapNotFound(path string, err error) error {
+	var dbxErr *Error
+	if errors.As(err, &dbxErr) && dbxErr.notFound() {
+		return fmt.Errorf("файл %s не найден: %w", path, fs.ErrNotExist)
+	}
+	return err
+}
+
+// rpc вызывает RPC-метод: аргументы и результат передаются в теле запроса в формате JSON
+func (s *Storage) rpc(ctx context.Context, endpoint string, arg, out any) error {
+	body, err := json.Marshal(arg)
+	if err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodPost, s.cfg.APIURL+endpoint, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResult(resp.Body, out)
+}
+
+// content вызывает метод загрузки: аргументы передаются в заголовке Dropbox-API-Arg, данные - в теле
+func (s *Storage) content(ctx context.Context, endpoint string, arg any, data []byte, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	resp, err := s.send(ctx, s.cfg.ContentURL+endpoint, arg, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResult(resp.Body, out)
+}
+
+func (s *Storage) send(ctx context.Context, endpoint string, arg any, data []byte) (*http.Response, error) {
+	header, err := apiArgHeader(arg)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	return s.do(ctx, http.MethodPost, endpoint, header, data)
+}
+
+// do выполняет авторизованный запрос с обновлением токена и повтором после Retry-After
+func (s *Storage) do(ctx context.Context, method, endpoint string, header http.Header, body []byte) (*http.Response, error) {
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		token, err := s.token(ctx, false)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		dbxErr := parseError(resp)
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && !refreshed:
+			refreshed = true
+			if _, err := s.token(ctx, true); err != nil {
+				return nil, err
+			}
+			continue
+		case resp.StatusCode == http.StatusTooManyRequests && attempt < maxRetries:
+			seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(min(max(seconds, 0), 30)) * time.Second):
+			}
+			continue
+		}
+		return nil, dbxErr
+	}
+}
+
+// token возвращает токен доступа, получая новый по токену обновления, если прежний истек
+func (s *Storage) token(ctx context.Context, force bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !force && s.accessToken != "" && s.now().Add(time.Minute).Before(s.expiry) {
+		return s.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.cfg.RefreshToken},
+		"client_id":     {s.cfg.AppKey},
+		"client_secret": {s.cfg.AppSecret},
+	}
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.APIURL+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения токена Dropbox: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("некорректный ответ сервера авторизации Dropbox: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return "", fmt.Errorf("ошибка авторизации Dropbox: %s %s", result.Error, result.ErrorDescription)
+	}
+
+	s.accessToken = result.AccessToken
+	s.expiry = s.now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return s.accessToken, nil
+}
+
+func decodeResult(body io.Reader, out any) error {
+	if out == nil {
+		_, _ = io.Copy(io.Discard, body)
+		return nil
+	}
+	if err := json.NewDecoder(body).Decode(out); err != nil {
+		return fmt.Errorf("некорректный ответ Dropbox: %w", err)
+	}
+	return nil
+}
+
+func parseError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	dbxErr := &Error{StatusCode: resp.StatusCode}
+	if json.Unmarshal(data, dbxErr) != nil || dbxErr.Summary == "" {
+		dbxErr.Summary = strings.TrimSpace(string(data))
+	}
+	return dbxErr
+}
+
+// apiArgHeader кодирует аргументы в ASCII-заголовок Dropbox-API-Arg
+func apiArgHeader(arg any) (http.Header, error) {
+	data, err := json.Marshal(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	for _, r := range string(data) {
+		if r < 0x80 {
+			b.WriteRune(r)
+			continue
+		}
+		for _, unit := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&b, `\u%04x`, unit)
+		}
+	}
+	header := http.Header{}
+	header.Set("Dropbox-API-Arg", b.String())
+	return header, nil
+}
@@ -0,0 +1,181 @@
+package dropbox
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mpm/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ storage.Provider = (*Storage)(nil)
+var _ storage.SignedURLProvider = (*Storage)(nil)
+var _ storage.RangeReader = (*Storage)(nil)
+
+var testPNGHeader = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n', 0, 0, 0, 0}
+
+func TestStorage(t *testing.T) {
+	fake := newFakeDropbox(t)
+	store, err := New(fake.config())
+	require.NoError(t, err)
+
+	t.Run("Сохранение и чтение", func(t *testing.T) {
+		path, err := store.Save(storage.NewBytesFile(testPNGHeader), "albums/1/фото 1.png")
+		require.NoError(t, err)
+		assert.Equal(t, "albums/1/фото 1.png", path)
+
+		data, ok := fake.file("/mpm/albums/1/фото 1.png")
+		require.True(t, ok, "имя с кириллицей передается в Dropbox-API-Arg экранированным")
+		assert.Equal(t, testPNGHeader, data)
+
+		data, err = store.Get(path)
+		require.NoError(t, err)
+		assert.Equal(t, testPNGHeader, data)
+	})
+
+	t.Run("Частичное чтение по Range", func(t *testing.T) {
+		data := make([]byte, 1000)
+		_, _ = rand.Read(data)
+		_, err := store.Save(storage.NewBytesFile(data), "albums/1/video.mp4")
+		require.NoError(t, err)
+
+		reader, err := store.GetReader("albums/1/video.mp4")
+		require.NoError(t, err)
+		defer reader.Close()
+		rs, ok := reader.(io.ReadSeeker)
+		require.True(t, ok)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/photos/1/content", nil)
+		req.Header.Set("Range", "bytes=600-")
+		w := httptest.NewRecorder()
+		http.ServeContent(w, req, "video.mp4", time.Time{}, rs)
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "bytes 600-999/1000", w.Header().Get("Content-Range"))
+		assert.Equal(t, data[600:], w.Body.Bytes())
+	})
+
+	t.Run("Временная ссылка", func(t *testing.T) {
+		link := store.GetPublicURL("albums/1/фото 1.png")
+		require.NotEmpty(t, link)
+
+		resp, err := http.Get(link)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, testPNGHeader, body)
+
+		now := time.Now()
+		_, expires, err := store.GetSignedURL("albums/1/фото 1.png", "", 24*time.Hour)
+		require.NoError(t, err)
+		assert.WithinDuration(t, now.Add(4*time.Hour), expires, 2*time.Second, "ссылка Dropbox действует не дольше четырех часов")
+
+		_, expires, err = store.GetSignedURL("albums/1/фото 1.png", "", time.Minute)
+		require.NoError(t, err)
+		assert.WithinDuration(t, now.Add(time.Minute), expires, 2*time.Second)
+
+		assert.Empty(t, store.GetPublicURL("albums/1/нет.png"))
+	})
+
+	t.Run("Отсутствующий файл", func(t *testing.T) {
+		_, err := store.GetReader("albums/1/нет.png")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, _, err = store.GetSignedURL("albums/1/нет.png", "", time.Minute)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		assert.ErrorIs(t, store.Delete("albums/1/нет.png"), fs.ErrNotExist)
+	})
+
+	t.Run("Удаление", func(t *testing.T) {
+		require.NoError(t, store.Delete("albums/1/фото 1.png"))
+		_, ok := fake.file("/mpm/albums/1/фото 1.png")
+		assert.False(t, ok)
+	})
+
+	t.Run("Обновление токена доступа", func(t *testing.T) {
+		tokens := fake.tokens
+		fake.revokeAccessToken()
+
+		_, err := store.Save(storage.NewBytesFile(testPNGHeader), "albums/2/a.png")
+		require.NoError(t, err)
+		assert.Equal(t, tokens+1, fake.tokens, "токен получен заново по токену обновления")
+
+		_, err = store.Get("albums/2/a.png")
+		require.NoError(t, err)
+		assert.Equal(t, tokens+1, fake.tokens, "новый токен используется повторно")
+	})
+
+	t.Run("Ограничение частоты запросов", func(t *testing.T) {
+		fake.rateLimit = 2
+		data, err := store.Get("albums/2/a.png")
+		require.NoError(t, err)
+		assert.Equal(t, testPNGHeader, data)
+	})
+}
+
+func TestStorage_UploadSession(t *testing.T) {
+	fake := newFakeDropbox(t)
+	cfg := fake.config()
+	cfg.ChunkSize = 1 << 10
+	store, err := New(cfg)
+	require.NoError(t, err)
+
+	for _, size := range []int{2<<10 + 100, 3 << 10} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+
+		before := len(fake.calls())
+		path, err := store.Save(storage.NewBytesFile(data), "albums/3/video.mov")
+		require.NoError(t, err)
+
+		stored, ok := fake.file("/mpm/albums/3/video.mov")
+		require.True(t, ok)
+		assert.True(t, bytes.Equal(data, stored), "файл размером %d собран из частей без потерь", size)
+
+		calls := fake.calls()[before:]
+		assert.Equal(t, "files/upload_session/start", calls[0])
+		assert.Equal(t, "files/upload_session/finish", calls[len(calls)-1])
+		assert.Contains(t, calls, "files/upload_session/append_v2")
+
+		got, err := store.Get(path)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(data, got))
+	}
+
+	t.Run("Небольшой файл загружается одним запросом", func(t *testing.T) {
+		before := len(fake.calls())
+		_, err := store.Save(storage.NewBytesFile(testPNGHeader), "albums/3/a.png")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"files/upload"}, fake.calls()[before:])
+	})
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(Config{AppKey: "key", AppSecret: "secret"})
+	assert.Error(t, err, "без токена обновления")
+
+	fake := newFakeDropbox(t)
+	cfg := fake.config()
+	cfg.RefreshToken = "wrong"
+	store, err := New(cfg)
+	require.NoError(t, err)
+	_, err = store.Get("a.png")
+	assert.ErrorContains(t, err, "invalid_grant")
+
+	cfg.HTTPClient = nil
+	store, err = New(cfg)
+	require.NoError(t, err)
+	assert.Zero(t, store.client.Timeout, "общий таймаут клиента обрывал бы загрузку больших файлов")
+}
+
+func TestAPIArgHeader(t *testing.T) {
+	header, err := apiArgHeader(map[string]string{"path": "/фото 😀.png"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"path":"/\u0444\u043e\u0442\u043e \ud83d\ude00.png"}`, header.Get("Dropbox-API-Arg"))
+}
@@ -0,0 +1,616 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"strings"
+	"time"
+)
+
+const (
+	// KeySuffix добавляется к пути файла, чтобы получить путь его зашифрованного ключа
+	KeySuffix = ".key"
+	// pendingKeySuffix новая версия ключа, записанная перед заменой основной при ротации
+	pendingKeySuffix = ".key.new"
+
+	// DefaultSegmentSize размер открытого текста в одном сегменте AES-GCM
+	DefaultSegmentSize = 64 << 10
+	// maxSegmentSize ограничивает размер сегмента, прочитанный из заголовка файла
+	maxSegmentSize = 16 << 20
+
+	encryptedMagic   = "MPMENC"
+	encryptedVersion = 1
+	noncePrefixSize  = 7
+	// headerSize магическая строка, версия, размер сегмента и префикс nonce
+	headerSize = len(encryptedMagic) + 1 + 4 + noncePrefixSize
+	tagSize    = 16
+)
+
+// ErrCorruptedFile возвращается, если зашифрованный файл изменен, обрезан или не подходит к ключу
+var ErrCorruptedFile = errors.New("зашифрованный файл поврежден")
+
+// ErrPlaintextFile возвращается при чтении незашифрованного файла, если чтение таких файлов не разрешено
+var ErrPlaintextFile = errors.New("файл не зашифрован")
+
+// KeyRewrapper реализуется хранилищами, которые шифруют ключи файлов мастер-ключом
+type KeyRewrapper interface {
+	RewrapKey(path string) (bool, error)
+}
+
+// wrappedKey содержимое файла ключа, который хранится рядом с зашифрованным файлом
+type wrappedKey struct {
+	Version    int    `json:"version"`
+	KeyID      string `json:"key_id"`      // Идентификатор мастер-ключа
+	WrappedKey []byte `json:"wrapped_key"` // Ключ файла, зашифрованный мастер-ключом
+}
+
+// EncryptedStorage шифрует файлы сегментами AES-256-GCM перед сохранением во вложенное хранилище
+type EncryptedStorage struct {
+	inner          Provider
+	keys           *KeyRing
+	segmentSize    int
+	delegateURLs   bool
+	allowPlaintext bool
+}
+
+// NewEncryptedStorage создает шифрующую обертку над хранилищем
+func NewEncryptedStorage(inner Provider, keys *KeyRing) *EncryptedStorage {
+	return &EncryptedStorage{
+		inner:       inner,
+		keys:        keys,
+		segmentSize: DefaultSegmentSize,
+	}
+}
+
+// DelegateURLs разрешает выдавать ссылки вложенного хранилища
+func (e *EncryptedStorage) DelegateURLs() {
+	e.delegateURLs = true
+}
+
+// AllowPlaintext разрешает читать без расшифровки файлы без ключа
+func (e *EncryptedStorage) AllowPlaintext() {
+	e.allowPlaintext = true
+}
+
+// Save шифрует файл и сохраняет его вместе с зашифрованным ключом
+func (e *EncryptedStorage) Save(file multipart.File, filename string) (string, error) {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	dek := make([]byte, MasterKeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	header := make([]byte, headerSize)
+	copy(header, encryptedMagic)
+	header[len(encryptedMagic)] = encryptedVersion
+	binary.BigEndian.PutUint32(header[len(encryptedMagic)+1:], uint32(e.segmentSize))
+	if _, err := rand.Read(header[headerSize-noncePrefixSize:]); err != nil {
+		return "", err
+	}
+
+	aead, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	encrypted := newEncryptingFile(file, aead, header, int64(e.segmentSize), size)
+
+	stored, err := e.inner.Save(encrypted, filename)
+	if err != nil {
+		return "", err
+	}
+	if stored != filename {
+		_ = e.inner.Delete(stored)
+		return "", fmt.Errorf("хранилище сохранило файл %s под другим именем, шифрование не поддерживается", filename)
+	}
+
+	if err := e.saveKey(stored, KeySuffix, dek); err != nil {
+		_ = e.inner.Delete(stored)
+		return "", fmt.Errorf("ошибка сохранения ключа файла %s: %w", stored, err)
+	}
+	return stored, nil
+}
+
+// Get читает и расшифровывает файл целиком
+func (e *EncryptedStorage) Get(path string) ([]byte, error) {
+	reader, err := e.GetReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// GetReader открывает файл и расшифровывает его по мере чтения
+func (e *EncryptedStorage) GetReader(path string) (io.ReadCloser, error) {
+	dek, _, keyErr := e.loadKey(path)
+	if keyErr != nil && !errors.Is(keyErr, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения ключа файла %s: %w", path, keyErr)
+	}
+
+	reader, err := e.inner.GetReader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(reader, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		reader.Close()
+		return nil, err
+	}
+	if n < headerSize || string(header[:len(encryptedMagic)]) != encryptedMagic {
+		// Ключ есть только у зашифрованных файлов, поэтому без заголовка файл подменен
+		if keyErr == nil {
+			reader.Close()
+			return nil, fmt.Errorf("%w: у файла %s есть ключ, но нет заголовка шифрования", ErrCorruptedFile, path)
+		}
+		if !e.allowPlaintext {
+			reader.Close()
+			return nil, fmt.Errorf("%w: %s", ErrPlaintextFile, path)
+		}
+		// Файл сохранен до включения шифрования
+		if rs, ok := reader.(io.ReadSeeker); ok {
+			if _, err := rs.Seek(0, io.SeekStart); err != nil {
+				reader.Close()
+				return nil, err
+			}
+			return reader, nil
+		}
+		return readCloser{io.MultiReader(bytes.NewReader(header[:n]), reader), reader}, nil
+	}
+	segSize := binary.BigEndian.Uint32(header[len(encryptedMagic)+1:])
+	if header[len(encryptedMagic)] != encryptedVersion || segSize == 0 || segSize > maxSegmentSize {
+		reader.Close()
+		return nil, fmt.Errorf("неподдерживаемый формат шифрования файла %s", path)
+	}
+	if keyErr != nil {
+		reader.Close()
+		return nil, fmt.Errorf("ошибка чтения ключа файла %s: %w", path, keyErr)
+	}
+
+	aead, err := newGCM(dek)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	decrypting := newDecryptingReader(reader, aead, header)
+	if rs, ok := reader.(io.ReadSeeker); ok {
+		return &seekingDecryptingReader{decryptingReader: decrypting, src: rs, size: -1}, nil
+	}
+	return decrypting, nil
+}
+
+// Delete удаляет файл и его ключ
+func (e *EncryptedStorage) Delete(path string) error {
+	err := e.inner.Delete(path)
+	for _, key := range []string{path + KeySuffix, path + pendingKeySuffix} {
+		if keyErr := e.inner.Delete(key); keyErr != nil && !errors.Is(keyErr, fs.ErrNotExist) && err == nil {
+			err = keyErr
+		}
+	}
+	return err
+}
+
+// List перечисляет файлы вложенного хранилища без файлов ключей
+func (e *EncryptedStorage) List(ctx context.Context, fn func(BlobInfo) error) error {
+	lister, ok := e.inner.(Lister)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	return lister.List(ctx, func(blob BlobInfo) error {
+		if strings.HasSuffix(blob.Path, KeySuffix) || strings.HasSuffix(blob.Path, pendingKeySuffix) {
+			return nil
+		}
+		return fn(blob)
+	})
+}
+
+// Move переносит файл без расшифровки содержимого
+func (e *EncryptedStorage) Move(from, to string) error {
+	mover, ok := e.inner.(Mover)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	dek, _, err := e.loadKey(from)
+	encrypted := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("ошибка чтения ключа файла %s: %w", from, err)
+	}
+
+	if err := mover.Move(from, to); err != nil {
+		return err
+	}
+	if !encrypted {
+		return nil
+	}
+	if err := e.saveKey(to, KeySuffix, dek); err != nil {
+		return err
+	}
+	for _, suffix := range []string{KeySuffix, pendingKeySuffix} {
+		if err := e.inner.Delete(from + suffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPublicURL возвращает ссылку вложенного хранилища, если это разрешено DelegateURLs
+func (e *EncryptedStorage) GetPublicURL(path string) string {
+	if !e.delegateURLs {
+		return ""
+	}
+	return e.inner.GetPublicURL(path)
+}
+
+// GetSignedURL возвращает подписанную ссылку вложенного хранилища, если это разрешено DelegateURLs
+func (e *EncryptedStorage) GetSignedURL(path, scope string, ttl time.Duration) (string, time.Time, error) {
+	signer, ok := e.inner.(SignedURLProvider)
+	if !e.delegateURLs || !ok {
+		return "", time.Time{}, ErrSignedURLsDisabled
+	}
+	return signer.GetSignedURL(path, scope, ttl)
+}
+
+// RewrapKey перешифровывает ключ файла текущим мастер-ключом
+func (e *EncryptedStorage) RewrapKey(path string) (bool, error) {
+	dek, keyID, err := e.loadKey(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if keyID == e.keys.CurrentID() {
+		return false, nil
+	}
+
+	if err := e.saveKey(path, pendingKeySuffix, dek); err != nil {
+		return false, err
+	}
+	if err := e.saveKey(path, KeySuffix, dek); err != nil {
+		return false, err
+	}
+	if err := e.inner.Delete(path + pendingKeySuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return true, err
+	}
+	return true, nil
+}
+
+// loadKey читает и расшифровывает ключ файла
+func (e *EncryptedStorage) loadKey(path string) ([]byte, string, error) {
+	var firstErr error
+	for _, name := range []string{path + KeySuffix, path + pendingKeySuffix} {
+		data, err := e.inner.Get(name)
+		if err == nil {
+			var key wrappedKey
+			if err = json.Unmarshal(data, &key); err == nil {
+				var dek []byte
+				if dek, err = e.keys.unwrap(path, key.KeyID, key.WrappedKey); err == nil {
+					return dek, key.KeyID, nil
+				}
+			}
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, "", firstErr
+}
+
+// saveKey шифрует ключ файла текущим мастер-ключом и сохраняет его в <path><suffix>
+func (e *EncryptedStorage) saveKey(path, suffix string, dek []byte) error {
+	name := path + suffix
+	keyID, wrapped, err := e.keys.wrap(path, dek)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(wrappedKey{Version: encryptedVersion, KeyID: keyID, WrappedKey: wrapped})
+	if err != nil {
+		return err
+	}
+	stored, err := e.inner.Save(NewBytesFile(data), name)
+	if err != nil {
+		return err
+	}
+	if stored != name {
+		_ = e.inner.Delete(stored)
+		return fmt.Errorf("хранилище сохранило ключ %s под другим именем", name)
+	}
+	return nil
+}
+
+// segmentNonce формирует nonce сегмента: префикс из заголовка, номер сегмента и признак последнего
+func segmentNonce(header []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, header[headerSize-noncePrefixSize:]...)
+	nonce = binary.BigEndian.AppendUint32(nonce, index)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// encryptingFile представляет исходный файл в зашифрованном виде
+type encryptingFile struct {
+	src     multipart.File
+	aead    cipher.AEAD
+	header  []byte
+	segSize int64
+	plain   int64 // Размер исходного файла
+	size    int64 // Размер зашифрованного файла
+	offset  int64
+
+	cached int64 // Номер сегмента в cache
+	cache  []byte
+	buf    []byte
+}
+
+func newEncryptingFile(src multipart.File, aead cipher.AEAD, header []byte, segSize, plain int64) *encryptingFile {
+	segments := max((plain+segSize-1)/segSize, 1)
+	return &encryptingFile{
+		src:     src,
+		aead:    aead,
+		header:  header,
+		segSize: segSize,
+		plain:   plain,
+		size:    int64(len(header)) + plain + segments*tagSize,
+		cached:  -1,
+	}
+}
+
+// segment возвращает зашифрованный сегмент с указанным номером
+func (f *encryptingFile) segment(index int64) ([]byte, error) {
+	if index == f.cached {
+		return f.cache, nil
+	}
+
+	start := index * f.segSize
+	n := min(f.segSize, f.plain-start)
+	if cap(f.buf) < int(n) {
+		f.buf = make([]byte, f.segSize)
+	}
+	buf := f.buf[:n]
+	if read, err := f.src.ReadAt(buf, start); read < len(buf) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	last := start+n >= f.plain
+	f.cache = f.aead.Seal(f.cache[:0], segmentNonce(f.header, uint32(index), last), buf, f.header)
+	f.cached = index
+	return f.cache, nil
+}
+
+func (f *encryptingFile) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) && off < f.size {
+		if off < int64(len(f.header)) {
+			copied := copy(p[n:], f.header[off:])
+			n += copied
+			off += int64(copied)
+			continue
+		}
+
+		rel := off - int64(len(f.header))
+		index := rel / (f.segSize + tagSize)
+		segment, err := f.segment(index)
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], segment[rel%(f.segSize+tagSize):])
+		n += copied
+		off += int64(copied)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *encryptingFile) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.offset)
+	f.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *encryptingFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, errors.New("неверный параметр whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("отрицательное смещение")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Close не закрывает исходный файл: им владеет вызывающий код
+func (f *encryptingFile) Close() error {
+	return nil
+}
+
+// decryptingReader расшифровывает файл по сегментам
+type decryptingReader struct {
+	src     io.Closer
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	segSize int
+	index   uint32
+	buf     []byte
+	plain   []byte
+	done    bool
+}
+
+func newDecryptingReader(src io.ReadCloser, aead cipher.AEAD, header []byte) *decryptingReader {
+	segSize := int(binary.BigEndian.Uint32(header[len(encryptedMagic)+1:]))
+	return &decryptingReader{
+		src:     src,
+		r:       bufio.NewReader(src),
+		aead:    aead,
+		header:  header,
+		segSize: segSize,
+		buf:     make([]byte, segSize+tagSize),
+	}
+}
+
+func (d *decryptingReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next читает и расшифровывает следующий сегмент
+func (d *decryptingReader) next() error {
+	n, err := io.ReadFull(d.r, d.buf)
+	last := false
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, err := d.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if n < tagSize {
+		return ErrCorruptedFile
+	}
+
+	plain, err := d.aead.Open(d.buf[:0], segmentNonce(d.header, d.index, last), d.buf[:n], d.header)
+	if err != nil {
+		return ErrCorruptedFile
+	}
+	d.plain = plain
+	d.index++
+	d.done = last
+	return nil
+}
+
+func (d *decryptingReader) Close() error {
+	return d.src.Close()
+}
+
+// seekingDecryptingReader расшифровывает файл, поток которого поддерживает Seek
+type seekingDecryptingReader struct {
+	*decryptingReader
+	src  io.ReadSeeker
+	size int64 // Размер расшифрованного файла, -1 пока не вычислен
+	pos  int64 // Текущая позиция в расшифрованном файле
+}
+
+func (d *seekingDecryptingReader) Read(p []byte) (int, error) {
+	n, err := d.decryptingReader.Read(p)
+	d.pos += int64(n)
+	return n, err
+}
+
+func (d *seekingDecryptingReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += d.pos
+	case io.SeekEnd:
+		size, err := d.plainSize()
+		if err != nil {
+			return 0, err
+		}
+		offset += size
+	default:
+		return 0, errors.New("неверный параметр whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("отрицательное смещение")
+	}
+
+	// Позиция на границе сегментов отсчитывается от конца предыдущего сегмента,
+	// чтобы конец файла определялся по признаку последнего сегмента
+	segSize := int64(d.segSize)
+	index, skip := offset/segSize, offset%segSize
+	if skip == 0 && index > 0 {
+		index, skip = index-1, segSize
+	}
+	if _, err := d.src.Seek(int64(headerSize)+index*(segSize+tagSize), io.SeekStart); err != nil {
+		return 0, err
+	}
+	d.r.Reset(d.src)
+	d.index = uint32(index)
+	d.plain = nil
+	d.done = false
+
+	for skip > 0 && !(d.done && len(d.plain) == 0) {
+		if len(d.plain) == 0 {
+			if err := d.next(); err != nil {
+				return 0, err
+			}
+		}
+		n := min(skip, int64(len(d.plain)))
+		d.plain = d.plain[n:]
+		skip -= n
+	}
+	d.pos = offset
+	return offset, nil
+}
+
+// plainSize вычисляет размер расшифрованного файла по размеру зашифрованного
+func (d *seekingDecryptingReader) plainSize() (int64, error) {
+	if d.size >= 0 {
+		return d.size, nil
+	}
+	end, err := d.src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	body := end - int64(headerSize)
+	stride := int64(d.segSize + tagSize)
+	segments := (body + stride - 1) / stride
+	if segments == 0 || body-segments*tagSize < 0 {
+		return 0, ErrCorruptedFile
+	}
+	d.size = body - segments*tagSize
+	return d.size, nil
+}
+
+// readCloser объединяет reader и закрытие исходного потока
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
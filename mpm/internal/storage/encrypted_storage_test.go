@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMasterKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, MasterKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func newTestEncryptedStorage(t *testing.T, keys *KeyRing) (*EncryptedStorage, string) {
+	t.Helper()
+	dir := t.TempDir()
+	enc := NewEncryptedStorage(NewLocalStorage(dir, "/files"), keys)
+	enc.segmentSize = 64
+	return enc, dir
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	keys, err := NewKeyRing(testMasterKey(t))
+	require.NoError(t, err)
+	enc, dir := newTestEncryptedStorage(t, keys)
+
+	t.Run("Сохранение и чтение файлов разного размера", func(t *testing.T) {
+		for _, size := range []int{0, 1, 63, 64, 65, 3*64 + 5} {
+			data := make([]byte, size)
+			_, _ = rand.Read(data)
+
+			path, err := enc.Save(NewBytesFile(data), "albums/1/photo.jpg")
+			require.NoError(t, err)
+			assert.Equal(t, "albums/1/photo.jpg", path)
+
+			got, err := enc.Get(path)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(data, got), "размер %d", size)
+		}
+	})
+
+	t.Run("Файл хранится в зашифрованном виде", func(t *testing.T) {
+		data := bytes.Repeat([]byte("секретное содержимое "), 20)
+		_, err := enc.Save(NewBytesFile(data), "albums/1/secret.jpg")
+		require.NoError(t, err)
+
+		raw, err := os.ReadFile(filepath.Join(dir, "albums/1/secret.jpg"))
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(raw, []byte("секретное")))
+
+		var key wrappedKey
+		sidecar, err := os.ReadFile(filepath.Join(dir, "albums/1/secret.jpg"+KeySuffix))
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(sidecar, &key))
+		assert.Equal(t, keys.CurrentID(), key.KeyID)
+	})
+
+	t.Run("Файл, сохраненный до включения шифрования", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "albums/1/old.jpg"), []byte("plain"), 0644))
+		_, err := enc.Get("albums/1/old.jpg")
+		assert.ErrorIs(t, err, ErrPlaintextFile, "по умолчанию незашифрованные файлы не читаются")
+
+		plain := NewEncryptedStorage(NewLocalStorage(dir, "/files"), keys)
+		plain.AllowPlaintext()
+		got, err := plain.Get("albums/1/old.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("plain"), got)
+
+		// Подмененный файл с ключом не выдается за незашифрованный
+		_, err = enc.Save(NewBytesFile([]byte("photo")), "albums/1/replaced.jpg")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "albums/1/replaced.jpg"), []byte("forged content"), 0644))
+		_, err = plain.Get("albums/1/replaced.jpg")
+		assert.ErrorIs(t, err, ErrCorruptedFile)
+	})
+
+	t.Run("Поврежденный и обрезанный файл", func(t *testing.T) {
+		data := make([]byte, 3*64)
+		_, _ = rand.Read(data)
+		_, err := enc.Save(NewBytesFile(data), "albums/1/damaged.jpg")
+		require.NoError(t, err)
+
+		full := filepath.Join(dir, "albums/1/damaged.jpg")
+		raw, err := os.ReadFile(full)
+		require.NoError(t, err)
+
+		damaged := append([]byte(nil), raw...)
+		damaged[headerSize+70] ^= 1
+		require.NoError(t, os.WriteFile(full, damaged, 0644))
+		_, err = enc.Get("albums/1/damaged.jpg")
+		assert.ErrorIs(t, err, ErrCorruptedFile)
+
+		// Обрезка по границе сегмента обнаруживается по признаку последнего сегмента
+		require.NoError(t, os.WriteFile(full, raw[:headerSize+2*(64+tagSize)], 0644))
+		_, err = enc.Get("albums/1/damaged.jpg")
+		assert.ErrorIs(t, err, ErrCorruptedFile)
+	})
+
+	t.Run("Чтение с произвольного смещения", func(t *testing.T) {
+		data := make([]byte, 1000)
+		_, _ = rand.Read(data)
+		aead, err := newGCM(testMasterKey(t))
+		require.NoError(t, err)
+		header := make([]byte, headerSize)
+		copy(header, encryptedMagic)
+
+		sequential, err := io.ReadAll(newEncryptingFile(NewBytesFile(data), aead, header, 64, int64(len(data))))
+		require.NoError(t, err)
+
+		// Составная загрузка читает части через ReadAt в произвольном порядке
+		file := newEncryptingFile(NewBytesFile(data), aead, header, 64, int64(len(data)))
+		assembled := make([]byte, len(sequential))
+		for _, off := range []int{700, 0, 350, 999, 100} {
+			end := min(off+350, len(assembled))
+			_, err := file.ReadAt(assembled[off:end], int64(off))
+			if err != nil {
+				require.ErrorIs(t, err, io.EOF)
+			}
+		}
+		assert.Equal(t, sequential, assembled)
+	})
+
+	t.Run("Частичное чтение по Range", func(t *testing.T) {
+		data := make([]byte, 5*64+17)
+		_, _ = rand.Read(data)
+		_, err := enc.Save(NewBytesFile(data), "albums/3/video.mp4")
+		require.NoError(t, err)
+
+		serve := func(rangeHeader string) *httptest.ResponseRecorder {
+			reader, err := enc.GetReader("albums/3/video.mp4")
+			require.NoError(t, err)
+			defer reader.Close()
+			rs, ok := reader.(io.ReadSeeker)
+			require.True(t, ok, "поток локального хранилища поддерживает Seek")
+
+			req := httptest.NewRequest(http.MethodGet, "/files/albums/3/video.mp4", nil)
+			req.Header.Set("Range", rangeHeader)
+			w := httptest.NewRecorder()
+			http.ServeContent(w, req, "video.mp4", time.Time{}, rs)
+			return w
+		}
+
+		for _, r := range [][2]int{{100, 300}, {0, 0}, {64, 127}, {63, 64}, {128, len(data) - 1}, {len(data) - 1, len(data) - 1}} {
+			w := serve(fmt.Sprintf("bytes=%d-%d", r[0], r[1]))
+			assert.Equal(t, http.StatusPartialContent, w.Code)
+			assert.Equal(t, fmt.Sprintf("bytes %d-%d/%d", r[0], r[1], len(data)), w.Header().Get("Content-Range"))
+			assert.True(t, bytes.Equal(data[r[0]:r[1]+1], w.Body.Bytes()), "диапазон %d-%d", r[0], r[1])
+		}
+
+		w := serve("bytes=-20")
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, data[len(data)-20:], w.Body.Bytes())
+
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, serve(fmt.Sprintf("bytes=%d-", len(data))).Code)
+	})
+
+	t.Run("Перечисление и перенос без файлов ключей", func(t *testing.T) {
+		_, err := enc.Save(NewBytesFile([]byte("фото")), "albums/2/moved.jpg")
+		require.NoError(t, err)
+		require.NoError(t, enc.Move("albums/2/moved.jpg", QuarantinePrefix+"albums/2/moved.jpg"))
+
+		got, err := enc.Get(QuarantinePrefix + "albums/2/moved.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("фото"), got)
+
+		var paths []string
+		require.NoError(t, enc.List(context.Background(), func(blob BlobInfo) error {
+			paths = append(paths, blob.Path)
+			return nil
+		}))
+		assert.NotEmpty(t, paths)
+		for _, p := range paths {
+			assert.NotContains(t, p, KeySuffix)
+			assert.NotContains(t, p, "moved.jpg")
+		}
+	})
+
+	t.Run("Ключ привязан к пути файла", func(t *testing.T) {
+		for _, name := range []string{"swap/a.jpg", "swap/b.jpg"} {
+			_, err := enc.Save(NewBytesFile([]byte(name)), name)
+			require.NoError(t, err)
+		}
+		// Файлы меняются местами вместе с ключами
+		for _, suffix := range []string{"", KeySuffix} {
+			a, b := filepath.Join(dir, "swap/a.jpg"+suffix), filepath.Join(dir, "swap/b.jpg"+suffix)
+			require.NoError(t, os.Rename(a, a+".tmp"))
+			require.NoError(t, os.Rename(b, a))
+			require.NoError(t, os.Rename(a+".tmp", b))
+		}
+
+		_, err := enc.Get("swap/a.jpg")
+		assert.Error(t, err)
+		_, err = enc.Get("swap/b.jpg")
+		assert.Error(t, err)
+	})
+
+	t.Run("Удаление вместе с ключом", func(t *testing.T) {
+		require.NoError(t, enc.Delete("albums/1/secret.jpg"))
+		_, err := os.Stat(filepath.Join(dir, "albums/1/secret.jpg"+KeySuffix))
+		assert.True(t, os.IsNotExist(err))
+		assert.ErrorIs(t, enc.Delete("albums/1/secret.jpg"), fs.ErrNotExist)
+	})
+}
+
+func TestEncryptedStorage_RewrapKey(t *testing.T) {
+	oldKey, newKey := testMasterKey(t), testMasterKey(t)
+	oldRing, err := NewKeyRing(oldKey)
+	require.NoError(t, err)
+	enc, dir := newTestEncryptedStorage(t, oldRing)
+
+	data := []byte("фотография до смены ключа")
+	_, err = enc.Save(NewBytesFile(data), "a.jpg")
+	require.NoError(t, err)
+	before, err := os.ReadFile(filepath.Join(dir, "a.jpg"))
+	require.NoError(t, err)
+
+	onlyNew, err := NewKeyRing(newKey)
+	require.NoError(t, err)
+	_, err = NewEncryptedStorage(NewLocalStorage(dir, "/files"), onlyNew).Get("a.jpg")
+	assert.ErrorIs(t, err, ErrUnknownMasterKey, "без старого ключа файл не читается")
+
+	rotated, err := NewKeyRing(newKey, oldKey)
+	require.NoError(t, err)
+	enc = NewEncryptedStorage(NewLocalStorage(dir, "/files"), rotated)
+
+	changed, err := enc.RewrapKey("a.jpg")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = enc.RewrapKey("a.jpg")
+	require.NoError(t, err)
+	assert.False(t, changed, "ключ уже зашифрован текущим мастер-ключом")
+
+	after, err := os.ReadFile(filepath.Join(dir, "a.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "содержимое файла не перешифровывается")
+	_, err = os.Stat(filepath.Join(dir, "a.jpg"+pendingKeySuffix))
+	assert.True(t, os.IsNotExist(err))
+
+	got, err := NewEncryptedStorage(NewLocalStorage(dir, "/files"), onlyNew).Get("a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, data, got, "после ротации старый ключ не нужен")
+
+	t.Run("Прерванная ротация", func(t *testing.T) {
+		sidecar, err := os.ReadFile(filepath.Join(dir, "a.jpg"+KeySuffix))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.jpg"+pendingKeySuffix), sidecar, 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.jpg"+KeySuffix), sidecar[:10], 0644))
+
+		got, err := enc.Get("a.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	})
+
+	t.Run("Незашифрованный файл", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "plain.jpg"), []byte("plain"), 0644))
+		changed, err := enc.RewrapKey("plain.jpg")
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+}
+
+func TestParseMasterKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, MasterKeySize)
+
+	parsed, err := ParseMasterKey("abababababababababababababababababababababababababababababababab")
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	parsed, err = ParseMasterKey("q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=")
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	_, err = ParseMasterKey("короткий")
+	assert.Error(t, err)
+}
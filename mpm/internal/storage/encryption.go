@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MasterKeySize размер мастер-ключа и ключей данных в байтах (AES-256)
+const MasterKeySize = 32
+
+// dekWrapAAD префикс дополнительных данных при шифровании ключа файла мастер-ключом
+const dekWrapAAD = "mpm-dek-v1:"
+
+// ErrUnknownMasterKey возвращается, если ключ файла зашифрован мастер-ключом, которого нет в KeyRing
+var ErrUnknownMasterKey = errors.New("ключ файла зашифрован неизвестным мастер-ключом")
+
+// ParseMasterKey разбирает мастер-ключ, заданный в base64 или шестнадцатеричном виде
+func ParseMasterKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := hex.DecodeString(s); err == nil && len(key) == MasterKeySize {
+		return key, nil
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := encoding.DecodeString(s); err == nil && len(key) == MasterKeySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("мастер-ключ должен содержать %d байта в base64 или hex", MasterKeySize)
+}
+
+// KeyRing хранит текущий мастер-ключ и предыдущие ключи
+type KeyRing struct {
+	currentID string
+	keys      map[string]cipher.AEAD
+}
+
+// NewKeyRing создает набор мастер-ключей. current используется для новых файлов.
+func NewKeyRing(current []byte, previous ...[]byte) (*KeyRing, error) {
+	kr := &KeyRing{keys: make(map[string]cipher.AEAD)}
+	for i, key := range append([][]byte{current}, previous...) {
+		if len(key) != MasterKeySize {
+			return nil, fmt.Errorf("мастер-ключ должен содержать %d байта", MasterKeySize)
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		id := masterKeyID(key)
+		if i == 0 {
+			kr.currentID = id
+		}
+		if _, ok := kr.keys[id]; !ok {
+			kr.keys[id] = aead
+		}
+	}
+	return kr, nil
+}
+
+// CurrentID возвращает идентификатор текущего мастер-ключа
+func (kr *KeyRing) CurrentID() string {
+	return kr.currentID
+}
+
+// wrap шифрует ключ файла текущим мастер-ключом. Зашифрованный ключ привязан к пути файла.
+func (kr *KeyRing) wrap(path string, dek []byte) (keyID string, wrapped []byte, err error) {
+	aead := kr.keys[kr.currentID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return kr.currentID, aead.Seal(nonce, nonce, dek, []byte(dekWrapAAD+path)), nil
+}
+
+// unwrap расшифровывает ключ файла с указанным путем мастер-ключом с указанным идентификатором
+func (kr *KeyRing) unwrap(path, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := kr.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMasterKey, keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("поврежденный ключ файла")
+	}
+	nonce, ciphertext := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	dek, err := aead.Open(nil, nonce, ciphertext, []byte(dekWrapAAD+path))
+	if err != nil {
+		return nil, errors.New("не удалось расшифровать ключ файла")
+	}
+	return dek, nil
+}
+
+// masterKeyID идентифицирует мастер-ключ, не раскрывая его
+func masterKeyID(key []byte) string {
+	sum := sha256.Sum256(append([]byte("mpm-master-key:"), key...))
+	return hex.EncodeToString(sum[:8])
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,370 @@
+// Package google реализует работу с Google Photos Library API: подключение аккаунта
+// по OAuth 2.0, загрузку и чтение медиафайлов и обход альбомов для импорта.
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAPIURL адрес Google Photos Library API
+	DefaultAPIURL = "https://photoslibrary.googleapis.com"
+
+	// albumsPageSize и itemsPageSize максимальные размеры страниц, допустимые API
+	albumsPageSize = 50
+	itemsPageSize  = 100
+)
+
+// APIError описывает ошибку, возвращенную Google Photos Library API
+type APIError struct {
+	StatusCode int
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ошибка Google Photos API: %s (%s), статус %d", e.Message, e.Status, e.StatusCode)
+}
+
+// Client клиент Google Photos Library API
+type Client struct {
+	apiURL string
+	oauth  OAuthConfig
+	store  TokenStore
+	http   *http.Client
+	now    func() time.Time
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewClient создает клиента. Пустой apiURL означает DefaultAPIURL, nil httpClient - клиент с таймаутом.
+func NewClient(apiURL string, oauth OAuthConfig, store TokenStore, httpClient *http.Client) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &Client{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		oauth:  oauth.withDefaults(),
+		store:  store,
+		http:   httpClient,
+		now:    time.Now,
+	}
+}
+
+// AuthCodeURL возвращает адрес страницы, на которой пользователь разрешает доступ к библиотеке
+func (c *Client) AuthCodeURL(state string) string {
+	return c.oauth.AuthCodeURL(state)
+}
+
+// Connect обменивает код авторизации на токены и сохраняет их
+func (c *Client) Connect(ctx context.Context, code string) error {
+	token, err := c.oauth.requestToken(ctx, c.http, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.oauth.RedirectURL},
+	}, c.now())
+	if err != nil {
+		return err
+	}
+	if err := c.store.Save(token); err != nil {
+		return fmt.Errorf("ошибка сохранения токена Google: %w", err)
+	}
+
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+	return nil
+}
+
+// Connected сообщает, подключен ли аккаунт Google
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != nil {
+		return true
+	}
+	token, err := c.store.Load()
+	return err == nil && token != nil
+}
+
+// accessToken возвращает действующий токен доступа, при необходимости обновляя его
+func (c *Client) accessToken(ctx context.Context, forceRefresh bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == nil {
+		token, err := c.store.Load()
+		if err != nil {
+			return "", err
+		}
+		c.token = token
+	}
+	if !forceRefresh && c.token.Valid(c.now()) {
+		return c.token.AccessToken, nil
+	}
+	if c.token.RefreshToken == "" {
+		return "", fmt.Errorf("%w: срок действия токена истек, подключите аккаунт заново", ErrNotConnected)
+	}
+
+	refreshed, err := c.oauth.requestToken(ctx, c.http, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {c.token.RefreshToken},
+	}, c.now())
+	if err != nil {
+		return "", err
+	}
+	// Google обычно не возвращает новый токен обновления, прежний остается действующим
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = c.token.RefreshToken
+	}
+	if err := c.store.Save(refreshed); err != nil {
+		return "", fmt.Errorf("ошибка сохранения токена Google: %w", err)
+	}
+	c.token = refreshed
+	return refreshed.AccessToken, nil
+}
+
+// do выполняет авторизованный запрос к API, при отказе в токене обновляет его и повторяет запрос
+func (c *Client) do(ctx context.Context, method, endpoint string, header http.Header, body []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := c.accessToken(ctx, attempt > 0)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.apiURL+endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		apiErr := parseAPIError(resp)
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			continue
+		}
+		return nil, apiErr
+	}
+}
+
+// doJSON отправляет in в формате JSON (если он не nil) и разбирает ответ в out
+func (c *Client) doJSON(ctx context.Context, method, endpoint string, in, out any) error {
+	var body []byte
+	header := http.Header{}
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+		header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.do(ctx, method, endpoint, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("некорректный ответ Google Photos API: %w", err)
+	}
+	return nil
+}
+
+// Upload загружает байты файла и возвращает токен загрузки для CreateMediaItem
+func (c *Client) Upload(ctx context.Context, data []byte, filename, mimeType string) (string, error) {
+	header := http.Header{
+		"Content-Type":               {"application/octet-stream"},
+		"X-Goog-Upload-Content-Type": {mimeType},
+		"X-Goog-Upload-File-Name":    {filename},
+		"X-Goog-Upload-Protocol":     {"raw"},
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/v1/uploads", header, data)
+	if err != nil {
+		return "", fmt.Errorf("ошибка загрузки файла в Google Photos: %w", err)
+	}
+	defer resp.Body.Close()
+
+	token, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", err
+	}
+	if len(token) == 0 {
+		return "", errors.New("Google Photos не вернул токен загрузки")
+	}
+	return string(token), nil
+}
+
+// CreateMediaItem создает медиафайл из загруженных байтов
+func (c *Client) CreateMediaItem(ctx context.Context, uploadToken, filename, albumID string) (MediaItem, error) {
+	type simpleMediaItem struct {
+		UploadToken string `json:"uploadToken"`
+		FileName    string `json:"fileName"`
+	}
+	type newMediaItem struct {
+		SimpleMediaItem simpleMediaItem `json:"simpleMediaItem"`
+	}
+	request := struct {
+		AlbumID       string         `json:"albumId,omitempty"`
+		NewMediaItems []newMediaItem `json:"newMediaItems"`
+	}{
+		AlbumID:       albumID,
+		NewMediaItems: []newMediaItem{{SimpleMediaItem: simpleMediaItem{UploadToken: uploadToken, FileName: filename}}},
+	}
+
+	var response struct {
+		NewMediaItemResults []struct {
+			Status struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"status"`
+			MediaItem *MediaItem `json:"mediaItem"`
+		} `json:"newMediaItemResults"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/v1/mediaItems:batchCreate", request, &response); err != nil {
+		return MediaItem{}, fmt.Errorf("ошибка создания медиафайла в Google Photos: %w", err)
+	}
+
+	if len(response.NewMediaItemResults) != 1 {
+		return MediaItem{}, errors.New("Google Photos не вернул созданный медиафайл")
+	}
+	result := response.NewMediaItemResults[0]
+	if result.Status.Code != 0 || result.MediaItem == nil {
+		return MediaItem{}, fmt.Errorf("Google Photos отклонил файл %s: %s", filename, result.Status.Message)
+	}
+	return *result.MediaItem, nil
+}
+
+// GetMediaItem возвращает медиафайл по идентификатору
+func (c *Client) GetMediaItem(ctx context.Context, id string) (MediaItem, error) {
+	var item MediaItem
+	err := c.doJSON(ctx, http.MethodGet, "/v1/mediaItems/"+url.PathEscape(id), nil, &item)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return MediaItem{}, fmt.Errorf("медиафайл %s не найден: %w", id, fs.ErrNotExist)
+		}
+		return MediaItem{}, err
+	}
+	return item, nil
+}
+
+// ListAlbums возвращает все альбомы библиотеки
+func (c *Client) ListAlbums(ctx context.Context) ([]Album, error) {
+	var albums []Album
+	pageToken := ""
+	for {
+		query := url.Values{"pageSize": {fmt.Sprint(albumsPageSize)}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		var page struct {
+			Albums        []Album `json:"albums"`
+			NextPageToken string  `json:"nextPageToken"`
+		}
+		if err := c.doJSON(ctx, http.MethodGet, "/v1/albums?"+query.Encode(), nil, &page); err != nil {
+			return nil, fmt.Errorf("ошибка получения альбомов Google Photos: %w", err)
+		}
+		albums = append(albums, page.Albums...)
+
+		if page.NextPageToken == "" {
+			return albums, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// ListAlbumItems вызывает fn для каждого медиафайла альбома. Обход прекращается при ошибке fn.
+func (c *Client) ListAlbumItems(ctx context.Context, albumID string, fn func(MediaItem) error) error {
+	pageToken := ""
+	for {
+		request := struct {
+			AlbumID   string `json:"albumId"`
+			PageSize  int    `json:"pageSize"`
+			PageToken string `json:"pageToken,omitempty"`
+		}{AlbumID: albumID, PageSize: itemsPageSize, PageToken: pageToken}
+
+		var page struct {
+			MediaItems    []MediaItem `json:"mediaItems"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if err := c.doJSON(ctx, http.MethodPost, "/v1/mediaItems:search", request, &page); err != nil {
+			return fmt.Errorf("ошибка получения медиафайлов альбома %s: %w", albumID, err)
+		}
+
+		for _, item := range page.MediaItems {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if page.NextPageToken == "" {
+			return nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// Download открывает оригинал медиафайла. Адрес baseUrl действует около часа и не требует токена.
+func (c *Client) Download(ctx context.Context, item MediaItem) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, item.DownloadURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка скачивания медиафайла %s: %w", item.ID, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("медиафайл %s не найден: %w", item.ID, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("ошибка скачивания медиафайла %s: статус %d", item.ID, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func parseAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+
+	var body struct {
+		Error APIError `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = json.Unmarshal(data, &body)
+
+	apiErr := body.Error
+	apiErr.StatusCode = resp.StatusCode
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return &apiErr
+}
@@ -0,0 +1,403 @@
+// Package googletest содержит поддельный сервер Google Photos Library API и OAuth 2.0
+// для тестов. Сервер хранит альбомы и медиафайлы в памяти.
+package googletest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Значения, которые принимает сервер авторизации
+const (
+	ClientID     = "test-client"
+	ClientSecret = "test-secret"
+	AuthCode     = "test-code"
+	RefreshToken = "test-refresh-token"
+)
+
+// Item медиафайл в библиотеке поддельного сервера
+type Item struct {
+	ID           string
+	Filename     string
+	MimeType     string
+	CreationTime string
+	Width        int
+	Height       int
+	CameraMake   string
+	CameraModel  string
+	Data         []byte
+}
+
+type album struct {
+	id    string
+	title string
+	items []string
+}
+
+// Server поддельный Google Photos Library API с сервером токенов по адресу URL + "/token"
+type Server struct {
+	*httptest.Server
+
+	// PageSize переопределяет размер страницы ответов, чтобы проверить постраничный обход
+	PageSize int
+
+	mu            sync.Mutex
+	accessToken   string
+	tokenRequests int
+	albums        []*album
+	items         map[string]*Item
+	uploads       map[string]*Item
+	nextID        int
+}
+
+// NewServer запускает сервер и останавливает его по завершении теста
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		items:   map[string]*Item{},
+		uploads: map[string]*Item{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", s.handleToken)
+	mux.HandleFunc("POST /v1/uploads", s.authorized(s.handleUpload))
+	mux.HandleFunc("POST /v1/mediaItems:batchCreate", s.authorized(s.handleBatchCreate))
+	mux.HandleFunc("POST /v1/mediaItems:search", s.authorized(s.handleSearch))
+	mux.HandleFunc("GET /v1/mediaItems/{id}", s.authorized(s.handleGetItem))
+	mux.HandleFunc("GET /v1/albums", s.authorized(s.handleAlbums))
+	mux.HandleFunc("GET /media/{ref}", s.handleDownload)
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// TokenURL возвращает адрес сервера токенов
+func (s *Server) TokenURL() string {
+	return s.URL + "/token"
+}
+
+// AddAlbum создает альбом с медиафайлами и возвращает его идентификатор
+func (s *Server) AddAlbum(title string, items ...Item) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := &album{id: s.newID("album"), title: title}
+	for _, item := range items {
+		item := item
+		if item.ID == "" {
+			item.ID = s.newID("item")
+		}
+		s.items[item.ID] = &item
+		a.items = append(a.items, item.ID)
+	}
+	s.albums = append(s.albums, a)
+	return a.id
+}
+
+// Item возвращает медиафайл по идентификатору
+func (s *Server) Item(id string) (Item, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[id]
+	if !ok {
+		return Item{}, false
+	}
+	return *item, true
+}
+
+// AlbumItems возвращает идентификаторы медиафайлов альбома
+func (s *Server) AlbumItems(albumID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.albums {
+		if a.id == albumID {
+			return append([]string(nil), a.items...)
+		}
+	}
+	return nil
+}
+
+// RevokeAccessToken делает выданный токен доступа недействительным, как по истечении срока
+func (s *Server) RevokeAccessToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessToken = ""
+}
+
+// TokenRequests возвращает количество запросов к серверу токенов
+func (s *Server) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequests
+}
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return prefix + "-" + strconv.Itoa(s.nextID)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenRequests++
+
+	if r.FormValue("client_id") != ClientID || r.FormValue("client_secret") != ClientSecret {
+		writeOAuthError(w, "invalid_client")
+		return
+	}
+
+	response := map[string]any{"token_type": "Bearer", "expires_in": 3600}
+	switch r.FormValue("grant_type") {
+	case "authorization_code":
+		if r.FormValue("code") != AuthCode {
+			writeOAuthError(w, "invalid_grant")
+			return
+		}
+		response["refresh_token"] = RefreshToken
+	case "refresh_token":
+		if r.FormValue("refresh_token") != RefreshToken {
+			writeOAuthError(w, "invalid_grant")
+			return
+		}
+	default:
+		writeOAuthError(w, "unsupported_grant_type")
+		return
+	}
+
+	s.accessToken = "access-" + strconv.Itoa(s.tokenRequests)
+	response["access_token"] = s.accessToken
+	writeJSON(w, http.StatusOK, response)
+}
+
+// authorized проверяет токен доступа из заголовка Authorization
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		valid := s.accessToken != "" && r.Header.Get("Authorization") == "Bearer "+s.accessToken
+		s.mu.Unlock()
+		if !valid {
+			writeAPIError(w, http.StatusUnauthorized, "UNAUTHENTICATED", "Request had invalid authentication credentials.")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Goog-Upload-Protocol") != "raw" || r.Header.Get("X-Goog-Upload-Content-Type") == "" {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "unsupported upload")
+		return
+	}
+	data, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := s.newID("upload")
+	s.uploads[token] = &Item{MimeType: r.Header.Get("X-Goog-Upload-Content-Type"), Data: data}
+	fmt.Fprint(w, token)
+}
+
+func (s *Server) handleBatchCreate(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		AlbumID       string `json:"albumId"`
+		NewMediaItems []struct {
+			SimpleMediaItem struct {
+				UploadToken string `json:"uploadToken"`
+				FileName    string `json:"fileName"`
+			} `json:"simpleMediaItem"`
+		} `json:"newMediaItems"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var target *album
+	for _, a := range s.albums {
+		if a.id == request.AlbumID {
+			target = a
+		}
+	}
+	if request.AlbumID != "" && target == nil {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_ARGUMENT", "album not found")
+		return
+	}
+
+	var results []map[string]any
+	for _, newItem := range request.NewMediaItems {
+		upload, ok := s.uploads[newItem.SimpleMediaItem.UploadToken]
+		if !ok {
+			results = append(results, map[string]any{
+				"status": map[string]any{"code": 3, "message": "Failed: There was an error while trying to create this media item."},
+			})
+			continue
+		}
+		delete(s.uploads, newItem.SimpleMediaItem.UploadToken)
+
+		item := *upload
+		item.ID = s.newID("item")
+		item.Filename = newItem.SimpleMediaItem.FileName
+		s.items[item.ID] = &item
+		if target != nil {
+			target.items = append(target.items, item.ID)
+		}
+		results = append(results, map[string]any{
+			"status":    map[string]any{"message": "Success"},
+			"mediaItem": s.mediaItemJSON(r, &item),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"newMediaItemResults": results})
+}
+
+func (s *Server) handleGetItem(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[r.PathValue("id")]
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "NOT_FOUND", "Requested entity was not found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.mediaItemJSON(r, item))
+}
+
+func (s *Server) handleAlbums(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var albums []map[string]any
+	for _, a := range s.albums {
+		albums = append(albums, map[string]any{
+			"id":              a.id,
+			"title":           a.title,
+			"mediaItemsCount": strconv.Itoa(len(a.items)),
+		})
+	}
+	page, next := paginate(albums, r.URL.Query().Get("pageToken"), s.pageSize(r.URL.Query().Get("pageSize")))
+	writeJSON(w, http.StatusOK, map[string]any{"albums": page, "nextPageToken": next})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		AlbumID   string `json:"albumId"`
+		PageSize  int    `json:"pageSize"`
+		PageToken string `json:"pageToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []map[string]any
+	found := false
+	for _, a := range s.albums {
+		if a.id != request.AlbumID {
+			continue
+		}
+		found = true
+		for _, id := range a.items {
+			items = append(items, s.mediaItemJSON(r, s.items[id]))
+		}
+	}
+	if !found {
+		writeAPIError(w, http.StatusNotFound, "NOT_FOUND", "Requested entity was not found.")
+		return
+	}
+
+	page, next := paginate(items, request.PageToken, s.pageSize(strconv.Itoa(request.PageSize)))
+	writeJSON(w, http.StatusOK, map[string]any{"mediaItems": page, "nextPageToken": next})
+}
+
+// handleDownload отдает содержимое по адресу baseUrl с параметром "=d" или "=dv"
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	id, param, _ := strings.Cut(r.PathValue("ref"), "=")
+
+	s.mu.Lock()
+	item, ok := s.items[id]
+	s.mu.Unlock()
+	if !ok || (param != "d" && param != "dv") {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", item.MimeType)
+	w.Write(item.Data)
+}
+
+func (s *Server) mediaItemJSON(r *http.Request, item *Item) map[string]any {
+	metadata := map[string]any{
+		"creationTime": item.CreationTime,
+		"width":        strconv.Itoa(item.Width),
+		"height":       strconv.Itoa(item.Height),
+	}
+	camera := map[string]any{"cameraMake": item.CameraMake, "cameraModel": item.CameraModel}
+	if strings.HasPrefix(item.MimeType, "video/") {
+		metadata["video"] = camera
+	} else {
+		metadata["photo"] = camera
+	}
+
+	return map[string]any{
+		"id":            item.ID,
+		"filename":      item.Filename,
+		"mimeType":      item.MimeType,
+		"baseUrl":       "http://" + r.Host + "/media/" + item.ID,
+		"productUrl":    "https://photos.google.com/lr/photo/" + item.ID,
+		"mediaMetadata": metadata,
+	}
+}
+
+func (s *Server) pageSize(requested string) int {
+	if s.PageSize > 0 {
+		return s.PageSize
+	}
+	size, err := strconv.Atoi(requested)
+	if err != nil || size <= 0 {
+		return 25
+	}
+	return size
+}
+
+// paginate возвращает страницу, начинающуюся с позиции pageToken, и токен следующей страницы
+func paginate(values []map[string]any, pageToken string, size int) ([]map[string]any, string) {
+	start, _ := strconv.Atoi(pageToken)
+	if start > len(values) {
+		start = len(values)
+	}
+	end := min(start+size, len(values))
+	next := ""
+	if end < len(values) {
+		next = strconv.Itoa(end)
+	}
+	return values[start:end], next
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, code int, status, message string) {
+	writeJSON(w, code, map[string]any{
+		"error": map[string]any{"code": code, "message": message, "status": status},
+	})
+}
+
+func writeOAuthError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]any{"error": code, "error_description": "test error"})
+}
@@ -0,0 +1,153 @@
+package google
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"mpm/internal/models"
+)
+
+// StorageType значение models.Photo.StorageType для фотографий, хранящихся в Google Photos
+const StorageType = "google"
+
+// Album альбом Google Photos
+type Album struct {
+	ID                string `json:"id"`
+	Title             string `json:"title"`
+	ProductURL        string `json:"productUrl,omitempty"`
+	MediaItemsCount   string `json:"mediaItemsCount,omitempty"`
+	CoverPhotoBaseURL string `json:"coverPhotoBaseUrl,omitempty"`
+}
+
+// MediaItem медиафайл (фотография или видео) Google Photos
+type MediaItem struct {
+	ID            string        `json:"id"`
+	Description   string        `json:"description,omitempty"`
+	ProductURL    string        `json:"productUrl,omitempty"`
+	BaseURL       string        `json:"baseUrl,omitempty"`
+	MimeType      string        `json:"mimeType,omitempty"`
+	Filename      string        `json:"filename,omitempty"`
+	MediaMetadata MediaMetadata `json:"mediaMetadata"`
+}
+
+// MediaMetadata метаданные медиафайла. Размеры API возвращает строками.
+type MediaMetadata struct {
+	CreationTime string         `json:"creationTime,omitempty"`
+	Width        string         `json:"width,omitempty"`
+	Height       string         `json:"height,omitempty"`
+	Photo        *PhotoMetadata `json:"photo,omitempty"`
+	Video        *VideoMetadata `json:"video,omitempty"`
+}
+
+// PhotoMetadata параметры съемки фотографии
+type PhotoMetadata struct {
+	CameraMake      string  `json:"cameraMake,omitempty"`
+	CameraModel     string  `json:"cameraModel,omitempty"`
+	FocalLength     float64 `json:"focalLength,omitempty"`
+	ApertureFNumber float64 `json:"apertureFNumber,omitempty"`
+	IsoEquivalent   int     `json:"isoEquivalent,omitempty"`
+	ExposureTime    string  `json:"exposureTime,omitempty"` // Длительность в формате "0.008s"
+}
+
+// VideoMetadata параметры видео
+type VideoMetadata struct {
+	CameraMake  string  `json:"cameraMake,omitempty"`
+	CameraModel string  `json:"cameraModel,omitempty"`
+	Fps         float64 `json:"fps,omitempty"`
+	Status      string  `json:"status,omitempty"`
+}
+
+// IsVideo сообщает, является ли медиафайл видео
+func (m MediaItem) IsVideo() bool {
+	return m.MediaMetadata.Video != nil || strings.HasPrefix(m.MimeType, "video/")
+}
+
+// DownloadURL возвращает адрес оригинала: параметр "=d" для фотографий и "=dv" для видео
+func (m MediaItem) DownloadURL() string {
+	if m.IsVideo() {
+		return m.BaseURL + "=dv"
+	}
+	return m.BaseURL + "=d"
+}
+
+// TakenAt возвращает время создания медиафайла или nil, если оно неизвестно
+func (m MediaItem) TakenAt() *time.Time {
+	t, err := time.Parse(time.RFC3339, m.MediaMetadata.CreationTime)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// ToPhoto преобразует медиафайл в фотографию
+func (m MediaItem) ToPhoto() models.Photo {
+	photo := models.Photo{
+		Name:        m.Filename,
+		Path:        m.ID,
+		Tags:        []string{},
+		Metadata:    m.Metadata(),
+		StorageType: StorageType,
+		MimeType:    m.MimeType,
+		TakenAt:     m.TakenAt(),
+	}
+	photo.Width, _ = strconv.Atoi(m.MediaMetadata.Width)
+	photo.Height, _ = strconv.Atoi(m.MediaMetadata.Height)
+	return photo
+}
+
+// Metadata преобразует метаданные Google Photos в ключи, используемые для EXIF
+func (m MediaItem) Metadata() []models.Metadata {
+	result := []models.Metadata{}
+	add := func(key, value string) {
+		if value != "" {
+			result = append(result, models.Metadata{Key: key, Value: value})
+		}
+	}
+
+	if photo := m.MediaMetadata.Photo; photo != nil {
+		add(models.MetadataCameraMake, photo.CameraMake)
+		add(models.MetadataCameraModel, photo.CameraModel)
+		add(models.MetadataExposureTime, formatExposure(photo.ExposureTime))
+		if photo.ApertureFNumber > 0 {
+			add(models.MetadataFNumber, formatFloat(photo.ApertureFNumber, 1))
+		}
+		if photo.IsoEquivalent > 0 {
+			add(models.MetadataISO, strconv.Itoa(photo.IsoEquivalent))
+		}
+		if photo.FocalLength > 0 {
+			add(models.MetadataFocalLength, formatFloat(photo.FocalLength, 1))
+		}
+	}
+	if video := m.MediaMetadata.Video; video != nil {
+		add(models.MetadataCameraMake, video.CameraMake)
+		add(models.MetadataCameraModel, video.CameraModel)
+	}
+	if takenAt := m.TakenAt(); takenAt != nil {
+		add(models.MetadataDateTaken, takenAt.Format(time.RFC3339))
+	}
+
+	return result
+}
+
+// formatExposure переводит длительность "0.004s" в привычный вид "1/250", как для EXIF
+func formatExposure(value string) string {
+	seconds, err := strconv.ParseFloat(strings.TrimSuffix(value, "s"), 64)
+	if err != nil || seconds <= 0 {
+		return ""
+	}
+	if seconds >= 1 {
+		return formatFloat(seconds, 1)
+	}
+	return "1/" + strconv.Itoa(int(math.Round(1/seconds)))
+}
+
+// formatFloat форматирует число без лишних нулей в дробной части
+func formatFloat(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"path"
+	"time"
+
+	"mpm/internal/storage"
+)
+
+// baseURLLifetime срок действия адреса baseUrl, выданного API
+const baseURLLifetime = time.Hour
+
+// ErrDeleteUnsupported возвращается при удалении: Library API не позволяет удалять медиафайлы
+var ErrDeleteUnsupported = fmt.Errorf("Google Photos API не поддерживает удаление медиафайлов: %w", errors.ErrUnsupported)
+
+// Storage реализует storage.Provider поверх Google Photos. Путь файла - идентификатор медиафайла.
+type Storage struct {
+	client  *Client
+	albumID string
+}
+
+// NewStorage создает хранилище, загружающее файлы в альбом albumID, если он задан
+func NewStorage(client *Client, albumID string) *Storage {
+	return &Storage{
+		client:  client,
+		albumID: albumID,
+	}
+}
+
+// Save загружает файл в библиотеку и возвращает идентификатор медиафайла
+func (s *Storage) Save(file multipart.File, filename string) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+
+	name := path.Base(filename)
+	mimeType := storage.DetectImageType(data)
+	if mimeType == "" {
+		mimeType = storage.DetectVideoType(data)
+	}
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(name))
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	ctx := context.Background()
+	uploadToken, err := s.client.Upload(ctx, data, name, mimeType)
+	if err != nil {
+		return "", err
+	}
+	item, err := s.client.CreateMediaItem(ctx, uploadToken, name, s.albumID)
+	if err != nil {
+		return "", err
+	}
+	return item.ID, nil
+}
+
+// Get скачивает оригинал медиафайла целиком
+func (s *Storage) Get(path string) ([]byte, error) {
+	reader, err := s.GetReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// GetReader открывает оригинал медиафайла
+func (s *Storage) GetReader(path string) (io.ReadCloser, error) {
+	ctx := context.Background()
+	item, err := s.client.GetMediaItem(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Download(ctx, item)
+}
+
+// Delete всегда возвращает ErrDeleteUnsupported
+func (s *Storage) Delete(path string) error {
+	return ErrDeleteUnsupported
+}
+
+// GetPublicURL возвращает временный адрес оригинала
+func (s *Storage) GetPublicURL(path string) string {
+	link, _, err := s.GetSignedURL(path, storage.ScopeOriginal, baseURLLifetime)
+	if err != nil {
+		log.Printf("Ошибка получения ссылки на медиафайл %s: %v", path, err)
+		return ""
+	}
+	return link
+}
+
+// GetSignedURL возвращает временный адрес медиафайла
+func (s *Storage) GetSignedURL(path, scope string, ttl time.Duration) (string, time.Time, error) {
+	item, err := s.client.GetMediaItem(context.Background(), path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := s.client.now().Add(min(ttl, baseURLLifetime)).Truncate(time.Second)
+	return item.DownloadURL(), expires, nil
+}
@@ -0,0 +1,189 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mpm/internal/models"
+	"mpm/internal/storage"
+	"mpm/internal/storage/google/googletest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ storage.Provider = (*Storage)(nil)
+var _ storage.SignedURLProvider = (*Storage)(nil)
+
+var pngHeader = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n', 0, 0, 0, 0}
+
+func newTestClient(t *testing.T, server *googletest.Server, tokenPath string) *Client {
+	t.Helper()
+	return NewClient(server.URL, OAuthConfig{
+		ClientID:     googletest.ClientID,
+		ClientSecret: googletest.ClientSecret,
+		RedirectURL:  "http://localhost/google/callback",
+		TokenURL:     server.TokenURL(),
+	}, NewFileTokenStore(tokenPath), nil)
+}
+
+func TestClient_Connect(t *testing.T) {
+	server := googletest.NewServer(t)
+	tokenPath := filepath.Join(t.TempDir(), "google", "token.json")
+	client := newTestClient(t, server, tokenPath)
+	ctx := context.Background()
+
+	assert.False(t, client.Connected())
+	_, err := client.ListAlbums(ctx)
+	assert.ErrorIs(t, err, ErrNotConnected)
+
+	link, err := url.Parse(client.AuthCodeURL("state-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", link.Query().Get("state"))
+	assert.Equal(t, "offline", link.Query().Get("access_type"))
+	assert.Equal(t, googletest.ClientID, link.Query().Get("client_id"))
+
+	assert.Error(t, client.Connect(ctx, "wrong-code"))
+	require.NoError(t, client.Connect(ctx, googletest.AuthCode))
+	assert.True(t, client.Connected())
+
+	info, err := os.Stat(tokenPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "токен доступен только владельцу")
+
+	t.Run("Токен обновляется после отказа сервера", func(t *testing.T) {
+		server.RevokeAccessToken()
+		requests := server.TokenRequests()
+
+		// Новый клиент читает токен из файла, как после перезапуска
+		restarted := newTestClient(t, server, tokenPath)
+		_, err := restarted.ListAlbums(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, requests+1, server.TokenRequests())
+
+		saved, err := NewFileTokenStore(tokenPath).Load()
+		require.NoError(t, err)
+		assert.Equal(t, googletest.RefreshToken, saved.RefreshToken, "токен обновления сохраняется")
+	})
+
+	t.Run("Истекший токен обновляется заранее", func(t *testing.T) {
+		requests := server.TokenRequests()
+		client.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		_, err := client.ListAlbums(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, requests+1, server.TokenRequests())
+	})
+}
+
+func TestStorage(t *testing.T) {
+	server := googletest.NewServer(t)
+	client := newTestClient(t, server, filepath.Join(t.TempDir(), "token.json"))
+	require.NoError(t, client.Connect(context.Background(), googletest.AuthCode))
+	albumID := server.AddAlbum("MPM")
+	store := NewStorage(client, albumID)
+
+	id, err := store.Save(storage.NewBytesFile(pngHeader), "albums/1/123_photo.png")
+	require.NoError(t, err)
+
+	item, ok := server.Item(id)
+	require.True(t, ok)
+	assert.Equal(t, "123_photo.png", item.Filename)
+	assert.Equal(t, "image/png", item.MimeType)
+	assert.Equal(t, []string{id}, server.AlbumItems(albumID))
+
+	data, err := store.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, pngHeader, data)
+
+	link, expires, err := store.GetSignedURL(id, storage.ScopeOriginal, 24*time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expires, time.Minute)
+	resp, err := http.Get(link)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, link, store.GetPublicURL(id))
+
+	_, err = store.GetReader("missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.True(t, errors.Is(store.Delete(id), errors.ErrUnsupported))
+}
+
+func TestClient_ListAlbumItems(t *testing.T) {
+	server := googletest.NewServer(t)
+	server.PageSize = 2
+	client := newTestClient(t, server, filepath.Join(t.TempDir(), "token.json"))
+	require.NoError(t, client.Connect(context.Background(), googletest.AuthCode))
+
+	server.AddAlbum("Пустой")
+	albumID := server.AddAlbum("Отпуск",
+		googletest.Item{Filename: "1.jpg", MimeType: "image/jpeg"},
+		googletest.Item{Filename: "2.jpg", MimeType: "image/jpeg"},
+		googletest.Item{Filename: "3.mp4", MimeType: "video/mp4"},
+	)
+	server.AddAlbum("Еще один")
+
+	albums, err := client.ListAlbums(context.Background())
+	require.NoError(t, err)
+	require.Len(t, albums, 3)
+	assert.Equal(t, "Отпуск", albums[1].Title)
+
+	var names []string
+	err = client.ListAlbumItems(context.Background(), albumID, func(item MediaItem) error {
+		names = append(names, item.Filename)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.jpg", "2.jpg", "3.mp4"}, names)
+}
+
+func TestMediaItem_ToPhoto(t *testing.T) {
+	item := MediaItem{
+		ID:       "abc",
+		Filename: "IMG_0001.JPG",
+		MimeType: "image/jpeg",
+		BaseURL:  "https://lh3.googleusercontent.com/abc",
+		MediaMetadata: MediaMetadata{
+			CreationTime: "2023-07-14T09:30:00Z",
+			Width:        "4032",
+			Height:       "3024",
+			Photo: &PhotoMetadata{
+				CameraMake:      "Apple",
+				CameraModel:     "iPhone 13",
+				FocalLength:     5.1,
+				ApertureFNumber: 1.6,
+				IsoEquivalent:   50,
+				ExposureTime:    "0.004s",
+			},
+		},
+	}
+
+	photo := item.ToPhoto()
+
+	assert.Equal(t, "IMG_0001.JPG", photo.Name)
+	assert.Equal(t, "abc", photo.Path)
+	assert.Equal(t, StorageType, photo.StorageType)
+	assert.Equal(t, 4032, photo.Width)
+	assert.Equal(t, 3024, photo.Height)
+	require.NotNil(t, photo.TakenAt)
+	assert.Equal(t, 2023, photo.TakenAt.Year())
+
+	value, _ := photo.MetadataValue(models.MetadataExposureTime)
+	assert.Equal(t, "1/250", value)
+	value, _ = photo.MetadataValue(models.MetadataFNumber)
+	assert.Equal(t, "1.6", value)
+	value, _ = photo.MetadataValue(models.MetadataCameraModel)
+	assert.Equal(t, "iPhone 13", value)
+
+	assert.Equal(t, "https://lh3.googleusercontent.com/abc=d", item.DownloadURL())
+	item.MimeType = "video/mp4"
+	assert.Equal(t, "https://lh3.googleusercontent.com/abc=dv", item.DownloadURL())
+}
@@ -0,0 +1,187 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAuthURL адрес страницы согласия Google OAuth 2.0
+	DefaultAuthURL = "https://accounts.google.com/o/oauth2/v2/auth"
+	// DefaultTokenURL адрес получения и обновления токенов Google OAuth 2.0
+	DefaultTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// DefaultScopes права доступа к библиотеке: чтение для импорта и добавление для загрузки
+var DefaultScopes = []string{
+	"https://www.googleapis.com/auth/photoslibrary.readonly",
+	"https://www.googleapis.com/auth/photoslibrary.appendonly",
+}
+
+// ErrNotConnected возвращается, если аккаунт Google еще не подключен
+var ErrNotConnected = errors.New("аккаунт Google не подключен")
+
+// Token токен доступа OAuth 2.0
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Valid сообщает, можно ли использовать токен доступа
+func (t *Token) Valid(now time.Time) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || now.Add(time.Minute).Before(t.Expiry))
+}
+
+// TokenStore хранит токен подключенного аккаунта между перезапусками
+type TokenStore interface {
+	// Load возвращает сохраненный токен или ErrNotConnected
+	Load() (*Token, error)
+	// Save сохраняет токен
+	Save(token *Token) error
+}
+
+// FileTokenStore хранит токен в JSON-файле, доступном только владельцу
+type FileTokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileTokenStore создает хранилище токена в файле path
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+func (s *FileTokenStore) Load() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotConnected
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения токена Google: %w", err)
+	}
+
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("ошибка разбора токена Google: %w", err)
+	}
+	return &token, nil
+}
+
+// Save записывает токен во временный файл и переименовывает его, чтобы не повредить сохраненный
+func (s *FileTokenStore) Save(token *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// OAuthConfig параметры OAuth-клиента Google
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	AuthURL      string   // По умолчанию DefaultAuthURL
+	TokenURL     string   // По умолчанию DefaultTokenURL
+	Scopes       []string // По умолчанию DefaultScopes
+}
+
+func (c OAuthConfig) withDefaults() OAuthConfig {
+	if c.AuthURL == "" {
+		c.AuthURL = DefaultAuthURL
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = DefaultTokenURL
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = DefaultScopes
+	}
+	return c
+}
+
+// AuthCodeURL возвращает адрес страницы согласия с офлайн-доступом
+func (c OAuthConfig) AuthCodeURL(state string) string {
+	query := url.Values{
+		"client_id":     {c.ClientID},
+		"redirect_uri":  {c.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {strings.Join(c.Scopes, " ")},
+		"state":         {state},
+		"access_type":   {"offline"},
+		"prompt":        {"consent"},
+	}
+	return c.AuthURL + "?" + query.Encode()
+}
+
+// tokenResponse ответ сервера авторизации
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestToken выполняет запрос к серверу авторизации с параметрами params
+func (c OAuthConfig) requestToken(ctx context.Context, client *http.Client, params url.Values, now time.Time) (*Token, error) {
+	params.Set("client_id", c.ClientID)
+	params.Set("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса токена Google: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("некорректный ответ сервера авторизации Google: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return nil, fmt.Errorf("ошибка авторизации Google: %s %s", result.Error, result.ErrorDescription)
+	}
+
+	token := &Token{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		TokenType:    result.TokenType,
+	}
+	if result.ExpiresIn > 0 {
+		token.Expiry = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
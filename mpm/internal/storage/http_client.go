@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// RequestTimeout ограничивает запрос к облачному хранилищу, кроме потокового чтения файлов
+const RequestTimeout = 10 * time.Minute
+
+// NewHTTPClient создает клиент HTTP для облачных хранилищ без общего таймаута
+func NewHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.ResponseHeaderTimeout = 2 * time.Minute
+	return &http.Client{Transport: transport}
+}
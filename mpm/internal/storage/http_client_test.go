@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	client := NewHTTPClient()
+	assert.Zero(t, client.Timeout, "общий таймаут обрывал бы чтение больших файлов")
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.NotNil(t, transport.DialContext)
+	assert.Positive(t, transport.TLSHandshakeTimeout)
+	assert.Positive(t, transport.ResponseHeaderTimeout)
+	assert.NotSame(t, http.DefaultTransport, transport)
+}
@@ -1,16 +1,43 @@
 package storage
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// objectsDir каталог для файлов в режиме адресации по содержимому
+	objectsDir = "objects"
+	// refsFile файл со счетчиками ссылок на объекты
+	refsFile = "refs.json"
 )
 
 // LocalStorage реализует работу с локальной файловой системой
 type LocalStorage struct {
 	BasePath string // Базовый путь для хранения файлов
 	BaseURL  string // Базовый URL для доступа к файлам
+
+	// Режим адресации по содержимому: файлы хранятся по SHA-256 и учитываются счетчиком ссылок
+	contentAddressed bool
+	mu               sync.Mutex
+	refs             map[string]int
+
+	// Подпись ссылок, выдаваемых GetPublicURL
+	signer *URLSigner
+	urlTTL time.Duration
 }
 
 func NewLocalStorage(basePath, baseURL string) *LocalStorage {
@@ -25,7 +52,36 @@ func NewLocalStorage(basePath, baseURL string) *LocalStorage {
 	}
 }
 
+// NewContentAddressedStorage создает локальное хранилище с адресацией по содержимому
+func NewContentAddressedStorage(basePath, baseURL string) (*LocalStorage, error) {
+	ls := NewLocalStorage(basePath, baseURL)
+	ls.contentAddressed = true
+	ls.refs = make(map[string]int)
+
+	data, err := os.ReadFile(filepath.Join(basePath, refsFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения счетчиков ссылок: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &ls.refs); err != nil {
+			return nil, fmt.Errorf("ошибка разбора счетчиков ссылок: %w", err)
+		}
+	}
+
+	return ls, nil
+}
+
+// ContentAddressed сообщает, включен ли режим адресации по содержимому
+func (ls *LocalStorage) ContentAddressed() bool {
+	return ls.contentAddressed
+}
+
+// Save сохраняет файл, в режиме адресации по содержимому - под путем из SHA-256
 func (ls *LocalStorage) Save(file multipart.File, filename string) (string, error) {
+	if ls.contentAddressed {
+		return ls.saveObject(file)
+	}
+
 	// Создаем полный путь для сохранения файла
 	fullPath := filepath.Join(ls.BasePath, filename)
 
@@ -61,10 +117,229 @@ func (ls *LocalStorage) GetReader(path string) (io.ReadCloser, error) {
 	return os.Open(filepath.Join(ls.BasePath, path))
 }
 
+// Delete удаляет файл, в режиме адресации по содержимому - после удаления последней ссылки
 func (ls *LocalStorage) Delete(path string) error {
+	if ls.contentAddressed {
+		if hash, ok := objectHash(path); ok {
+			return ls.releaseObject(path, hash)
+		}
+	}
 	return os.Remove(filepath.Join(ls.BasePath, path))
 }
 
+// GetPublicURL возвращает ссылку на файл, подписанную, если задан ключ подписи
 func (ls *LocalStorage) GetPublicURL(path string) string {
+	if ls.signer != nil {
+		signed, _ := ls.signer.Sign(path, "", ls.urlTTL)
+		return signed
+	}
 	return ls.BaseURL + "/" + path
 }
+
+// SetURLSigner включает подпись ссылок. ttl - срок действия ссылок из GetPublicURL.
+func (ls *LocalStorage) SetURLSigner(signer *URLSigner, ttl time.Duration) {
+	ls.signer = signer
+	ls.urlTTL = ttl
+}
+
+// GetSignedURL возвращает подписанную ссылку на файл с указанной областью действия
+func (ls *LocalStorage) GetSignedURL(path, scope string, ttl time.Duration) (string, time.Time, error) {
+	if ls.signer == nil {
+		return "", time.Time{}, ErrSignedURLsDisabled
+	}
+	signed, expires := ls.signer.Sign(path, scope, ttl)
+	return signed, expires, nil
+}
+
+// List перечисляет файлы хранилища, кроме карантина и служебных файлов режима адресации по содержимому
+func (ls *LocalStorage) List(ctx context.Context, fn func(BlobInfo) error) error {
+	return filepath.WalkDir(ls.BasePath, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(ls.BasePath, fullPath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel+"/" == QuarantinePrefix || (ls.contentAddressed && rel == "tmp") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || (ls.contentAddressed && strings.HasPrefix(rel, refsFile)) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		return fn(BlobInfo{Path: rel, Size: info.Size(), ModTime: info.ModTime()})
+	})
+}
+
+// Move переименовывает файл
+func (ls *LocalStorage) Move(from, to string) error {
+	target := filepath.Join(ls.BasePath, to)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := os.Rename(filepath.Join(ls.BasePath, from), target); err != nil {
+		return err
+	}
+	if hash, ok := objectHash(from); ok && ls.contentAddressed {
+		delete(ls.refs, hash)
+		return ls.saveRefs()
+	}
+	return nil
+}
+
+// RefCount возвращает количество ссылок на объект
+func (ls *LocalStorage) RefCount(path string) int {
+	hash, ok := objectHash(path)
+	if !ok {
+		return 0
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.refs[hash]
+}
+
+// saveObject сохраняет файл под именем, равным SHA-256 его содержимого
+func (ls *LocalStorage) saveObject(file multipart.File) (string, error) {
+	tmpDir := filepath.Join(ls.BasePath, "tmp")
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return "", err
+	}
+
+	// Пишем во временный файл, одновременно вычисляя хеш
+	tmp, err := os.CreateTemp(tmpDir, "upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hasher), file); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	key := objectKey(hash)
+	fullPath := filepath.Join(ls.BasePath, key)
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if _, err := os.Stat(fullPath); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return "", err
+		}
+		if err := os.Rename(tmp.Name(), fullPath); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	ls.refs[hash]++
+	if err := ls.saveRefs(); err != nil {
+		ls.refs[hash]--
+		return "", err
+	}
+
+	return key, nil
+}
+
+// releaseObject уменьшает счетчик ссылок и удаляет объект, если ссылок не осталось
+func (ls *LocalStorage) releaseObject(path, hash string) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if count := ls.refs[hash]; count > 1 {
+		ls.refs[hash] = count - 1
+		return ls.saveRefs()
+	}
+
+	// Объект без счетчика считаем единственной ссылкой
+	delete(ls.refs, hash)
+	if err := ls.saveRefs(); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(ls.BasePath, path))
+}
+
+// saveRefs атомарно сохраняет счетчики ссылок. Вызывается под ls.mu.
+func (ls *LocalStorage) saveRefs() error {
+	data, err := json.Marshal(ls.refs)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(ls.BasePath, refsFile)
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("ошибка сохранения счетчиков ссылок: %w", err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return fmt.Errorf("ошибка сохранения счетчиков ссылок: %w", err)
+	}
+	return nil
+}
+
+// objectKey возвращает путь объекта в шардированной структуре каталогов
+func objectKey(hash string) string {
+	return path.Join(objectsDir, hash[:2], hash[2:4], hash)
+}
+
+// objectHash извлекает хеш из пути объекта
+func objectHash(key string) (string, bool) {
+	key = filepath.ToSlash(key)
+	if !strings.HasPrefix(key, objectsDir+"/") {
+		return "", false
+	}
+
+	hash := path.Base(key)
+	if len(hash) != sha256.Size*2 || objectKey(hash) != key {
+		return "", false
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+	return hash, true
+}
+
+// Checksum вычисляет SHA-256 содержимого и возвращает позицию чтения в начало
+func Checksum(file io.ReadSeeker) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
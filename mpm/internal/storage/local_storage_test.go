@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentAddressedStorage(t *testing.T) {
+	dir := t.TempDir()
+	ls, err := NewContentAddressedStorage(dir, "/files")
+	require.NoError(t, err)
+
+	content := []byte("одинаковое содержимое")
+
+	first, err := ls.Save(NewBytesFile(content), "albums/1/a.jpg")
+	require.NoError(t, err)
+	second, err := ls.Save(NewBytesFile(content), "albums/2/b.jpg")
+	require.NoError(t, err)
+	other, err := ls.Save(NewBytesFile([]byte("другой файл")), "albums/1/c.jpg")
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second, "одинаковые файлы должны храниться один раз")
+	assert.NotEqual(t, first, other)
+	assert.True(t, strings.HasPrefix(first, "objects/"))
+	assert.Equal(t, 2, ls.RefCount(first))
+
+	data, err := ls.Get(first)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	// Первое удаление только уменьшает счетчик
+	require.NoError(t, ls.Delete(first))
+	assert.Equal(t, 1, ls.RefCount(first))
+	_, err = os.Stat(filepath.Join(dir, first))
+	assert.NoError(t, err)
+
+	// Счетчики переживают перезапуск
+	reopened, err := NewContentAddressedStorage(dir, "/files")
+	require.NoError(t, err)
+	assert.Equal(t, 1, reopened.RefCount(first))
+
+	require.NoError(t, reopened.Delete(first))
+	_, err = os.Stat(filepath.Join(dir, first))
+	assert.True(t, os.IsNotExist(err), "объект удаляется вместе с последней ссылкой")
+	assert.Equal(t, 0, reopened.RefCount(first))
+
+	tmp, err := os.ReadDir(filepath.Join(dir, "tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmp, "временные файлы не должны оставаться")
+}
+
+func TestContentAddressedStorage_LegacyFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	// Файл, сохраненный до включения дедупликации
+	plain := NewLocalStorage(dir, "/files")
+	legacy, err := plain.Save(NewBytesFile([]byte("старый файл")), "albums/1/old.jpg")
+	require.NoError(t, err)
+
+	ls, err := NewContentAddressedStorage(dir, "/files")
+	require.NoError(t, err)
+	require.NoError(t, ls.Delete(legacy))
+
+	_, err = os.Stat(filepath.Join(dir, legacy))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalStorage_ListAndMove(t *testing.T) {
+	listPaths := func(t *testing.T, ls *LocalStorage) []string {
+		t.Helper()
+		var paths []string
+		require.NoError(t, ls.List(context.Background(), func(blob BlobInfo) error {
+			paths = append(paths, blob.Path)
+			return nil
+		}))
+		return paths
+	}
+
+	t.Run("Обычный режим", func(t *testing.T) {
+		ls := NewLocalStorage(t.TempDir(), "/files")
+		for _, name := range []string{"albums/1/a.jpg", "albums/2/b.jpg"} {
+			_, err := ls.Save(NewBytesFile([]byte(name)), name)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"albums/1/a.jpg", "albums/2/b.jpg"}, listPaths(t, ls))
+
+		require.NoError(t, ls.Move("albums/2/b.jpg", QuarantinePrefix+"albums/2/b.jpg"))
+		assert.Equal(t, []string{"albums/1/a.jpg"}, listPaths(t, ls), "карантин не перечисляется")
+		data, err := ls.Get(QuarantinePrefix + "albums/2/b.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("albums/2/b.jpg"), data)
+	})
+
+	t.Run("Адресация по содержимому", func(t *testing.T) {
+		ls, err := NewContentAddressedStorage(t.TempDir(), "/files")
+		require.NoError(t, err)
+		key, err := ls.Save(NewBytesFile([]byte("объект")), "albums/1/a.jpg")
+		require.NoError(t, err)
+		assert.Equal(t, []string{key}, listPaths(t, ls), "refs.json не перечисляется")
+
+		require.NoError(t, ls.Move(key, QuarantinePrefix+key))
+		assert.Equal(t, 0, ls.RefCount(key))
+		assert.Empty(t, listPaths(t, ls))
+	})
+}
+
+func TestChecksum(t *testing.T) {
+	file := NewBytesFile([]byte("abc"))
+	_, _ = file.Read(make([]byte, 2))
+
+	sum, err := Checksum(file)
+	require.NoError(t, err)
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", sum)
+
+	rest := make([]byte, 3)
+	n, _ := file.Read(rest)
+	assert.Equal(t, 3, n, "позиция чтения возвращается в начало")
+}
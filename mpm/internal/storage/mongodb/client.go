@@ -162,6 +162,36 @@ func (c *Client) ensureIndexes() error {
 		return fmt.Errorf("failed to create tags indexes: %w", err)
 	}
 
+	// Индексы для коллекции photos
+	photosCol := c.GetPhotosCollection()
+	photoIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "photo_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "album_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "checksum", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "phash", Value: 1}},
+		},
+		{
+			// Multikey-индекс по полосам перцептивного хеша для поиска похожих фотографий
+			Keys: bson.D{{Key: "phash_bands", Value: 1}},
+		},
+		{
+			// Сферический индекс координат съемки для поиска фотографий на карте
+			Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+		},
+	}
+
+	if _, err := photosCol.Indexes().CreateMany(ctx, photoIndexes); err != nil {
+		return fmt.Errorf("failed to create photos indexes: %w", err)
+	}
+
 	log.Println("MongoDB индексы успешно созданы")
 	return nil
 }
@@ -0,0 +1,129 @@
+package mongodb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mpm/internal/models"
+	"mpm/internal/similarity"
+)
+
+// PhotoDocument представляет структуру фотографии в MongoDB
+type PhotoDocument struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	PhotoID     int                `bson:"photo_id"` // Идентификатор фотографии в приложении
+	Name        string             `bson:"name"`
+	Path        string             `bson:"path"`
+	AlbumID     *int               `bson:"album_id,omitempty"`
+	UserID      *int               `bson:"user_id,omitempty"`
+	Tags        []string           `bson:"tags,omitempty"`
+	Metadata    []models.Metadata  `bson:"metadata,omitempty"`
+	StorageType string             `bson:"storage_type"`
+	MimeType    string             `bson:"mime_type,omitempty"`
+	Size        int64              `bson:"size,omitempty"`
+	Checksum    string             `bson:"checksum,omitempty"`
+	Width       int                `bson:"width,omitempty"`
+	Height      int                `bson:"height,omitempty"`
+	Renditions  []models.Rendition `bson:"renditions,omitempty"`
+	TakenAt     *time.Time         `bson:"taken_at,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at"`
+	DeletedAt   *time.Time         `bson:"deleted_at,omitempty"`
+	MediaType   string             `bson:"media_type,omitempty"`
+	Video       *models.VideoInfo  `bson:"video,omitempty"`
+	Motion      *models.MotionClip `bson:"motion,omitempty"`
+
+	// Перцептивный хеш и ключи его полос для индекса поиска похожих фотографий
+	PHash      string   `bson:"phash,omitempty"`
+	PHashBands []string `bson:"phash_bands,omitempty"`
+
+	// Координаты съемки в формате GeoJSON для индекса 2dsphere
+	Location *GeoPoint `bson:"location,omitempty"`
+}
+
+// GeoPoint точка GeoJSON. Координаты указываются в порядке долгота, широта.
+type GeoPoint struct {
+	Type        string    `bson:"type"`
+	Coordinates []float64 `bson:"coordinates"`
+}
+
+// ToModel преобразует PhotoDocument в models.Photo
+func (pd *PhotoDocument) ToModel() *models.Photo {
+	photo := &models.Photo{
+		ID:          pd.PhotoID,
+		Name:        pd.Name,
+		Path:        pd.Path,
+		Tags:        pd.Tags,
+		Metadata:    pd.Metadata,
+		StorageType: pd.StorageType,
+		MimeType:    pd.MimeType,
+		Size:        pd.Size,
+		Checksum:    pd.Checksum,
+		PHash:       pd.PHash,
+		Width:       pd.Width,
+		Height:      pd.Height,
+		Renditions:  pd.Renditions,
+		TakenAt:     pd.TakenAt,
+		CreatedAt:   pd.CreatedAt,
+		DeletedAt:   pd.DeletedAt,
+		MediaType:   pd.MediaType,
+		Video:       pd.Video,
+		Motion:      pd.Motion,
+	}
+
+	if pd.AlbumID != nil {
+		photo.Album = &models.Album{ID: *pd.AlbumID}
+	}
+	if pd.UserID != nil {
+		photo.User = &models.User{ID: *pd.UserID}
+	}
+
+	return photo
+}
+
+// PhotoDocumentFromModel создает PhotoDocument из models.Photo
+func PhotoDocumentFromModel(photo *models.Photo) *PhotoDocument {
+	doc := &PhotoDocument{
+		PhotoID:     photo.ID,
+		Name:        photo.Name,
+		Path:        photo.Path,
+		Tags:        photo.Tags,
+		Metadata:    photo.Metadata,
+		StorageType: photo.StorageType,
+		MimeType:    photo.MimeType,
+		Size:        photo.Size,
+		Checksum:    photo.Checksum,
+		PHash:       photo.PHash,
+		Width:       photo.Width,
+		Height:      photo.Height,
+		Renditions:  photo.Renditions,
+		TakenAt:     photo.TakenAt,
+		CreatedAt:   photo.CreatedAt,
+		UpdatedAt:   time.Now(),
+		DeletedAt:   photo.DeletedAt,
+		MediaType:   photo.MediaType,
+		Video:       photo.Video,
+		Motion:      photo.Motion,
+	}
+
+	if photo.Album != nil {
+		albumID := photo.Album.ID
+		doc.AlbumID = &albumID
+	}
+	if photo.User != nil {
+		userID := photo.User.ID
+		doc.UserID = &userID
+	}
+
+	if lat, lon, ok := photo.Location(); ok {
+		doc.Location = &GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}}
+	}
+
+	if photo.PHash != "" {
+		if hash, err := similarity.ParseHash(photo.PHash); err == nil {
+			doc.PHashBands = similarity.BandKeys(hash, PHashIndexThreshold)
+		}
+	}
+
+	return doc
+}
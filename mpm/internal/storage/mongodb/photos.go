@@ -0,0 +1,238 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"mpm/internal/geo"
+	"mpm/internal/models"
+	"mpm/internal/similarity"
+)
+
+// PHashIndexThreshold порог, для которого строятся ключи полос; больший порог ищется перебором
+const PHashIndexThreshold = similarity.DefaultThreshold
+
+// PhotoStorage реализация хранилища фотографий для MongoDB
+type PhotoStorage struct {
+	client     *Client
+	collection *mongo.Collection
+}
+
+// NewPhotoStorage создает новое хранилище фотографий
+func NewPhotoStorage(client *Client) *PhotoStorage {
+	return &PhotoStorage{
+		client:     client,
+		collection: client.GetPhotosCollection(),
+	}
+}
+
+// Save создает или обновляет фотографию по ее идентификатору в приложении
+func (s *PhotoStorage) Save(ctx context.Context, photo *models.Photo) error {
+	doc := PhotoDocumentFromModel(photo)
+
+	_, err := s.collection.ReplaceOne(ctx,
+		bson.M{"photo_id": photo.ID},
+		doc,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save photo: %w", err)
+	}
+	return nil
+}
+
+// Delete удаляет фотографию по ее идентификатору в приложении
+func (s *PhotoStorage) Delete(ctx context.Context, photoID int) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"photo_id": photoID})
+	if err != nil {
+		return fmt.Errorf("failed to delete photo: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("photo not found")
+	}
+	return nil
+}
+
+// List возвращает все фотографии вместе с находящимися в корзине
+func (s *PhotoStorage) List(ctx context.Context) ([]models.Photo, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "photo_id", Value: 1}})
+
+	cursor, err := s.collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find photos: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	photos := []models.Photo{}
+	for cursor.Next(ctx) {
+		var doc PhotoDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode photo: %w", err)
+		}
+		photos = append(photos, *doc.ToModel())
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return photos, nil
+}
+
+// FindSimilar находит фотографии с похожим перцептивным хешем, отбирая кандидатов по индексу полос
+func (s *PhotoStorage) FindSimilar(ctx context.Context, hash uint64, threshold, excludeID int) ([]similarity.Match, error) {
+	entries, err := s.phashEntries(ctx, similarFilter(hash, threshold))
+	if err != nil {
+		return nil, err
+	}
+	return similarity.Similar(entries, hash, threshold, excludeID), nil
+}
+
+// Clusters группирует визуально похожие фотографии
+func (s *PhotoStorage) Clusters(ctx context.Context, threshold int) ([][]int, error) {
+	entries, err := s.phashEntries(ctx, phashFilter())
+	if err != nil {
+		return nil, err
+	}
+	return similarity.Clusters(entries, threshold), nil
+}
+
+// phashFilter отбирает фотографии с перцептивным хешем, кроме находящихся в корзине
+func phashFilter() bson.M {
+	return bson.M{
+		"phash":      bson.M{"$exists": true, "$ne": ""},
+		"deleted_at": bson.M{"$exists": false},
+	}
+}
+
+// similarFilter отбирает кандидатов по индексу phash_bands, если порог не больше PHashIndexThreshold
+func similarFilter(hash uint64, threshold int) bson.M {
+	if threshold > PHashIndexThreshold {
+		return phashFilter()
+	}
+	return bson.M{
+		"phash_bands": bson.M{"$in": similarity.BandKeys(hash, PHashIndexThreshold)},
+		"deleted_at":  bson.M{"$exists": false},
+	}
+}
+
+// maxIndexedBox наибольший размер области в градусах для индекса 2dsphere: многоугольник должен быть меньше полушария
+const maxIndexedBox = 90
+
+// FindInBox возвращает фотографии внутри области, кроме находящихся в корзине, отбирая кандидатов по индексу 2dsphere
+func (s *PhotoStorage) FindInBox(ctx context.Context, bbox geo.BBox) ([]models.Photo, error) {
+	cursor, err := s.collection.Find(ctx, boxFilter(bbox))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find photos: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	photos := []models.Photo{}
+	for cursor.Next(ctx) {
+		var doc PhotoDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode photo: %w", err)
+		}
+		if doc.Location == nil || len(doc.Location.Coordinates) != 2 {
+			continue
+		}
+
+		// Стороны многоугольника в 2dsphere - дуги большого круга, а не параллели,
+		// поэтому попадание в область проверяется еще раз
+		lon, lat := doc.Location.Coordinates[0], doc.Location.Coordinates[1]
+		if bbox.Contains(lat, lon) {
+			photos = append(photos, *doc.ToModel())
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return photos, nil
+}
+
+// boxFilter отбирает фотографии внутри области через $geoWithin, деля область на 180-м меридиане
+func boxFilter(bbox geo.BBox) bson.M {
+	notTrashed := bson.M{"$exists": false}
+	if bbox.Width() > maxIndexedBox || bbox.MaxLat-bbox.MinLat > maxIndexedBox {
+		return bson.M{"location": bson.M{"$exists": true}, "deleted_at": notTrashed}
+	}
+
+	var polygons []bson.M
+	for _, part := range bbox.Split() {
+		polygons = append(polygons, bson.M{"location": bson.M{"$geoWithin": bson.M{"$geometry": boxPolygon(part)}}})
+	}
+	return bson.M{"$or": polygons, "deleted_at": notTrashed}
+}
+
+// boxPolygon строит многоугольник GeoJSON для области, стороны которого разбиты на отрезки не длиннее градуса
+func boxPolygon(bbox geo.BBox) bson.M {
+	const step = 1.0
+	var ring [][]float64
+
+	// Вырожденный многоугольник MongoDB отклоняет, поэтому область-точка немного расширяется
+	const pad = 1e-6
+	if bbox.MaxLon-bbox.MinLon < pad {
+		bbox.MinLon, bbox.MaxLon = max(bbox.MinLon-pad, -180), min(bbox.MaxLon+pad, 180)
+	}
+	if bbox.MaxLat-bbox.MinLat < pad {
+		bbox.MinLat, bbox.MaxLat = max(bbox.MinLat-pad, -90), min(bbox.MaxLat+pad, 90)
+	}
+
+	edge := func(fromLon, fromLat, toLon, toLat float64) {
+		n := int(max(math.Abs(toLon-fromLon), math.Abs(toLat-fromLat))/step) + 1
+		for i := 0; i < n; i++ {
+			t := float64(i) / float64(n)
+			ring = append(ring, []float64{fromLon + (toLon-fromLon)*t, fromLat + (toLat-fromLat)*t})
+		}
+	}
+	edge(bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MinLat)
+	edge(bbox.MaxLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat)
+	edge(bbox.MaxLon, bbox.MaxLat, bbox.MinLon, bbox.MaxLat)
+	edge(bbox.MinLon, bbox.MaxLat, bbox.MinLon, bbox.MinLat)
+	ring = append(ring, ring[0])
+
+	return bson.M{"type": "Polygon", "coordinates": [][][]float64{ring}}
+}
+
+// phashEntries загружает идентификаторы и перцептивные хеши фотографий
+func (s *PhotoStorage) phashEntries(ctx context.Context, filter bson.M) ([]similarity.Entry, error) {
+	findOptions := options.Find().SetProjection(bson.M{"photo_id": 1, "phash": 1})
+
+	cursor, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find photos: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var entries []similarity.Entry
+	for cursor.Next(ctx) {
+		var doc PhotoDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode photo: %w", err)
+		}
+		hash, err := similarity.ParseHash(doc.PHash)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, similarity.Entry{ID: doc.PhotoID, Hash: hash})
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,190 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"mpm/internal/geo"
+	"mpm/internal/models"
+	"mpm/internal/similarity"
+)
+
+func TestSimilarFilter(t *testing.T) {
+	hash := uint64(0x0123456789ABCDEF)
+	notTrashed := bson.M{"$exists": false}
+
+	t.Run("Кандидаты отбираются по полосам хеша", func(t *testing.T) {
+		filter := similarFilter(hash, similarity.DefaultThreshold)
+
+		assert.Equal(t, notTrashed, filter["deleted_at"])
+		assert.NotContains(t, filter, "phash")
+		bands, ok := filter["phash_bands"].(bson.M)
+		require.True(t, ok)
+		assert.Equal(t, similarity.BandKeys(hash, PHashIndexThreshold), bands["$in"])
+	})
+
+	t.Run("Похожая фотография попадает в кандидаты", func(t *testing.T) {
+		similar := hash ^ (1 << 3) ^ (1 << 40) ^ (1 << 63)
+		doc := PhotoDocumentFromModel(&models.Photo{ID: 2, PHash: similarity.FormatHash(similar)})
+		keys := similarFilter(hash, 3)["phash_bands"].(bson.M)["$in"].([]string)
+
+		assert.NotEmpty(t, intersect(doc.PHashBands, keys), "документ должен найтись по индексу phash_bands")
+	})
+
+	t.Run("Большой порог просматривает все хеши", func(t *testing.T) {
+		filter := similarFilter(hash, PHashIndexThreshold+1)
+
+		assert.Equal(t, notTrashed, filter["deleted_at"])
+		assert.NotContains(t, filter, "phash_bands")
+		assert.Equal(t, bson.M{"$exists": true, "$ne": ""}, filter["phash"])
+	})
+}
+
+func TestPhotoStorage_FindSimilar(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("Кандидаты из индекса проверяются по расстоянию", func(mt *mtest.T) {
+		s := &PhotoStorage{collection: mt.Coll}
+		hash := uint64(0x0123456789ABCDEF)
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			bson.D{{Key: "photo_id", Value: 1}, {Key: "phash", Value: similarity.FormatHash(hash)}},
+			bson.D{{Key: "photo_id", Value: 2}, {Key: "phash", Value: similarity.FormatHash(hash ^ 0b111)}},
+			bson.D{{Key: "photo_id", Value: 3}, {Key: "phash", Value: similarity.FormatHash(^hash)}},
+		))
+
+		matches, err := s.FindSimilar(context.Background(), hash, similarity.DefaultThreshold, 1)
+		require.NoError(mt, err)
+
+		require.Len(mt, matches, 1)
+		assert.Equal(mt, 2, matches[0].ID)
+		assert.Equal(mt, 3, matches[0].Distance)
+
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		_, err = filter.LookupErr("phash_bands", "$in")
+		assert.NoError(mt, err, "кандидаты должны отбираться по индексу phash_bands")
+	})
+}
+
+func TestPhotoDocumentFromModel(t *testing.T) {
+	photo := &models.Photo{ID: 5, Album: &models.Album{ID: 2}, PHash: "0123456789abcdef", MediaType: models.MediaTypePhoto}
+
+	doc := PhotoDocumentFromModel(photo)
+	assert.Len(t, doc.PHashBands, PHashIndexThreshold+1)
+
+	restored := doc.ToModel()
+	assert.Equal(t, photo.ID, restored.ID)
+	assert.Equal(t, photo.PHash, restored.PHash)
+	assert.Equal(t, photo.MediaType, restored.MediaType)
+	require.NotNil(t, restored.Album)
+	assert.Equal(t, 2, restored.Album.ID)
+
+	assert.Empty(t, PhotoDocumentFromModel(&models.Photo{ID: 6, PHash: "не хеш"}).PHashBands)
+}
+
+func intersect(a, b []string) []string {
+	set := make(map[string]bool, len(b))
+	for _, v := range b {
+		set[v] = true
+	}
+	var common []string
+	for _, v := range a {
+		if set[v] {
+			common = append(common, v)
+		}
+	}
+	return common
+}
+
+func TestPhotoStorage_FindInBox(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("Кандидаты из индекса проверяются по области", func(mt *mtest.T) {
+		s := &PhotoStorage{collection: mt.Coll}
+		point := func(id int, lon, lat float64) bson.D {
+			return bson.D{
+				{Key: "photo_id", Value: id},
+				{Key: "location", Value: bson.D{{Key: "type", Value: "Point"}, {Key: "coordinates", Value: bson.A{lon, lat}}}},
+			}
+		}
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			point(1, 37.62, 55.75),
+			point(2, 37.62, 56.2),
+		))
+
+		photos, err := s.FindInBox(context.Background(), geo.BBox{MinLon: 37.3, MinLat: 55.5, MaxLon: 37.9, MaxLat: 55.9})
+		require.NoError(mt, err)
+
+		require.Len(mt, photos, 1)
+		assert.Equal(mt, 1, photos[0].ID)
+
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		_, err = filter.LookupErr("$or", "0", "location", "$geoWithin")
+		assert.NoError(mt, err, "кандидаты должны отбираться через $geoWithin")
+	})
+}
+
+func TestBoxFilter(t *testing.T) {
+	notTrashed := bson.M{"$exists": false}
+
+	// polygons возвращает кольца многоугольников из условий $geoWithin
+	polygons := func(t *testing.T, filter bson.M) [][][]float64 {
+		t.Helper()
+		or, ok := filter["$or"].([]bson.M)
+		require.True(t, ok, "ожидается $or из условий $geoWithin")
+
+		var rings [][][]float64
+		for _, cond := range or {
+			within := cond["location"].(bson.M)["$geoWithin"].(bson.M)["$geometry"].(bson.M)
+			assert.Equal(t, "Polygon", within["type"])
+			ring := within["coordinates"].([][][]float64)[0]
+			assert.Equal(t, ring[0], ring[len(ring)-1], "кольцо многоугольника замкнуто")
+			rings = append(rings, ring)
+		}
+		return rings
+	}
+
+	t.Run("Область ищется через $geoWithin", func(t *testing.T) {
+		moscow := geo.BBox{MinLon: 37.3, MinLat: 55.5, MaxLon: 37.9, MaxLat: 55.9}
+		filter := boxFilter(moscow)
+
+		assert.Equal(t, notTrashed, filter["deleted_at"])
+		rings := polygons(t, filter)
+		require.Len(t, rings, 1)
+		for _, point := range rings[0] {
+			lon, lat := point[0], point[1]
+			assert.True(t, moscow.Contains(lat, lon), "координаты в порядке долгота, широта: %v", point)
+		}
+	})
+
+	t.Run("Область через 180-й меридиан делится на две", func(t *testing.T) {
+		pacific := geo.BBox{MinLon: 170, MinLat: -20, MaxLon: -170, MaxLat: 0}
+
+		assert.Len(t, polygons(t, boxFilter(pacific)), 2)
+	})
+
+	t.Run("Большая область просматривается без многоугольника", func(t *testing.T) {
+		filter := boxFilter(geo.World)
+
+		assert.NotContains(t, filter, "$or")
+		assert.Equal(t, bson.M{"$exists": true}, filter["location"])
+		assert.Equal(t, notTrashed, filter["deleted_at"])
+	})
+
+	t.Run("Координаты сохраняются в формате GeoJSON", func(t *testing.T) {
+		doc := PhotoDocumentFromModel(&models.Photo{ID: 1, Metadata: []models.Metadata{
+			{Key: models.MetadataGPSLatitude, Value: "55.75"},
+			{Key: models.MetadataGPSLongitude, Value: "37.62"},
+		}})
+
+		require.NotNil(t, doc.Location)
+		assert.Equal(t, "Point", doc.Location.Type)
+		assert.Equal(t, []float64{37.62, 55.75}, doc.Location.Coordinates)
+	})
+}
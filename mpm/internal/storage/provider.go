@@ -1,10 +1,19 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"io"
 	"mime/multipart"
+	"time"
 )
 
+// QuarantinePrefix каталог в хранилище для файлов, не принадлежащих ни одной фотографии
+const QuarantinePrefix = "quarantine/"
+
+// ErrSignedURLsDisabled возвращается, если хранилище не настроено на выдачу подписанных ссылок
+var ErrSignedURLsDisabled = errors.New("подписанные ссылки не настроены")
+
 // Provider определяет общий интерфейс для работы с разными хранилищами файлов
 type Provider interface {
 	// Save сохраняет файл в хранилище и возвращает путь для доступа к нему
@@ -22,3 +31,25 @@ type Provider interface {
 	// GetPublicURL возвращает публичную ссылку для доступа к файлу
 	GetPublicURL(path string) string
 }
+
+// SignedURLProvider реализуется хранилищами, которые выдают ссылки с ограниченным сроком
+type SignedURLProvider interface {
+	GetSignedURL(path, scope string, ttl time.Duration) (string, time.Time, error)
+}
+
+// BlobInfo описывает файл, сохраненный в хранилище
+type BlobInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Lister реализуется хранилищами, которые умеют перечислять сохраненные файлы
+type Lister interface {
+	List(ctx context.Context, fn func(BlobInfo) error) error
+}
+
+// Mover реализуется хранилищами, которые умеют переносить файл без копирования содержимого
+type Mover interface {
+	Move(from, to string) error
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RangeReader реализуется хранилищами, которые умеют читать часть файла
+type RangeReader interface {
+	GetRange(path string, offset, length int64) (io.ReadCloser, int64, error)
+}
+
+// OpenRange открывает файл через RangeReader и возвращает поток с поддержкой Seek
+func OpenRange(src RangeReader, path string) (io.ReadSeekCloser, error) {
+	body, size, err := src.GetRange(path, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	return &rangeReadSeeker{src: src, path: path, body: body, size: size}, nil
+}
+
+// rangeReadSeeker поток файла, позиционирование в котором выполняется новым запросом части файла
+type rangeReadSeeker struct {
+	src  RangeReader
+	path string
+	body io.ReadCloser // Поток с позиции pos; nil, если его нужно запросить
+	size int64         // Размер файла, -1 если неизвестен
+	pos  int64
+}
+
+func (r *rangeReadSeeker) Read(p []byte) (int, error) {
+	if r.size >= 0 && r.pos >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, _, err := r.src.GetRange(r.path, r.pos, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+func (r *rangeReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		if r.size < 0 {
+			return 0, errors.New("размер файла неизвестен")
+		}
+		offset += r.size
+	default:
+		return 0, errors.New("неверный параметр whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("отрицательное смещение")
+	}
+	if offset != r.pos && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+func (r *rangeReadSeeker) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
+}
+
+// RangeHeader формирует значение заголовка Range, пустое для всего файла
+func RangeHeader(offset, length int64) string {
+	switch {
+	case length > 0:
+		return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	case offset > 0:
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return ""
+}
+
+// RangeResponse возвращает запрошенную часть ответа и полный размер файла
+func RangeResponse(resp *http.Response, offset, length int64) (io.ReadCloser, int64, error) {
+	size := int64(-1)
+	body := io.Reader(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		var start, end int64
+		var total string
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%s", &start, &end, &total); err != nil || start != offset {
+			resp.Body.Close()
+			return nil, 0, fmt.Errorf("некорректный заголовок Content-Range: %q", resp.Header.Get("Content-Range"))
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64); err == nil {
+			size = n
+		}
+	default:
+		size = resp.ContentLength
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil && err != io.EOF {
+				resp.Body.Close()
+				return nil, 0, err
+			}
+		}
+	}
+
+	if length >= 0 {
+		body = io.LimitReader(body, length)
+	}
+	return readCloser{body, resp.Body}, size, nil
+}
@@ -0,0 +1,295 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 минимальный S3-совместимый сервер в памяти с проверкой подписи запросов.
+// Поддерживает адресацию path-style, объекты, составную загрузку и ListObjectsV2.
+type fakeS3 struct {
+	t      *testing.T
+	creds  credentials
+	server *httptest.Server
+
+	mu       sync.Mutex
+	buckets  map[string]bool
+	objects  map[string]fakeObject
+	uploads  map[string]map[int][]byte
+	requests []string
+	failPart int // Номер части, загрузка которой завершится ошибкой
+	pageSize int // Количество объектов на странице списка, по умолчанию 1000
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+func newFakeS3(t *testing.T, bucket string) *fakeS3 {
+	t.Helper()
+
+	f := &fakeS3{
+		t:       t,
+		creds:   credentials{accessKey: "test-access", secretKey: "test-secret", region: "us-east-1"},
+		buckets: map[string]bool{},
+		objects: map[string]fakeObject{},
+		uploads: map[string]map[int][]byte{},
+	}
+	if bucket != "" {
+		f.buckets[bucket] = true
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeS3) config(bucket string) Config {
+	return Config{
+		Endpoint:     f.server.URL,
+		Region:       f.creds.region,
+		Bucket:       bucket,
+		AccessKey:    f.creds.accessKey,
+		SecretKey:    f.creds.secretKey,
+		UsePathStyle: true,
+	}
+}
+
+func (f *fakeS3) object(key string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[key]
+	return obj, ok
+}
+
+func (f *fakeS3) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if code := f.verify(r, body); code != "" {
+		writeFakeError(w, http.StatusForbidden, code)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	f.requests = append(f.requests, r.Method+" "+key+" "+canonicalQuery(url.Values{
+		"uploads": query["uploads"], "partNumber": query["partNumber"],
+	}))
+
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if !f.buckets[bucket] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			f.buckets[bucket] = true
+		case http.MethodGet:
+			f.listObjects(w, bucket, query)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if !f.buckets[bucket] {
+		writeFakeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	objectKey := bucket + "/" + key
+	uploadID := query.Get("uploadId")
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		id := strconv.Itoa(len(f.uploads) + 1)
+		f.uploads[id] = map[int][]byte{}
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, id)
+
+	case r.Method == http.MethodPut && uploadID != "":
+		parts, ok := f.uploads[uploadID]
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if !ok || number < 1 {
+			writeFakeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		if number == f.failPart {
+			writeFakeError(w, http.StatusInternalServerError, "InternalError")
+			return
+		}
+		parts[number] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+
+	case r.Method == http.MethodPost && uploadID != "":
+		parts, ok := f.uploads[uploadID]
+		if !ok {
+			writeFakeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		var complete struct {
+			Parts []completedPart `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil || len(complete.Parts) == 0 {
+			writeFakeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var data []byte
+		for i, part := range complete.Parts {
+			chunk, ok := parts[part.PartNumber]
+			if !ok || part.PartNumber != i+1 || part.ETag != fmt.Sprintf(`"etag-%d"`, part.PartNumber) {
+				writeFakeError(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			if i < len(complete.Parts)-1 && len(chunk) < MinPartSize {
+				writeFakeError(w, http.StatusBadRequest, "EntityTooSmall")
+				return
+			}
+			data = append(data, chunk...)
+		}
+		f.objects[objectKey] = fakeObject{data: data, contentType: "application/octet-stream", modified: time.Now()}
+		delete(f.uploads, uploadID)
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Key>`+key+`</Key></CompleteMultipartUploadResult>`)
+
+	case r.Method == http.MethodDelete && uploadID != "":
+		delete(f.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		f.objects[objectKey] = fakeObject{data: body, contentType: r.Header.Get("Content-Type"), modified: time.Now()}
+
+	case r.Method == http.MethodGet:
+		obj, ok := f.objects[objectKey]
+		if !ok {
+			writeFakeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		http.ServeContent(w, r, key, obj.modified, bytes.NewReader(obj.data))
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, objectKey)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// listObjects отвечает на ListObjectsV2. Маркер продолжения - последний ключ предыдущей страницы.
+func (f *fakeS3) listObjects(w http.ResponseWriter, bucket string, query url.Values) {
+	if !f.buckets[bucket] {
+		writeFakeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if query.Get("list-type") != "2" {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	var keys []string
+	for objectKey := range f.objects {
+		key, ok := strings.CutPrefix(objectKey, bucket+"/")
+		if ok && strings.HasPrefix(key, query.Get("prefix")) && key > query.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pageSize := f.pageSize
+	if pageSize == 0 {
+		pageSize = 1000
+	}
+	var buf bytes.Buffer
+	buf.WriteString("<ListBucketResult>")
+	if len(keys) > pageSize {
+		keys = keys[:pageSize]
+		fmt.Fprintf(&buf, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", keys[len(keys)-1])
+	}
+	for _, key := range keys {
+		obj := f.objects[bucket+"/"+key]
+		buf.WriteString("<Contents><Key>")
+		xml.EscapeText(&buf, []byte(key))
+		fmt.Fprintf(&buf, "</Key><Size>%d</Size><LastModified>%s</LastModified></Contents>",
+			len(obj.data), obj.modified.UTC().Format(time.RFC3339))
+	}
+	buf.WriteString("</ListBucketResult>")
+	w.Write(buf.Bytes())
+}
+
+// verify проверяет подпись из заголовка Authorization или из параметров подписанной ссылки.
+// Возвращает код ошибки S3 или пустую строку.
+func (f *fakeS3) verify(r *http.Request, body []byte) string {
+	query := r.URL.Query()
+	if signature := query.Get("X-Amz-Signature"); signature != "" {
+		date, err := time.Parse(amzDateFormat, query.Get("X-Amz-Date"))
+		if err != nil {
+			return "AuthorizationQueryParametersError"
+		}
+		expires, _ := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if time.Now().After(date.Add(time.Duration(expires) * time.Second)) {
+			return "AccessDenied"
+		}
+
+		query.Del("X-Amz-Signature")
+		unsigned := r.Clone(r.Context())
+		unsigned.URL.RawQuery = canonicalQuery(query)
+		canonical := canonicalRequest(unsigned, strings.Split(query.Get("X-Amz-SignedHeaders"), ";"), unsignedPayload)
+		expected := f.creds.signature(date, stringToSign(query.Get("X-Amz-Date"), f.creds.scope(date), canonical))
+		if !hmac.Equal([]byte(expected), []byte(signature)) {
+			return "SignatureDoesNotMatch"
+		}
+		return ""
+	}
+
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), signAlgorithm+" ")
+	fields := map[string]string{}
+	for _, field := range strings.Split(auth, ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		fields[name] = value
+	}
+	if fields["Credential"] != f.creds.accessKey+"/"+f.creds.scope(time.Now()) {
+		return "InvalidAccessKeyId"
+	}
+
+	hash := r.Header.Get("X-Amz-Content-Sha256")
+	if hash != unsignedPayload && hash != payloadHash(body) {
+		return "XAmzContentSHA256Mismatch"
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(signedHeaders) {
+		return "SignatureDoesNotMatch"
+	}
+	date, err := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return "AccessDenied"
+	}
+	canonical := canonicalRequest(r, signedHeaders, hash)
+	expected := f.creds.signature(date, stringToSign(r.Header.Get("X-Amz-Date"), f.creds.scope(date), canonical))
+	if !hmac.Equal([]byte(expected), []byte(fields["Signature"])) {
+		return "SignatureDoesNotMatch"
+	}
+	return ""
+}
+
+func writeFakeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(code))
+	fmt.Fprintf(w, `<Error><Code>%s</Code><Message>тестовая ошибка</Message></Error>`, buf.String())
+}
@@ -0,0 +1,556 @@
+// Package s3 реализует storage.Provider поверх S3-совместимого хранилища (AWS S3, MinIO и др.).
+// Запросы подписываются AWS Signature Version 4 без внешних зависимостей.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"mpm/internal/storage"
+)
+
+const (
+	// MinPartSize минимальный размер части составной загрузки, кроме последней
+	MinPartSize = 5 << 20
+	// DefaultPartSize размер части по умолчанию
+	DefaultPartSize = 16 << 20
+	// maxPresignTTL максимальный срок действия подписанной ссылки в S3
+	maxPresignTTL = 7 * 24 * time.Hour
+	// maxParts максимальное количество частей составной загрузки
+	maxParts = 10000
+)
+
+// Config описывает подключение к S3-совместимому хранилищу
+type Config struct {
+	Endpoint     string        // Адрес сервиса, например https://s3.amazonaws.com или http://localhost:9000
+	Region       string        // Регион, для MinIO обычно us-east-1
+	Bucket       string        // Имя бакета
+	AccessKey    string        // Ключ доступа
+	SecretKey    string        // Секретный ключ
+	Prefix       string        // Префикс ключей объектов внутри бакета
+	UsePathStyle bool          // Адресация endpoint/bucket/key вместо bucket.endpoint/key
+	PartSize     int64         // Размер части составной загрузки; файлы больше загружаются частями
+	PresignTTL   time.Duration // Срок действия ссылок из GetPublicURL
+
+	HTTPClient *http.Client // Клиент HTTP; по умолчанию storage.NewHTTPClient
+}
+
+// Error описывает ошибку, возвращенную S3
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("ошибка S3: статус %d", e.StatusCode)
+	}
+	return fmt.Sprintf("ошибка S3: %s (%s), статус %d", e.Code, e.Message, e.StatusCode)
+}
+
+// Storage реализует storage.Provider для S3-совместимого хранилища
+type Storage struct {
+	endpoint   *url.URL
+	bucket     string
+	prefix     string
+	pathStyle  bool
+	partSize   int64
+	presignTTL time.Duration
+	creds      credentials
+	client     *http.Client
+	now        func() time.Time
+}
+
+// New создает хранилище S3 по конфигурации
+func New(cfg Config) (*Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("не задан адрес или бакет S3")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("не заданы ключи доступа S3")
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("некорректный адрес S3: %s", cfg.Endpoint)
+	}
+
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.PartSize <= 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.PartSize < MinPartSize {
+		cfg.PartSize = MinPartSize
+	}
+	if cfg.PresignTTL <= 0 {
+		cfg.PresignTTL = time.Hour
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = storage.NewHTTPClient()
+	}
+
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &Storage{
+		endpoint:   endpoint,
+		bucket:     cfg.Bucket,
+		prefix:     prefix,
+		pathStyle:  cfg.UsePathStyle,
+		partSize:   cfg.PartSize,
+		presignTTL: min(cfg.PresignTTL, maxPresignTTL),
+		creds: credentials{
+			accessKey: cfg.AccessKey,
+			secretKey: cfg.SecretKey,
+			region:    cfg.Region,
+		},
+		client: cfg.HTTPClient,
+		now:    time.Now,
+	}, nil
+}
+
+// EnsureBucket создает бакет, если он не существует
+func (s *Storage) EnsureBucket(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodHead, "", nil, nil, nil, emptyPayloadHash)
+	if err == nil {
+		resp.Body.Close()
+		return nil
+	}
+	var s3Err *Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("ошибка проверки бакета %s: %w", s.bucket, err)
+	}
+
+	var body []byte
+	if s.creds.region != "us-east-1" {
+		body = []byte(`<CreateBucketConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+			`<LocationConstraint>` + s.creds.region + `</LocationConstraint></CreateBucketConfiguration>`)
+	}
+	resp, err = s.do(ctx, http.MethodPut, "", nil, nil, bytes.NewReader(body), payloadHash(body))
+	if err != nil {
+		return fmt.Errorf("ошибка создания бакета %s: %w", s.bucket, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Save загружает файл в бакет, большие файлы - составной загрузкой
+func (s *Storage) Save(file multipart.File, filename string) (string, error) {
+	key := strings.TrimPrefix(path.Clean("/"+filename), "/")
+	if key == "" {
+		return "", errors.New("пустое имя файла")
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	contentType := s.contentType(file, key)
+	ctx := context.Background()
+	if size <= s.partSize {
+		err = s.putObject(ctx, key, file, size, contentType)
+	} else {
+		err = s.multipartUpload(ctx, key, file, size, contentType)
+	}
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Get загружает содержимое объекта целиком
+func (s *Storage) Get(path string) ([]byte, error) {
+	reader, err := s.GetReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// GetReader возвращает поток с содержимым объекта с поддержкой Seek
+func (s *Storage) GetReader(path string) (io.ReadCloser, error) {
+	return storage.OpenRange(s, path)
+}
+
+// GetRange возвращает поток из length байт объекта, начиная с offset, и размер объекта
+func (s *Storage) GetRange(path string, offset, length int64) (io.ReadCloser, int64, error) {
+	var header http.Header
+	if value := storage.RangeHeader(offset, length); value != "" {
+		header = http.Header{"Range": {value}}
+	}
+	// Тело ответа читается потоком, поэтому время запроса не ограничивается
+	resp, err := s.do(context.Background(), http.MethodGet, path, nil, header, nil, emptyPayloadHash)
+	if err != nil {
+		return nil, 0, err
+	}
+	return storage.RangeResponse(resp, offset, length)
+}
+
+// Delete удаляет объект. Удаление отсутствующего объекта не считается ошибкой.
+func (s *Storage) Delete(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), storage.RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodDelete, path, nil, nil, nil, emptyPayloadHash)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// List перечисляет объекты под префиксом хранилища, кроме карантина
+func (s *Storage) List(ctx context.Context, fn func(storage.BlobInfo) error) error {
+	query := url.Values{"list-type": {"2"}}
+	if s.prefix != "" {
+		query.Set("prefix", s.prefix)
+	}
+	for {
+		page, err := s.listObjects(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, obj := range page.Contents {
+			key := strings.TrimPrefix(obj.Key, s.prefix)
+			if key == "" || strings.HasSuffix(key, "/") || strings.HasPrefix(key, storage.QuarantinePrefix) {
+				continue
+			}
+			if err := fn(storage.BlobInfo{Path: key, Size: obj.Size, ModTime: obj.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+
+// listBucketResult страница ответа ListObjectsV2
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+func (s *Storage) listObjects(ctx context.Context, query url.Values) (listBucketResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	var page listBucketResult
+	resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil, emptyPayloadHash)
+	if err != nil {
+		return page, fmt.Errorf("ошибка получения списка объектов бакета %s: %w", s.bucket, err)
+	}
+	defer resp.Body.Close()
+
+	if err := xml.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return page, fmt.Errorf("ошибка разбора списка объектов бакета %s: %w", s.bucket, err)
+	}
+	return page, nil
+}
+
+// GetPublicURL возвращает подписанную ссылку на объект, действующую PresignTTL
+func (s *Storage) GetPublicURL(path string) string {
+	link, _ := s.presignGet(path, s.presignTTL)
+	return link
+}
+
+// GetSignedURL возвращает подписанную ссылку на объект
+func (s *Storage) GetSignedURL(path, scope string, ttl time.Duration) (string, time.Time, error) {
+	ttl = min(max(ttl, time.Second), maxPresignTTL)
+	link, expires := s.presignGet(path, ttl)
+	return link, expires, nil
+}
+
+func (s *Storage) presignGet(key string, ttl time.Duration) (string, time.Time) {
+	now := s.now()
+	req, _ := http.NewRequest(http.MethodGet, s.objectURL(key, nil), nil)
+	return s.creds.presign(req, ttl, now), now.Add(ttl).Truncate(time.Second)
+}
+
+// putObject загружает объект одним запросом
+func (s *Storage) putObject(ctx context.Context, key string, file io.ReadSeeker, size int64, contentType string) error {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var body io.Reader = http.NoBody
+	if size > 0 {
+		body = sizedReader{file, size}
+	}
+	header := http.Header{"Content-Type": {contentType}}
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodPut, key, nil, header, body, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки объекта %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// multipartUpload загружает объект частями по partSize
+func (s *Storage) multipartUpload(ctx context.Context, key string, file io.Reader, size int64, contentType string) error {
+	partSize := s.partSize
+	if size > partSize*maxParts {
+		partSize = (size + maxParts - 1) / maxParts
+	}
+
+	uploadID, err := s.createMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return fmt.Errorf("ошибка начала составной загрузки %s: %w", key, err)
+	}
+
+	parts, err := s.uploadParts(ctx, key, uploadID, file, partSize)
+	if err == nil {
+		err = s.completeMultipartUpload(ctx, key, uploadID, parts)
+	}
+	if err != nil {
+		if abortErr := s.abortMultipartUpload(ctx, key, uploadID); abortErr != nil {
+			err = errors.Join(err, fmt.Errorf("ошибка отмены составной загрузки: %w", abortErr))
+		}
+		return fmt.Errorf("ошибка составной загрузки %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *Storage) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	header := http.Header{"Content-Type": {contentType}}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, header, nil, emptyPayloadHash)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("некорректный ответ S3: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", errors.New("S3 не вернул идентификатор загрузки")
+	}
+	return result.UploadID, nil
+}
+
+func (s *Storage) uploadParts(ctx context.Context, key, uploadID string, file io.Reader, partSize int64) ([]completedPart, error) {
+	var parts []completedPart
+	buf := make([]byte, partSize)
+
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(file, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+
+		etag, reqErr := s.uploadPart(ctx, key, uploadID, number, buf[:n])
+		if reqErr != nil {
+			return nil, fmt.Errorf("ошибка загрузки части %d: %w", number, reqErr)
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: etag})
+
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+	return parts, nil
+}
+
+// uploadPart загружает одну часть и возвращает ее ETag
+func (s *Storage) uploadPart(ctx context.Context, key, uploadID string, number int, data []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	query := url.Values{
+		"partNumber": {strconv.Itoa(number)},
+		"uploadId":   {uploadID},
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, query, nil, bytes.NewReader(data), payloadHash(data))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", errors.New("S3 не вернул ETag")
+	}
+	return etag, nil
+}
+
+func (s *Storage) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, bytes.NewReader(body), payloadHash(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// S3 может вернуть ошибку в теле ответа со статусом 200
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if s3Err := parseError(resp.StatusCode, data); s3Err.Code != "" {
+		return s3Err
+	}
+	return nil
+}
+
+func (s *Storage) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	ctx, cancel := context.WithTimeout(ctx, storage.RequestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do выполняет подписанный запрос к объекту key или к бакету, если key пуст
+func (s *Storage) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, hash string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key, query), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if sized, ok := body.(sizedReader); ok {
+		req.ContentLength = sized.size
+	}
+	s.creds.signRequest(req, hash, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	s3Err := parseError(resp.StatusCode, data)
+	if resp.StatusCode == http.StatusNotFound && key != "" && s3Err.Code != "NoSuchBucket" {
+		return nil, fmt.Errorf("объект %s не найден: %w", key, fs.ErrNotExist)
+	}
+	return nil, s3Err
+}
+
+// objectURL строит адрес объекта с учетом стиля адресации
+func (s *Storage) objectURL(key string, query url.Values) string {
+	u := *s.endpoint
+	objectPath := ""
+	if key != "" {
+		objectPath = "/" + s.prefix + key
+	}
+	if s.pathStyle {
+		objectPath = "/" + s.bucket + objectPath
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	if objectPath == "" {
+		objectPath = "/"
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + objectPath
+	u.RawPath = ""
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+// contentType определяет тип содержимого по сигнатуре, затем по расширению
+func (s *Storage) contentType(file io.ReadSeeker, key string) string {
+	if contentType, err := storage.SniffMedia(file); err == nil && contentType != "" {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+func parseError(status int, data []byte) *Error {
+	s3Err := &Error{StatusCode: status}
+	if len(data) > 0 {
+		_ = xml.Unmarshal(data, s3Err)
+	}
+	return s3Err
+}
+
+func payloadHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// sizedReader передает известный размер тела запроса, чтобы не использовать chunked-кодирование
+type sizedReader struct {
+	io.Reader
+	size int64
+}
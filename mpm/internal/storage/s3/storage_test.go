@@ -0,0 +1,260 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mpm/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ storage.Provider = (*Storage)(nil)
+var _ storage.SignedURLProvider = (*Storage)(nil)
+var _ storage.Lister = (*Storage)(nil)
+var _ storage.RangeReader = (*Storage)(nil)
+
+var testPNGHeader = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n', 0, 0, 0, 0}
+
+func TestStorage(t *testing.T) {
+	fake := newFakeS3(t, "photos")
+	store, err := New(fake.config("photos"))
+	require.NoError(t, err)
+
+	t.Run("Сохранение и чтение", func(t *testing.T) {
+		path, err := store.Save(storage.NewBytesFile(testPNGHeader), "albums/1/фото 1.png")
+		require.NoError(t, err)
+		assert.Equal(t, "albums/1/фото 1.png", path)
+
+		obj, ok := fake.object("photos/albums/1/фото 1.png")
+		require.True(t, ok)
+		assert.Equal(t, "image/png", obj.contentType)
+
+		data, err := store.Get(path)
+		require.NoError(t, err)
+		assert.Equal(t, testPNGHeader, data)
+	})
+
+	t.Run("Подписанная ссылка", func(t *testing.T) {
+		link := store.GetPublicURL("albums/1/фото 1.png")
+		require.True(t, strings.HasPrefix(link, fake.server.URL+"/photos/albums/1/"))
+
+		resp, err := http.Get(link)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, testPNGHeader, body)
+
+		resp, err = http.Get(strings.Replace(link, "%D1%84", "%D1%85", 1))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "подпись привязана к ключу объекта")
+
+		_, expires, err := store.GetSignedURL("albums/1/фото 1.png", storage.ScopeOriginal, 30*24*time.Hour)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(maxPresignTTL), expires, time.Minute)
+	})
+
+	t.Run("Частичное чтение по Range", func(t *testing.T) {
+		data := make([]byte, 1000)
+		_, _ = rand.Read(data)
+		_, err := store.Save(storage.NewBytesFile(data), "albums/1/video.mp4")
+		require.NoError(t, err)
+
+		part, size, err := store.GetRange("albums/1/video.mp4", 100, 50)
+		require.NoError(t, err)
+		got, _ := io.ReadAll(part)
+		part.Close()
+		assert.Equal(t, int64(len(data)), size)
+		assert.Equal(t, data[100:150], got)
+
+		reader, err := store.GetReader("albums/1/video.mp4")
+		require.NoError(t, err)
+		defer reader.Close()
+		rs, ok := reader.(io.ReadSeeker)
+		require.True(t, ok)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/photos/1/content", nil)
+		req.Header.Set("Range", "bytes=200-499")
+		w := httptest.NewRecorder()
+		http.ServeContent(w, req, "video.mp4", time.Time{}, rs)
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "bytes 200-499/1000", w.Header().Get("Content-Range"))
+		assert.Equal(t, data[200:500], w.Body.Bytes())
+
+		// Повторный запрос части с того же потока
+		_, err = rs.Seek(990, io.SeekStart)
+		require.NoError(t, err)
+		tail, err := io.ReadAll(rs)
+		require.NoError(t, err)
+		assert.Equal(t, data[990:], tail)
+	})
+
+	t.Run("Отсутствующий объект", func(t *testing.T) {
+		_, err := store.GetReader("missing.png")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("Удаление", func(t *testing.T) {
+		require.NoError(t, store.Delete("albums/1/фото 1.png"))
+		_, ok := fake.object("photos/albums/1/фото 1.png")
+		assert.False(t, ok)
+		assert.NoError(t, store.Delete("albums/1/фото 1.png"))
+	})
+
+	t.Run("Пустой файл", func(t *testing.T) {
+		_, err := store.Save(storage.NewBytesFile(nil), "empty.txt")
+		require.NoError(t, err)
+		obj, ok := fake.object("photos/empty.txt")
+		require.True(t, ok)
+		assert.Empty(t, obj.data)
+	})
+
+	t.Run("Неверные ключи", func(t *testing.T) {
+		cfg := fake.config("photos")
+		cfg.SecretKey = "wrong"
+		other, err := New(cfg)
+		require.NoError(t, err)
+
+		_, err = other.Get("empty.txt")
+		var s3Err *Error
+		require.ErrorAs(t, err, &s3Err)
+		assert.Equal(t, "SignatureDoesNotMatch", s3Err.Code)
+	})
+}
+
+func TestStorage_MultipartUpload(t *testing.T) {
+	fake := newFakeS3(t, "photos")
+	cfg := fake.config("photos")
+	cfg.Prefix = "/mpm/"
+	cfg.PartSize = MinPartSize
+	store, err := New(cfg)
+	require.NoError(t, err)
+
+	data := make([]byte, 2*MinPartSize+1234)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+
+	path, err := store.Save(storage.NewBytesFile(data), "albums/2/video.mov")
+	require.NoError(t, err)
+	assert.Equal(t, "albums/2/video.mov", path)
+
+	obj, ok := fake.object("photos/mpm/albums/2/video.mov")
+	require.True(t, ok)
+	assert.True(t, bytes.Equal(data, obj.data))
+	assert.Contains(t, fake.requests, "PUT mpm/albums/2/video.mov partNumber=3")
+
+	t.Run("Ошибка загрузки части отменяет загрузку", func(t *testing.T) {
+		fake.failPart = 2
+		_, err := store.Save(storage.NewBytesFile(data), "albums/2/broken.mov")
+		require.Error(t, err)
+
+		_, ok := fake.object("photos/mpm/albums/2/broken.mov")
+		assert.False(t, ok)
+		assert.Empty(t, fake.uploads, "незавершенная загрузка должна быть отменена")
+	})
+}
+
+func TestStorage_List(t *testing.T) {
+	fake := newFakeS3(t, "photos")
+	fake.pageSize = 2
+	cfg := fake.config("photos")
+	cfg.Prefix = "mpm"
+	store, err := New(cfg)
+	require.NoError(t, err)
+
+	for _, key := range []string{"albums/1/a.jpg", "albums/1/b.jpg", "albums/2/c.jpg", storage.QuarantinePrefix + "d.jpg"} {
+		_, err := store.Save(storage.NewBytesFile([]byte(key)), key)
+		require.NoError(t, err)
+	}
+	fake.objects["photos/other/e.jpg"] = fakeObject{data: []byte("чужой префикс")}
+
+	var listed []string
+	err = store.List(context.Background(), func(blob storage.BlobInfo) error {
+		listed = append(listed, blob.Path)
+		assert.Equal(t, int64(len(blob.Path)), blob.Size)
+		assert.WithinDuration(t, time.Now(), blob.ModTime, time.Minute)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"albums/1/a.jpg", "albums/1/b.jpg", "albums/2/c.jpg"}, listed,
+		"все страницы списка без карантина и объектов вне префикса")
+}
+
+func TestStorage_EnsureBucket(t *testing.T) {
+	fake := newFakeS3(t, "")
+	store, err := New(fake.config("new-bucket"))
+	require.NoError(t, err)
+
+	_, err = store.Save(storage.NewBytesFile([]byte("x")), "a.txt")
+	require.Error(t, err, "бакет еще не создан")
+	assert.NotErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, store.EnsureBucket(context.Background()))
+	require.NoError(t, store.EnsureBucket(context.Background()))
+	assert.True(t, fake.buckets["new-bucket"])
+
+	_, err = store.Save(storage.NewBytesFile([]byte("x")), "a.txt")
+	assert.NoError(t, err)
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(Config{Endpoint: "localhost:9000", Bucket: "b", AccessKey: "a", SecretKey: "s"})
+	assert.Error(t, err, "адрес без схемы")
+
+	_, err = New(Config{Endpoint: "http://localhost:9000", AccessKey: "a", SecretKey: "s"})
+	assert.Error(t, err, "бакет не задан")
+
+	store, err := New(Config{Endpoint: "https://s3.amazonaws.com", Bucket: "b", AccessKey: "a", SecretKey: "s", PartSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(MinPartSize), store.partSize)
+	assert.True(t, strings.HasPrefix(store.GetPublicURL("x.jpg"), "https://b.s3.amazonaws.com/x.jpg?"))
+}
+
+// TestStorage_MinIO проверяет работу с настоящим сервером, например
+// MPM_TEST_S3_ENDPOINT=http://localhost:9000 MPM_TEST_S3_ACCESS_KEY=minioadmin MPM_TEST_S3_SECRET_KEY=minioadmin
+func TestStorage_MinIO(t *testing.T) {
+	endpoint := os.Getenv("MPM_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MPM_TEST_S3_ENDPOINT не задан")
+	}
+
+	store, err := New(Config{
+		Endpoint:     endpoint,
+		Bucket:       "mpm-test",
+		AccessKey:    os.Getenv("MPM_TEST_S3_ACCESS_KEY"),
+		SecretKey:    os.Getenv("MPM_TEST_S3_SECRET_KEY"),
+		UsePathStyle: true,
+		PartSize:     MinPartSize,
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.EnsureBucket(context.Background()))
+
+	data := make([]byte, MinPartSize+1)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+
+	path, err := store.Save(storage.NewBytesFile(data), "test/large file.bin")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Delete(path) })
+
+	got, err := store.Get(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(data, got))
+
+	resp, err := http.Get(store.GetPublicURL(path))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
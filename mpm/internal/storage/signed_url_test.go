@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner([]byte("секрет"), "https://cdn.example.com/files/")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	signer.now = func() time.Time { return now }
+
+	link, expires := signer.Sign("albums/1/фото 1.jpg", ScopeThumbnail, time.Hour)
+	assert.Equal(t, now.Add(time.Hour), expires)
+	require.True(t, strings.HasPrefix(link, "https://cdn.example.com/files/albums/1/"))
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	path := strings.TrimPrefix(u.Path, "/files/")
+	assert.Equal(t, "albums/1/фото 1.jpg", path)
+
+	got, err := signer.Verify(path, u.Query())
+	require.NoError(t, err)
+	assert.Equal(t, expires, got.UTC())
+
+	t.Run("Подпись привязана к пути, области действия и сроку", func(t *testing.T) {
+		_, err := signer.Verify("albums/1/other.jpg", u.Query())
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+
+		query := u.Query()
+		query.Set("scope", ScopeOriginal)
+		_, err = signer.Verify(path, query)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+
+		query = u.Query()
+		query.Del("scope")
+		_, err = signer.Verify(path, query)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+
+		query = u.Query()
+		query.Set("expires", "4102444800")
+		_, err = signer.Verify(path, query)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Другой ключ", func(t *testing.T) {
+		other := NewURLSigner([]byte("другой"), "/files")
+		other.now = signer.now
+		_, err := other.Verify(path, u.Query())
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Истекшая ссылка", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		_, err := signer.Verify(path, u.Query())
+		assert.ErrorIs(t, err, ErrURLExpired)
+	})
+}
+
+func TestDeriveSigningKey(t *testing.T) {
+	key, ephemeral, err := DeriveSigningKey("explicit", "jwt")
+	require.NoError(t, err)
+	assert.False(t, ephemeral)
+	assert.Equal(t, []byte("explicit"), key)
+
+	first, ephemeral, err := DeriveSigningKey("", "jwt")
+	require.NoError(t, err)
+	assert.False(t, ephemeral)
+	second, _, _ := DeriveSigningKey("", "jwt")
+	assert.Equal(t, first, second, "ключ из JWT_SECRET должен быть стабильным")
+	assert.NotEqual(t, []byte("jwt"), first, "секрет JWT не должен использоваться напрямую")
+
+	random, ephemeral, err := DeriveSigningKey("", "")
+	require.NoError(t, err)
+	assert.True(t, ephemeral)
+	assert.Len(t, random, 32)
+}
@@ -0,0 +1,541 @@
+package tus
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"mpm/internal/models"
+	"mpm/middleware"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Version поддерживаемая версия протокола
+	Version = "1.0.0"
+	// Extensions поддерживаемые расширения протокола
+	Extensions = "creation,creation-with-upload,termination,expiration"
+
+	offsetContentType = "application/offset+octet-stream"
+)
+
+// CompleteFunc обрабатывает полностью полученный файл и возвращает ссылку на созданный ресурс
+type CompleteFunc func(ctx context.Context, info Info, file *os.File) (string, error)
+
+// ValidateFunc проверяет метаданные новой загрузки до получения данных
+type ValidateFunc func(ctx context.Context, info Info) error
+
+// Handler обслуживает tus-загрузки по адресу basePath и basePath/{id}
+type Handler struct {
+	store      *Store
+	basePath   string
+	maxSize    int64
+	expiration time.Duration
+	complete   CompleteFunc
+	validate   ValidateFunc
+}
+
+// rejectError окончательная ошибка обработки загрузки с HTTP-статусом для клиента
+type rejectError struct {
+	status int
+	err    error
+}
+
+func (e *rejectError) Error() string { return e.err.Error() }
+func (e *rejectError) Unwrap() error { return e.err }
+
+// Reject помечает ошибку CompleteFunc или ValidateFunc как окончательную
+func Reject(status int, err error) error {
+	return &rejectError{status: status, err: err}
+}
+
+// rejectStatus возвращает HTTP-статус окончательной ошибки
+func rejectStatus(err error) (int, bool) {
+	var rejected *rejectError
+	if errors.As(err, &rejected) {
+		return rejected.status, true
+	}
+	return 0, false
+}
+
+// NewHandler создает обработчик tus-загрузок, maxSize = 0 снимает ограничение размера
+func NewHandler(store *Store, basePath string, maxSize int64, expiration time.Duration, complete CompleteFunc) *Handler {
+	return &Handler{
+		store:      store,
+		basePath:   strings.TrimSuffix(basePath, "/"),
+		maxSize:    maxSize,
+		expiration: expiration,
+		complete:   complete,
+	}
+}
+
+// SetValidateFunc задает проверку метаданных, которая выполняется при создании загрузки
+func (h *Handler) SetValidateFunc(validate ValidateFunc) {
+	h.validate = validate
+}
+
+// Options godoc
+// @Summary Возможности сервера tus
+// @Description Возвращает версию протокола, поддерживаемые расширения и максимальный размер загрузки
+// @Tags uploads
+// @Security Bearer
+// @Success 204 "Tus-Version, Tus-Extension, Tus-Max-Size"
+// @Router /uploads [options]
+func (h *Handler) Options(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос OPTIONS /api/uploads")
+
+	h.commonHeaders(w)
+	w.Header().Set("Tus-Version", Version)
+	w.Header().Set("Tus-Extension", Extensions)
+	if h.maxSize > 0 {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.maxSize, 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Create godoc
+// @Summary Начать возобновляемую загрузку
+// @Description Создает загрузку по протоколу tus 1.0. В Upload-Metadata ожидаются ключи filename и album_id.
+// @Description Тело запроса с Content-Type application/offset+octet-stream сразу записывается как первый блок.
+// @Tags uploads
+// @Security Bearer
+// @Param Tus-Resumable header string true "Версия протокола" default(1.0.0)
+// @Param Upload-Length header int true "Полный размер файла"
+// @Param Upload-Metadata header string false "Метаданные: пары ключ base64(значение) через запятую"
+// @Success 201 "Location содержит адрес загрузки"
+// @Failure 400 {object} string "Некорректные заголовки или метаданные"
+// @Failure 404 {object} string "Альбом не найден"
+// @Failure 412 {object} string "Неподдерживаемая версия протокола"
+// @Failure 413 {object} string "Превышен максимальный размер"
+// @Router /uploads [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос POST /api/uploads")
+
+	h.commonHeaders(w)
+	if !checkVersion(w, r) {
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "Некорректный заголовок Upload-Length", http.StatusBadRequest)
+		return
+	}
+	if h.maxSize > 0 && length > h.maxSize {
+		http.Error(w, "Превышен максимальный размер загрузки", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	metadata, err := ParseMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	info := Info{
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: time.Now(),
+	}
+	if h.expiration > 0 {
+		info.ExpiresAt = info.CreatedAt.Add(h.expiration)
+	}
+	if user := contextUser(r); user != nil {
+		info.UserID = user.ID
+		info.Username = user.Username
+	}
+
+	// Метаданные проверяются до получения данных, чтобы клиент не передавал файл впустую
+	if h.validate != nil {
+		if err := h.validate(r.Context(), info); err != nil {
+			if status, ok := rejectStatus(err); ok {
+				http.Error(w, err.Error(), status)
+				return
+			}
+			log.Printf("Ошибка при проверке загрузки: %v", err)
+			http.Error(w, "Ошибка при проверке загрузки", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	info, err = h.store.Create(info)
+	if err != nil {
+		log.Printf("Ошибка при создании загрузки: %v", err)
+		http.Error(w, "Ошибка при создании загрузки", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Создана загрузка %s размером %d байт", info.ID, info.Length)
+
+	w.Header().Set("Location", h.basePath+"/"+info.ID)
+	h.expiresHeader(w, info)
+
+	// creation-with-upload: первый блок передан вместе с запросом создания
+	if r.Header.Get("Content-Type") == offsetContentType || length == 0 {
+		unlock := h.store.Lock(info.ID)
+		defer unlock()
+
+		info, status, err := h.write(r, info.ID, 0)
+		if err != nil {
+			if info.ID != "" {
+				w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+		if info.Location != "" {
+			w.Header().Set("Content-Location", info.Location)
+		}
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Head godoc
+// @Summary Состояние загрузки
+// @Description Возвращает количество полученных байт, чтобы клиент мог продолжить загрузку
+// @Tags uploads
+// @Security Bearer
+// @Param id path string true "ID загрузки"
+// @Param Tus-Resumable header string true "Версия протокола" default(1.0.0)
+// @Success 200 "Upload-Offset и Upload-Length"
+// @Failure 403 {object} string "Загрузка принадлежит другому пользователю"
+// @Failure 404 {object} string "Загрузка не найдена"
+// @Router /uploads/{id} [head]
+func (h *Handler) Head(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос HEAD /api/uploads/{id}")
+
+	h.commonHeaders(w)
+	w.Header().Set("Cache-Control", "no-store")
+	if !checkVersion(w, r) {
+		return
+	}
+
+	info, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	if !h.owns(r, info) {
+		http.Error(w, "Загрузка принадлежит другому пользователю", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(info.Length, 10))
+	if len(info.Metadata) > 0 {
+		w.Header().Set("Upload-Metadata", FormatMetadata(info.Metadata))
+	}
+	if info.Location != "" {
+		w.Header().Set("Content-Location", info.Location)
+	}
+	h.expiresHeader(w, info)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Patch godoc
+// @Summary Передать блок данных
+// @Description Дописывает блок начиная с Upload-Offset. После получения последнего байта файл
+// @Description сохраняется как фотография, ссылка на нее возвращается в Content-Location. Если сохранить файл
+// @Description не удалось из-за временной ошибки, полученные данные остаются: запрос с Upload-Offset = Upload-Length
+// @Description и пустым телом повторяет сохранение.
+// @Tags uploads
+// @Accept application/offset+octet-stream
+// @Security Bearer
+// @Param id path string true "ID загрузки"
+// @Param Tus-Resumable header string true "Версия протокола" default(1.0.0)
+// @Param Upload-Offset header int true "Смещение блока"
+// @Success 204 "Upload-Offset содержит новое смещение"
+// @Failure 404 {object} string "Загрузка не найдена"
+// @Failure 409 {object} string "Смещение не совпадает"
+// @Failure 413 {object} string "Блок выходит за пределы Upload-Length"
+// @Failure 415 {object} string "Неверный Content-Type"
+// @Failure 422 {object} string "Файл получен, но не может быть сохранен"
+// @Failure 500 {object} string "Временная ошибка сохранения, запрос можно повторить"
+// @Router /uploads/{id} [patch]
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос PATCH /api/uploads/{id}")
+
+	h.commonHeaders(w)
+	if !checkVersion(w, r) {
+		return
+	}
+	if r.Header.Get("Content-Type") != offsetContentType {
+		http.Error(w, "Ожидается Content-Type "+offsetContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "Некорректный заголовок Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	// Блокировка создается только для существующей загрузки, состояние перечитывается под ней
+	if _, ok := h.lookup(w, r); !ok {
+		return
+	}
+	id := r.PathValue("id")
+	unlock := h.store.Lock(id)
+	defer unlock()
+
+	info, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	if !h.owns(r, info) {
+		http.Error(w, "Загрузка принадлежит другому пользователю", http.StatusForbidden)
+		return
+	}
+	// Повтор последнего блока после завершения: данные уже обработаны
+	if info.Finished() {
+		if offset != info.Offset {
+			http.Error(w, ErrOffsetMismatch.Error(), http.StatusConflict)
+			return
+		}
+		w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+		w.Header().Set("Content-Location", info.Location)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.ContentLength > 0 && offset+r.ContentLength > info.Length {
+		http.Error(w, "Блок выходит за пределы Upload-Length", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	info, status, err := h.write(r, id, offset)
+	if err != nil {
+		if info.ID != "" {
+			w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	if info.Location != "" {
+		w.Header().Set("Content-Location", info.Location)
+	}
+	h.expiresHeader(w, info)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete godoc
+// @Summary Отменить загрузку
+// @Description Удаляет незавершенную загрузку и полученные данные (расширение termination)
+// @Tags uploads
+// @Security Bearer
+// @Param id path string true "ID загрузки"
+// @Param Tus-Resumable header string true "Версия протокола" default(1.0.0)
+// @Success 204 "Загрузка удалена"
+// @Failure 404 {object} string "Загрузка не найдена"
+// @Router /uploads/{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	log.Println("Получен запрос DELETE /api/uploads/{id}")
+
+	h.commonHeaders(w)
+	if !checkVersion(w, r) {
+		return
+	}
+
+	// Блокировка создается только для существующей загрузки, состояние перечитывается под ней
+	if _, ok := h.lookup(w, r); !ok {
+		return
+	}
+	id := r.PathValue("id")
+	unlock := h.store.Lock(id)
+	defer unlock()
+
+	info, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	if !h.owns(r, info) {
+		http.Error(w, "Загрузка принадлежит другому пользователю", http.StatusForbidden)
+		return
+	}
+
+	if err := h.store.Delete(id); err != nil {
+		log.Printf("Ошибка при удалении загрузки %s: %v", id, err)
+		http.Error(w, "Ошибка при удалении загрузки", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MethodOverride принимает метод из заголовка X-HTTP-Method-Override запроса POST
+func (h *Handler) MethodOverride(w http.ResponseWriter, r *http.Request) {
+	switch strings.ToUpper(r.Header.Get("X-HTTP-Method-Override")) {
+	case http.MethodPatch:
+		h.Patch(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	case http.MethodHead:
+		h.Head(w, r)
+	default:
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+	}
+}
+
+// CleanupExpired периодически удаляет загрузки с истекшим сроком до отмены контекста
+func (h *Handler) CleanupExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := h.store.DeleteExpired(time.Now())
+			if err != nil {
+				log.Printf("Ошибка очистки загрузок: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Удалено просроченных загрузок: %d", deleted)
+			}
+		}
+	}
+}
+
+// write записывает тело запроса и завершает загрузку после последнего байта
+func (h *Handler) write(r *http.Request, id string, offset int64) (Info, int, error) {
+	info, err := h.store.WriteChunk(id, offset, r.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return info, http.StatusNotFound, err
+		case errors.Is(err, ErrOffsetMismatch):
+			return info, http.StatusConflict, err
+		}
+		log.Printf("Загрузка %s прервана на смещении %d: %v", id, info.Offset, err)
+		return info, http.StatusInternalServerError, errors.New("ошибка при записи блока")
+	}
+
+	if !info.Complete() || info.Finished() || h.complete == nil {
+		return info, 0, nil
+	}
+
+	// Обработка файла не должна прерываться, если клиент уже отключился
+	ctx := context.WithoutCancel(r.Context())
+	location, err := h.finish(ctx, info)
+	if err != nil {
+		if status, ok := rejectStatus(err); ok {
+			log.Printf("Загрузка %s отклонена: %v", id, err)
+			_ = h.store.Delete(id)
+			return Info{}, status, err
+		}
+		// Полученный файл сохраняется, чтобы клиент мог повторить обработку без повторной передачи
+		log.Printf("Ошибка обработки загрузки %s, файл оставлен для повторной попытки: %v", id, err)
+		return info, http.StatusInternalServerError, errors.New("ошибка при сохранении файла, повторите запрос")
+	}
+
+	if err := h.store.Finish(id, location); err != nil {
+		log.Printf("Ошибка сохранения результата загрузки %s: %v", id, err)
+	}
+	info.Location = location
+	log.Printf("Загрузка %s завершена: %s", id, location)
+	return info, 0, nil
+}
+
+// finish передает полученный файл обработчику завершения
+func (h *Handler) finish(ctx context.Context, info Info) (string, error) {
+	file, err := h.store.Open(info.ID)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return h.complete(ctx, info, file)
+}
+
+// lookup находит загрузку по id из пути, отвечая 404 при ее отсутствии
+func (h *Handler) lookup(w http.ResponseWriter, r *http.Request) (Info, bool) {
+	info, err := h.store.Get(r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			log.Printf("Ошибка при получении загрузки: %v", err)
+			http.Error(w, "Ошибка при получении загрузки", http.StatusInternalServerError)
+		}
+		return Info{}, false
+	}
+
+	// Просроченные загрузки считаются удаленными, даже если очистка еще не выполнялась.
+	// Удаляет их DeleteExpired под блокировкой загрузки.
+	if !info.ExpiresAt.IsZero() && time.Now().After(info.ExpiresAt) {
+		http.Error(w, ErrNotFound.Error(), http.StatusNotFound)
+		return Info{}, false
+	}
+	return info, true
+}
+
+// owns проверяет, что загрузку изменяет тот же пользователь, который ее начал
+func (h *Handler) owns(r *http.Request, info Info) bool {
+	if info.UserID == 0 {
+		return true
+	}
+	user := contextUser(r)
+	return user != nil && user.ID == info.UserID
+}
+
+func (h *Handler) commonHeaders(w http.ResponseWriter) {
+	w.Header().Set("Tus-Resumable", Version)
+}
+
+func (h *Handler) expiresHeader(w http.ResponseWriter, info Info) {
+	if !info.ExpiresAt.IsZero() {
+		w.Header().Set("Upload-Expires", info.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// checkVersion проверяет заголовок Tus-Resumable
+func checkVersion(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Tus-Resumable") != Version {
+		w.Header().Set("Tus-Version", Version)
+		http.Error(w, "Неподдерживаемая версия протокола tus", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+func contextUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(middleware.UserContextKey).(*models.User)
+	return user
+}
+
+// ParseMetadata разбирает заголовок Upload-Metadata: пары "ключ base64(значение)" через запятую
+func ParseMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("некорректный заголовок Upload-Metadata: пустой ключ")
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("некорректный заголовок Upload-Metadata: значение %s не в base64", key)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// FormatMetadata формирует заголовок Upload-Metadata
+func FormatMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,410 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mpm/internal/models"
+	"mpm/middleware"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tusTestEnv struct {
+	dir       string
+	store     *Store
+	mux       *http.ServeMux
+	completed [][]byte
+	failWith  error // Ошибка обработки полученного файла
+	rejectNew error // Ошибка проверки метаданных новой загрузки
+}
+
+func newTusTestEnv(t *testing.T, maxSize int64, expiration time.Duration) *tusTestEnv {
+	t.Helper()
+
+	env := &tusTestEnv{dir: t.TempDir()}
+	env.reopen(t, maxSize, expiration)
+	return env
+}
+
+// reopen создает хранилище и обработчик заново поверх того же каталога, имитируя перезапуск
+func (env *tusTestEnv) reopen(t *testing.T, maxSize int64, expiration time.Duration) {
+	t.Helper()
+
+	store, err := NewStore(env.dir)
+	require.NoError(t, err)
+	env.store = store
+
+	complete := func(ctx context.Context, info Info, file *os.File) (string, error) {
+		if env.failWith != nil {
+			return "", env.failWith
+		}
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", err
+		}
+		env.completed = append(env.completed, data)
+		return "/api/photos/" + strconv.Itoa(len(env.completed)), nil
+	}
+	handler := NewHandler(store, "/api/uploads", maxSize, expiration, complete)
+	handler.SetValidateFunc(func(ctx context.Context, info Info) error {
+		return env.rejectNew
+	})
+
+	env.mux = http.NewServeMux()
+	env.mux.HandleFunc("OPTIONS /api/uploads", handler.Options)
+	env.mux.HandleFunc("POST /api/uploads", handler.Create)
+	env.mux.HandleFunc("HEAD /api/uploads/{id}", handler.Head)
+	env.mux.HandleFunc("PATCH /api/uploads/{id}", handler.Patch)
+	env.mux.HandleFunc("DELETE /api/uploads/{id}", handler.Delete)
+	env.mux.HandleFunc("POST /api/uploads/{id}", handler.MethodOverride)
+}
+
+func (env *tusTestEnv) do(method, path string, headers map[string]string, body []byte, user *models.User) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Tus-Resumable", Version)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, user))
+	}
+
+	rec := httptest.NewRecorder()
+	env.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func (env *tusTestEnv) create(t *testing.T, length int, user *models.User) string {
+	t.Helper()
+
+	rec := env.do(http.MethodPost, "/api/uploads", map[string]string{
+		"Upload-Length":   strconv.Itoa(length),
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("photo.jpg")) + ",album_id " + base64.StdEncoding.EncodeToString([]byte("1")),
+	}, nil, user)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	return rec.Header().Get("Location")
+}
+
+func (env *tusTestEnv) patch(location string, offset int, chunk []byte, user *models.User) *httptest.ResponseRecorder {
+	return env.do(http.MethodPatch, location, map[string]string{
+		"Content-Type":  offsetContentType,
+		"Upload-Offset": strconv.Itoa(offset),
+	}, chunk, user)
+}
+
+func TestTusUpload(t *testing.T) {
+	data := []byte("0123456789abcdefghij")
+	user := &models.User{ID: 7, Username: "alice"}
+
+	t.Run("Возможности сервера", func(t *testing.T) {
+		env := newTusTestEnv(t, 1024, time.Hour)
+
+		rec := env.do(http.MethodOptions, "/api/uploads", nil, nil, nil)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, Version, rec.Header().Get("Tus-Version"))
+		assert.Equal(t, Extensions, rec.Header().Get("Tus-Extension"))
+		assert.Equal(t, "1024", rec.Header().Get("Tus-Max-Size"))
+	})
+
+	t.Run("Загрузка по частям", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		location := env.create(t, len(data), user)
+		assert.Regexp(t, `^/api/uploads/[0-9a-f]{32}$`, location)
+
+		rec := env.patch(location, 0, data[:8], user)
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+		assert.Equal(t, "8", rec.Header().Get("Upload-Offset"))
+		assert.Empty(t, rec.Header().Get("Content-Location"))
+
+		rec = env.do(http.MethodHead, location, nil, nil, user)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "8", rec.Header().Get("Upload-Offset"))
+		assert.Equal(t, strconv.Itoa(len(data)), rec.Header().Get("Upload-Length"))
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.NotEmpty(t, rec.Header().Get("Upload-Expires"))
+
+		rec = env.patch(location, 8, data[8:], user)
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+		assert.Equal(t, strconv.Itoa(len(data)), rec.Header().Get("Upload-Offset"))
+		assert.Equal(t, "/api/photos/1", rec.Header().Get("Content-Location"))
+
+		require.Len(t, env.completed, 1)
+		assert.Equal(t, data, env.completed[0])
+
+		// Повтор последнего блока не приводит к повторной обработке
+		rec = env.patch(location, len(data), nil, user)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "/api/photos/1", rec.Header().Get("Content-Location"))
+		assert.Len(t, env.completed, 1)
+	})
+
+	t.Run("Загрузка продолжается после перезапуска", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		location := env.create(t, len(data), user)
+		require.Equal(t, http.StatusNoContent, env.patch(location, 0, data[:5], user).Code)
+
+		env.reopen(t, 0, time.Hour)
+
+		rec := env.do(http.MethodHead, location, nil, nil, user)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "5", rec.Header().Get("Upload-Offset"))
+
+		require.Equal(t, http.StatusNoContent, env.patch(location, 5, data[5:], user).Code)
+		require.Len(t, env.completed, 1)
+		assert.Equal(t, data, env.completed[0])
+	})
+
+	t.Run("Загрузка вместе с созданием", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+
+		rec := env.do(http.MethodPost, "/api/uploads", map[string]string{
+			"Upload-Length": strconv.Itoa(len(data)),
+			"Content-Type":  offsetContentType,
+		}, data, user)
+
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.Equal(t, strconv.Itoa(len(data)), rec.Header().Get("Upload-Offset"))
+		assert.Equal(t, "/api/photos/1", rec.Header().Get("Content-Location"))
+	})
+
+	t.Run("Неверное смещение", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		location := env.create(t, len(data), user)
+
+		rec := env.patch(location, 3, data[3:], user)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Неверный Content-Type", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		location := env.create(t, len(data), user)
+
+		rec := env.do(http.MethodPatch, location, map[string]string{"Upload-Offset": "0"}, data, user)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+
+	t.Run("Неподдерживаемая версия протокола", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+
+		rec := env.do(http.MethodPost, "/api/uploads", map[string]string{
+			"Tus-Resumable": "0.2.2",
+			"Upload-Length": "10",
+		}, nil, user)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Equal(t, Version, rec.Header().Get("Tus-Version"))
+	})
+
+	t.Run("Превышен максимальный размер", func(t *testing.T) {
+		env := newTusTestEnv(t, 10, time.Hour)
+
+		rec := env.do(http.MethodPost, "/api/uploads", map[string]string{"Upload-Length": "11"}, nil, user)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("Блок длиннее загрузки", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		location := env.create(t, 4, user)
+
+		rec := env.patch(location, 0, data, user)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("Чужая загрузка", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		location := env.create(t, len(data), user)
+		bob := &models.User{ID: 8, Username: "bob"}
+
+		rec := env.patch(location, 0, data, bob)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = env.do(http.MethodHead, location, nil, nil, bob)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, rec.Header().Get("Upload-Metadata"))
+
+		rec = env.do(http.MethodDelete, location, nil, nil, bob)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Метаданные проверяются при создании", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		env.rejectNew = Reject(http.StatusNotFound, errors.New("альбом с ID=1 не найден"))
+
+		rec := env.do(http.MethodPost, "/api/uploads", map[string]string{
+			"Upload-Length": strconv.Itoa(len(data)),
+			"Content-Type":  offsetContentType,
+		}, data, user)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"))
+		entries, err := os.ReadDir(env.dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("Отмена загрузки", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		location := env.create(t, len(data), user)
+
+		rec := env.do(http.MethodDelete, location, nil, nil, user)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = env.do(http.MethodHead, location, nil, nil, user)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		entries, err := os.ReadDir(env.dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("Переопределение метода", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		location := env.create(t, len(data), user)
+
+		rec := env.do(http.MethodPost, location, map[string]string{
+			"X-HTTP-Method-Override": "PATCH",
+			"Content-Type":           offsetContentType,
+			"Upload-Offset":          "0",
+		}, data, user)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Len(t, env.completed, 1)
+	})
+
+	t.Run("Файл отклонен при обработке", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		env.failWith = Reject(http.StatusUnprocessableEntity, errors.New("файл не является изображением или видео"))
+		location := env.create(t, len(data), user)
+
+		rec := env.patch(location, 0, data, user)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		rec = env.do(http.MethodHead, location, nil, nil, user)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Повтор обработки после временной ошибки", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+		env.failWith = errors.New("хранилище недоступно")
+		location := env.create(t, len(data), user)
+
+		rec := env.patch(location, 0, data, user)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, strconv.Itoa(len(data)), rec.Header().Get("Upload-Offset"))
+		assert.NotContains(t, rec.Body.String(), "хранилище недоступно")
+
+		// Данные сохранены: загрузка получена целиком, но еще не обработана
+		rec = env.do(http.MethodHead, location, nil, nil, user)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, strconv.Itoa(len(data)), rec.Header().Get("Upload-Offset"))
+		assert.Empty(t, rec.Header().Get("Content-Location"))
+
+		env.failWith = nil
+		rec = env.patch(location, len(data), nil, user)
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+		assert.Equal(t, "/api/photos/1", rec.Header().Get("Content-Location"))
+		require.Len(t, env.completed, 1)
+		assert.Equal(t, data, env.completed[0])
+	})
+
+	t.Run("Некорректный идентификатор", func(t *testing.T) {
+		env := newTusTestEnv(t, 0, time.Hour)
+
+		rec := env.do(http.MethodHead, "/api/uploads/..%2Fetc", nil, nil, user)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = env.patch("/api/uploads/abc", 0, data, user)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = env.do(http.MethodDelete, "/api/uploads/abc", nil, nil, user)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestStore_Lock(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	info, err := store.Create(Info{Length: 10})
+	require.NoError(t, err)
+
+	// Удаление загрузки под блокировкой не должно освобождать ее для других запросов
+	unlock := store.Lock(info.ID)
+	require.NoError(t, store.Delete(info.ID))
+
+	locked := make(chan struct{})
+	go func() {
+		defer close(locked)
+		store.Lock(info.ID)()
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("блокировка получена до разблокировки")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("блокировка не получена после разблокировки")
+	}
+
+	// Другие загрузки не ждут, пока заблокирована эта
+	unlock = store.Lock(info.ID)
+	store.Lock("другая")()
+	unlock()
+
+	store.locksMu.Lock()
+	defer store.locksMu.Unlock()
+	assert.Empty(t, store.locks, "освобожденные блокировки удаляются")
+}
+
+func TestStore_DeleteExpired(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	now := time.Now()
+	expired, err := store.Create(Info{Length: 10, ExpiresAt: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	active, err := store.Create(Info{Length: 10, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+
+	deleted, err := store.DeleteExpired(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = store.Get(expired.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Get(active.ID)
+	assert.NoError(t, err)
+}
+
+func TestParseMetadata(t *testing.T) {
+	metadata, err := ParseMetadata("filename cGhvdG8uanBn, album_id MQ==,is_confidential")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"filename": "photo.jpg", "album_id": "1", "is_confidential": ""}, metadata)
+
+	_, err = ParseMetadata("filename не-base64")
+	assert.Error(t, err)
+
+	parsed, err := ParseMetadata(FormatMetadata(map[string]string{"filename": "фото 1.jpg"}))
+	require.NoError(t, err)
+	assert.Equal(t, "фото 1.jpg", parsed["filename"])
+}
@@ -0,0 +1,279 @@
+// Package tus реализует протокол возобновляемых загрузок tus 1.0
+// (https://tus.io/protocols/resumable-upload). Незавершенные загрузки хранятся
+// на диске и переживают перезапуск сервера.
+package tus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound возвращается, если загрузка не найдена
+	ErrNotFound = errors.New("загрузка не найдена")
+	// ErrOffsetMismatch возвращается, если смещение блока не совпадает с уже загруженным объемом
+	ErrOffsetMismatch = errors.New("смещение не совпадает с текущим размером загрузки")
+)
+
+// idPattern допустимый формат идентификатора загрузки, защищает от выхода за пределы каталога
+var idPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Info описывает состояние загрузки
+type Info struct {
+	ID        string            `json:"id"`
+	Length    int64             `json:"length"`             // Полный размер файла
+	Offset    int64             `json:"offset"`             // Сколько байт уже получено
+	Metadata  map[string]string `json:"metadata,omitempty"` // Upload-Metadata клиента
+	UserID    int               `json:"user_id,omitempty"`  // Пользователь, начавший загрузку
+	Username  string            `json:"username,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Location  string            `json:"location,omitempty"` // Ссылка на созданный ресурс после завершения
+}
+
+// Complete сообщает, получен ли файл целиком
+func (i Info) Complete() bool {
+	return i.Offset >= i.Length
+}
+
+// Finished сообщает, обработан ли загруженный файл
+func (i Info) Finished() bool {
+	return i.Location != ""
+}
+
+// Store хранит загрузки в каталоге: <id>.bin содержит полученные данные, <id>.info - состояние
+type Store struct {
+	dir string
+
+	locksMu sync.Mutex
+	locks   map[string]*uploadLock // Блокировки загрузок, которые сейчас изменяются
+}
+
+// uploadLock блокировка загрузки и число запросов, которые ее удерживают или ожидают
+type uploadLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewStore создает хранилище загрузок в указанном каталоге
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога загрузок: %w", err)
+	}
+	return &Store{dir: dir, locks: make(map[string]*uploadLock)}, nil
+}
+
+// Lock блокирует загрузку на время изменения и возвращает функцию разблокировки
+func (s *Store) Lock(id string) func() {
+	s.locksMu.Lock()
+	lock, ok := s.locks[id]
+	if !ok {
+		lock = &uploadLock{}
+		s.locks[id] = lock
+	}
+	lock.refs++
+	s.locksMu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		s.locksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(s.locks, id)
+		}
+		s.locksMu.Unlock()
+	}
+}
+
+// Create регистрирует новую загрузку и создает пустой файл для данных
+func (s *Store) Create(info Info) (Info, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return Info{}, err
+	}
+	info.ID = hex.EncodeToString(buf)
+	info.Offset = 0
+	if info.CreatedAt.IsZero() {
+		info.CreatedAt = time.Now()
+	}
+
+	file, err := os.OpenFile(s.binPath(info.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return Info{}, fmt.Errorf("ошибка создания файла загрузки: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return Info{}, err
+	}
+
+	if err := s.saveInfo(info); err != nil {
+		_ = os.Remove(s.binPath(info.ID))
+		return Info{}, err
+	}
+	return info, nil
+}
+
+// Get возвращает состояние загрузки
+func (s *Store) Get(id string) (Info, error) {
+	if !idPattern.MatchString(id) {
+		return Info{}, ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.infoPath(id))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Info{}, ErrNotFound
+		}
+		return Info{}, fmt.Errorf("ошибка чтения состояния загрузки: %w", err)
+	}
+
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return Info{}, fmt.Errorf("ошибка разбора состояния загрузки: %w", err)
+	}
+	return info, nil
+}
+
+// WriteChunk дописывает данные с offset, вызывающий код удерживает блокировку загрузки
+func (s *Store) WriteChunk(id string, offset int64, r io.Reader) (Info, error) {
+	info, err := s.Get(id)
+	if err != nil {
+		return Info{}, err
+	}
+	if offset != info.Offset {
+		return info, ErrOffsetMismatch
+	}
+
+	file, err := os.OpenFile(s.binPath(id), os.O_WRONLY, 0644)
+	if err != nil {
+		return info, fmt.Errorf("ошибка открытия файла загрузки: %w", err)
+	}
+
+	// Отбрасываем возможный хвост от записи, состояние которой не успело сохраниться
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return info, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return info, err
+	}
+
+	n, copyErr := io.Copy(file, io.LimitReader(r, info.Length-offset))
+	syncErr := file.Sync()
+	closeErr := file.Close()
+
+	info.Offset += n
+	if err := s.saveInfo(info); err != nil {
+		return info, err
+	}
+
+	return info, errors.Join(copyErr, syncErr, closeErr)
+}
+
+// Open открывает полученные данные загрузки для чтения
+func (s *Store) Open(id string) (*os.File, error) {
+	if !idPattern.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	return os.Open(s.binPath(id))
+}
+
+// Finish сохраняет ссылку на созданный ресурс и удаляет данные загрузки
+func (s *Store) Finish(id, location string) error {
+	info, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	info.Location = location
+	if err := s.saveInfo(info); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.binPath(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Delete удаляет загрузку вместе с данными
+func (s *Store) Delete(id string) error {
+	if !idPattern.MatchString(id) {
+		return ErrNotFound
+	}
+
+	infoErr := os.Remove(s.infoPath(id))
+	binErr := os.Remove(s.binPath(id))
+
+	if errors.Is(infoErr, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	if binErr != nil && !errors.Is(binErr, fs.ErrNotExist) {
+		return binErr
+	}
+	return infoErr
+}
+
+// DeleteExpired удаляет загрузки, срок хранения которых истек
+func (s *Store) DeleteExpired(now time.Time) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".info")
+		if !ok || !idPattern.MatchString(id) {
+			continue
+		}
+
+		unlock := s.Lock(id)
+		info, err := s.Get(id)
+		if err == nil && !info.ExpiresAt.IsZero() && now.After(info.ExpiresAt) {
+			if err := s.Delete(id); err == nil {
+				deleted++
+			}
+		}
+		unlock()
+	}
+	return deleted, nil
+}
+
+// saveInfo атомарно записывает состояние загрузки
+func (s *Store) saveInfo(info Info) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.infoPath(info.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("ошибка сохранения состояния загрузки: %w", err)
+	}
+	if err := os.Rename(tmp, s.infoPath(info.ID)); err != nil {
+		return fmt.Errorf("ошибка сохранения состояния загрузки: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) binPath(id string) string {
+	return filepath.Join(s.dir, id+".bin")
+}
+
+func (s *Store) infoPath(id string) string {
+	return filepath.Join(s.dir, id+".info")
+}
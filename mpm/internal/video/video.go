@@ -0,0 +1,537 @@
+// Package video извлекает параметры видео из контейнеров MP4 и MOV (ISO BMFF) без внешних утилит
+package video
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotVideo возвращается, если файл не похож на контейнер MP4 или MOV
+var ErrNotVideo = errors.New("файл не является видео MP4 или MOV")
+
+const (
+	// maxBoxes ограничивает количество просматриваемых атомов, защищает от поврежденных файлов
+	maxBoxes = 4096
+	// maxDepth ограничивает вложенность атомов
+	maxDepth = 8
+	// maxTableSize ограничивает размер таблицы stts, которая читается целиком
+	maxTableSize = 8 << 20
+	// maxValueSize ограничивает размер читаемых строковых значений
+	maxValueSize = 4096
+)
+
+// Ключи метаданных QuickTime (moov/meta) в пространстве имен mdta
+const (
+	keyContentIdentifier = "com.apple.quicktime.content.identifier"
+	keyLocation          = "com.apple.quicktime.location.ISO6709"
+	keyMake              = "com.apple.quicktime.make"
+	keyModel             = "com.apple.quicktime.model"
+	keyCreationDate      = "com.apple.quicktime.creationdate"
+)
+
+// epoch1904 начало отсчета времени в атомах mvhd, tkhd и mdhd
+var epoch1904 = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Location координаты съемки
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  *float64 // Высота над уровнем моря в метрах
+}
+
+// Info параметры видео
+type Info struct {
+	Duration   time.Duration
+	Width      int // Ширина кадра с учетом поворота
+	Height     int // Высота кадра с учетом поворота
+	Rotation   int // Поворот при воспроизведении в градусах: 0, 90, 180 или 270
+	FrameRate  float64
+	VideoCodec string // FourCC видеодорожки, например avc1 или hvc1
+	AudioCodec string // FourCC звуковой дорожки, например mp4a
+
+	CreatedAt         time.Time // Дата съемки, нулевая если неизвестна
+	Make              string
+	Model             string
+	Location          *Location
+	ContentIdentifier string // Идентификатор, связывающий видео Live Photo со снимком
+}
+
+// box атом контейнера
+type box struct {
+	typ    string
+	offset int64 // Смещение содержимого
+	size   int64 // Размер содержимого
+}
+
+// track параметры дорожки, собранные из trak
+type track struct {
+	handler       string
+	codec         string
+	width, height int
+	rotation      int
+	timescale     uint32
+	duration      uint64
+	samples       uint64
+	sampleTime    uint64
+}
+
+// parser обходит атомы файла
+type parser struct {
+	r     io.ReaderAt
+	boxes int
+	info  *Info
+
+	keys   []string
+	values map[int]string
+}
+
+// Probe читает параметры видео из файла MP4 или MOV
+func Probe(r io.ReaderAt, size int64) (*Info, error) {
+	p := &parser{r: r, info: &Info{}, values: map[int]string{}}
+
+	top, err := p.children(0, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var moov *box
+	for i, b := range top {
+		if b.typ == "moov" {
+			moov = &top[i]
+		}
+	}
+	if moov == nil {
+		return nil, ErrNotVideo
+	}
+	if err := p.parseMoov(*moov); err != nil {
+		return nil, err
+	}
+	p.applyKeys()
+	return p.info, nil
+}
+
+// children читает атомы, расположенные подряд в диапазоне [offset, offset+size)
+func (p *parser) children(offset, size int64) ([]box, error) {
+	var result []box
+	end := offset + size
+	header := make([]byte, 16)
+
+	for offset+8 <= end {
+		p.boxes++
+		if p.boxes > maxBoxes {
+			return nil, ErrNotVideo
+		}
+		if _, err := p.r.ReadAt(header[:8], offset); err != nil {
+			return nil, ErrNotVideo
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		switch boxSize {
+		case 0: // Атом до конца файла
+			boxSize = end - offset
+		case 1: // 64-битный размер
+			if _, err := p.r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, ErrNotVideo
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || offset+boxSize > end {
+			// Обрезанный последний атом, например недописанный mdat, не мешает чтению moov
+			if len(result) > 0 {
+				break
+			}
+			return nil, ErrNotVideo
+		}
+
+		result = append(result, box{
+			typ:    string(header[4:8]),
+			offset: offset + headerSize,
+			size:   boxSize - headerSize,
+		})
+		offset += boxSize
+	}
+	return result, nil
+}
+
+// read читает содержимое атома, но не больше limit байт
+func (p *parser) read(b box, limit int64) ([]byte, error) {
+	n := min(b.size, limit)
+	data := make([]byte, n)
+	if _, err := p.r.ReadAt(data, b.offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (p *parser) parseMoov(moov box) error {
+	boxes, err := p.children(moov.offset, moov.size)
+	if err != nil {
+		return err
+	}
+
+	var movieDuration time.Duration
+	for _, b := range boxes {
+		switch b.typ {
+		case "mvhd":
+			data, err := p.read(b, 32)
+			if err != nil {
+				return ErrNotVideo
+			}
+			var created, timescale, duration uint64
+			if len(data) >= 32 && data[0] == 1 {
+				created = binary.BigEndian.Uint64(data[4:12])
+				timescale = uint64(binary.BigEndian.Uint32(data[20:24]))
+				duration = binary.BigEndian.Uint64(data[24:32])
+			} else if len(data) >= 20 {
+				created = uint64(binary.BigEndian.Uint32(data[4:8]))
+				timescale = uint64(binary.BigEndian.Uint32(data[12:16]))
+				duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+			}
+			movieDuration = scaledDuration(duration, timescale)
+			if created > 0 {
+				p.info.CreatedAt = epoch1904.Add(time.Duration(created) * time.Second)
+			}
+		case "trak":
+			t, err := p.parseTrak(b, 1)
+			if err != nil {
+				return err
+			}
+			p.applyTrack(t)
+		case "udta":
+			if err := p.parseUdta(b, 1); err != nil {
+				return err
+			}
+		case "meta":
+			if err := p.parseMeta(b, 1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if movieDuration > 0 {
+		p.info.Duration = movieDuration
+	}
+	return nil
+}
+
+// applyTrack переносит параметры первой видео- и первой звуковой дорожки в Info
+func (p *parser) applyTrack(t track) {
+	switch t.handler {
+	case "vide":
+		if p.info.VideoCodec != "" {
+			return
+		}
+		p.info.VideoCodec = t.codec
+		p.info.Rotation = t.rotation
+		p.info.Width, p.info.Height = t.width, t.height
+		if t.rotation == 90 || t.rotation == 270 {
+			p.info.Width, p.info.Height = t.height, t.width
+		}
+		if t.sampleTime > 0 && t.timescale > 0 {
+			fps := float64(t.samples) * float64(t.timescale) / float64(t.sampleTime)
+			p.info.FrameRate = math.Round(fps*100) / 100
+		}
+		if p.info.Duration == 0 {
+			p.info.Duration = scaledDuration(t.duration, uint64(t.timescale))
+		}
+	case "soun":
+		if p.info.AudioCodec == "" {
+			p.info.AudioCodec = t.codec
+		}
+	}
+}
+
+func (p *parser) parseTrak(trak box, depth int) (track, error) {
+	var t track
+	err := p.walk(trak, depth, func(b box) error {
+		switch b.typ {
+		case "tkhd":
+			data, err := p.read(b, 96)
+			if err != nil {
+				return ErrNotVideo
+			}
+			// Матрица и размеры кадра расположены после полей, размер которых зависит от версии
+			offset := 40
+			if len(data) > 0 && data[0] == 1 {
+				offset = 52
+			}
+			if len(data) >= offset+44 {
+				matrix := data[offset : offset+36]
+				t.rotation = rotation(
+					int32(binary.BigEndian.Uint32(matrix[0:4])),
+					int32(binary.BigEndian.Uint32(matrix[4:8])),
+					int32(binary.BigEndian.Uint32(matrix[12:16])),
+					int32(binary.BigEndian.Uint32(matrix[16:20])),
+				)
+				t.width = int(binary.BigEndian.Uint32(data[offset+36:offset+40]) >> 16)
+				t.height = int(binary.BigEndian.Uint32(data[offset+40:offset+44]) >> 16)
+			}
+		case "mdhd":
+			data, err := p.read(b, 32)
+			if err != nil {
+				return ErrNotVideo
+			}
+			if len(data) >= 32 && data[0] == 1 {
+				t.timescale = binary.BigEndian.Uint32(data[20:24])
+				t.duration = binary.BigEndian.Uint64(data[24:32])
+			} else if len(data) >= 20 {
+				t.timescale = binary.BigEndian.Uint32(data[12:16])
+				t.duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+			}
+		case "hdlr":
+			data, err := p.read(b, 12)
+			if err != nil {
+				return ErrNotVideo
+			}
+			if len(data) >= 12 {
+				t.handler = string(data[8:12])
+			}
+		case "stsd":
+			data, err := p.read(b, 16)
+			if err != nil {
+				return ErrNotVideo
+			}
+			if len(data) >= 16 && binary.BigEndian.Uint32(data[4:8]) > 0 {
+				t.codec = strings.TrimSpace(string(data[12:16]))
+			}
+		case "stts":
+			data, err := p.read(b, maxTableSize)
+			if err != nil {
+				return ErrNotVideo
+			}
+			if len(data) < 8 {
+				return nil
+			}
+			count := int(binary.BigEndian.Uint32(data[4:8]))
+			for i := 0; i < count && 8+i*8+8 <= len(data); i++ {
+				entry := data[8+i*8:]
+				samples := uint64(binary.BigEndian.Uint32(entry[0:4]))
+				t.samples += samples
+				t.sampleTime += samples * uint64(binary.BigEndian.Uint32(entry[4:8]))
+			}
+		}
+		return nil
+	})
+	return t, err
+}
+
+// walk обходит вложенные атомы trak, в которых лежат параметры дорожки
+func (p *parser) walk(parent box, depth int, fn func(box) error) error {
+	if depth > maxDepth {
+		return ErrNotVideo
+	}
+	boxes, err := p.children(parent.offset, parent.size)
+	if err != nil {
+		return err
+	}
+	for _, b := range boxes {
+		switch b.typ {
+		case "mdia", "minf", "stbl":
+			if err := p.walk(b, depth+1, fn); err != nil {
+				return err
+			}
+		default:
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseUdta читает координаты из атома ©xyz пользовательских данных QuickTime
+func (p *parser) parseUdta(udta box, depth int) error {
+	boxes, err := p.children(udta.offset, udta.size)
+	if err != nil {
+		return err
+	}
+	for _, b := range boxes {
+		switch b.typ {
+		case "\xa9xyz":
+			data, err := p.read(b, maxValueSize)
+			if err != nil || len(data) < 4 {
+				continue
+			}
+			n := int(binary.BigEndian.Uint16(data[0:2]))
+			if 4+n <= len(data) && p.info.Location == nil {
+				p.info.Location = parseISO6709(string(data[4 : 4+n]))
+			}
+		case "meta":
+			if err := p.parseMeta(b, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseMeta читает метаданные QuickTime: список ключей keys и значения ilst
+func (p *parser) parseMeta(meta box, depth int) error {
+	if depth > maxDepth {
+		return ErrNotVideo
+	}
+
+	// В MP4 meta - полный атом с версией и флагами, в QuickTime - нет
+	peek, err := p.read(meta, 8)
+	if err != nil || len(peek) < 8 {
+		return nil
+	}
+	if string(peek[4:8]) != "hdlr" {
+		meta.offset += 4
+		meta.size -= 4
+	}
+
+	boxes, err := p.children(meta.offset, meta.size)
+	if err != nil {
+		// Метаданные необязательны, поврежденный атом не мешает чтению параметров видео
+		return nil
+	}
+	for _, b := range boxes {
+		switch b.typ {
+		case "keys":
+			p.keys = p.parseKeys(b)
+		case "ilst":
+			p.parseIlst(b)
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseKeys(b box) []string {
+	data, err := p.read(b, maxTableSize)
+	if err != nil || len(data) < 8 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint32(data[4:8]))
+	keys := make([]string, 0, min(count, 256))
+	offset := 8
+	for i := 0; i < count && offset+8 <= len(data); i++ {
+		size := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		if size < 8 || offset+size > len(data) {
+			break
+		}
+		keys = append(keys, string(data[offset+8:offset+size]))
+		offset += size
+	}
+	return keys
+}
+
+// parseIlst читает строковые значения ilst. Тип атома значения - номер ключа в keys, начиная с 1.
+func (p *parser) parseIlst(ilst box) {
+	items, err := p.children(ilst.offset, ilst.size)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		index := int(binary.BigEndian.Uint32([]byte(item.typ)))
+		values, err := p.children(item.offset, item.size)
+		if err != nil {
+			continue
+		}
+		for _, v := range values {
+			if v.typ != "data" {
+				continue
+			}
+			data, err := p.read(v, maxValueSize)
+			// Тип 1 - строка UTF-8
+			if err != nil || len(data) < 8 || binary.BigEndian.Uint32(data[0:4]) != 1 {
+				continue
+			}
+			p.values[index] = string(data[8:])
+		}
+	}
+}
+
+// applyKeys переносит значения метаданных QuickTime в Info
+func (p *parser) applyKeys() {
+	for i, key := range p.keys {
+		value, ok := p.values[i+1]
+		if !ok || value == "" {
+			continue
+		}
+		switch key {
+		case keyContentIdentifier:
+			p.info.ContentIdentifier = value
+		case keyMake:
+			p.info.Make = value
+		case keyModel:
+			p.info.Model = value
+		case keyLocation:
+			if location := parseISO6709(value); location != nil {
+				p.info.Location = location
+			}
+		case keyCreationDate:
+			// Дата с часовым поясом точнее времени mvhd, которое многие камеры пишут без него
+			if t, err := time.Parse("2006-01-02T15:04:05-0700", value); err == nil {
+				p.info.CreatedAt = t
+			} else if t, err := time.Parse(time.RFC3339, value); err == nil {
+				p.info.CreatedAt = t
+			}
+		}
+	}
+}
+
+// parseISO6709 разбирает координаты вида +55.7558+037.6173+150.000/
+func parseISO6709(value string) *Location {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "/")
+
+	var parts []string
+	start := 0
+	for i := 1; i < len(value); i++ {
+		if value[i] == '+' || value[i] == '-' {
+			parts = append(parts, value[start:i])
+			start = i
+		}
+	}
+	parts = append(parts, value[start:])
+	if len(parts) < 2 {
+		return nil
+	}
+
+	lat, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || math.Abs(lat) > 90 {
+		return nil
+	}
+	lon, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || math.Abs(lon) > 180 {
+		return nil
+	}
+	location := &Location{Latitude: lat, Longitude: lon}
+	if len(parts) > 2 {
+		if alt, err := strconv.ParseFloat(parts[2], 64); err == nil {
+			location.Altitude = &alt
+		}
+	}
+	return location
+}
+
+// rotation определяет поворот по элементам a, b, c, d матрицы преобразования tkhd
+func rotation(a, b, c, d int32) int {
+	const one = 1 << 16
+	switch {
+	case a == 0 && b == one && c == -one && d == 0:
+		return 90
+	case a == -one && b == 0 && c == 0 && d == -one:
+		return 180
+	case a == 0 && b == -one && c == one && d == 0:
+		return 270
+	default:
+		return 0
+	}
+}
+
+// scaledDuration переводит длительность в единицах timescale в time.Duration
+func scaledDuration(duration, timescale uint64) time.Duration {
+	if timescale == 0 {
+		return 0
+	}
+	seconds := float64(duration) / float64(timescale)
+	return time.Duration(seconds * float64(time.Second))
+}
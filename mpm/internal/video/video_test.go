@@ -0,0 +1,173 @@
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// atom собирает атом из типа и содержимого
+func atom(typ string, parts ...[]byte) []byte {
+	content := bytes.Join(parts, nil)
+	b := make([]byte, 8, 8+len(content))
+	binary.BigEndian.PutUint32(b[0:4], uint32(8+len(content)))
+	copy(b[4:8], typ)
+	return append(b, content...)
+}
+
+func u32(values ...uint32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(b[i*4:], v)
+	}
+	return b
+}
+
+// matrix возвращает матрицу tkhd для поворота на угол degrees
+func matrix(degrees int) []byte {
+	const one = 1 << 16
+	var a, b, c, d int32 = one, 0, 0, one
+	switch degrees {
+	case 90:
+		a, b, c, d = 0, one, -one, 0
+	case 180:
+		a, d = -one, -one
+	case 270:
+		a, b, c, d = 0, -one, one, 0
+	}
+	return u32(uint32(a), uint32(b), 0, uint32(c), uint32(d), 0, 0, 0, 1<<30)
+}
+
+// videoTrack собирает дорожку: 30 кадров по 1000 единиц при timescale 30000
+func videoTrack(codec string, width, height uint32, degrees int) []byte {
+	tkhd := atom("tkhd", u32(0, 0, 0, 1, 0, 0, 0, 0, 0, 0), matrix(degrees), u32(width<<16, height<<16))
+	mdhd := atom("mdhd", u32(0, 0, 0, 30000, 30000, 0))
+	hdlr := atom("hdlr", u32(0, 0), []byte("vide"), u32(0, 0, 0), []byte{0})
+	stsd := atom("stsd", u32(0, 1), atom(codec, make([]byte, 78)))
+	stts := atom("stts", u32(0, 1, 30, 1000))
+	return atom("trak", tkhd, atom("mdia", mdhd, hdlr, atom("minf", atom("stbl", stsd, stts))))
+}
+
+func audioTrack() []byte {
+	mdhd := atom("mdhd", u32(0, 0, 0, 44100, 44100, 0))
+	hdlr := atom("hdlr", u32(0, 0), []byte("soun"), u32(0, 0, 0), []byte{0})
+	stsd := atom("stsd", u32(0, 1), atom("mp4a", make([]byte, 28)))
+	return atom("trak", atom("mdia", mdhd, hdlr, atom("minf", atom("stbl", stsd))))
+}
+
+// quickTimeMeta собирает метаданные moov/meta с ключами mdta
+func quickTimeMeta(values map[string]string) []byte {
+	var keys, items [][]byte
+	i := uint32(0)
+	for key, value := range values {
+		i++
+		keys = append(keys, append(append(u32(uint32(8+len(key))), "mdta"...), key...))
+		data := atom("data", u32(1, 0), []byte(value))
+		item := atom("xxxx", data)
+		binary.BigEndian.PutUint32(item[4:8], i)
+		items = append(items, item)
+	}
+	hdlr := atom("hdlr", u32(0, 0), []byte("mdta"), u32(0, 0, 0), []byte{0})
+	return atom("meta", hdlr, atom("keys", u32(0, i), bytes.Join(keys, nil)), atom("ilst", items...))
+}
+
+// created 2024-05-01 10:00:00 UTC в секундах от 1904 года
+var created = uint32(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Sub(epoch1904) / time.Second)
+
+func TestProbe(t *testing.T) {
+	t.Run("MOV с поворотом, звуком и метаданными Apple", func(t *testing.T) {
+		mvhd := atom("mvhd", u32(0, created, created, 600, 1530))
+		meta := quickTimeMeta(map[string]string{
+			keyContentIdentifier: "8F2A-11",
+			keyLocation:          "+55.7558+037.6173+150.000/",
+			keyMake:              "Apple",
+			keyModel:             "iPhone 15",
+			keyCreationDate:      "2024-05-01T13:00:00+0300",
+		})
+		moov := atom("moov", mvhd, videoTrack("hvc1", 1920, 1080, 90), audioTrack(), meta)
+		data := append(append(atom("ftyp", []byte("qt  "), u32(0)), moov...), atom("mdat", make([]byte, 64))...)
+
+		info, err := Probe(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+
+		assert.Equal(t, 2550*time.Millisecond, info.Duration)
+		assert.Equal(t, 1080, info.Width, "размеры с учетом поворота")
+		assert.Equal(t, 1920, info.Height)
+		assert.Equal(t, 90, info.Rotation)
+		assert.Equal(t, 30.0, info.FrameRate)
+		assert.Equal(t, "hvc1", info.VideoCodec)
+		assert.Equal(t, "mp4a", info.AudioCodec)
+		assert.Equal(t, "8F2A-11", info.ContentIdentifier)
+		assert.Equal(t, "Apple", info.Make)
+		assert.Equal(t, "iPhone 15", info.Model)
+		require.NotNil(t, info.Location)
+		assert.InDelta(t, 55.7558, info.Location.Latitude, 1e-9)
+		assert.InDelta(t, 37.6173, info.Location.Longitude, 1e-9)
+		require.NotNil(t, info.Location.Altitude)
+		assert.InDelta(t, 150.0, *info.Location.Altitude, 1e-9)
+		assert.True(t, info.CreatedAt.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+		_, offset := info.CreatedAt.Zone()
+		assert.Equal(t, 3*3600, offset, "дата из метаданных Apple сохраняет часовой пояс")
+	})
+
+	t.Run("MP4 с 64-битным mdat перед moov и координатами в udta", func(t *testing.T) {
+		mdat := append(u32(1), "mdat"...)
+		mdat = append(mdat, 0, 0, 0, 0, 0, 0, 0, 32)
+		mdat = append(mdat, make([]byte, 16)...)
+
+		mvhd := atom("mvhd", u32(0, created, created, 1000, 0))
+		xyz := append([]byte{0, 18, 0x15, 0xc7}, "-33.8688+151.2093/"...)
+		moov := atom("moov", mvhd, videoTrack("avc1", 640, 480, 180), atom("udta", atom("\xa9xyz", xyz)))
+		data := append(append(atom("ftyp", []byte("isom"), u32(512)), mdat...), moov...)
+
+		info, err := Probe(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Second, info.Duration, "длительность дорожки, если mvhd пуст")
+		assert.Equal(t, 640, info.Width)
+		assert.Equal(t, 480, info.Height)
+		assert.Equal(t, 180, info.Rotation)
+		assert.Empty(t, info.AudioCodec)
+		require.NotNil(t, info.Location)
+		assert.InDelta(t, -33.8688, info.Location.Latitude, 1e-9)
+		assert.Nil(t, info.Location.Altitude)
+		assert.True(t, info.CreatedAt.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Недописанный mdat после moov", func(t *testing.T) {
+		moov := atom("moov", atom("mvhd", u32(0, 0, 0, 1000, 500)), videoTrack("avc1", 320, 240, 0))
+		data := append(atom("ftyp", []byte("mp42"), u32(0)), moov...)
+		data = append(data, u32(1<<20)...)
+		data = append(data, "mdat"...)
+
+		info, err := Probe(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		assert.Equal(t, 500*time.Millisecond, info.Duration)
+		assert.True(t, info.CreatedAt.IsZero())
+	})
+
+	t.Run("Не видео", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"текст":    []byte("hello world, this is not a video"),
+			"без moov": append(atom("ftyp", []byte("isom"), u32(0)), atom("mdat", make([]byte, 8))...),
+			"пустой":   {},
+		} {
+			_, err := Probe(bytes.NewReader(data), int64(len(data)))
+			assert.ErrorIs(t, err, ErrNotVideo, name)
+		}
+	})
+}
+
+func TestParseISO6709(t *testing.T) {
+	location := parseISO6709("+40.6892-074.0445/")
+	require.NotNil(t, location)
+	assert.InDelta(t, 40.6892, location.Latitude, 1e-9)
+	assert.InDelta(t, -74.0445, location.Longitude, 1e-9)
+
+	assert.Nil(t, parseISO6709("+95.0+010.0/"), "широта вне диапазона")
+	assert.Nil(t, parseISO6709("garbage"))
+}
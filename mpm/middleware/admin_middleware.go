@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"log"
+	"mpm/internal/models"
+	"net/http"
+)
+
+// AdminMiddleware пропускает только администраторов, выполняется после AuthMiddleware
+func AdminMiddleware(admins []string) func(next http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(admins))
+	for _, username := range admins {
+		allowed[username] = true
+	}
+	if len(allowed) == 0 {
+		log.Println("Администраторы не заданы (MPM_ADMIN_USERS), административные операции недоступны")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(UserContextKey).(*models.User)
+			if !ok || user == nil {
+				http.Error(w, "Требуется авторизация", http.StatusUnauthorized)
+				return
+			}
+			if !allowed[user.Username] {
+				http.Error(w, "Операция доступна только администраторам", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mpm/internal/models"
+)
+
+func TestAdminMiddleware(t *testing.T) {
+	handler := AdminMiddleware([]string{"admin"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		expected int
+	}{
+		{"Администратор", &models.User{ID: 1, Username: "admin"}, http.StatusNoContent},
+		{"Обычный пользователь", &models.User{ID: 2, Username: "alice"}, http.StatusForbidden},
+		{"Без пользователя", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/storage/migration", nil)
+			if tt.user != nil {
+				req = req.WithContext(context.WithValue(req.Context(), UserContextKey, tt.user))
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expected, rr.Code)
+		})
+	}
+
+	t.Run("Без списка администраторов доступ закрыт", func(t *testing.T) {
+		closed := AdminMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/api/storage/migration", nil)
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey, &models.User{ID: 1, Username: "admin"}))
+		rr := httptest.NewRecorder()
+
+		closed.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
@@ -27,6 +27,7 @@ type Album struct {
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	CreatedAt     string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Filter        *PhotoFilter           `protobuf:"bytes,8,opt,name=filter,proto3" json:"filter,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -89,17 +90,201 @@ func (x *Album) GetCreatedAt() string {
 	return ""
 }
 
+func (x *Album) GetFilter() *PhotoFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type PhotoFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []string               `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Camera        string                 `protobuf:"bytes,4,opt,name=camera,proto3" json:"camera,omitempty"`
+	Query         string                 `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	Media         string                 `protobuf:"bytes,6,opt,name=media,proto3" json:"media,omitempty"`
+	AlbumId       int32                  `protobuf:"varint,7,opt,name=album_id,json=albumId,proto3" json:"album_id,omitempty"`
+	UserId        int32                  `protobuf:"varint,8,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Bbox          *BBox                  `protobuf:"bytes,9,opt,name=bbox,proto3" json:"bbox,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PhotoFilter) Reset() {
+	*x = PhotoFilter{}
+	mi := &file_proto_albums_album_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PhotoFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PhotoFilter) ProtoMessage() {}
+
+func (x *PhotoFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_albums_album_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PhotoFilter.ProtoReflect.Descriptor instead.
+func (*PhotoFilter) Descriptor() ([]byte, []int) {
+	return file_proto_albums_album_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PhotoFilter) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *PhotoFilter) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *PhotoFilter) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *PhotoFilter) GetCamera() string {
+	if x != nil {
+		return x.Camera
+	}
+	return ""
+}
+
+func (x *PhotoFilter) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *PhotoFilter) GetMedia() string {
+	if x != nil {
+		return x.Media
+	}
+	return ""
+}
+
+func (x *PhotoFilter) GetAlbumId() int32 {
+	if x != nil {
+		return x.AlbumId
+	}
+	return 0
+}
+
+func (x *PhotoFilter) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *PhotoFilter) GetBbox() *BBox {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+type BBox struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinLon        float64                `protobuf:"fixed64,1,opt,name=min_lon,json=minLon,proto3" json:"min_lon,omitempty"`
+	MinLat        float64                `protobuf:"fixed64,2,opt,name=min_lat,json=minLat,proto3" json:"min_lat,omitempty"`
+	MaxLon        float64                `protobuf:"fixed64,3,opt,name=max_lon,json=maxLon,proto3" json:"max_lon,omitempty"`
+	MaxLat        float64                `protobuf:"fixed64,4,opt,name=max_lat,json=maxLat,proto3" json:"max_lat,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BBox) Reset() {
+	*x = BBox{}
+	mi := &file_proto_albums_album_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BBox) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BBox) ProtoMessage() {}
+
+func (x *BBox) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_albums_album_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BBox.ProtoReflect.Descriptor instead.
+func (*BBox) Descriptor() ([]byte, []int) {
+	return file_proto_albums_album_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BBox) GetMinLon() float64 {
+	if x != nil {
+		return x.MinLon
+	}
+	return 0
+}
+
+func (x *BBox) GetMinLat() float64 {
+	if x != nil {
+		return x.MinLat
+	}
+	return 0
+}
+
+func (x *BBox) GetMaxLon() float64 {
+	if x != nil {
+		return x.MaxLon
+	}
+	return 0
+}
+
+func (x *BBox) GetMaxLat() float64 {
+	if x != nil {
+		return x.MaxLat
+	}
+	return 0
+}
+
 type CreateAlbumRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Filter        *PhotoFilter           `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateAlbumRequest) Reset() {
 	*x = CreateAlbumRequest{}
-	mi := &file_proto_albums_album_proto_msgTypes[1]
+	mi := &file_proto_albums_album_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -111,7 +296,7 @@ func (x *CreateAlbumRequest) String() string {
 func (*CreateAlbumRequest) ProtoMessage() {}
 
 func (x *CreateAlbumRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_albums_album_proto_msgTypes[1]
+	mi := &file_proto_albums_album_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -124,7 +309,7 @@ func (x *CreateAlbumRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateAlbumRequest.ProtoReflect.Descriptor instead.
 func (*CreateAlbumRequest) Descriptor() ([]byte, []int) {
-	return file_proto_albums_album_proto_rawDescGZIP(), []int{1}
+	return file_proto_albums_album_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *CreateAlbumRequest) GetName() string {
@@ -141,6 +326,13 @@ func (x *CreateAlbumRequest) GetDescription() string {
 	return ""
 }
 
+func (x *CreateAlbumRequest) GetFilter() *PhotoFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
 type GetAlbumsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -149,7 +341,7 @@ type GetAlbumsRequest struct {
 
 func (x *GetAlbumsRequest) Reset() {
 	*x = GetAlbumsRequest{}
-	mi := &file_proto_albums_album_proto_msgTypes[2]
+	mi := &file_proto_albums_album_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -161,7 +353,7 @@ func (x *GetAlbumsRequest) String() string {
 func (*GetAlbumsRequest) ProtoMessage() {}
 
 func (x *GetAlbumsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_albums_album_proto_msgTypes[2]
+	mi := &file_proto_albums_album_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -174,7 +366,7 @@ func (x *GetAlbumsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAlbumsRequest.ProtoReflect.Descriptor instead.
 func (*GetAlbumsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_albums_album_proto_rawDescGZIP(), []int{2}
+	return file_proto_albums_album_proto_rawDescGZIP(), []int{4}
 }
 
 type GetAlbumsResponse struct {
@@ -186,7 +378,7 @@ type GetAlbumsResponse struct {
 
 func (x *GetAlbumsResponse) Reset() {
 	*x = GetAlbumsResponse{}
-	mi := &file_proto_albums_album_proto_msgTypes[3]
+	mi := &file_proto_albums_album_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -198,7 +390,7 @@ func (x *GetAlbumsResponse) String() string {
 func (*GetAlbumsResponse) ProtoMessage() {}
 
 func (x *GetAlbumsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_albums_album_proto_msgTypes[3]
+	mi := &file_proto_albums_album_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -211,7 +403,7 @@ func (x *GetAlbumsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAlbumsResponse.ProtoReflect.Descriptor instead.
 func (*GetAlbumsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_albums_album_proto_rawDescGZIP(), []int{3}
+	return file_proto_albums_album_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetAlbumsResponse) GetAlbums() []*Album {
@@ -230,7 +422,7 @@ type DeleteAlbumRequest struct {
 
 func (x *DeleteAlbumRequest) Reset() {
 	*x = DeleteAlbumRequest{}
-	mi := &file_proto_albums_album_proto_msgTypes[4]
+	mi := &file_proto_albums_album_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -242,7 +434,7 @@ func (x *DeleteAlbumRequest) String() string {
 func (*DeleteAlbumRequest) ProtoMessage() {}
 
 func (x *DeleteAlbumRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_albums_album_proto_msgTypes[4]
+	mi := &file_proto_albums_album_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -255,7 +447,7 @@ func (x *DeleteAlbumRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteAlbumRequest.ProtoReflect.Descriptor instead.
 func (*DeleteAlbumRequest) Descriptor() ([]byte, []int) {
-	return file_proto_albums_album_proto_rawDescGZIP(), []int{4}
+	return file_proto_albums_album_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *DeleteAlbumRequest) GetId() int32 {
@@ -274,7 +466,7 @@ type DeleteAlbumResponse struct {
 
 func (x *DeleteAlbumResponse) Reset() {
 	*x = DeleteAlbumResponse{}
-	mi := &file_proto_albums_album_proto_msgTypes[5]
+	mi := &file_proto_albums_album_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -286,7 +478,7 @@ func (x *DeleteAlbumResponse) String() string {
 func (*DeleteAlbumResponse) ProtoMessage() {}
 
 func (x *DeleteAlbumResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_albums_album_proto_msgTypes[5]
+	mi := &file_proto_albums_album_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -299,7 +491,7 @@ func (x *DeleteAlbumResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteAlbumResponse.ProtoReflect.Descriptor instead.
 func (*DeleteAlbumResponse) Descriptor() ([]byte, []int) {
-	return file_proto_albums_album_proto_rawDescGZIP(), []int{5}
+	return file_proto_albums_album_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *DeleteAlbumResponse) GetSuccess() bool {
@@ -317,7 +509,7 @@ type Empty struct {
 
 func (x *Empty) Reset() {
 	*x = Empty{}
-	mi := &file_proto_albums_album_proto_msgTypes[6]
+	mi := &file_proto_albums_album_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -329,7 +521,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_albums_album_proto_msgTypes[6]
+	mi := &file_proto_albums_album_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -342,7 +534,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_proto_albums_album_proto_rawDescGZIP(), []int{6}
+	return file_proto_albums_album_proto_rawDescGZIP(), []int{8}
 }
 
 var File_proto_albums_album_proto protoreflect.FileDescriptor
@@ -350,16 +542,33 @@ var File_proto_albums_album_proto protoreflect.FileDescriptor
 const file_proto_albums_album_proto_rawDesc = "" +
 	"\n" +
 	"\x18proto/albums/album.proto\x12\n" +
-	"mpm.albums\"l\n" +
+	"mpm.albums\"\x9d\x01\n" +
 	"\x05Album\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
 	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1d\n" +
 	"\n" +
-	"created_at\x18\x06 \x01(\tR\tcreatedAt\"J\n" +
+	"created_at\x18\x06 \x01(\tR\tcreatedAt\x12/\n" +
+	"\x06filter\x18\b \x01(\v2\x17.mpm.albums.PhotoFilterR\x06filter\"\xe3\x01\n" +
+	"\vPhotoFilter\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x16\n" +
+	"\x06camera\x18\x04 \x01(\tR\x06camera\x12\x14\n" +
+	"\x05query\x18\x05 \x01(\tR\x05query\x12\x14\n" +
+	"\x05media\x18\x06 \x01(\tR\x05media\x12\x19\n" +
+	"\balbum_id\x18\a \x01(\x05R\aalbumId\x12\x17\n" +
+	"\auser_id\x18\b \x01(\x05R\x06userId\x12$\n" +
+	"\x04bbox\x18\t \x01(\v2\x10.mpm.albums.BBoxR\x04bbox\"j\n" +
+	"\x04BBox\x12\x17\n" +
+	"\amin_lon\x18\x01 \x01(\x01R\x06minLon\x12\x17\n" +
+	"\amin_lat\x18\x02 \x01(\x01R\x06minLat\x12\x17\n" +
+	"\amax_lon\x18\x03 \x01(\x01R\x06maxLon\x12\x17\n" +
+	"\amax_lat\x18\x04 \x01(\x01R\x06maxLat\"{\n" +
 	"\x12CreateAlbumRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
-	"\vdescription\x18\x02 \x01(\tR\vdescription\"\x12\n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12/\n" +
+	"\x06filter\x18\x03 \x01(\v2\x17.mpm.albums.PhotoFilterR\x06filter\"\x12\n" +
 	"\x10GetAlbumsRequest\">\n" +
 	"\x11GetAlbumsResponse\x12)\n" +
 	"\x06albums\x18\x01 \x03(\v2\x11.mpm.albums.AlbumR\x06albums\"$\n" +
@@ -385,29 +594,34 @@ func file_proto_albums_album_proto_rawDescGZIP() []byte {
 	return file_proto_albums_album_proto_rawDescData
 }
 
-var file_proto_albums_album_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_albums_album_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_proto_albums_album_proto_goTypes = []any{
 	(*Album)(nil),               // 0: mpm.albums.Album
-	(*CreateAlbumRequest)(nil),  // 1: mpm.albums.CreateAlbumRequest
-	(*GetAlbumsRequest)(nil),    // 2: mpm.albums.GetAlbumsRequest
-	(*GetAlbumsResponse)(nil),   // 3: mpm.albums.GetAlbumsResponse
-	(*DeleteAlbumRequest)(nil),  // 4: mpm.albums.DeleteAlbumRequest
-	(*DeleteAlbumResponse)(nil), // 5: mpm.albums.DeleteAlbumResponse
-	(*Empty)(nil),               // 6: mpm.albums.Empty
+	(*PhotoFilter)(nil),         // 1: mpm.albums.PhotoFilter
+	(*BBox)(nil),                // 2: mpm.albums.BBox
+	(*CreateAlbumRequest)(nil),  // 3: mpm.albums.CreateAlbumRequest
+	(*GetAlbumsRequest)(nil),    // 4: mpm.albums.GetAlbumsRequest
+	(*GetAlbumsResponse)(nil),   // 5: mpm.albums.GetAlbumsResponse
+	(*DeleteAlbumRequest)(nil),  // 6: mpm.albums.DeleteAlbumRequest
+	(*DeleteAlbumResponse)(nil), // 7: mpm.albums.DeleteAlbumResponse
+	(*Empty)(nil),               // 8: mpm.albums.Empty
 }
 var file_proto_albums_album_proto_depIdxs = []int32{
-	0, // 0: mpm.albums.GetAlbumsResponse.albums:type_name -> mpm.albums.Album
-	1, // 1: mpm.albums.AlbumService.CreateAlbum:input_type -> mpm.albums.CreateAlbumRequest
-	2, // 2: mpm.albums.AlbumService.GetAlbums:input_type -> mpm.albums.GetAlbumsRequest
-	4, // 3: mpm.albums.AlbumService.DeleteAlbum:input_type -> mpm.albums.DeleteAlbumRequest
-	0, // 4: mpm.albums.AlbumService.CreateAlbum:output_type -> mpm.albums.Album
-	3, // 5: mpm.albums.AlbumService.GetAlbums:output_type -> mpm.albums.GetAlbumsResponse
-	5, // 6: mpm.albums.AlbumService.DeleteAlbum:output_type -> mpm.albums.DeleteAlbumResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1, // 0: mpm.albums.Album.filter:type_name -> mpm.albums.PhotoFilter
+	2, // 1: mpm.albums.PhotoFilter.bbox:type_name -> mpm.albums.BBox
+	1, // 2: mpm.albums.CreateAlbumRequest.filter:type_name -> mpm.albums.PhotoFilter
+	0, // 3: mpm.albums.GetAlbumsResponse.albums:type_name -> mpm.albums.Album
+	3, // 4: mpm.albums.AlbumService.CreateAlbum:input_type -> mpm.albums.CreateAlbumRequest
+	4, // 5: mpm.albums.AlbumService.GetAlbums:input_type -> mpm.albums.GetAlbumsRequest
+	6, // 6: mpm.albums.AlbumService.DeleteAlbum:input_type -> mpm.albums.DeleteAlbumRequest
+	0, // 7: mpm.albums.AlbumService.CreateAlbum:output_type -> mpm.albums.Album
+	5, // 8: mpm.albums.AlbumService.GetAlbums:output_type -> mpm.albums.GetAlbumsResponse
+	7, // 9: mpm.albums.AlbumService.DeleteAlbum:output_type -> mpm.albums.DeleteAlbumResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_proto_albums_album_proto_init() }
@@ -421,7 +635,7 @@ func file_proto_albums_album_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_albums_album_proto_rawDesc), len(file_proto_albums_album_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},